@@ -7,8 +7,28 @@ import (
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	zh_translations "github.com/go-playground/validator/v10/translations/zh"
+	"reflect"
+	"strings"
 )
 
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError 全部字段的校验错误
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 0 {
+		return "validation failed"
+	}
+	return e.Fields[0].Message
+}
+
 type Validate struct {
 	validate *validator.Validate
 	trans    ut.Translator
@@ -28,6 +48,14 @@ func (v *Validate) InitValidates(localTrans locales.Translator, local string) {
 	v.trans, _ = uni.GetTranslator(local)
 
 	v.validate = validator.New()
+	// 字段名使用 json 标签，与请求参数保持一致
+	v.validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" || name == "" {
+			return field.Name
+		}
+		return name
+	})
 
 	err := zh_translations.RegisterDefaultTranslations(v.validate, v.trans)
 	if err != nil {
@@ -60,6 +88,40 @@ func (v *Validate) HandleError(r interface{}, m map[string]string) error {
 	return nil
 }
 
+// HandleErrors 处理错误，返回全部字段的错误信息
+// r 为验证的赋值模型
+// m 为自定义错误消息
+func (v *Validate) HandleErrors(r interface{}, m map[string]string) error {
+	err := v.validate.Struct(r)
+	if err == nil {
+		return nil
+	}
+	errs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return err
+	}
+	result := &ValidationError{}
+	for _, e := range errs {
+		message, ok := m[e.Field()+"."+e.Tag()]
+		if !ok {
+			message = e.Translate(v.trans)
+		}
+		result.Fields = append(result.Fields, FieldError{
+			Field:   fieldPath(e.Namespace()),
+			Message: message,
+		})
+	}
+	return result
+}
+
+// fieldPath 去掉结构体名称，如 CrawlParams.record.mode -> record.mode
+func fieldPath(namespace string) string {
+	if index := strings.Index(namespace, "."); index >= 0 {
+		return namespace[index+1:]
+	}
+	return namespace
+}
+
 // New 执行验证
 // r 为验证的赋值模型
 // m 为自定义错误消息
@@ -70,6 +132,13 @@ func New(r interface{}, m map[string]string, localTrans locales.Translator, loca
 	return err
 }
 
+// Check 执行验证并返回全部字段错误，错误类型为 *ValidationError
+func Check(r interface{}, m map[string]string) error {
+	v := Validate{}
+	v.InitValidates(zh.New(), "zh")
+	return v.HandleErrors(r, m)
+}
+
 // new validator
 func Run(r interface{}, m map[string]string) error {
 	// 这里默认是中文，可以根据需求进行修改，就没有做过多的封装了
@@ -0,0 +1,33 @@
+package validate
+
+import (
+	"noctua/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckCrawlParams(t *testing.T) {
+	err := Check(&types.CrawlParams{
+		MediaCode: "douyin",
+		CrawlType: "search",
+		Keywords:  []string{"go", ""},
+		MaxCount:  -1,
+		Record:    &types.RecordParams{Mode: "live"},
+	}, nil)
+	validationErr, ok := err.(*ValidationError)
+	assert.True(t, ok)
+	fields := make([]string, 0, len(validationErr.Fields))
+	for _, item := range validationErr.Fields {
+		fields = append(fields, item.Field)
+		assert.NotEmpty(t, item.Message)
+	}
+	assert.Equal(t, []string{"region", "maxCount", "keywords[1]", "record.mode"}, fields)
+
+	assert.NoError(t, Check(&types.CrawlParams{
+		MediaCode: "douyin",
+		CrawlType: "search",
+		Region:    "cn",
+		Keywords:  []string{"go"},
+	}, nil))
+}
@@ -3,6 +3,7 @@ package errors
 import (
 	"github.com/kataras/golog"
 	"github.com/kataras/iris/v12"
+	"noctua/api/core/output"
 )
 
 func ErrorHandle(app *iris.Application) {
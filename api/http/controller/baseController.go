@@ -1,12 +1,19 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"github.com/kataras/iris/v12"
+	"gorm.io/gorm"
 	"noctua/api/core/output"
+	"noctua/internal/model"
 	"noctua/kernel"
 	"strings"
 )
 
+// ProjectHeader 指定请求所属项目编码的请求头，为空时使用默认项目
+const ProjectHeader = "X-Project"
+
 type BaseController struct {
 	Kernel *kernel.Kernel
 	Output output.Output
@@ -39,3 +46,16 @@ func (b *BaseController) ClientIP(ctx iris.Context) string {
 
 	return "0.0.0.0"
 }
+
+// Project 按请求头 X-Project 获取请求所属项目，失败时返回对应的状态码：项目不存在 404，查询失败 500
+func (b *BaseController) Project(ctx iris.Context) (*model.Project, int, error) {
+	code := strings.TrimSpace(ctx.GetHeader(ProjectHeader))
+	project, err := (&model.Project{}).FindByCode(code)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, iris.StatusNotFound, fmt.Errorf("Project %s not found", code)
+	}
+	if err != nil {
+		return nil, iris.StatusInternalServerError, err
+	}
+	return project, 0, nil
+}
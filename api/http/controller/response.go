@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kataras/iris/v12"
+	"noctua/api/core/validate"
+)
+
+// Success 写出成功响应
+func Success(ctx iris.Context, data interface{}) error {
+	return ctx.JSON(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": data,
+	})
+}
+
+// Fail 写出错误响应，status 同时作为状态码和 code
+func Fail(ctx iris.Context, status int, msg string) error {
+	ctx.StatusCode(status)
+	return ctx.JSON(map[string]interface{}{
+		"code": status,
+		"msg":  msg,
+	})
+}
+
+// ReadRequest 读取并校验请求体，失败时已写出响应：无法解析返回 400，校验失败返回 422 及字段错误
+func ReadRequest(ctx iris.Context, request interface{}) bool {
+	if err := ctx.ReadJSON(request); err != nil {
+		_ = Fail(ctx, iris.StatusBadRequest, fmt.Sprintf("Unmarshal request params failed: %s", err.Error()))
+		return false
+	}
+	if err := validate.Check(request, nil); err != nil {
+		var validationErr *validate.ValidationError
+		if !errors.As(err, &validationErr) {
+			_ = Fail(ctx, iris.StatusUnprocessableEntity, err.Error())
+			return false
+		}
+		ctx.StatusCode(iris.StatusUnprocessableEntity)
+		_ = ctx.JSON(map[string]interface{}{
+			"code":   iris.StatusUnprocessableEntity,
+			"msg":    validationErr.Error(),
+			"errors": validationErr.Fields,
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,455 @@
+package account
+
+import (
+	"errors"
+	"github.com/kataras/iris/v12"
+	"gorm.io/gorm"
+	"noctua/api/http/controller"
+	"noctua/internal/model"
+	"noctua/kernel/session"
+	"noctua/pkg/cookie"
+	"noctua/pkg/secret"
+	"noctua/pkg/utils/str"
+	"strings"
+	"time"
+)
+
+// 掩码保留的首尾字符数
+const maskKeep = 6
+
+// 允许排序的字段
+var sortFields = map[string]string{
+	"id":         "id",
+	"lastUsed":   "last_used",
+	"createTime": "create_time",
+	"updateTime": "update_time",
+}
+
+type AccountController struct {
+	controller.BaseController
+}
+
+// AccountRequest 新建或更新账号的请求体
+type AccountRequest struct {
+	MediaCode  string `json:"mediaCode" validate:"required,alphanum,max=32"`
+	Type       int    `json:"type" validate:"min=0,max=2"`
+	UserID     string `json:"userId" validate:"max=64"` // 为空时自动生成
+	UID        string `json:"uid" validate:"max=128"`
+	Username   string `json:"username" validate:"max=64"`
+	Nickname   string `json:"nickname" validate:"max=64"`
+	Cookie     string `json:"cookie"`                       // 更新时为空或为掩码则保留原值
+	UserAgent  string `json:"userAgent" validate:"max=512"` // 更新时为空或为掩码则保留原值
+	DeviceInfo string `json:"deviceInfo"`                   // 更新时为空或为掩码则保留原值
+	Status     int    `json:"status" validate:"omitempty,oneof=10 100"`
+}
+
+// AccountView 账号的返回结构，Cookie、UserAgent 和设备信息以掩码展示
+type AccountView struct {
+	ID         uint      `json:"id"`
+	ProjectID  uint      `json:"projectId"`
+	MediaCode  string    `json:"mediaCode"`
+	Type       int       `json:"type"`
+	UserID     string    `json:"userId"`
+	UID        string    `json:"uid"`
+	Username   string    `json:"username"`
+	Nickname   string    `json:"nickname"`
+	Cookie     string    `json:"cookie"`
+	UserAgent  string    `json:"userAgent"`
+	DeviceInfo string    `json:"deviceInfo"`
+	Status     int       `json:"status"`
+	LastUsed   time.Time `json:"lastUsed"`
+	CreateTime time.Time `json:"createTime"`
+	UpdateTime time.Time `json:"updateTime"`
+
+	DisabledReason string     `json:"disabledReason"`
+	ProbeStatus    string     `json:"probeStatus"`
+	ProbeLatency   int64      `json:"probeLatency"`
+	ProbeError     string     `json:"probeError"`
+	ProbeFailures  int        `json:"probeFailures"`
+	ProbedAt       *time.Time `json:"probedAt"`
+	CookieVersion  int        `json:"cookieVersion"`
+	CookieDiff     string     `json:"cookieDiff"`
+	CookieSavedAt  *time.Time `json:"cookieSavedAt"`
+}
+
+func newAccountView(account *model.MediaAccount) AccountView {
+	return AccountView{
+		ID:         account.ID,
+		ProjectID:  account.ProjectID,
+		MediaCode:  account.MediaCode,
+		Type:       account.Type,
+		UserID:     account.UserID,
+		UID:        account.UID,
+		Username:   account.Username,
+		Nickname:   account.Nickname,
+		Cookie:     str.Mask(account.Cookie, maskKeep),
+		UserAgent:  str.Mask(account.UserAgent, maskKeep),
+		DeviceInfo: str.Mask(account.DeviceInfo, maskKeep),
+		Status:     account.Status,
+		LastUsed:   account.LastUsed,
+		CreateTime: account.CreateTime,
+		UpdateTime: account.UpdateTime,
+
+		DisabledReason: account.DisabledReason,
+		ProbeStatus:    account.ProbeStatus,
+		ProbeLatency:   account.ProbeLatency,
+		ProbeError:     account.ProbeError,
+		ProbeFailures:  account.ProbeFailures,
+		ProbedAt:       account.ProbedAt,
+		CookieVersion:  account.CookieVersion,
+		CookieDiff:     account.CookieDiff,
+		CookieSavedAt:  account.CookieSavedAt,
+	}
+}
+
+// List 分页查询请求所属项目的账号
+func (c *AccountController) List(ctx iris.Context) error {
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		return controller.Fail(ctx, status, err.Error())
+	}
+	params := &model.QueryMediaAccountParams{
+		ProjectID: project.ID,
+		MediaCode: ctx.URLParam("mediaCode"),
+		Type:      ctx.URLParamIntDefault("type", 0),
+		Status:    ctx.URLParamIntDefault("status", 0),
+		UserID:    ctx.URLParam("userId"),
+		Username:  ctx.URLParam("username"),
+	}
+	page := ctx.URLParamIntDefault("page", 1)
+	pageSize := ctx.URLParamIntDefault("pageSize", 20)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	sort, ok := sortFields[ctx.URLParamDefault("sort", "id")]
+	if !ok {
+		return controller.Fail(ctx, iris.StatusBadRequest, "Invalid sort field")
+	}
+	order := strings.ToLower(ctx.URLParamDefault("order", "desc"))
+	if order != "asc" && order != "desc" {
+		return controller.Fail(ctx, iris.StatusBadRequest, "Invalid sort order")
+	}
+	result, err := (&model.MediaAccount{}).List(params, sort, order, page, pageSize)
+	if err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	views := make([]AccountView, 0, len(result.Items))
+	for i := range result.Items {
+		views = append(views, newAccountView(&result.Items[i]))
+	}
+	return controller.Success(ctx, map[string]interface{}{
+		"page":  result.Page,
+		"total": result.Total,
+		"items": views,
+	})
+}
+
+// Detail 账号详情
+func (c *AccountController) Detail(ctx iris.Context) error {
+	account, ok := c.findAccount(ctx)
+	if !ok {
+		return nil
+	}
+	return controller.Success(ctx, newAccountView(account))
+}
+
+// Create 在请求所属项目下新建账号
+func (c *AccountController) Create(ctx iris.Context) error {
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		return controller.Fail(ctx, status, err.Error())
+	}
+	request, ok := readAccount(ctx)
+	if !ok {
+		return nil
+	}
+	if request.UserID == "" {
+		request.UserID = str.GenerateRandString(64)
+	}
+	if request.UID == "" {
+		request.UID = request.UserID
+	}
+	exists, err := (&model.MediaAccount{}).Exists(request.MediaCode, request.UserID, request.UID)
+	if err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	if exists {
+		return controller.Fail(ctx, iris.StatusConflict, "Media account already exists")
+	}
+	if request.Cookie != "" {
+		if request.Cookie, err = normalizeCookie(request.Cookie, cookie.FormatAuto); err != nil {
+			return controller.Fail(ctx, iris.StatusUnprocessableEntity, err.Error())
+		}
+	}
+	account := &model.MediaAccount{
+		ProjectID:  project.ID,
+		MediaCode:  request.MediaCode,
+		Type:       request.Type,
+		UserID:     request.UserID,
+		UID:        request.UID,
+		Username:   request.Username,
+		Nickname:   request.Nickname,
+		Cookie:     request.Cookie,
+		UserAgent:  request.UserAgent,
+		DeviceInfo: request.DeviceInfo,
+		Status:     model.MediaAccountStatusNormal,
+		IsReal:     1,
+		LastUsed:   time.Now(),
+	}
+	if request.Status > 0 {
+		account.Status = request.Status
+	}
+	if err := account.Create(); err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	return controller.Success(ctx, newAccountView(account))
+}
+
+// Update 更新账号，平台和用户ID不可修改
+func (c *AccountController) Update(ctx iris.Context) error {
+	account, ok := c.findAccount(ctx)
+	if !ok {
+		return nil
+	}
+	request, ok := readAccount(ctx)
+	if !ok {
+		return nil
+	}
+	if request.MediaCode != account.MediaCode || (request.UserID != "" && request.UserID != account.UserID) {
+		return controller.Fail(ctx, iris.StatusBadRequest, "mediaCode and userId cannot be changed")
+	}
+	fields := []string{"type", "username", "nickname"}
+	var cookieValue *string
+	account.Type = request.Type
+	account.Username = request.Username
+	account.Nickname = request.Nickname
+	if isChanged(request.UserAgent) {
+		account.UserAgent = request.UserAgent
+		fields = append(fields, "user_agent")
+	}
+	if isChanged(request.Cookie) {
+		value, err := normalizeCookie(request.Cookie, cookie.FormatAuto)
+		if err != nil {
+			return controller.Fail(ctx, iris.StatusUnprocessableEntity, err.Error())
+		}
+		cookieValue = &value
+	}
+	if isChanged(request.DeviceInfo) {
+		account.DeviceInfo = request.DeviceInfo
+		fields = append(fields, "device_info")
+	}
+	if request.Status > 0 && request.Status != account.Status {
+		account.Status = request.Status
+		account.DisabledReason = ""
+		if request.Status == model.MediaAccountStatusDisabled {
+			account.DisabledReason = model.DisabledReasonManual
+		}
+		fields = append(fields, "status", "disabled_reason")
+	}
+	if err := account.UpdateFields(fields...); err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	if cookieValue != nil {
+		if err := account.ReplaceCookie(*cookieValue); err != nil {
+			return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+		}
+	}
+	// 账号信息变化后旧会话不再有效
+	c.Kernel.SessionManager.EvictAccount(account.MediaCode, account.UserID)
+	return controller.Success(ctx, newAccountView(account))
+}
+
+// Delete 删除账号并移除其会话
+func (c *AccountController) Delete(ctx iris.Context) error {
+	account, ok := c.findAccount(ctx)
+	if !ok {
+		return nil
+	}
+	if _, err := account.DeleteItems([]int{int(account.ID)}); err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	c.Kernel.SessionManager.EvictAccount(account.MediaCode, account.UserID)
+	return controller.Success(ctx, nil)
+}
+
+// Enable 启用账号
+func (c *AccountController) Enable(ctx iris.Context) error {
+	return c.setStatus(ctx, model.MediaAccountStatusNormal)
+}
+
+// Disable 禁用账号并移除其会话
+func (c *AccountController) Disable(ctx iris.Context) error {
+	return c.setStatus(ctx, model.MediaAccountStatusDisabled)
+}
+
+// Usage 账号的使用情况
+func (c *AccountController) Usage(ctx iris.Context) error {
+	account, ok := c.findAccount(ctx)
+	if !ok {
+		return nil
+	}
+	return controller.Success(ctx, struct {
+		session.AccountUsage
+		Status   int                `json:"status"`
+		LastUsed time.Time          `json:"lastUsed"`
+		Quota    session.QuotaUsage `json:"quota"`
+	}{
+		AccountUsage: c.Kernel.SessionManager.Usage(account.MediaCode, account.UserID),
+		Status:       account.Status,
+		LastUsed:     account.LastUsed,
+		Quota:        c.Kernel.SessionManager.AccountQuota(account),
+	})
+}
+
+// Reencrypt 使用当前密钥重新加密账号凭据，用于启用加密或轮换密钥后处理已有数据
+func (c *AccountController) Reencrypt(ctx iris.Context) error {
+	result, err := (&model.MediaAccount{}).ReencryptCredentials(ctx.URLParamIntDefault("batchSize", 100))
+	if errors.Is(err, secret.ErrNoKey) {
+		return controller.Fail(ctx, iris.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	return controller.Success(ctx, result)
+}
+
+// CookieImportRequest 导入 Cookie 的请求体
+type CookieImportRequest struct {
+	Format string `json:"format" validate:"omitempty,oneof=netscape json header har"` // 为空时自动识别
+	Data   string `json:"data" validate:"required"`
+}
+
+// CookieView Cookie 的返回结构，值以掩码展示
+type CookieView struct {
+	Name     string     `json:"name"`
+	Value    string     `json:"value"`
+	Domain   string     `json:"domain"`
+	Path     string     `json:"path"`
+	Expires  *time.Time `json:"expires"` // 会话 Cookie 为空
+	HttpOnly bool       `json:"httpOnly"`
+	Secure   bool       `json:"secure"`
+}
+
+// Cookies 账号的 Cookie 列表及关键 Cookie 的过期情况
+func (c *AccountController) Cookies(ctx iris.Context) error {
+	account, ok := c.findAccount(ctx)
+	if !ok {
+		return nil
+	}
+	return c.cookieResult(ctx, account)
+}
+
+// ImportCookies 导入 Cookie，支持 Netscape、浏览器插件 JSON、Cookie 请求头和 HAR
+func (c *AccountController) ImportCookies(ctx iris.Context) error {
+	account, ok := c.findAccount(ctx)
+	if !ok {
+		return nil
+	}
+	request := &CookieImportRequest{}
+	if !controller.ReadRequest(ctx, request) {
+		return nil
+	}
+	value, err := normalizeCookie(request.Data, cookie.Format(request.Format))
+	if err != nil {
+		return controller.Fail(ctx, iris.StatusUnprocessableEntity, err.Error())
+	}
+	if err := account.ReplaceCookie(value); err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	// 因 Cookie 过期被禁用的账号导入后恢复
+	if account.Status == model.MediaAccountStatusDisabled && account.DisabledReason == model.DisabledReasonCookie {
+		if err := account.SetStatus(model.MediaAccountStatusNormal, ""); err != nil {
+			return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+		}
+	}
+	// 旧会话仍携带原 Cookie
+	c.Kernel.SessionManager.EvictAccount(account.MediaCode, account.UserID)
+	return c.cookieResult(ctx, account)
+}
+
+func (c *AccountController) cookieResult(ctx iris.Context, account *model.MediaAccount) error {
+	cookies, format, err := cookie.Parse(account.Cookie)
+	if err != nil && !errors.Is(err, cookie.ErrEmpty) {
+		return controller.Fail(ctx, iris.StatusUnprocessableEntity, err.Error())
+	}
+	views := make([]CookieView, 0, len(cookies))
+	for _, item := range cookies {
+		view := CookieView{
+			Name:     item.Name,
+			Value:    str.Mask(item.Value, 2),
+			Domain:   item.Domain,
+			Path:     item.Path,
+			HttpOnly: item.HttpOnly,
+			Secure:   item.Secure,
+		}
+		if !item.Session() {
+			expires := item.Expires
+			view.Expires = &expires
+		}
+		views = append(views, view)
+	}
+	return controller.Success(ctx, map[string]interface{}{
+		"format":  format,
+		"cookies": views,
+		"check":   cookies.CheckExpiry(c.Kernel.SessionManager.EssentialCookies(account.MediaCode), time.Now()),
+	})
+}
+
+// normalizeCookie 解析 Cookie 并统一保存为带过期时间的 JSON
+func normalizeCookie(data string, format cookie.Format) (string, error) {
+	cookies, err := cookie.ParseFormat(data, format)
+	if err != nil {
+		return "", err
+	}
+	return cookies.JSON()
+}
+
+func (c *AccountController) setStatus(ctx iris.Context, status int) error {
+	account, ok := c.findAccount(ctx)
+	if !ok {
+		return nil
+	}
+	if err := account.SetStatus(status, model.DisabledReasonManual); err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	if status == model.MediaAccountStatusDisabled {
+		c.Kernel.SessionManager.EvictAccount(account.MediaCode, account.UserID)
+	}
+	return controller.Success(ctx, newAccountView(account))
+}
+
+// isChanged 判断敏感字段是否提交了新值
+func isChanged(value string) bool {
+	return value != "" && !strings.Contains(value, str.MaskPlaceholder)
+}
+
+// findAccount 查询请求所属项目下的账号，失败时已写出响应
+func (c *AccountController) findAccount(ctx iris.Context) (*model.MediaAccount, bool) {
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		_ = controller.Fail(ctx, status, err.Error())
+		return nil, false
+	}
+	account, err := (&model.MediaAccount{}).Find(ctx.Params().GetUintDefault("id", 0))
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && account.ProjectID != project.ID) {
+		_ = controller.Fail(ctx, iris.StatusNotFound, "Media account not found")
+		return nil, false
+	}
+	if err != nil {
+		_ = controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	return account, true
+}
+
+// readAccount 读取并校验账号请求体，失败时已写出响应
+func readAccount(ctx iris.Context) (*AccountRequest, bool) {
+	request := &AccountRequest{}
+	if !controller.ReadRequest(ctx, request) {
+		return nil, false
+	}
+	return request, true
+}
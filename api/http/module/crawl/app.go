@@ -1,9 +1,13 @@
 package crawl
 
 import (
+	"errors"
 	"fmt"
 	"github.com/kataras/iris/v12"
+	"noctua/api/core/validate"
 	"noctua/api/http/controller"
+	"noctua/kernel"
+	"noctua/kernel/reference"
 	"noctua/pkg/logger"
 	"noctua/types"
 )
@@ -12,14 +16,22 @@ type CrawlController struct {
 	controller.BaseController
 }
 
-// Status  监测投放数据
+// Status  监测投放数据，只返回请求所属项目的任务
 func (c *CrawlController) Status(ctx iris.Context) error {
-	data := c.Kernel.CrawlerManager.Status()
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		ctx.StatusCode(status)
+		return ctx.JSON(map[string]interface{}{
+			"code": status,
+			"msg":  err.Error(),
+		})
+	}
+	data := c.Kernel.CrawlerManager.Status(project.ID)
 
 	return ctx.JSON(data)
 }
 
-// Status  监测投放数据
+// Start 校验参数并启动采集任务
 func (c *CrawlController) Start(ctx iris.Context) error {
 	data := map[string]interface{}{
 		"code": 0,
@@ -28,33 +40,40 @@ func (c *CrawlController) Start(ctx iris.Context) error {
 	crawlParams := &types.CrawlParams{}
 	err := ctx.ReadJSON(crawlParams)
 	if err != nil {
+		ctx.StatusCode(iris.StatusBadRequest)
 		return ctx.JSON(map[string]interface{}{
-			"code": 200,
+			"code": iris.StatusBadRequest,
 			"msg":  fmt.Sprintf("Unmarshal request params failed: %s", err.Error()),
 		})
 	}
-	if crawlParams.MediaCode == "" {
+	if fields := c.validateParams(crawlParams); len(fields) > 0 {
+		ctx.StatusCode(iris.StatusUnprocessableEntity)
 		return ctx.JSON(map[string]interface{}{
-			"code": 200,
-			"msg":  "Media code can not be none",
+			"code":   iris.StatusUnprocessableEntity,
+			"msg":    fields[0].Message,
+			"errors": fields,
 		})
 	}
-	if crawlParams.CrawlType == "" {
-		return ctx.JSON(map[string]interface{}{
-			"code": 200,
-			"msg":  "Crawl type can not be none",
-		})
-	}
-	if crawlParams.Region == "" {
+	// 任务归属请求头指定的项目，忽略请求体中的项目
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		ctx.StatusCode(status)
 		return ctx.JSON(map[string]interface{}{
-			"code": 200,
-			"msg":  "Region can not be none",
+			"code": status,
+			"msg":  err.Error(),
 		})
 	}
-	if len(crawlParams.Keywords) == 0 {
+	crawlParams.ProjectID = project.ID
+	// 项目已禁用返回 403，运行中的任务达到项目上限返回 409
+	if err := c.Kernel.CrawlerManager.CheckProject(project); err != nil {
+		status := iris.StatusForbidden
+		if errors.Is(err, kernel.ErrProjectJobLimit) {
+			status = iris.StatusConflict
+		}
+		ctx.StatusCode(status)
 		return ctx.JSON(map[string]interface{}{
-			"code": 200,
-			"msg":  "Keywords can not be none",
+			"code": status,
+			"msg":  err.Error(),
 		})
 	}
 
@@ -69,11 +88,46 @@ func (c *CrawlController) Start(ctx iris.Context) error {
 	return ctx.JSON(data)
 }
 
+// validateParams 依次执行结构体校验和平台约束校验，返回字段错误列表
+func (c *CrawlController) validateParams(crawlParams *types.CrawlParams) []validate.FieldError {
+	var fields []validate.FieldError
+	if err := validate.Check(crawlParams, nil); err != nil {
+		var validationErr *validate.ValidationError
+		if !errors.As(err, &validationErr) {
+			return []validate.FieldError{{Message: err.Error()}}
+		}
+		fields = append(fields, validationErr.Fields...)
+	}
+	// 平台或采集类型缺失时无需继续校验平台约束
+	if crawlParams.MediaCode == "" || crawlParams.CrawlType == "" {
+		return fields
+	}
+	if err := c.Kernel.CrawlerManager.Validate(crawlParams); err != nil {
+		var paramErrs reference.ParamErrors
+		if !errors.As(err, &paramErrs) {
+			return append(fields, validate.FieldError{Message: err.Error()})
+		}
+		for _, item := range paramErrs {
+			fields = append(fields, validate.FieldError{Field: item.Field, Message: item.Message})
+		}
+	}
+	return fields
+}
+
+// Stop 停止请求所属项目运行中的采集任务
 func (c *CrawlController) Stop(ctx iris.Context) error {
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		ctx.StatusCode(status)
+		return ctx.JSON(map[string]interface{}{
+			"code": status,
+			"msg":  err.Error(),
+		})
+	}
 	data := map[string]interface{}{
 		"code": 0,
 		"msg":  "success",
 	}
-	c.Kernel.Scheduler.Shutdown()
+	c.Kernel.CrawlerManager.Stop(project.ID)
 	return ctx.JSON(data)
 }
@@ -0,0 +1,70 @@
+package event
+
+import (
+	"github.com/kataras/iris/v12"
+	"noctua/api/http/controller"
+	"noctua/kernel/journal"
+	"strconv"
+)
+
+type EventController struct {
+	controller.BaseController
+}
+
+// List 按序列号续读事件日志，客户端以返回的 next 作为下一次的 since
+func (c *EventController) List(ctx iris.Context) error {
+	if c.Kernel.Journal == nil {
+		ctx.StatusCode(iris.StatusServiceUnavailable)
+		return ctx.JSON(map[string]interface{}{
+			"code": iris.StatusServiceUnavailable,
+			"msg":  "Event journal is disabled",
+		})
+	}
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		ctx.StatusCode(status)
+		return ctx.JSON(map[string]interface{}{
+			"code": status,
+			"msg":  err.Error(),
+		})
+	}
+	var since uint64
+	if raw := ctx.URLParam("since"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			ctx.StatusCode(iris.StatusBadRequest)
+			return ctx.JSON(map[string]interface{}{
+				"code": iris.StatusBadRequest,
+				"msg":  "Invalid since: " + err.Error(),
+			})
+		}
+		since = parsed
+	}
+	filter := journal.Filter{
+		Kind:      ctx.URLParam("kind"),
+		Topic:     ctx.URLParam("topic"),
+		JobID:     ctx.URLParam("jobId"),
+		ProjectID: project.ID,
+	}
+	events, next, err := c.Kernel.Journal.Since(since, ctx.URLParamIntDefault("limit", journal.DefaultLimit), filter)
+	if err != nil {
+		ctx.StatusCode(iris.StatusInternalServerError)
+		return ctx.JSON(map[string]interface{}{
+			"code": iris.StatusInternalServerError,
+			"msg":  err.Error(),
+		})
+	}
+	if events == nil {
+		events = []journal.Entry{}
+	}
+	return ctx.JSON(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"events":  events,
+			"next":    next,
+			"lastSeq": c.Kernel.Journal.LastSeq(),
+			"dropped": c.Kernel.Journal.Dropped(), // 丢弃的记录数，大于 0 时日志不完整
+		},
+	})
+}
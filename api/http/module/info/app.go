@@ -17,7 +17,7 @@ func (c *InfoController) PushRuntime(ctx iris.Context) error {
 		"code": 0,
 		"msg":  "success",
 	}
-	c.Kernel.RuntimeChannel <- types.NewRuntimeData(
+	c.Kernel.PublishRuntime(types.NewRuntimeData(
 		types.RuntimeEventCodeNotification,
 		types.EventData{
 			Title:     "洞察中心",
@@ -29,12 +29,38 @@ func (c *InfoController) PushRuntime(ctx iris.Context) error {
 				ShowType: "modal",
 			},
 		},
-	)
+	))
 	return ctx.JSON(data)
 }
 
-// Status  监测投放数据
+// Status  监测投放数据，只统计请求所属项目的会话
 func (c *InfoController) SessionStatus(ctx iris.Context) error {
-	data := c.Kernel.SessionManager.Status()
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		ctx.StatusCode(status)
+		return ctx.JSON(map[string]interface{}{
+			"code": status,
+			"msg":  err.Error(),
+		})
+	}
+	data := c.Kernel.SessionManager.Status(project.ID)
+	return ctx.JSON(data)
+}
+
+// SinkStatus 数据输出状态
+func (c *InfoController) SinkStatus(ctx iris.Context) error {
+	data := c.Kernel.SinkManager.Status()
+	return ctx.JSON(data)
+}
+
+// EventBusStatus 事件总线订阅及丢弃统计
+func (c *InfoController) EventBusStatus(ctx iris.Context) error {
+	data := c.Kernel.EventBus.Stats()
+	return ctx.JSON(data)
+}
+
+// RuntimeStatus 运行时数据分发统计
+func (c *InfoController) RuntimeStatus(ctx iris.Context) error {
+	data := c.Kernel.Runtime.Stats()
 	return ctx.JSON(data)
 }
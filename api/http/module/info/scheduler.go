@@ -5,33 +5,46 @@ import (
 	"noctua/api/http/controller"
 )
 
+// SchedulerController 调度器操作，只操作请求所属项目运行中的采集任务
 type SchedulerController struct {
 	controller.BaseController
 }
 
 func (c *SchedulerController) Pause(ctx iris.Context) error {
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		return controller.Fail(ctx, status, err.Error())
+	}
 	data := map[string]interface{}{
 		"code": 0,
 		"msg":  "success",
 	}
-	c.Kernel.Scheduler.Pause()
+	c.Kernel.CrawlerManager.Pause(project.ID)
 	return ctx.JSON(data)
 }
 
 func (c *SchedulerController) Resume(ctx iris.Context) error {
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		return controller.Fail(ctx, status, err.Error())
+	}
 	data := map[string]interface{}{
 		"code": 0,
 		"msg":  "success",
 	}
-	c.Kernel.Scheduler.Resume()
+	c.Kernel.CrawlerManager.Resume(project.ID)
 	return ctx.JSON(data)
 }
 
 func (c *SchedulerController) TaskTree(ctx iris.Context) error {
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		return controller.Fail(ctx, status, err.Error())
+	}
 	data := map[string]interface{}{
 		"code": 0,
 		"msg":  "success",
-		"data": c.Kernel.Scheduler.GetTaskTree(),
+		"data": c.Kernel.CrawlerManager.TaskTree(project.ID),
 	}
 
 	return ctx.JSON(data)
@@ -0,0 +1,133 @@
+package notification
+
+import (
+	"fmt"
+	"github.com/kataras/iris/v12"
+	"noctua/api/http/controller"
+	"noctua/internal/model"
+)
+
+type NotificationController struct {
+	controller.BaseController
+}
+
+// IDsRequest 批量操作的请求体
+type IDsRequest struct {
+	IDs []uint `json:"ids"`
+}
+
+// List 分页查询通知，read=true|false 按已读状态过滤
+func (c *NotificationController) List(ctx iris.Context) error {
+	project, ok := c.project(ctx)
+	if !ok {
+		return nil
+	}
+	params := &model.NotificationQueryParams{
+		ProjectID: project.ID,
+		Level:     ctx.URLParam("level"),
+		EventCode: ctx.URLParam("code"),
+		JobID:     ctx.URLParam("jobId"),
+	}
+	if ctx.URLParamExists("read") {
+		read, err := ctx.URLParamBool("read")
+		if err != nil {
+			return controller.Fail(ctx, iris.StatusBadRequest, "Invalid read: "+err.Error())
+		}
+		params.IsRead = &read
+	}
+	page := ctx.URLParamIntDefault("page", 1)
+	pageSize := ctx.URLParamIntDefault("pageSize", 20)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	result, err := c.Kernel.Notify.List(params, page, pageSize)
+	if err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	return controller.Success(ctx, result)
+}
+
+// Unread 未读通知数
+func (c *NotificationController) Unread(ctx iris.Context) error {
+	project, ok := c.project(ctx)
+	if !ok {
+		return nil
+	}
+	count, err := c.Kernel.Notify.UnreadCount(project.ID)
+	if err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	return controller.Success(ctx, map[string]interface{}{"count": count})
+}
+
+// MarkRead 标记已读，ids 为空时标记全部
+func (c *NotificationController) MarkRead(ctx iris.Context) error {
+	project, ok := c.project(ctx)
+	if !ok {
+		return nil
+	}
+	request := &IDsRequest{}
+	if ctx.GetContentLength() > 0 {
+		if err := ctx.ReadJSON(request); err != nil {
+			return controller.Fail(ctx, iris.StatusBadRequest, fmt.Sprintf("Unmarshal request params failed: %s", err.Error()))
+		}
+	}
+	updated, err := c.Kernel.Notify.MarkRead(project.ID, request.IDs)
+	if err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	return controller.Success(ctx, map[string]interface{}{"updated": updated})
+}
+
+// Delete 删除单条通知
+func (c *NotificationController) Delete(ctx iris.Context) error {
+	project, ok := c.project(ctx)
+	if !ok {
+		return nil
+	}
+	deleted, err := c.Kernel.Notify.Delete(project.ID, []uint{ctx.Params().GetUintDefault("id", 0)})
+	if err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	if deleted == 0 {
+		return controller.Fail(ctx, iris.StatusNotFound, "Notification not found")
+	}
+	return controller.Success(ctx, nil)
+}
+
+// BatchDelete 批量删除通知
+func (c *NotificationController) BatchDelete(ctx iris.Context) error {
+	project, ok := c.project(ctx)
+	if !ok {
+		return nil
+	}
+	request := &IDsRequest{}
+	if err := ctx.ReadJSON(request); err != nil {
+		return controller.Fail(ctx, iris.StatusBadRequest, fmt.Sprintf("Unmarshal request params failed: %s", err.Error()))
+	}
+	if len(request.IDs) == 0 {
+		return controller.Fail(ctx, iris.StatusUnprocessableEntity, "ids can not be empty")
+	}
+	deleted, err := c.Kernel.Notify.Delete(project.ID, request.IDs)
+	if err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	return controller.Success(ctx, map[string]interface{}{"deleted": deleted})
+}
+
+// project 返回请求所属项目，消息中心未启用时返回 503，失败时已写出响应
+func (c *NotificationController) project(ctx iris.Context) (*model.Project, bool) {
+	if c.Kernel.Notify == nil {
+		_ = controller.Fail(ctx, iris.StatusServiceUnavailable, "Notification center is disabled")
+		return nil, false
+	}
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		_ = controller.Fail(ctx, status, err.Error())
+		return nil, false
+	}
+	return project, true
+}
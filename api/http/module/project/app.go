@@ -0,0 +1,166 @@
+package project
+
+import (
+	"errors"
+	"github.com/kataras/iris/v12"
+	"gorm.io/gorm"
+	"noctua/api/http/controller"
+	"noctua/internal/model"
+	"time"
+)
+
+type ProjectController struct {
+	controller.BaseController
+}
+
+// ProjectRequest 新建或更新项目的请求体
+type ProjectRequest struct {
+	Code              string `json:"code" validate:"required,alphanum,max=64"` // 更新时不可修改
+	Name              string `json:"name" validate:"max=128"`
+	MaxConcurrentJobs int    `json:"maxConcurrentJobs" validate:"min=0"` // 同时运行的采集任务数上限，0 使用默认配置
+	Status            int    `json:"status" validate:"omitempty,oneof=10 100"`
+}
+
+// ProjectView 项目的返回结构
+type ProjectView struct {
+	ID                uint      `json:"id"`
+	Code              string    `json:"code"`
+	Name              string    `json:"name"`
+	MaxConcurrentJobs int       `json:"maxConcurrentJobs"`
+	Status            int       `json:"status"`
+	RunningJobs       int       `json:"runningJobs"` // 运行中的采集任务数
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+func (c *ProjectController) newProjectView(project *model.Project) ProjectView {
+	return ProjectView{
+		ID:                project.ID,
+		Code:              project.Code,
+		Name:              project.Name,
+		MaxConcurrentJobs: project.MaxConcurrentJobs,
+		Status:            project.Status,
+		RunningJobs:       c.Kernel.CrawlerManager.ProjectJobs(project.ID),
+		CreatedAt:         project.CreatedAt,
+		UpdatedAt:         project.UpdatedAt,
+	}
+}
+
+// List 项目列表，第一项为默认项目
+func (c *ProjectController) List(ctx iris.Context) error {
+	projects, err := (&model.Project{}).All()
+	if err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	views := make([]ProjectView, 0, len(projects)+1)
+	views = append(views, c.newProjectView(model.DefaultProject()))
+	for i := range projects {
+		views = append(views, c.newProjectView(&projects[i]))
+	}
+	return controller.Success(ctx, views)
+}
+
+// Detail 项目详情
+func (c *ProjectController) Detail(ctx iris.Context) error {
+	project, ok := findProject(ctx)
+	if !ok {
+		return nil
+	}
+	return controller.Success(ctx, c.newProjectView(project))
+}
+
+// Create 新建项目
+func (c *ProjectController) Create(ctx iris.Context) error {
+	request := &ProjectRequest{}
+	if !controller.ReadRequest(ctx, request) {
+		return nil
+	}
+	if request.Code == model.DefaultProjectCode {
+		return controller.Fail(ctx, iris.StatusConflict, "Project code default is reserved")
+	}
+	_, err := (&model.Project{}).FindByCode(request.Code)
+	if err == nil {
+		return controller.Fail(ctx, iris.StatusConflict, "Project already exists")
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	project := &model.Project{
+		Code:              request.Code,
+		Name:              request.Name,
+		MaxConcurrentJobs: request.MaxConcurrentJobs,
+		Status:            model.ProjectStatusNormal,
+	}
+	if request.Status > 0 {
+		project.Status = request.Status
+	}
+	if err := project.Create(); err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	return controller.Success(ctx, c.newProjectView(project))
+}
+
+// Update 更新项目，编码不可修改，默认项目不可修改
+func (c *ProjectController) Update(ctx iris.Context) error {
+	project, ok := findProject(ctx)
+	if !ok {
+		return nil
+	}
+	if project.ID == model.DefaultProjectID {
+		return controller.Fail(ctx, iris.StatusBadRequest, "Default project cannot be modified")
+	}
+	request := &ProjectRequest{}
+	if !controller.ReadRequest(ctx, request) {
+		return nil
+	}
+	if request.Code != project.Code {
+		return controller.Fail(ctx, iris.StatusBadRequest, "code cannot be changed")
+	}
+	project.Name = request.Name
+	project.MaxConcurrentJobs = request.MaxConcurrentJobs
+	fields := []string{"name", "max_concurrent_jobs"}
+	if request.Status > 0 {
+		project.Status = request.Status
+		fields = append(fields, "status")
+	}
+	if err := project.UpdateFields(fields...); err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	return controller.Success(ctx, c.newProjectView(project))
+}
+
+// Delete 删除项目，项目下仍有账号或运行中的任务时不可删除
+func (c *ProjectController) Delete(ctx iris.Context) error {
+	project, ok := findProject(ctx)
+	if !ok {
+		return nil
+	}
+	if project.ID == model.DefaultProjectID {
+		return controller.Fail(ctx, iris.StatusBadRequest, "Default project cannot be deleted")
+	}
+	count, err := project.AccountCount()
+	if err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	if count > 0 || c.Kernel.CrawlerManager.ProjectJobs(project.ID) > 0 {
+		return controller.Fail(ctx, iris.StatusConflict, "Project still has accounts or running jobs")
+	}
+	if err := project.Delete(project.ID); err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	return controller.Success(ctx, nil)
+}
+
+// findProject 按路径中的ID查询项目，ID 为 0 时为默认项目，失败时已写出响应
+func findProject(ctx iris.Context) (*model.Project, bool) {
+	project, err := (&model.Project{}).Find(ctx.Params().GetUintDefault("id", 0))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		_ = controller.Fail(ctx, iris.StatusNotFound, "Project not found")
+		return nil, false
+	}
+	if err != nil {
+		_ = controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	return project, true
+}
@@ -0,0 +1,148 @@
+package session
+
+import (
+	"errors"
+	"github.com/kataras/iris/v12"
+	"noctua/api/http/controller"
+	"noctua/kernel/session"
+	"noctua/types"
+	"time"
+)
+
+type SessionController struct {
+	controller.BaseController
+}
+
+// AcquireRequest 获取会话租约的请求体
+type AcquireRequest struct {
+	MediaCode   string `json:"mediaCode" validate:"required,alphanum,max=32"`
+	UserID      string `json:"userId" validate:"max=64"` // 优先使用的账号，不可用时按选择策略更换
+	AccountType int    `json:"accountType" validate:"min=0,max=2"`
+	Region      string `json:"region" validate:"max=16"`
+	Holder      string `json:"holder" validate:"max=64"`
+	TTL         int    `json:"ttl" validate:"min=0,max=86400"` // 租期，秒，为 0 时使用默认租期
+}
+
+// RenewRequest 续期租约的请求体
+type RenewRequest struct {
+	TTL int `json:"ttl" validate:"min=0,max=86400"` // 租期，秒，为 0 时沿用上次的租期
+}
+
+// LeaseView 租约及会话信息，不包含账号 Cookie
+type LeaseView struct {
+	session.Lease
+	Session    types.SessionInfo `json:"session"`
+	ExpireTime time.Time         `json:"expireTime"` // 会话代理的过期时间
+}
+
+// Status 请求所属项目的会话及租约概况
+func (c *SessionController) Status(ctx iris.Context) error {
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		return controller.Fail(ctx, status, err.Error())
+	}
+	return controller.Success(ctx, c.Kernel.SessionManager.Status(project.ID))
+}
+
+// Leases 查询请求所属项目的租约
+func (c *SessionController) Leases(ctx iris.Context) error {
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		return controller.Fail(ctx, status, err.Error())
+	}
+	return controller.Success(ctx, c.Kernel.SessionManager.Leases(project.ID, ctx.URLParam("mediaCode")))
+}
+
+// Lease 租约详情
+func (c *SessionController) Lease(ctx iris.Context) error {
+	lease, ok := c.findLease(ctx)
+	if !ok {
+		return nil
+	}
+	return controller.Success(ctx, lease)
+}
+
+// Acquire 获取请求所属项目账号的会话并持有租约
+func (c *SessionController) Acquire(ctx iris.Context) error {
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		return controller.Fail(ctx, status, err.Error())
+	}
+	request := &AcquireRequest{}
+	if !controller.ReadRequest(ctx, request) {
+		return nil
+	}
+	current, lease, err := c.Kernel.SessionManager.Acquire(&session.SessionParams{
+		ProjectID:     project.ID,
+		MediaCode:     request.MediaCode,
+		UserID:        request.UserID,
+		AccountType:   request.AccountType,
+		SessionRegion: request.Region,
+		Holder:        request.Holder,
+		LeaseTTL:      time.Duration(request.TTL) * time.Second,
+	})
+	if err != nil {
+		return controller.Fail(ctx, iris.StatusConflict, err.Error())
+	}
+	info := types.SessionInfo{MediaCode: lease.MediaCode, UserID: lease.UserID, Region: request.Region}
+	if current.ProxyInfo != nil {
+		info.ProxyKey = current.ProxyInfo.ProxyKey
+	}
+	return controller.Success(ctx, LeaseView{Lease: lease, Session: info, ExpireTime: current.ExpireTime})
+}
+
+// Renew 续期租约
+func (c *SessionController) Renew(ctx iris.Context) error {
+	request := &RenewRequest{}
+	if ctx.GetContentLength() > 0 && !controller.ReadRequest(ctx, request) {
+		return nil
+	}
+	current, ok := c.findLease(ctx)
+	if !ok {
+		return nil
+	}
+	lease, err := c.Kernel.SessionManager.Renew(current.ID, time.Duration(request.TTL)*time.Second)
+	if errors.Is(err, session.ErrLeaseNotFound) {
+		return controller.Fail(ctx, iris.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	return controller.Success(ctx, lease)
+}
+
+// Release 释放租约
+func (c *SessionController) Release(ctx iris.Context) error {
+	lease, ok := c.findLease(ctx)
+	if !ok {
+		return nil
+	}
+	err := c.Kernel.SessionManager.Release(lease.ID)
+	if errors.Is(err, session.ErrLeaseNotFound) {
+		return controller.Fail(ctx, iris.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	return controller.Success(ctx, nil)
+}
+
+// Reclaim 立即回收到期的租约
+func (c *SessionController) Reclaim(ctx iris.Context) error {
+	return controller.Success(ctx, map[string]int{"reclaimed": c.Kernel.SessionManager.ReclaimExpired()})
+}
+
+// findLease 查询请求所属项目的租约，其他项目的租约视为不存在，失败时已写出响应
+func (c *SessionController) findLease(ctx iris.Context) (session.Lease, bool) {
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		_ = controller.Fail(ctx, status, err.Error())
+		return session.Lease{}, false
+	}
+	lease, ok := c.Kernel.SessionManager.Lease(ctx.Params().Get("id"))
+	if !ok || lease.ProjectID != project.ID {
+		_ = controller.Fail(ctx, iris.StatusNotFound, session.ErrLeaseNotFound.Error())
+		return session.Lease{}, false
+	}
+	return lease, true
+}
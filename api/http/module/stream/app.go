@@ -0,0 +1,145 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"github.com/kataras/iris/v12"
+	"golang.org/x/net/websocket"
+	"net/http"
+	"noctua/api/http/controller"
+	"noctua/kernel/stream"
+	"noctua/pkg/logger"
+	"time"
+)
+
+type StreamController struct {
+	controller.BaseController
+}
+
+// sseWriter 以 Server-Sent Events 格式写出消息
+type sseWriter struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController // 用于设置底层连接的写超时
+}
+
+func (s *sseWriter) WriteMessage(msg *stream.Message, deadline time.Time) error {
+	_ = s.rc.SetWriteDeadline(deadline)
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", msg.Type, msg.JSON()); err != nil {
+		return err
+	}
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// wsWriter 以 WebSocket 文本帧写出消息
+type wsWriter struct {
+	conn *websocket.Conn
+}
+
+func (s *wsWriter) WriteMessage(msg *stream.Message, deadline time.Time) error {
+	_ = s.conn.SetWriteDeadline(deadline)
+	return websocket.Message.Send(s.conn, string(msg.JSON()))
+}
+
+// SSE 通过 Server-Sent Events 推送事件和运行时数据
+func (c *StreamController) SSE(ctx iris.Context) error {
+	client, ok := c.register(ctx)
+	if !ok {
+		return nil
+	}
+	ctx.ContentType("text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.StatusCode(iris.StatusOK)
+	w := ctx.ResponseWriter()
+	w.Flush()
+	writer := &sseWriter{w: w, rc: http.NewResponseController(w.Naive())}
+	if err := c.Kernel.Stream.Serve(ctx.Request().Context(), client, writer); err != nil {
+		logger.Log.Debugf("Stream client %d (%s) write failed: %v", client.ID, client.Remote, err)
+	}
+	return nil
+}
+
+// WebSocket 通过 WebSocket 推送事件和运行时数据，客户端发送的消息会被忽略
+func (c *StreamController) WebSocket(ctx iris.Context) error {
+	client, ok := c.register(ctx)
+	if !ok {
+		return nil
+	}
+	server := websocket.Server{Handshake: c.handshake, Handler: func(conn *websocket.Conn) {
+		connCtx, cancel := context.WithCancel(ctx.Request().Context())
+		defer cancel()
+		// 读取到错误说明连接已关闭
+		go func() {
+			defer cancel()
+			var discard string
+			for websocket.Message.Receive(conn, &discard) == nil {
+			}
+		}()
+		if err := c.Kernel.Stream.Serve(connCtx, client, &wsWriter{conn: conn}); err != nil {
+			logger.Log.Debugf("Stream client %d (%s) write failed: %v", client.ID, client.Remote, err)
+		}
+	}}
+	server.ServeHTTP(ctx.ResponseWriter(), ctx.Request())
+	// 握手失败时 Handler 不会执行
+	c.Kernel.Stream.Unregister(client)
+	return nil
+}
+
+// handshake 校验握手请求的来源，拒绝时返回 403
+func (c *StreamController) handshake(config *websocket.Config, req *http.Request) error {
+	origin, err := websocket.Origin(config, req)
+	if err != nil {
+		return err
+	}
+	if !c.Kernel.Stream.Config().AllowOrigin(origin, req.Host) {
+		return fmt.Errorf("origin %s not allowed", origin)
+	}
+	config.Origin = origin
+	return nil
+}
+
+// Status 推送连接状态，只列出所属项目的连接
+func (c *StreamController) Status(ctx iris.Context) error {
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		ctx.StatusCode(status)
+		return ctx.JSON(map[string]interface{}{
+			"code": status,
+			"msg":  err.Error(),
+		})
+	}
+	return ctx.JSON(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": c.Kernel.Stream.Status(project.ID),
+	})
+}
+
+// register 按查询参数注册客户端，只推送请求所属项目的消息，失败时已写出响应
+func (c *StreamController) register(ctx iris.Context) (*stream.Client, bool) {
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		ctx.StatusCode(status)
+		_ = ctx.JSON(map[string]interface{}{
+			"code": status,
+			"msg":  err.Error(),
+		})
+		return nil, false
+	}
+	filter := stream.ParseFilter(ctx.URLParam("type"), ctx.URLParam("topic"), ctx.URLParam("code"), ctx.URLParam("jobId"))
+	filter.ProjectID = project.ID
+	client, err := c.Kernel.Stream.Register(ctx.RemoteAddr(), filter)
+	if err != nil {
+		ctx.StatusCode(iris.StatusServiceUnavailable)
+		_ = ctx.JSON(map[string]interface{}{
+			"code": iris.StatusServiceUnavailable,
+			"msg":  err.Error(),
+		})
+		return nil, false
+	}
+	return client, true
+}
@@ -0,0 +1,254 @@
+package webhook
+
+import (
+	"errors"
+	"github.com/kataras/iris/v12"
+	"gorm.io/gorm"
+	"noctua/api/http/controller"
+	"noctua/internal/model"
+	"noctua/kernel/webhook"
+	"noctua/pkg/logger"
+	"time"
+)
+
+const maskedSecret = "******"
+
+type WebhookController struct {
+	controller.BaseController
+}
+
+// SubscriptionRequest 新建或更新订阅的请求体
+type SubscriptionRequest struct {
+	Name    string   `json:"name" validate:"max=64"`
+	URL     string   `json:"url" validate:"required,url,max=512"`
+	Events  []string `json:"events" validate:"required,min=1,dive,required,max=64"`
+	Secret  string   `json:"secret" validate:"max=128"` // 更新时为空或为掩码则保留原密钥
+	Enabled *bool    `json:"enabled"`
+}
+
+// SubscriptionView 订阅的返回结构，密钥以掩码展示
+type SubscriptionView struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newSubscriptionView(subscription *model.WebhookSubscription) SubscriptionView {
+	view := SubscriptionView{
+		ID:        subscription.ID,
+		Name:      subscription.Name,
+		URL:       subscription.URL,
+		Events:    subscription.Topics(),
+		Enabled:   subscription.Enabled,
+		CreatedAt: subscription.CreatedAt,
+		UpdatedAt: subscription.UpdatedAt,
+	}
+	if subscription.Secret != "" {
+		view.Secret = maskedSecret
+	}
+	return view
+}
+
+// List 订阅列表
+func (c *WebhookController) List(ctx iris.Context) error {
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		return controller.Fail(ctx, status, err.Error())
+	}
+	subscriptions, err := (&model.WebhookSubscription{}).List(project.ID)
+	if err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	views := make([]SubscriptionView, 0, len(subscriptions))
+	for i := range subscriptions {
+		views = append(views, newSubscriptionView(&subscriptions[i]))
+	}
+	return controller.Success(ctx, views)
+}
+
+// Create 新建订阅
+func (c *WebhookController) Create(ctx iris.Context) error {
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		return controller.Fail(ctx, status, err.Error())
+	}
+	request, ok := readSubscription(ctx)
+	if !ok {
+		return nil
+	}
+	subscription := &model.WebhookSubscription{ProjectID: project.ID, Name: request.Name, URL: request.URL, Secret: request.Secret, Enabled: true}
+	subscription.SetTopics(request.Events)
+	if request.Enabled != nil {
+		subscription.Enabled = *request.Enabled
+	}
+	if err := subscription.Create(); err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	// 新建时 gorm 会忽略零值，禁用状态需单独写入
+	if !subscription.Enabled {
+		if err := subscription.Save(); err != nil {
+			return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+		}
+	}
+	c.reload()
+	return controller.Success(ctx, newSubscriptionView(subscription))
+}
+
+// Update 更新订阅
+func (c *WebhookController) Update(ctx iris.Context) error {
+	subscription, ok := c.findSubscription(ctx)
+	if !ok {
+		return nil
+	}
+	request, ok := readSubscription(ctx)
+	if !ok {
+		return nil
+	}
+	subscription.Name = request.Name
+	subscription.URL = request.URL
+	subscription.SetTopics(request.Events)
+	if request.Secret != "" && request.Secret != maskedSecret {
+		subscription.Secret = request.Secret
+	}
+	if request.Enabled != nil {
+		subscription.Enabled = *request.Enabled
+	}
+	if err := subscription.Save(); err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	c.reload()
+	return controller.Success(ctx, newSubscriptionView(subscription))
+}
+
+// Delete 删除订阅，未完成的投递将标记为失败
+func (c *WebhookController) Delete(ctx iris.Context) error {
+	subscription, ok := c.findSubscription(ctx)
+	if !ok {
+		return nil
+	}
+	if err := subscription.Delete(subscription.ID); err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	c.reload()
+	return controller.Success(ctx, nil)
+}
+
+// Deliveries 分页查询投递记录
+func (c *WebhookController) Deliveries(ctx iris.Context) error {
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		return controller.Fail(ctx, status, err.Error())
+	}
+	params := &model.WebhookDeliveryQueryParams{
+		ProjectID:      project.ID,
+		SubscriptionID: uint(ctx.URLParamUint64("subscriptionId")),
+		Status:         ctx.URLParam("status"),
+		Topic:          ctx.URLParam("topic"),
+		JobID:          ctx.URLParam("jobId"),
+	}
+	page := ctx.URLParamIntDefault("page", 1)
+	pageSize := ctx.URLParamIntDefault("pageSize", 20)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	result, err := (&model.WebhookDelivery{}).List(params, page, pageSize)
+	if err != nil {
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	return controller.Success(ctx, result)
+}
+
+// Delivery 投递记录详情
+func (c *WebhookController) Delivery(ctx iris.Context) error {
+	delivery, ok := c.findDelivery(ctx)
+	if !ok {
+		return nil
+	}
+	return controller.Success(ctx, delivery)
+}
+
+// Redeliver 以原请求体重新投递
+func (c *WebhookController) Redeliver(ctx iris.Context) error {
+	if c.Kernel.Webhook == nil {
+		return controller.Fail(ctx, iris.StatusServiceUnavailable, "Webhook is disabled")
+	}
+	origin, ok := c.findDelivery(ctx)
+	if !ok {
+		return nil
+	}
+	delivery, err := c.Kernel.Webhook.Redeliver(origin.ID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return controller.Fail(ctx, iris.StatusNotFound, "Webhook delivery not found")
+	case errors.Is(err, webhook.ErrDeliveryPending):
+		return controller.Fail(ctx, iris.StatusConflict, err.Error())
+	case err != nil:
+		return controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+	}
+	return controller.Success(ctx, delivery)
+}
+
+// findSubscription 查询请求所属项目的订阅，失败时已写出响应
+func (c *WebhookController) findSubscription(ctx iris.Context) (*model.WebhookSubscription, bool) {
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		_ = controller.Fail(ctx, status, err.Error())
+		return nil, false
+	}
+	subscription, err := (&model.WebhookSubscription{}).Find(ctx.Params().GetUintDefault("id", 0))
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && subscription.ProjectID != project.ID) {
+		_ = controller.Fail(ctx, iris.StatusNotFound, "Webhook subscription not found")
+		return nil, false
+	}
+	if err != nil {
+		_ = controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	return subscription, true
+}
+
+// findDelivery 查询请求所属项目的投递记录，失败时已写出响应
+func (c *WebhookController) findDelivery(ctx iris.Context) (*model.WebhookDelivery, bool) {
+	project, status, err := c.Project(ctx)
+	if err != nil {
+		_ = controller.Fail(ctx, status, err.Error())
+		return nil, false
+	}
+	delivery, err := (&model.WebhookDelivery{}).Find(ctx.Params().GetUintDefault("id", 0))
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && delivery.ProjectID != project.ID) {
+		_ = controller.Fail(ctx, iris.StatusNotFound, "Webhook delivery not found")
+		return nil, false
+	}
+	if err != nil {
+		_ = controller.Fail(ctx, iris.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	return delivery, true
+}
+
+// reload 订阅变更后刷新投递器的缓存
+func (c *WebhookController) reload() {
+	if c.Kernel.Webhook == nil {
+		return
+	}
+	if err := c.Kernel.Webhook.Reload(); err != nil {
+		logger.Log.Errorf("Reload webhook subscriptions failed: %v", err)
+	}
+}
+
+// readSubscription 读取并校验请求体，失败时已写出响应
+func readSubscription(ctx iris.Context) (*SubscriptionRequest, bool) {
+	request := &SubscriptionRequest{}
+	if !controller.ReadRequest(ctx, request) {
+		return nil, false
+	}
+	return request, true
+}
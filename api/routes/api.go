@@ -32,4 +32,39 @@ func ApiRoutes(app *iris.Application, kernel *kernel.Kernel) {
 	{
 		modules.CrawlRoutes(crawlGroup, kernel)
 	}
+	// 事件日志
+	eventGroup := app.Party("/v1/events")
+	{
+		modules.EventRoutes(eventGroup, kernel)
+	}
+	// 实时推送
+	streamGroup := app.Party("/v1/stream")
+	{
+		modules.StreamRoutes(streamGroup, kernel)
+	}
+	// Webhook 订阅与投递记录
+	webhookGroup := app.Party("/v1/webhooks")
+	{
+		modules.WebhookRoutes(webhookGroup, kernel)
+	}
+	// 消息中心
+	notificationGroup := app.Party("/v1/notifications")
+	{
+		modules.NotificationRoutes(notificationGroup, kernel)
+	}
+	// 项目管理
+	projectGroup := app.Party("/v1/projects")
+	{
+		modules.ProjectRoutes(projectGroup, kernel)
+	}
+	// 账号管理
+	accountGroup := app.Party("/v1/accounts")
+	{
+		modules.AccountRoutes(accountGroup, kernel)
+	}
+	// 会话租约
+	sessionGroup := app.Party("/v1/sessions")
+	{
+		modules.SessionRoutes(sessionGroup, kernel)
+	}
 }
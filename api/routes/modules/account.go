@@ -0,0 +1,46 @@
+package modules
+
+import (
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/core/router"
+	"noctua/api/http/module/account"
+	"noctua/kernel"
+)
+
+func AccountRoutes(app router.Party, kernel *kernel.Kernel) {
+	c := account.AccountController{}
+	c.SetKernel(kernel)
+	app.Get("/", func(ctx iris.Context) {
+		_ = c.List(ctx)
+	})
+	app.Post("/", func(ctx iris.Context) {
+		_ = c.Create(ctx)
+	})
+	app.Post("/reencrypt", func(ctx iris.Context) {
+		_ = c.Reencrypt(ctx)
+	})
+	app.Get("/{id:uint}", func(ctx iris.Context) {
+		_ = c.Detail(ctx)
+	})
+	app.Put("/{id:uint}", func(ctx iris.Context) {
+		_ = c.Update(ctx)
+	})
+	app.Delete("/{id:uint}", func(ctx iris.Context) {
+		_ = c.Delete(ctx)
+	})
+	app.Post("/{id:uint}/enable", func(ctx iris.Context) {
+		_ = c.Enable(ctx)
+	})
+	app.Post("/{id:uint}/disable", func(ctx iris.Context) {
+		_ = c.Disable(ctx)
+	})
+	app.Get("/{id:uint}/usage", func(ctx iris.Context) {
+		_ = c.Usage(ctx)
+	})
+	app.Get("/{id:uint}/cookies", func(ctx iris.Context) {
+		_ = c.Cookies(ctx)
+	})
+	app.Post("/{id:uint}/cookies", func(ctx iris.Context) {
+		_ = c.ImportCookies(ctx)
+	})
+}
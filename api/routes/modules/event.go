@@ -0,0 +1,16 @@
+package modules
+
+import (
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/core/router"
+	"noctua/api/http/module/event"
+	"noctua/kernel"
+)
+
+func EventRoutes(app router.Party, kernel *kernel.Kernel) {
+	c := event.EventController{}
+	c.SetKernel(kernel)
+	app.Get("/", func(ctx iris.Context) {
+		_ = c.List(ctx)
+	})
+}
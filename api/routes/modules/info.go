@@ -16,4 +16,13 @@ func InfoRoutes(app router.Party, kernel *kernel.Kernel) {
 	app.Get("/sessionStatus", func(ctx iris.Context) {
 		_ = c.SessionStatus(ctx)
 	})
+	app.Get("/sinkStatus", func(ctx iris.Context) {
+		_ = c.SinkStatus(ctx)
+	})
+	app.Get("/eventBusStatus", func(ctx iris.Context) {
+		_ = c.EventBusStatus(ctx)
+	})
+	app.Get("/runtimeStatus", func(ctx iris.Context) {
+		_ = c.RuntimeStatus(ctx)
+	})
 }
@@ -0,0 +1,28 @@
+package modules
+
+import (
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/core/router"
+	"noctua/api/http/module/notification"
+	"noctua/kernel"
+)
+
+func NotificationRoutes(app router.Party, kernel *kernel.Kernel) {
+	c := notification.NotificationController{}
+	c.SetKernel(kernel)
+	app.Get("/", func(ctx iris.Context) {
+		_ = c.List(ctx)
+	})
+	app.Get("/unread", func(ctx iris.Context) {
+		_ = c.Unread(ctx)
+	})
+	app.Post("/read", func(ctx iris.Context) {
+		_ = c.MarkRead(ctx)
+	})
+	app.Post("/delete", func(ctx iris.Context) {
+		_ = c.BatchDelete(ctx)
+	})
+	app.Delete("/{id:uint}", func(ctx iris.Context) {
+		_ = c.Delete(ctx)
+	})
+}
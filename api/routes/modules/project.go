@@ -0,0 +1,28 @@
+package modules
+
+import (
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/core/router"
+	"noctua/api/http/module/project"
+	"noctua/kernel"
+)
+
+func ProjectRoutes(app router.Party, kernel *kernel.Kernel) {
+	c := project.ProjectController{}
+	c.SetKernel(kernel)
+	app.Get("/", func(ctx iris.Context) {
+		_ = c.List(ctx)
+	})
+	app.Post("/", func(ctx iris.Context) {
+		_ = c.Create(ctx)
+	})
+	app.Get("/{id:uint}", func(ctx iris.Context) {
+		_ = c.Detail(ctx)
+	})
+	app.Put("/{id:uint}", func(ctx iris.Context) {
+		_ = c.Update(ctx)
+	})
+	app.Delete("/{id:uint}", func(ctx iris.Context) {
+		_ = c.Delete(ctx)
+	})
+}
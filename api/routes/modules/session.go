@@ -0,0 +1,34 @@
+package modules
+
+import (
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/core/router"
+	"noctua/api/http/module/session"
+	"noctua/kernel"
+)
+
+func SessionRoutes(app router.Party, kernel *kernel.Kernel) {
+	c := session.SessionController{}
+	c.SetKernel(kernel)
+	app.Get("/", func(ctx iris.Context) {
+		_ = c.Status(ctx)
+	})
+	app.Get("/leases", func(ctx iris.Context) {
+		_ = c.Leases(ctx)
+	})
+	app.Post("/leases", func(ctx iris.Context) {
+		_ = c.Acquire(ctx)
+	})
+	app.Get("/leases/{id:string}", func(ctx iris.Context) {
+		_ = c.Lease(ctx)
+	})
+	app.Post("/leases/{id:string}/renew", func(ctx iris.Context) {
+		_ = c.Renew(ctx)
+	})
+	app.Delete("/leases/{id:string}", func(ctx iris.Context) {
+		_ = c.Release(ctx)
+	})
+	app.Post("/leases/reclaim", func(ctx iris.Context) {
+		_ = c.Reclaim(ctx)
+	})
+}
@@ -0,0 +1,22 @@
+package modules
+
+import (
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/core/router"
+	"noctua/api/http/module/stream"
+	"noctua/kernel"
+)
+
+func StreamRoutes(app router.Party, kernel *kernel.Kernel) {
+	c := stream.StreamController{}
+	c.SetKernel(kernel)
+	app.Get("/sse", func(ctx iris.Context) {
+		_ = c.SSE(ctx)
+	})
+	app.Get("/ws", func(ctx iris.Context) {
+		_ = c.WebSocket(ctx)
+	})
+	app.Get("/status", func(ctx iris.Context) {
+		_ = c.Status(ctx)
+	})
+}
@@ -0,0 +1,34 @@
+package modules
+
+import (
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/core/router"
+	"noctua/api/http/module/webhook"
+	"noctua/kernel"
+)
+
+func WebhookRoutes(app router.Party, kernel *kernel.Kernel) {
+	c := webhook.WebhookController{}
+	c.SetKernel(kernel)
+	app.Get("/", func(ctx iris.Context) {
+		_ = c.List(ctx)
+	})
+	app.Post("/", func(ctx iris.Context) {
+		_ = c.Create(ctx)
+	})
+	app.Put("/{id:uint}", func(ctx iris.Context) {
+		_ = c.Update(ctx)
+	})
+	app.Delete("/{id:uint}", func(ctx iris.Context) {
+		_ = c.Delete(ctx)
+	})
+	app.Get("/deliveries", func(ctx iris.Context) {
+		_ = c.Deliveries(ctx)
+	})
+	app.Get("/deliveries/{id:uint}", func(ctx iris.Context) {
+		_ = c.Delivery(ctx)
+	})
+	app.Post("/deliveries/{id:uint}/redeliver", func(ctx iris.Context) {
+		_ = c.Redeliver(ctx)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"noctua/api"
 	"noctua/kernel"
+	"noctua/kernel/broadcast"
 	_ "noctua/pkg"
 	"noctua/types"
 )
@@ -13,8 +14,7 @@ func main() {
 	app := kernel.NewKernel(context.Background(), "dev")
 	app.AddRuntimeHandler(func(data types.RuntimeData) {
 		fmt.Println(data.EventData)
-	})
-	app.ProcessRuntime()
+	}, broadcast.Options{Name: "console"})
 
 	server := api.NewServer(app)
 	err := server.Run(app.Ctx)
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"flag"
+	"noctua/internal/model"
+	_ "noctua/pkg"
+	"noctua/pkg/logger"
+)
+
+// 使用当前密钥重新加密账号凭据，启用加密或轮换密钥后执行，旧密钥需保留在 secret.keys 中直至执行完成
+func main() {
+	batchSize := flag.Int("batch", 100, "number of accounts read per batch")
+	flag.Parse()
+
+	result, err := (&model.MediaAccount{}).ReencryptCredentials(*batchSize)
+	if err != nil {
+		logger.Log.Fatalf("Re-encrypt account credentials failed: %v", err)
+	}
+	logger.Log.Infof("Re-encrypted account credentials, scanned: %d, updated: %d, failed: %d",
+		result.Scanned, result.Updated, len(result.FailedIDs))
+	if len(result.FailedIDs) > 0 {
+		logger.Log.Fatalf("Failed to decrypt accounts %v, check whether their keys are configured", result.FailedIDs)
+	}
+}
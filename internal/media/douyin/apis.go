@@ -70,6 +70,10 @@ func (c *DouYinApiClient) SearchInfoByKeyword(params *SearchParams) (*SearchResp
 	if err != nil {
 		return nil, err
 	}
+	// 触发验证码视为限流
+	if result.SearchNilInfo.SearchNilType == "verify_check" {
+		c.reportRateLimit("verify_check")
+	}
 
 	return result, nil
 }
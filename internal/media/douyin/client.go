@@ -1,9 +1,11 @@
 package douyin
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/go-resty/resty/v2"
+	"net/http"
 	"noctua/internal/signer"
 	"noctua/pkg/engine"
 	"noctua/pkg/httpx"
@@ -15,16 +17,23 @@ import (
 
 const MaxRetries = 5
 
-// DouYinApiClient 负责抖音 API 请求
+// DouYinApiClient 负责抖音 API 请求，绑定单个会话，不能并发使用，并发任务需各自持有客户端
 type DouYinApiClient struct {
 	userAgent      string
 	verifyParams   VerifyParams
 	signClient     *signer.SignServerClient
 	currentSession *types.Session
+	leaseID        string // 当前会话持有的租约，持有期间不再重新获取会话
 	missingSession func()
 	discardSession func(*types.Session)
 	acquireSession func(*types.Session) (*types.Session, error)
+	releaseSession func(leaseID string)
 	refreshSession func(*types.Session) (*types.Session, error)
+	rateLimited    func(*types.Session, string)
+	onCookies      func(*types.Session, []*http.Cookie)
+	proxyResult    func(*types.Session, time.Duration, error)
+	recorder       *httpx.Recorder
+	endpoints      Endpoints
 }
 
 // NewDouYinApiClient 创建 DouYinApiClient
@@ -32,13 +41,40 @@ func NewDouYinApiClient(signClient *signer.SignServerClient) *DouYinApiClient {
 	return &DouYinApiClient{
 		signClient: signClient,
 		userAgent:  DOUYIN_FIXED_USER_AGENT,
+		endpoints:  DefaultEndpoints(),
 	}
 }
 
+// SetRecorder 设置录制回放器，为 nil 时直连
+func (c *DouYinApiClient) SetRecorder(recorder *httpx.Recorder) {
+	c.recorder = recorder
+}
+
+// SetEndpoints 覆盖接口地址，空字段保持默认
+func (c *DouYinApiClient) SetEndpoints(endpoints Endpoints) {
+	c.endpoints = c.endpoints.Merge(endpoints)
+}
+
+// setProxy 设置代理，开启录制回放时通过包装后的 Transport 生效
+func (c *DouYinApiClient) setProxy(client *resty.Client, proxy string) {
+	if c.recorder == nil {
+		if proxy != "" {
+			client.SetProxy(proxy)
+		}
+		return
+	}
+	client.SetTransport(c.recorder.Wrap(httpx.ProxyTransport(proxy)))
+}
+
 func (c *DouYinApiClient) OnAcquireSession(fn func(session *types.Session) (*types.Session, error)) {
 	c.acquireSession = fn
 }
 
+// OnReleaseSession 释放会话租约时回调
+func (c *DouYinApiClient) OnReleaseSession(fn func(leaseID string)) {
+	c.releaseSession = fn
+}
+
 func (c *DouYinApiClient) OnRefreshSession(fn func(session *types.Session) (*types.Session, error)) {
 	c.refreshSession = fn
 }
@@ -51,7 +87,64 @@ func (c *DouYinApiClient) OnMissingSession(fn func()) {
 	c.missingSession = fn
 }
 
+// OnRateLimited 当前账号触发平台限流时回调，reason 为限流信号
+func (c *DouYinApiClient) OnRateLimited(fn func(session *types.Session, reason string)) {
+	c.rateLimited = fn
+}
+
+// OnResponseCookies 响应携带 Set-Cookie 时回调，用于更新账号的 Cookie
+func (c *DouYinApiClient) OnResponseCookies(fn func(session *types.Session, cookies []*http.Cookie)) {
+	c.onCookies = fn
+}
+
+// OnProxyResult 每次通过代理请求后回调，latency 为成功请求的耗时，err 不为空时代理请求失败
+func (c *DouYinApiClient) OnProxyResult(fn func(session *types.Session, latency time.Duration, err error)) {
+	c.proxyResult = fn
+}
+
+// reportProxy 上报当前会话代理的请求结果，连接错误及代理网关错误视为代理失败
+func (c *DouYinApiClient) reportProxy(resp *resty.Response, err error) {
+	if c.proxyResult == nil || c.currentSession == nil || c.currentSession.ProxyInfo == nil || !c.currentSession.ProxyInfo.Useable {
+		return
+	}
+	if err == nil && resp != nil {
+		switch resp.StatusCode() {
+		case http.StatusProxyAuthRequired, http.StatusBadGateway, http.StatusGatewayTimeout:
+			err = fmt.Errorf("proxy responded %d", resp.StatusCode())
+		}
+	}
+	var latency time.Duration
+	if err == nil && resp != nil {
+		latency = resp.Time()
+	}
+	c.proxyResult(c.currentSession, latency, err)
+}
+
+func (c *DouYinApiClient) reportRateLimit(reason string) {
+	if c.rateLimited != nil && c.currentSession != nil {
+		c.rateLimited(c.currentSession, reason)
+	}
+	// 限流的账号进入冷却，释放租约后下次请求更换账号
+	c.ReleaseSession()
+}
+
+// ReleaseSession 释放当前会话的租约，保留会话信息供下次获取时优先使用同一账号
+func (c *DouYinApiClient) ReleaseSession() {
+	if c.leaseID == "" {
+		return
+	}
+	if c.releaseSession != nil {
+		c.releaseSession(c.leaseID)
+	}
+	c.leaseID = ""
+}
+
+// withSession 获取会话，当前会话持有租约时沿用，否则释放失效的租约后重新获取
 func (c *DouYinApiClient) withSession() error {
+	if c.currentSession != nil && c.leaseID != "" {
+		return nil
+	}
+	c.ReleaseSession()
 	newSession, err := c.acquireSession(c.currentSession)
 	if err != nil {
 		return err
@@ -65,6 +158,7 @@ func (c *DouYinApiClient) withSession() error {
 	}
 
 	c.currentSession = newSession
+	c.leaseID = newSession.LeaseID
 	return nil
 }
 
@@ -107,7 +201,12 @@ func (c *DouYinApiClient) BuildVerifyParams(currentUserAgent string) {
 		userAgent = currentUserAgent
 	}
 	// 处理verifyParams
-	verifyParams, err := BuildVerifyParams(userAgent)
+	tokenManager := NewTokenManager(userAgent)
+	tokenManager.SetEndpoints(c.endpoints)
+	if c.recorder != nil {
+		tokenManager.SetTransport(c.recorder.Wrap(nil))
+	}
+	verifyParams, err := BuildVerifyParamsBy(tokenManager)
 	if err != nil {
 		logger.Log.Errorf("build verify params err: %v", err)
 	}
@@ -229,7 +328,7 @@ func (c *DouYinApiClient) fetch(uri string, params map[string]string, requestPar
 	}
 	client := resty.New().
 		//SetDebug(true).
-		SetBaseURL(DOUYIN_API_URL).
+		SetBaseURL(c.endpoints.API).
 		SetAuthScheme("").
 		SetTimeout(10 * time.Second).
 		SetRetryCount(3).
@@ -243,22 +342,29 @@ func (c *DouYinApiClient) fetch(uri string, params map[string]string, requestPar
 		return nil, fmt.Errorf("fetch failed, session is nil")
 	} else {
 		//初次使用代理
+		proxy := ""
 		if c.currentSession.ProxyInfo.Useable {
-			client.SetProxy(c.currentSession.ProxyInfo.BuildProtocol())
+			proxy = c.currentSession.ProxyInfo.BuildProtocol()
 		}
+		c.setProxy(client, proxy)
 	}
 	// 增加重试条件
 	client.AddRetryCondition(func(r *resty.Response, err error) bool {
+		// 每次请求后上报代理结果，更换会话前上报的是当前代理
+		c.reportProxy(r, err)
 		if r.StatusCode() == 200 {
 			return false
 		}
+		if r.StatusCode() == http.StatusTooManyRequests {
+			c.reportRateLimit("http 429")
+		}
 		newSession, err := c.refreshSession(c.currentSession)
 		if err != nil {
 			return false
 		}
 		c.currentSession = newSession
 		if c.currentSession.ProxyInfo.Useable {
-			client.SetProxy(c.currentSession.ProxyInfo.BuildProtocol())
+			c.setProxy(client, c.currentSession.ProxyInfo.BuildProtocol())
 		}
 		return true
 	})
@@ -278,9 +384,13 @@ func (c *DouYinApiClient) fetch(uri string, params map[string]string, requestPar
 		}
 	}
 	respBody := resp.Body()
+	if cookies := resp.Cookies(); len(cookies) > 0 && c.onCookies != nil && c.currentSession != nil {
+		c.onCookies(c.currentSession, cookies)
+	}
 	// 如果相应为空需要更换账号
 	if !redoFetch && len(string(respBody)) == 0 || string(respBody) == "blocked" {
 		logger.Log.Errorf("Account may blocked. try again later to confirm: %s", c.currentSession.Account.UserID)
+		c.reportRateLimit("blocked or empty response")
 		redoFetch = true
 	}
 	// redoFetch重新更换账号
@@ -294,15 +404,20 @@ func (c *DouYinApiClient) fetch(uri string, params map[string]string, requestPar
 	return respBody, err
 }
 
-// Pong 获取用户信息
-func (c *DouYinApiClient) Pong() (*PongResp, error) {
+// Pong 获取当前账号的登录信息，用于校验登录态
+func (c *DouYinApiClient) Pong(ctx context.Context) (*PongResp, error) {
+	if err := c.withSession(); err != nil {
+		return nil, err
+	}
 	selfInfo := &PongResp{}
 	client := resty.New().
-		SetBaseURL(DOUYIN_INDEX_URL).
+		SetBaseURL(c.endpoints.Index).
 		SetAuthScheme("")
+	proxy := ""
 	if c.currentSession.ProxyInfo.Useable == true {
-		client.SetProxy(c.currentSession.ProxyInfo.BuildProtocol())
+		proxy = c.currentSession.ProxyInfo.BuildProtocol()
 	}
+	c.setProxy(client, proxy)
 	uri := "/aweme/v1/web/query/user/"
 	queryParams, err := c.processQueryParams(uri, nil, false)
 	if err != nil {
@@ -321,9 +436,13 @@ func (c *DouYinApiClient) Pong() (*PongResp, error) {
 		"User-Agent":      c.currentSession.Account.UserAgent,
 		"Cookie":          cookieString,
 	}
-	_, err = client.R().SetHeaders(headers).SetResult(selfInfo).SetQueryParams(queryParams).Get(uri)
+	resp, err := client.R().SetContext(ctx).SetHeaders(headers).SetResult(selfInfo).SetQueryParams(queryParams).Get(uri)
+	c.reportProxy(resp, err)
 	if err != nil {
 		return nil, err
 	}
-	return selfInfo, err
+	if resp.IsError() {
+		return nil, fmt.Errorf("query user failed, status: %d", resp.StatusCode())
+	}
+	return selfInfo, nil
 }
@@ -0,0 +1,155 @@
+package douyin
+
+import (
+	"context"
+	"net/http"
+	"noctua/internal/media/douyin/douyintest"
+	"noctua/internal/model"
+	"noctua/internal/signer"
+	"noctua/pkg/logger"
+	"noctua/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	logger.Init(&logger.LoggerConfig{Level: "error"})
+}
+
+// newFakeClient 创建指向模拟服务的客户端
+func newFakeClient(server *douyintest.Server) (*DouYinApiClient, *int) {
+	client := NewDouYinApiClient(signer.NewSignServerClient(server.URL))
+	client.SetEndpoints(Endpoints{
+		API:     server.URL,
+		Index:   server.URL,
+		MsToken: server.MsTokenURL(),
+		WebID:   server.WebIDURL(),
+	})
+	session := &types.Session{
+		Enabled:   true,
+		Account:   &model.MediaAccount{UserID: "u1", MediaCode: "douyin", Cookie: `[{"name":"sessionid","value":"x"}]`},
+		ProxyInfo: &types.ProxyInfo{},
+	}
+	discarded := 0
+	client.OnAcquireSession(func(*types.Session) (*types.Session, error) { return session, nil })
+	client.OnRefreshSession(func(*types.Session) (*types.Session, error) { return session, nil })
+	client.OnDiscardSession(func(*types.Session) { discarded++ })
+	client.OnMissingSession(func() {})
+	return client, &discarded
+}
+
+func TestClientSearchPagination(t *testing.T) {
+	server := douyintest.NewServer(douyintest.Options{Awemes: 5})
+	defer server.Close()
+	client, _ := newFakeClient(server)
+
+	result, err := client.SearchInfoByKeyword(&SearchParams{Keyword: "go", Offset: 0, Count: 3})
+	assert.NoError(t, err)
+	assert.Len(t, result.Data, 3)
+	assert.Equal(t, "aweme-0", result.Data[0].AwemeInfo.AwemeID)
+
+	result, err = client.SearchInfoByKeyword(&SearchParams{Keyword: "go", Offset: 3, Count: 3})
+	assert.NoError(t, err)
+	assert.Len(t, result.Data, 2)
+	assert.Equal(t, 2, server.Signed(douyintest.PathSearch))
+	assert.Equal(t, "fake-ms-token", client.verifyParams.MsToken)
+}
+
+func TestClientSearchVerifyCheck(t *testing.T) {
+	server := douyintest.NewServer(douyintest.Options{Awemes: 5})
+	defer server.Close()
+	server.Inject(douyintest.PathSearch, douyintest.FaultVerify)
+	client, _ := newFakeClient(server)
+	var reasons []string
+	client.OnRateLimited(func(_ *types.Session, reason string) {
+		reasons = append(reasons, reason)
+	})
+
+	result, err := client.SearchInfoByKeyword(&SearchParams{Keyword: "go", Count: 3})
+	assert.NoError(t, err)
+	assert.Empty(t, result.Data)
+	assert.Equal(t, "verify_check", result.SearchNilInfo.SearchNilType)
+	assert.Equal(t, []string{"verify_check"}, reasons)
+}
+
+func TestClientBlockedRetry(t *testing.T) {
+	server := douyintest.NewServer(douyintest.Options{Awemes: 1, Comments: 3})
+	defer server.Close()
+	server.Inject(douyintest.PathComment, douyintest.FaultBlocked, douyintest.FaultEmpty)
+	client, discarded := newFakeClient(server)
+	rateLimited := 0
+	client.OnRateLimited(func(*types.Session, string) {
+		rateLimited++
+	})
+
+	result, err := client.GetAwemeComments("aweme-0", 0, "go")
+	assert.NoError(t, err)
+	assert.Len(t, result.Comments, 3)
+	assert.Equal(t, 3, server.Hits(douyintest.PathComment))
+	assert.Equal(t, 0, *discarded)
+	assert.Equal(t, 2, rateLimited)
+
+	// 持续被封时丢弃账号并最终失败
+	server.Inject(douyintest.PathComment,
+		douyintest.FaultBlocked, douyintest.FaultBlocked, douyintest.FaultBlocked,
+		douyintest.FaultBlocked, douyintest.FaultBlocked)
+	_, err = client.GetAwemeComments("aweme-0", 0, "go")
+	assert.Error(t, err)
+	assert.Greater(t, *discarded, 0)
+}
+
+func TestClientCommentPagination(t *testing.T) {
+	server := douyintest.NewServer(douyintest.Options{Awemes: 1, Comments: 5, CommentPageSize: 2})
+	defer server.Close()
+	client, _ := newFakeClient(server)
+
+	cursor, total := 0, 0
+	for {
+		result, err := client.GetAwemeComments("aweme-0", cursor, "go")
+		assert.NoError(t, err)
+		total += len(result.Comments)
+		cursor = result.Cursor
+		if result.HasMore == 0 {
+			break
+		}
+	}
+	assert.Equal(t, 5, total)
+	assert.Equal(t, 3, server.Hits(douyintest.PathComment))
+}
+
+func TestClientPong(t *testing.T) {
+	server := douyintest.NewServer(douyintest.Options{})
+	defer server.Close()
+	client, _ := newFakeClient(server)
+
+	pong, err := client.Pong(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "10000", pong.UserUID)
+
+	server.Inject(douyintest.PathQueryUser, douyintest.FaultStatus)
+	_, err = client.Pong(context.Background())
+	assert.Error(t, err)
+}
+
+func TestClientResponseCookies(t *testing.T) {
+	server := douyintest.NewServer(douyintest.Options{Awemes: 1, Comments: 1, SetCookies: []*http.Cookie{
+		{Name: "ttwid", Value: "rotated", Path: "/", MaxAge: 3600},
+	}})
+	defer server.Close()
+	client, _ := newFakeClient(server)
+	var received []*http.Cookie
+	var holder *types.Session
+	client.OnResponseCookies(func(session *types.Session, cookies []*http.Cookie) {
+		holder = session
+		received = append(received, cookies...)
+	})
+
+	_, err := client.GetAwemeComments("aweme-0", 0, "go")
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+	assert.Equal(t, "ttwid", received[0].Name)
+	assert.Equal(t, "rotated", received[0].Value)
+	assert.Same(t, client.CurrentSession(), holder)
+}
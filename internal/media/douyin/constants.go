@@ -129,6 +129,41 @@ func (p PublishTimeType) String() string {
 	return fmt.Sprintf("%d", p)
 }
 
+// Endpoints 抖音相关接口地址，测试时可指向本地服务
+type Endpoints struct {
+	API     string `mapstructure:"api"`
+	Index   string `mapstructure:"index"`
+	MsToken string `mapstructure:"ms_token"`
+	WebID   string `mapstructure:"webid"`
+}
+
+// DefaultEndpoints 线上接口地址
+func DefaultEndpoints() Endpoints {
+	return Endpoints{
+		API:     DOUYIN_API_URL,
+		Index:   DOUYIN_INDEX_URL,
+		MsToken: DOUYIN_MS_TOKEN_REQ_URL,
+		WebID:   DOUYIN_WEBID_REQ_URL,
+	}
+}
+
+// Merge 用非空字段覆盖当前地址
+func (e Endpoints) Merge(override Endpoints) Endpoints {
+	if override.API != "" {
+		e.API = override.API
+	}
+	if override.Index != "" {
+		e.Index = override.Index
+	}
+	if override.MsToken != "" {
+		e.MsToken = override.MsToken
+	}
+	if override.WebID != "" {
+		e.WebID = override.WebID
+	}
+	return e
+}
+
 const (
 	// 抖音相关常量
 	DOUYIN_INDEX_URL        = "https://www.douyin.com"
@@ -0,0 +1,268 @@
+// Package douyintest 提供基于 httptest 的抖音接口模拟服务，用于离线测试
+package douyintest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+)
+
+const (
+	PathSearch    = "/aweme/v1/web/search/item/"
+	PathComment   = "/aweme/v1/web/comment/list/"
+	PathDetail    = "/aweme/v1/web/aweme/detail/"
+	PathProfile   = "/aweme/v1/web/user/profile/other/"
+	PathQueryUser = "/aweme/v1/web/query/user/"
+	PathSign      = "/signsrv/v1/douyin/sign"
+	PathPong      = "/signsrv/pong"
+	PathMsToken   = "/web/r/token"
+	PathWebID     = "/webid"
+)
+
+// Fault 注入的故障类型
+type Fault int
+
+const (
+	FaultStatus  Fault = iota + 1 // 返回 500
+	FaultBlocked                  // 返回 blocked
+	FaultEmpty                    // 返回空响应
+	FaultVerify                   // 搜索返回 verify_check
+)
+
+// Options 模拟数据配置
+type Options struct {
+	Awemes          int            // 搜索结果总数
+	Comments        int            // 每个作品的评论总数
+	CommentPageSize int            // 评论每页数量，默认使用请求中的 count
+	CommentCount    int64          // 作品统计中的评论数，默认等于 Comments
+	SetCookies      []*http.Cookie // 每个响应携带的 Set-Cookie
+}
+
+// Server 抖音接口模拟服务
+type Server struct {
+	*httptest.Server
+	opts   Options
+	mu     sync.Mutex
+	faults map[string][]Fault
+	hits   map[string]int
+	signed map[string]int
+}
+
+// NewServer 创建并启动模拟服务
+func NewServer(opts Options) *Server {
+	if opts.CommentCount == 0 {
+		opts.CommentCount = int64(opts.Comments)
+	}
+	s := &Server{
+		opts:   opts,
+		faults: make(map[string][]Fault),
+		hits:   make(map[string]int),
+		signed: make(map[string]int),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(PathSearch, s.wrap(s.handleSearch))
+	mux.HandleFunc(PathComment, s.wrap(s.handleComment))
+	mux.HandleFunc(PathDetail, s.wrap(s.handleDetail))
+	mux.HandleFunc(PathProfile, s.wrap(s.handleProfile))
+	mux.HandleFunc(PathQueryUser, s.wrap(s.handleQueryUser))
+	mux.HandleFunc(PathSign, s.wrap(s.handleSign))
+	mux.HandleFunc(PathPong, s.wrap(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("pong"))
+	}))
+	mux.HandleFunc(PathMsToken, s.wrap(s.handleMsToken))
+	mux.HandleFunc(PathWebID, s.wrap(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"e": 0, "web_id": "7000000000000000000"})
+	}))
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// MsTokenURL msToken 接口地址
+func (s *Server) MsTokenURL() string {
+	return s.URL + PathMsToken
+}
+
+// WebIDURL webid 接口地址
+func (s *Server) WebIDURL() string {
+	return s.URL + PathWebID
+}
+
+// Inject 为指定路径注入故障，按顺序作用于后续请求
+func (s *Server) Inject(path string, faults ...Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[path] = append(s.faults[path], faults...)
+}
+
+// Hits 指定路径的请求次数
+func (s *Server) Hits(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[path]
+}
+
+// Signed 指定路径携带 a_bogus 签名的请求次数
+func (s *Server) Signed(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.signed[path]
+}
+
+func (s *Server) wrap(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.hits[r.URL.Path]++
+		if r.URL.Query().Get("a_bogus") != "" {
+			s.signed[r.URL.Path]++
+		}
+		var fault Fault
+		if queue := s.faults[r.URL.Path]; len(queue) > 0 {
+			fault, s.faults[r.URL.Path] = queue[0], queue[1:]
+		}
+		s.mu.Unlock()
+
+		for _, cookie := range s.opts.SetCookies {
+			http.SetCookie(w, cookie)
+		}
+		switch fault {
+		case FaultStatus:
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		case FaultBlocked:
+			_, _ = w.Write([]byte("blocked"))
+			return
+		case FaultEmpty:
+			return
+		case FaultVerify:
+			writeJSON(w, map[string]interface{}{
+				"status_code": 0,
+				"data":        []interface{}{},
+				"search_nil_info": map[string]interface{}{
+					"search_nil_type": "verify_check",
+					"search_nil_item": "invalid_app",
+					"is_load_more":    "is_load_more",
+				},
+			})
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	offset := queryInt(r, "offset", 0)
+	count := queryInt(r, "count", 10)
+	data := make([]interface{}, 0, count)
+	for i := offset; i < offset+count && i < s.opts.Awemes; i++ {
+		data = append(data, map[string]interface{}{"aweme_info": s.aweme(i)})
+	}
+	writeJSON(w, map[string]interface{}{
+		"status_code": 0,
+		"data":        data,
+		"extra":       map[string]interface{}{"now": 0, "logid": fmt.Sprintf("log-%d", offset)},
+	})
+}
+
+func (s *Server) handleComment(w http.ResponseWriter, r *http.Request) {
+	awemeID := r.URL.Query().Get("aweme_id")
+	cursor := queryInt(r, "cursor", 0)
+	pageSize := s.opts.CommentPageSize
+	if pageSize <= 0 {
+		pageSize = queryInt(r, "count", 20)
+	}
+	comments := make([]interface{}, 0, pageSize)
+	next := cursor
+	for ; next < cursor+pageSize && next < s.opts.Comments; next++ {
+		comments = append(comments, map[string]interface{}{
+			"cid":         fmt.Sprintf("%s-c%d", awemeID, next),
+			"text":        fmt.Sprintf("comment %d", next),
+			"aweme_id":    awemeID,
+			"create_time": 1700000000 + next,
+			"digg_count":  next,
+			"ip_label":    "北京",
+			"user":        user(fmt.Sprintf("%s-u%d", awemeID, next)),
+		})
+	}
+	hasMore := 0
+	if next < s.opts.Comments {
+		hasMore = 1
+	}
+	writeJSON(w, map[string]interface{}{
+		"status_code": 0,
+		"comments":    comments,
+		"cursor":      next,
+		"has_more":    hasMore,
+		"total":       s.opts.Comments,
+	})
+}
+
+func (s *Server) handleDetail(w http.ResponseWriter, r *http.Request) {
+	index := 0
+	_, _ = fmt.Sscanf(r.URL.Query().Get("aweme_id"), "aweme-%d", &index)
+	writeJSON(w, map[string]interface{}{"status_code": 0, "aweme_detail": s.aweme(index)})
+}
+
+func (s *Server) handleProfile(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{"status_code": 0, "user": user(r.URL.Query().Get("sec_user_id"))})
+}
+
+func (s *Server) handleQueryUser(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{"id": "1", "user_uid": "10000", "browser_name": "Chrome"})
+}
+
+func (s *Server) handleSign(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"biz_code": 0,
+		"msg":      "OK!",
+		"isok":     true,
+		"data":     map[string]interface{}{"a_bogus": "fake-a-bogus"},
+	})
+}
+
+func (s *Server) handleMsToken(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: "msToken", Value: "fake-ms-token"})
+	writeJSON(w, map[string]interface{}{})
+}
+
+func (s *Server) aweme(index int) map[string]interface{} {
+	return map[string]interface{}{
+		"aweme_id":    fmt.Sprintf("aweme-%d", index),
+		"aweme_type":  0,
+		"desc":        fmt.Sprintf("video %d", index),
+		"create_time": 1700000000 + index,
+		"author":      user(fmt.Sprintf("sec-%d", index)),
+		"statistics": map[string]interface{}{
+			"comment_count": s.opts.CommentCount,
+			"digg_count":    index,
+		},
+	}
+}
+
+func user(secUID string) map[string]interface{} {
+	return map[string]interface{}{
+		"uid":       "uid-" + secUID,
+		"sec_uid":   secUID,
+		"nickname":  "user " + secUID,
+		"short_id":  "short-" + secUID,
+		"unique_id": "unique-" + secUID,
+		"avatar_thumb": map[string]interface{}{
+			"url_list": []string{"https://example.com/" + secUID + ".jpg"},
+		},
+	}
+}
+
+func queryInt(r *http.Request, key string, fallback int) int {
+	value, err := strconv.Atoi(r.URL.Query().Get(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func writeJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(data)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/go-resty/resty/v2"
 	"math/rand"
+	"net/http"
 	"strings"
 	"time"
 )
@@ -11,18 +12,39 @@ import (
 // TokenManager 负责 token 相关的管理
 type TokenManager struct {
 	userAgent string
+	transport http.RoundTripper
+	endpoints Endpoints
 }
 
 // NewTokenManager 创建 TokenManager
 func NewTokenManager(userAgent string) *TokenManager {
 	return &TokenManager{
 		userAgent: userAgent,
+		endpoints: DefaultEndpoints(),
 	}
 }
 
+// SetTransport 设置底层 Transport，用于录制回放
+func (t *TokenManager) SetTransport(transport http.RoundTripper) {
+	t.transport = transport
+}
+
+// SetEndpoints 设置 token 接口地址
+func (t *TokenManager) SetEndpoints(endpoints Endpoints) {
+	t.endpoints = endpoints
+}
+
+func (t *TokenManager) newClient() *resty.Client {
+	client := resty.New()
+	if t.transport != nil {
+		client.SetTransport(t.transport)
+	}
+	return client
+}
+
 // GetMsToken 获取 ms_token
 func (t *TokenManager) GetMsToken() (string, error) {
-	resp, err := resty.New().
+	resp, err := t.newClient().
 		SetRetryCount(2).
 		SetRetryWaitTime(1 * time.Second).    // 初次重试等待 2s
 		SetRetryMaxWaitTime(3 * time.Second). // 最大等待时间
@@ -42,7 +64,7 @@ func (t *TokenManager) GetMsToken() (string, error) {
 			"tspFromClient": time.Now().UnixMilli(),
 			"ulr":           0,
 		}).
-		Post(DOUYIN_MS_TOKEN_REQ_URL)
+		Post(t.endpoints.MsToken)
 	if err != nil {
 		return "", fmt.Errorf("获取 ms_token 失败: %w", err)
 	}
@@ -64,7 +86,7 @@ func (t *TokenManager) GetMsToken() (string, error) {
 // GenWebID 获取 webid
 func (t *TokenManager) GenWebID() (string, error) {
 	var responseData map[string]interface{}
-	resp, err := resty.New().
+	resp, err := t.newClient().
 		SetRetryCount(2).
 		SetRetryWaitTime(1 * time.Second).    // 初次重试等待 2s
 		SetRetryMaxWaitTime(3 * time.Second). // 最大等待时间
@@ -85,7 +107,7 @@ func (t *TokenManager) GenWebID() (string, error) {
 			"user_agent":     t.userAgent,
 			"user_unique_id": "",
 		}).
-		Post(DOUYIN_WEBID_REQ_URL)
+		Post(t.endpoints.WebID)
 
 	if err != nil {
 		return "", fmt.Errorf("获取 webid 失败: %w", err)
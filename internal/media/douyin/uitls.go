@@ -1,34 +1,34 @@
 package douyin
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
+	"noctua/pkg/cookie"
 	"strings"
 	"time"
 )
 
-// 转换 JSON 到 Cookie 字符串
+// 转换账号 Cookie 到 Cookie 请求头，支持 cookie 包可识别的全部格式
 func JsonToCookieString(jsonString string) (string, error) {
-	var cookies []Cookie
-	// 解析 JSON
-	err := json.Unmarshal([]byte(jsonString), &cookies)
+	cookies, _, err := cookie.Parse(jsonString)
+	// 未设置 Cookie 的临时账号不携带 Cookie
+	if errors.Is(err, cookie.ErrEmpty) {
+		return "", nil
+	}
 	if err != nil {
 		return "", err
 	}
-	// 构建 Cookie 字符串
-	var cookieParts []string
-	for _, cookie := range cookies {
-		if cookie.Name != "" {
-			cookieParts = append(cookieParts, fmt.Sprintf("%s=%s", cookie.Name, cookie.Value))
-		}
-	}
-	return strings.Join(cookieParts, ";"), nil
+	return cookies.Header(), nil
 }
 
 // 获取 VerifyParams
 func BuildVerifyParams(userAgent string) (VerifyParams, error) {
-	tm := NewTokenManager(userAgent)
+	return BuildVerifyParamsBy(NewTokenManager(userAgent))
+}
+
+// BuildVerifyParamsBy 使用指定的 TokenManager 获取 VerifyParams
+func BuildVerifyParamsBy(tm *TokenManager) (VerifyParams, error) {
 	vfm := VerifyFpManager{}
 
 	msToken, _ := tm.GetMsToken()
@@ -9,10 +9,11 @@ import (
 // CrawlComment 视频评论表
 type CrawlComment struct {
 	ID              uint           `json:"id" gorm:"primaryKey"`
+	ProjectID       uint           `json:"project_id" gorm:"uniqueIndex:idx_crawl_comment_project_comment;default:0"` // 所属项目，0 为默认项目
 	TaskId          string         `json:"task_id" gorm:"index;size:64"`
 	SourceTaskId    string         `json:"source_task_id" gorm:"index;size:64"`
 	MediaCode       string         `json:"media_code" gorm:"size:32;index"`
-	CommentID       string         `json:"comment_id" gorm:"uniqueIndex;size:64"`
+	CommentID       string         `json:"comment_id" gorm:"uniqueIndex:idx_crawl_comment_project_comment;size:64"`
 	MediaID         string         `json:"media_id" gorm:"index;size:64"`
 	SecUID          string         `json:"sec_uid" gorm:"size:64;index"`
 	Nickname        string         `json:"nickname" gorm:"size:255"`
@@ -38,6 +39,7 @@ func (m *CrawlComment) TableName() string {
 
 // CrawlCommentQueryParams 查询参数
 type CrawlCommentQueryParams struct {
+	ProjectID uint // 所属项目，始终按项目过滤
 	Keyword   string
 	TaskId    string // 任务 ID
 	CommentID string // 评论 ID
@@ -51,7 +53,7 @@ type CrawlCommentQueryParams struct {
 func (c *CrawlComment) UpsertModel() error {
 	// 查找是否已经存在记录
 	var existingCrawlComment CrawlComment
-	err := database.DB.Where("comment_id = ?", c.CommentID).First(&existingCrawlComment).Error
+	err := database.DB.Where("project_id = ? AND comment_id = ?", c.ProjectID, c.CommentID).First(&existingCrawlComment).Error
 	if err != nil && err != gorm.ErrRecordNotFound {
 		return err
 	}
@@ -59,7 +61,7 @@ func (c *CrawlComment) UpsertModel() error {
 	// 如果记录已存在，执行更新操作
 	if err == nil {
 		return database.DB.Model(&existingCrawlComment).
-			Where("comment_id = ?", c.CommentID).
+			Where("project_id = ? AND comment_id = ?", c.ProjectID, c.CommentID).
 			Updates(c).Error
 	} else {
 		// 如果记录不存在，执行插入操作
@@ -69,7 +71,7 @@ func (c *CrawlComment) UpsertModel() error {
 
 // List 分页查询评论列表
 func (c *CrawlComment) List(params *CrawlCommentQueryParams, sort, order string, page, pageSize int) (database.PageResult[CrawlComment], error) {
-	query := database.DB.Model(&CrawlComment{})
+	query := database.DB.Model(&CrawlComment{}).Where("project_id = ?", params.ProjectID)
 	if params.TaskId != "" {
 		query = query.Where("task_id = ?", params.TaskId)
 	}
@@ -9,10 +9,11 @@ import (
 // CrawlMedia 采集到的视频表
 type CrawlMedia struct {
 	ID             uint           `json:"id" gorm:"primaryKey"`
+	ProjectID      uint           `json:"project_id" gorm:"uniqueIndex:idx_crawl_media_project_media;default:0"` // 所属项目，0 为默认项目
 	TaskId         string         `json:"task_id" gorm:"index;size:64"`
 	SourceTaskId   string         `json:"source_task_id" gorm:"index;size:64"`
 	MediaCode      string         `json:"media_code" gorm:"size:32;index"`
-	MediaID        string         `json:"media_id" gorm:"uniqueIndex;size:64"`
+	MediaID        string         `json:"media_id" gorm:"uniqueIndex:idx_crawl_media_project_media;size:64"`
 	Type           int            `json:"type" gorm:"index"`
 	Title          string         `json:"title" gorm:"size:1024"`
 	Description    string         `json:"description" gorm:"type:text"`
@@ -38,6 +39,7 @@ func (m *CrawlMedia) TableName() string {
 
 // CrawlMediaQueryParams 查询参数
 type CrawlMediaQueryParams struct {
+	ProjectID uint `json:"project_id"` // 所属项目，始终按项目过滤
 	Keyword   string
 	TaskId    string      `json:"task_id"`  // 任务 ID
 	MediaID   string      `json:"media_id"` // 视频 ID
@@ -50,7 +52,7 @@ type CrawlMediaQueryParams struct {
 func (m *CrawlMedia) UpsertModel() error {
 	// 查找是否已经存在记录
 	var existingCrawlMedia CrawlMedia
-	err := database.DB.Where("media_id = ?", m.MediaID).First(&existingCrawlMedia).Error
+	err := database.DB.Where("project_id = ? AND media_id = ?", m.ProjectID, m.MediaID).First(&existingCrawlMedia).Error
 	if err != nil && err != gorm.ErrRecordNotFound {
 		return err
 	}
@@ -59,7 +61,7 @@ func (m *CrawlMedia) UpsertModel() error {
 	if err == nil {
 		// 使用 Omit 排除不需要更新的字段（如 ID 和更新时间字段）
 		return database.DB.Model(&existingCrawlMedia).
-			Where("media_id = ?", m.MediaID).
+			Where("project_id = ? AND media_id = ?", m.ProjectID, m.MediaID).
 			Updates(m).Error
 	} else {
 		// 如果记录不存在，执行插入操作
@@ -69,7 +71,7 @@ func (m *CrawlMedia) UpsertModel() error {
 
 // List 分页查询视频列表
 func (m *CrawlMedia) List(params *CrawlMediaQueryParams, sort, order string, page, pageSize int) (database.PageResult[CrawlMedia], error) {
-	query := database.DB.Model(&CrawlMedia{})
+	query := database.DB.Model(&CrawlMedia{}).Where("project_id = ?", params.ProjectID)
 	if params.TaskId != "" {
 		query = query.Where("task_id = ?", params.TaskId)
 	}
@@ -9,6 +9,7 @@ import (
 // CrawlTask 采集任务表
 type CrawlTask struct {
 	ID           uint           `json:"id" gorm:"primaryKey"`
+	ProjectID    uint           `json:"project_id" gorm:"index;default:0"` // 所属项目，0 为默认项目
 	TaskId       string         `json:"task_id" gorm:"uniqueIndex;size:64"`
 	ParentTaskId string         `json:"parent_task_id" gorm:"size:64;index"`
 	SourceTaskId string         `json:"source_task_id" gorm:"size:64;index"`
@@ -31,6 +32,7 @@ func (m *CrawlTask) TableName() string {
 
 // CrawlTaskQueryParams 查询参数
 type CrawlTaskQueryParams struct {
+	ProjectID    uint   // 所属项目，始终按项目过滤
 	MediaCode    string // 平台代码
 	Type         string // 任务类型
 	Status       string // 任务状态
@@ -60,7 +62,7 @@ func (c *CrawlTask) UpsertModel() error {
 
 // List 分页查询任务列表
 func (c *CrawlTask) List(params *CrawlTaskQueryParams, sort, order string, page, pageSize int) (database.PageResult[CrawlTask], error) {
-	query := database.DB.Model(&CrawlTask{})
+	query := database.DB.Model(&CrawlTask{}).Where("project_id = ?", params.ProjectID)
 	if params.MediaCode != "" {
 		query = query.Where("media_code = ?", params.MediaCode)
 	}
@@ -9,10 +9,11 @@ import (
 // CrawlUser 媒体用户表
 type CrawlUser struct {
 	ID           uint           `json:"id" gorm:"primaryKey"`
+	ProjectID    uint           `json:"project_id" gorm:"uniqueIndex:idx_crawl_user_project_sec_uid;default:0"` // 所属项目，0 为默认项目
 	TaskId       string         `json:"task_id" gorm:"index;size:64"`
 	SourceTaskId string         `json:"source_task_id" gorm:"index;size:64"`
 	MediaCode    string         `json:"media_code" gorm:"size:32;index"`
-	SecUID       string         `json:"sec_uid" gorm:"uniqueIndex;size:64"`
+	SecUID       string         `json:"sec_uid" gorm:"uniqueIndex:idx_crawl_user_project_sec_uid;size:64"`
 	ShortUserID  string         `json:"short_user_id" gorm:"size:64"`
 	UserUniqueID string         `json:"user_unique_id" gorm:"size:64"`
 	Nickname     string         `json:"nickname" gorm:"size:255"`
@@ -40,6 +41,7 @@ func (m *CrawlUser) TableName() string {
 
 // CrawlUserQueryParams 查询参数
 type CrawlUserQueryParams struct {
+	ProjectID uint   // 所属项目，始终按项目过滤
 	TaskId    string // 任务 ID
 	SecUID    string // 用户 SecUID
 	Nickname  string // 用户昵称
@@ -50,7 +52,7 @@ type CrawlUserQueryParams struct {
 func (c *CrawlUser) UpsertModel() error {
 	// 查找是否已经存在记录
 	var existingCrawlUser CrawlUser
-	err := database.DB.Where("sec_uid = ?", c.SecUID).First(&existingCrawlUser).Error
+	err := database.DB.Where("project_id = ? AND sec_uid = ?", c.ProjectID, c.SecUID).First(&existingCrawlUser).Error
 	if err != nil && err != gorm.ErrRecordNotFound {
 		return err
 	}
@@ -58,7 +60,7 @@ func (c *CrawlUser) UpsertModel() error {
 	// 如果记录已存在，执行更新操作
 	if err == nil {
 		return database.DB.Model(&existingCrawlUser).
-			Where("sec_uid = ?", c.SecUID).
+			Where("project_id = ? AND sec_uid = ?", c.ProjectID, c.SecUID).
 			Updates(c).Error
 	} else {
 		// 如果记录不存在，执行插入操作
@@ -69,7 +71,7 @@ func (c *CrawlUser) UpsertModel() error {
 // List 分页查询用户列表
 func (m *CrawlUser) List(params *CrawlUserQueryParams, sort, order string, page, pageSize int) (database.PageResult[CrawlUser], error) {
 
-	query := database.DB.Model(&CrawlUser{})
+	query := database.DB.Model(&CrawlUser{}).Where("project_id = ?", params.ProjectID)
 	if params.TaskId != "" {
 		query = query.Where("task_id = ?", params.TaskId)
 	}
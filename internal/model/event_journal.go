@@ -0,0 +1,74 @@
+package model
+
+import (
+	"noctua/pkg/database"
+	"time"
+)
+
+// EventJournal 事件日志表，只追加，Seq 即序列号
+type EventJournal struct {
+	Seq        uint64    `json:"seq" gorm:"primaryKey;autoIncrement"`
+	Kind       string    `json:"kind" gorm:"size:16;index"` // event / runtime
+	Topic      string    `json:"topic" gorm:"size:64;index"`
+	JobID      string    `json:"job_id" gorm:"size:64;index"`
+	ProjectID  uint      `json:"project_id" gorm:"index;default:0"` // 所属项目，0 为默认项目
+	Payload    string    `json:"payload" gorm:"type:text"`
+	OccurredAt time.Time `json:"occurred_at" gorm:"index"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName 指定表名
+func (m *EventJournal) TableName() string {
+	return "event_journal"
+}
+
+// EventJournalQueryParams 查询参数
+type EventJournalQueryParams struct {
+	Since     uint64 // 返回序列号大于该值的记录
+	Kind      string
+	Topic     string // 精确匹配，通配过滤由调用方处理
+	JobID     string
+	ProjectID uint // 所属项目，始终过滤
+	Limit     int
+}
+
+// Append 批量追加事件，写入后回填序列号
+func (m *EventJournal) Append(entries []*EventJournal) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return database.DB.CreateInBatches(entries, 100).Error
+}
+
+// Since 按序列号升序查询
+func (m *EventJournal) Since(params *EventJournalQueryParams) ([]EventJournal, error) {
+	var entries []EventJournal
+	query := database.DB.Model(&EventJournal{}).Where("seq > ? AND project_id = ?", params.Since, params.ProjectID)
+	if params.Kind != "" {
+		query = query.Where("kind = ?", params.Kind)
+	}
+	if params.Topic != "" {
+		query = query.Where("topic = ?", params.Topic)
+	}
+	if params.JobID != "" {
+		query = query.Where("job_id = ?", params.JobID)
+	}
+	if params.Limit > 0 {
+		query = query.Limit(params.Limit)
+	}
+	err := query.Order("seq ASC").Find(&entries).Error
+	return entries, err
+}
+
+// LastSeq 最新的序列号
+func (m *EventJournal) LastSeq() (uint64, error) {
+	var seq uint64
+	err := database.DB.Model(&EventJournal{}).Select("COALESCE(MAX(seq), 0)").Scan(&seq).Error
+	return seq, err
+}
+
+// Prune 删除早于指定时间的记录
+func (m *EventJournal) Prune(before time.Time) (int64, error) {
+	result := database.DB.Where("occurred_at < ?", before).Delete(&EventJournal{})
+	return result.RowsAffected, result.Error
+}
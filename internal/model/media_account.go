@@ -3,30 +3,66 @@ package model
 import (
 	"gorm.io/gorm"
 	"noctua/pkg/database"
+	"noctua/pkg/secret"
 	"time"
 )
 
+// 账号状态
+const (
+	MediaAccountStatusNormal   = 10  // 正常
+	MediaAccountStatusDisabled = 100 // 禁用
+)
+
+// 禁用原因，被平台限制或探测失败的账号可由探测恢复
+const (
+	DisabledReasonManual  = "manual"  // 手动禁用
+	DisabledReasonBlocked = "blocked" // 采集中被平台限制
+	DisabledReasonCookie  = "cookie"  // 关键 Cookie 缺失或过期
+	DisabledReasonProbe   = "probe"   // 探测连续失败
+)
+
+// 探测结果
+const (
+	ProbeStatusOK     = "ok"
+	ProbeStatusFailed = "failed"
+)
+
 // MediaAccount 结构体表示 media_account 表
 type MediaAccount struct {
-	ID         uint           `json:"id" gorm:"primaryKey"`                              // 主键
-	MediaCode  string         `json:"media_code" gorm:"not null"`                        // 平台名称
-	Type       int            `json:"type" gorm:"default:0"`                             // 0: 通用，1: 爬虫用 2 私信
-	UserID     string         `json:"user_id" gorm:"not null"`                           // 用户id，我方生成
-	UID        string         `json:"uid" gorm:"not null"`                               // uid 媒体用户id
-	Username   string         `json:"username" gorm:"default:''"`                        // 账号名称
-	Nickname   string         `json:"nickname" gorm:"not null"`                          // 昵称
-	Cookie     string         `json:"cookie" gorm:"not null"`                            // 账号cookie
-	UserAgent  string         `json:"user_agent" gorm:"default:''"`                      // userAgent
-	DeviceInfo string         `json:"device_info" gorm:"default:''"`                     // 设置信息
-	Status     int            `json:"status" gorm:"default:10"`                          // 状态：10 正常,100 禁用
-	IsReal     int            `json:"is_real" gorm:"type:tinyint(1);default:1;not null"` // 是否为真实的，数据库中都为真实
-	LastUsed   time.Time      `json:"last_used" gorm:"default:CURRENT_TIMESTAMP"`        // 最后使用时间
-	CreateTime time.Time      `json:"create_time" gorm:"autoCreateTime"`                 // 自动创建时间
-	UpdateTime time.Time      `json:"update_time" gorm:"autoUpdateTime"`                 // 自动更新时间
-	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	ID             uint           `json:"id" gorm:"primaryKey"`                               // 主键
+	ProjectID      uint           `json:"project_id" gorm:"index;default:0"`                  // 所属项目，0 为默认项目
+	MediaCode      string         `json:"media_code" gorm:"not null"`                         // 平台名称
+	Type           int            `json:"type" gorm:"default:0"`                              // 0: 通用，1: 爬虫用 2 私信
+	UserID         string         `json:"user_id" gorm:"not null"`                            // 用户id，我方生成
+	UID            string         `json:"uid" gorm:"not null"`                                // uid 媒体用户id
+	Username       string         `json:"username" gorm:"default:''"`                         // 账号名称
+	Nickname       string         `json:"nickname" gorm:"not null"`                           // 昵称
+	Cookie         string         `json:"cookie" gorm:"not null;serializer:encrypted"`        // 账号cookie，加密存储
+	CookieVersion  int            `json:"cookie_version" gorm:"default:0"`                    // Cookie 版本号，每次写入加一
+	CookieDiff     string         `json:"cookie_diff" gorm:"type:text"`                       // 最近一次从响应合并的 Cookie 名称变化
+	CookieSavedAt  *time.Time     `json:"cookie_saved_at"`                                    // 最近一次从响应合并后保存的时间
+	UserAgent      string         `json:"user_agent" gorm:"default:'';serializer:encrypted"`  // userAgent，加密存储
+	DeviceInfo     string         `json:"device_info" gorm:"default:'';serializer:encrypted"` // 设置信息，加密存储
+	Status         int            `json:"status" gorm:"default:10"`                           // 状态：10 正常,100 禁用
+	DisabledReason string         `json:"disabled_reason" gorm:"size:32;default:''"`          // 禁用原因，旧数据为空时视为手动禁用
+	ProbeStatus    string         `json:"probe_status" gorm:"size:16;default:''"`             // 最近一次探测结果
+	ProbeLatency   int64          `json:"probe_latency" gorm:"default:0"`                     // 最近一次探测耗时，毫秒
+	ProbeError     string         `json:"probe_error" gorm:"type:text"`                       // 最近一次探测失败原因
+	ProbeFailures  int            `json:"probe_failures" gorm:"default:0"`                    // 连续探测失败次数
+	ProbedAt       *time.Time     `json:"probed_at"`                                          // 最近一次探测时间
+	HourlyUsage    int            `json:"hourly_usage" gorm:"default:0"`                      // LastUsed 所在小时的请求数
+	DailyUsage     int            `json:"daily_usage" gorm:"default:0"`                       // LastUsed 所在日的请求数
+	RateLimits     int            `json:"rate_limits" gorm:"default:0"`                       // LastUsed 所在日触发限流的次数
+	CooldownUntil  *time.Time     `json:"cooldown_until"`                                     // 限流冷却截止时间
+	IsReal         int            `json:"is_real" gorm:"type:tinyint(1);default:1;not null"`  // 是否为真实的，数据库中都为真实
+	LastUsed       time.Time      `json:"last_used" gorm:"default:CURRENT_TIMESTAMP"`         // 最后使用时间
+	CreateTime     time.Time      `json:"create_time" gorm:"autoCreateTime"`                  // 自动创建时间
+	UpdateTime     time.Time      `json:"update_time" gorm:"autoUpdateTime"`                  // 自动更新时间
+	DeletedAt      gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
 type QueryMediaAccountParams struct {
+	ProjectID      uint // 所属项目，List、FindMediaAccount 和 QueryAvailableAccounts 始终按项目过滤
 	MediaCode      string
 	Type           int
 	ID             uint
@@ -45,7 +81,7 @@ func (m *MediaAccount) TableName() string {
 
 func (m *MediaAccount) FindMediaAccount(params *QueryMediaAccountParams) (*MediaAccount, error) {
 	mediaAccount := &MediaAccount{}
-	query := database.DB.Where("status = 10").Where("deleted_at is null").Order("id desc")
+	query := database.DB.Where("status = 10").Where("deleted_at is null").Where("project_id = ?", params.ProjectID).Order("id desc")
 	if params.MediaCode != "" {
 		query.Where("media_code = ?", params.MediaCode)
 	}
@@ -144,7 +180,7 @@ func (m *MediaAccount) DeleteItems(ids []int) (bool, error) {
 
 // List 分页查询任务列表
 func (m *MediaAccount) List(params *QueryMediaAccountParams, sort, order string, page, pageSize int) (database.PageResult[MediaAccount], error) {
-	query := database.DB.Model(&MediaAccount{}).Where("deleted_at is null")
+	query := database.DB.Model(&MediaAccount{}).Where("deleted_at is null").Where("project_id = ?", params.ProjectID)
 	if params.MediaCode != "" {
 		query.Where("media_code = ?", params.MediaCode)
 	}
@@ -173,3 +209,226 @@ func (m *MediaAccount) List(params *QueryMediaAccountParams, sort, order string,
 		Order:    order,
 	})
 }
+
+// Find 按ID查询未删除的账号，不存在时返回 gorm.ErrRecordNotFound
+func (m *MediaAccount) Find(id uint) (*MediaAccount, error) {
+	account := &MediaAccount{}
+	err := database.DB.Where("deleted_at is null").First(account, id).Error
+	return account, err
+}
+
+// Exists 判断平台下是否已存在相同 user_id 或 uid 的账号
+func (m *MediaAccount) Exists(mediaCode, userID, uid string) (bool, error) {
+	var count int64
+	err := database.DB.Model(&MediaAccount{}).
+		Where("deleted_at is null").
+		Where("media_code = ?", mediaCode).
+		Where("user_id = ? OR uid = ?", userID, uid).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Create 新建账号
+func (m *MediaAccount) Create() error {
+	return database.DB.Create(m).Error
+}
+
+// UpdateFields 更新指定字段，零值也会写入
+func (m *MediaAccount) UpdateFields(fields ...string) error {
+	return database.DB.Model(m).Select(fields).Updates(m).Error
+}
+
+// SetStatus 更新账号状态，启用时清空禁用原因
+func (m *MediaAccount) SetStatus(status int, reason string) error {
+	m.Status = status
+	m.DisabledReason = reason
+	if status != MediaAccountStatusDisabled {
+		m.DisabledReason = ""
+	}
+	return m.UpdateFields("status", "disabled_reason")
+}
+
+// recoverableReasons 可由探测恢复的禁用原因，Cookie 过期的账号需重新导入 Cookie
+var recoverableReasons = []string{DisabledReasonBlocked, DisabledReasonProbe}
+
+// Recoverable 是否为可由探测恢复的禁用账号
+func (m *MediaAccount) Recoverable() bool {
+	if m.Status != MediaAccountStatusDisabled {
+		return false
+	}
+	for _, reason := range recoverableReasons {
+		if m.DisabledReason == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// QueryProbeAccounts 查询需要探测的账号：正常账号及可恢复的禁用账号
+func (m *MediaAccount) QueryProbeAccounts(mediaCodes []string) ([]*MediaAccount, error) {
+	accounts := make([]*MediaAccount, 0)
+	err := database.DB.Where("deleted_at is null").
+		Where("media_code IN ?", mediaCodes).
+		Where("status = ? OR (status = ? AND disabled_reason IN ?)",
+			MediaAccountStatusNormal, MediaAccountStatusDisabled, recoverableReasons).
+		Order("id asc").
+		Find(&accounts).Error
+	return accounts, err
+}
+
+// RecordProbe 保存探测结果
+func (m *MediaAccount) RecordProbe() error {
+	return m.UpdateFields("probe_status", "probe_latency", "probe_error", "probe_failures", "probed_at")
+}
+
+// QueryAvailableAccounts 查询项目下可用的候选账号，按ID升序
+func (m *MediaAccount) QueryAvailableAccounts(params *QueryMediaAccountParams) ([]*MediaAccount, error) {
+	accounts := make([]*MediaAccount, 0)
+	query := database.DB.Where("status = ?", MediaAccountStatusNormal).Where("deleted_at is null").
+		Where("project_id = ?", params.ProjectID).
+		Order("id asc")
+	if params.MediaCode != "" {
+		query = query.Where("media_code = ?", params.MediaCode)
+	}
+	if params.Type > 0 {
+		query = query.Where("type = ?", params.Type)
+	}
+	if len(params.UserID) > 0 {
+		query = query.Where("user_id = ?", params.UserID)
+	}
+	if len(params.ExcludeUserIDs) > 0 {
+		query = query.Where("user_id not in (?)", params.ExcludeUserIDs)
+	}
+	err := query.Find(&accounts).Error
+	return accounts, err
+}
+
+// SaveUsage 保存使用计数、最后使用时间及冷却时间
+func (m *MediaAccount) SaveUsage() error {
+	return m.UpdateFields("last_used", "hourly_usage", "daily_usage", "rate_limits", "cooldown_until")
+}
+
+// ReplaceCookie 替换 Cookie 并增加版本号，未保存的合并结果将以新 Cookie 为准
+func (m *MediaAccount) ReplaceCookie(value string) error {
+	encrypted, err := secret.EncryptString(value)
+	if err != nil {
+		return err
+	}
+	err = database.DB.Model(m).Updates(map[string]interface{}{
+		"cookie":         encrypted,
+		"cookie_version": gorm.Expr("cookie_version + 1"),
+		"cookie_diff":    "",
+	}).Error
+	if err != nil {
+		return err
+	}
+	m.Cookie = value
+	m.CookieDiff = ""
+	return database.DB.Model(m).Select("cookie_version").First(m).Error
+}
+
+// SaveMergedCookie 保存从响应合并的 Cookie，数据库中的版本号不等于 version 时不写入并返回 false
+func (m *MediaAccount) SaveMergedCookie(version int) (bool, error) {
+	encrypted, err := secret.EncryptString(m.Cookie)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	result := database.DB.Model(&MediaAccount{}).
+		Where("id = ? AND cookie_version = ?", m.ID, version).
+		Updates(map[string]interface{}{
+			"cookie":          encrypted,
+			"cookie_version":  version + 1,
+			"cookie_diff":     m.CookieDiff,
+			"cookie_saved_at": now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	m.CookieVersion = version + 1
+	m.CookieSavedAt = &now
+	return true, nil
+}
+
+// ReencryptResult 重新加密的结果
+type ReencryptResult struct {
+	Scanned   int    `json:"scanned"`   // 检查的账号数，包含已删除的账号
+	Updated   int    `json:"updated"`   // 重新加密的账号数
+	FailedIDs []uint `json:"failedIds"` // 无法解密的账号，密钥缺失或数据损坏
+}
+
+// credentialRow 凭据字段的原始值，不经过加密序列化
+type credentialRow struct {
+	ID         uint
+	Cookie     string
+	UserAgent  string
+	DeviceInfo string
+}
+
+// ReencryptCredentials 使用当前密钥重新加密未加密或使用旧密钥加密的凭据字段，batchSize 为每批读取的账号数
+func (m *MediaAccount) ReencryptCredentials(batchSize int) (ReencryptResult, error) {
+	result := ReencryptResult{}
+	if !secret.Enabled() {
+		return result, secret.ErrNoKey
+	}
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	var lastID uint
+	for {
+		var rows []credentialRow
+		err := database.DB.Table(m.TableName()).
+			Select("id", "cookie", "user_agent", "device_info").
+			Where("id > ?", lastID).Order("id").Limit(batchSize).
+			Find(&rows).Error
+		if err != nil {
+			return result, err
+		}
+		for _, row := range rows {
+			lastID = row.ID
+			result.Scanned++
+			updates, err := reencryptColumns(map[string]string{
+				"cookie":      row.Cookie,
+				"user_agent":  row.UserAgent,
+				"device_info": row.DeviceInfo,
+			})
+			if err != nil {
+				result.FailedIDs = append(result.FailedIDs, row.ID)
+				continue
+			}
+			if len(updates) == 0 {
+				continue
+			}
+			if err := database.DB.Table(m.TableName()).Where("id = ?", row.ID).UpdateColumns(updates).Error; err != nil {
+				return result, err
+			}
+			result.Updated++
+		}
+		if len(rows) < batchSize {
+			return result, nil
+		}
+	}
+}
+
+// reencryptColumns 返回需要重新加密的字段及密文
+func reencryptColumns(values map[string]string) (map[string]interface{}, error) {
+	updates := make(map[string]interface{})
+	for column, value := range values {
+		if !secret.NeedsRotation(value) {
+			continue
+		}
+		plaintext, err := secret.DecryptString(value)
+		if err != nil {
+			return nil, err
+		}
+		encrypted, err := secret.EncryptString(plaintext)
+		if err != nil {
+			return nil, err
+		}
+		updates[column] = encrypted
+	}
+	return updates, nil
+}
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"noctua/pkg/database"
+	"time"
+)
+
+// Notification 通知消息表
+// 保留策略：过期（ExpiredAt 已到）的通知由 Prune 物理删除；用户删除的通知先软删除，
+// 保留期内仍按 CheckHash 去重，超过保留期后物理删除，之后相同 CheckHash 的消息可再次保存
+type Notification struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	ProjectID  uint           `json:"project_id" gorm:"uniqueIndex:idx_notification_project_hash;default:0"` // 所属项目，0 为默认项目
+	CheckHash  string         `json:"check_hash" gorm:"uniqueIndex:idx_notification_project_hash;size:64"`   // 消息唯一hash，项目内重复的消息只保留第一条
+	EventCode  string         `json:"event_code" gorm:"size:32;index"`
+	JobID      string         `json:"job_id" gorm:"size:64;index"`
+	Level      string         `json:"level" gorm:"size:16;index"`
+	Title      string         `json:"title" gorm:"size:128"`
+	Message    string         `json:"message" gorm:"type:text"`
+	MetaData   string         `json:"meta_data" gorm:"type:text"` // JSON 编码的元数据
+	IsNotify   bool           `json:"is_notify"`
+	ShowType   string         `json:"show_type" gorm:"size:16"`
+	IsRead     bool           `json:"is_read" gorm:"index"`
+	ReadAt     *time.Time     `json:"read_at"`
+	ExpiredAt  *time.Time     `json:"expired_at" gorm:"index"` // 为空时不过期
+	ReceivedAt time.Time      `json:"received_at" gorm:"index"`
+	CreatedAt  time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (m *Notification) TableName() string {
+	return "notification"
+}
+
+// NotificationQueryParams 查询参数
+type NotificationQueryParams struct {
+	ProjectID uint // 所属项目，始终过滤
+	IsRead    *bool
+	Level     string
+	EventCode string
+	JobID     string
+}
+
+// Store 保存通知，项目内 CheckHash 已存在（包括已删除的）时忽略，返回是否新增
+func (m *Notification) Store() (bool, error) {
+	result := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "project_id"}, {Name: "check_hash"}},
+		DoNothing: true,
+	}).Create(m)
+	return result.RowsAffected > 0, result.Error
+}
+
+// unexpired 排除已过期的通知
+func unexpired(query *gorm.DB, now time.Time) *gorm.DB {
+	return query.Where("expired_at IS NULL OR expired_at > ?", now)
+}
+
+// List 分页查询未过期的通知
+func (m *Notification) List(params *NotificationQueryParams, page, pageSize int) (database.PageResult[Notification], error) {
+	query := unexpired(database.DB.Model(&Notification{}), time.Now()).Where("project_id = ?", params.ProjectID)
+	if params.IsRead != nil {
+		query = query.Where("is_read = ?", *params.IsRead)
+	}
+	if params.Level != "" {
+		query = query.Where("level = ?", params.Level)
+	}
+	if params.EventCode != "" {
+		query = query.Where("event_code = ?", params.EventCode)
+	}
+	if params.JobID != "" {
+		query = query.Where("job_id = ?", params.JobID)
+	}
+	return database.Paginate[Notification](query, database.ListOptions{
+		Page:     page,
+		PageSize: pageSize,
+		Sort:     "id",
+		Order:    "desc",
+	})
+}
+
+// UnreadCount 项目内未读且未过期的通知数
+func (m *Notification) UnreadCount(projectID uint) (int64, error) {
+	var count int64
+	err := unexpired(database.DB.Model(&Notification{}), time.Now()).
+		Where("project_id = ? AND is_read = ?", projectID, false).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead 标记项目内的通知已读，ids 为空时标记全部，返回更新的条数
+func (m *Notification) MarkRead(projectID uint, ids []uint) (int64, error) {
+	query := database.DB.Model(&Notification{}).Where("project_id = ? AND is_read = ?", projectID, false)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	result := query.Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()})
+	return result.RowsAffected, result.Error
+}
+
+// Delete 软删除项目内的通知，保留期内继续按 CheckHash 去重，返回删除的条数
+func (m *Notification) Delete(projectID uint, ids []uint) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := database.DB.Where("project_id = ? AND id IN ?", projectID, ids).Delete(&Notification{})
+	return result.RowsAffected, result.Error
+}
+
+// Prune 物理删除已过期的通知，以及在 deletedBefore 之前软删除的通知
+func (m *Notification) Prune(now, deletedBefore time.Time) (int64, error) {
+	result := database.DB.Unscoped().
+		Where("(expired_at IS NOT NULL AND expired_at <= ?) OR (deleted_at IS NOT NULL AND deleted_at <= ?)", now, deletedBefore).
+		Delete(&Notification{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"noctua/pkg/database"
+	"time"
+)
+
+// 项目状态
+const (
+	ProjectStatusNormal   = 10  // 正常
+	ProjectStatusDisabled = 100 // 禁用，不能启动采集任务
+)
+
+// 默认项目，未指定项目的请求及历史数据归属该项目，不在项目表中存储
+const (
+	DefaultProjectID   uint = 0
+	DefaultProjectCode      = "default"
+)
+
+// Project 项目表，账号、采集任务及采集数据按项目隔离
+type Project struct {
+	ID                uint           `json:"id" gorm:"primaryKey"`
+	Code              string         `json:"code" gorm:"uniqueIndex;size:64;not null"` // 项目编码，请求头 X-Project 使用
+	Name              string         `json:"name" gorm:"size:128"`
+	MaxConcurrentJobs int            `json:"max_concurrent_jobs" gorm:"default:0"` // 同时运行的采集任务数上限，0 使用 crawler.project_jobs 配置
+	Status            int            `json:"status" gorm:"default:10"`             // 状态：10 正常,100 禁用
+	CreatedAt         time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt         time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt         gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (m *Project) TableName() string {
+	return "project"
+}
+
+// DefaultProject 默认项目，并发任务数上限使用 crawler.project_jobs 配置
+func DefaultProject() *Project {
+	return &Project{ID: DefaultProjectID, Code: DefaultProjectCode, Name: "默认项目", Status: ProjectStatusNormal}
+}
+
+// Create 新建项目
+func (m *Project) Create() error {
+	return database.DB.Create(m).Error
+}
+
+// UpdateFields 更新指定字段，零值也会写入
+func (m *Project) UpdateFields(fields ...string) error {
+	return database.DB.Model(m).Select(fields).Updates(m).Error
+}
+
+// Delete 删除项目
+func (m *Project) Delete(id uint) error {
+	return database.DB.Delete(&Project{}, id).Error
+}
+
+// Find 按ID查询项目，ID 为 0 时返回默认项目，不存在时返回 gorm.ErrRecordNotFound
+func (m *Project) Find(id uint) (*Project, error) {
+	if id == DefaultProjectID {
+		return DefaultProject(), nil
+	}
+	project := &Project{}
+	err := database.DB.First(project, id).Error
+	return project, err
+}
+
+// FindByCode 按编码查询项目，编码为空或 default 时返回默认项目，不存在时返回 gorm.ErrRecordNotFound
+func (m *Project) FindByCode(code string) (*Project, error) {
+	if code == "" || code == DefaultProjectCode {
+		return DefaultProject(), nil
+	}
+	project := &Project{}
+	err := database.DB.Where("code = ?", code).First(project).Error
+	return project, err
+}
+
+// All 查询全部项目，不含默认项目
+func (m *Project) All() ([]Project, error) {
+	var projects []Project
+	err := database.DB.Model(&Project{}).Order("id asc").Find(&projects).Error
+	return projects, err
+}
+
+// AccountCount 项目下未删除的账号数
+func (m *Project) AccountCount() (int64, error) {
+	var count int64
+	err := database.DB.Model(&MediaAccount{}).
+		Where("deleted_at is null").
+		Where("project_id = ?", m.ID).
+		Count(&count).Error
+	return count, err
+}
+
+// legacyUniqueIndexes 按项目隔离前的全局唯一索引，已由项目内唯一索引替代
+var legacyUniqueIndexes = []struct {
+	model interface{}
+	name  string
+}{
+	{&CrawlMedia{}, "idx_crawl_media_media_id"},
+	{&CrawlComment{}, "idx_crawl_comment_comment_id"},
+	{&CrawlUser{}, "idx_crawl_user_sec_uid"},
+	{&Notification{}, "idx_notification_check_hash"},
+}
+
+// MigrateProjectScope 删除旧的全局唯一索引，不同项目可以采集相同的数据
+func MigrateProjectScope() error {
+	migrator := database.DB.Migrator()
+	for _, index := range legacyUniqueIndexes {
+		if !migrator.HasIndex(index.model, index.name) {
+			continue
+		}
+		if err := migrator.DropIndex(index.model, index.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
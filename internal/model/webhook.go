@@ -0,0 +1,178 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"noctua/pkg/database"
+	"strings"
+	"time"
+)
+
+// 投递状态
+const (
+	WebhookDeliveryPending  = "pending"  // 等待投递
+	WebhookDeliveryRetrying = "retrying" // 投递失败，等待重试
+	WebhookDeliverySuccess  = "success"  // 投递成功
+	WebhookDeliveryFailed   = "failed"   // 重试耗尽
+)
+
+// WebhookSubscription Webhook 订阅表
+type WebhookSubscription struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	ProjectID uint           `json:"project_id" gorm:"index;default:0"` // 所属项目，只接收该项目的事件
+	Name      string         `json:"name" gorm:"size:64"`
+	URL       string         `json:"url" gorm:"size:512;not null"`
+	Events    string         `json:"events" gorm:"size:512;not null"` // 事件主题模式，逗号分隔，支持 * 和 # 通配
+	Secret    string         `json:"secret" gorm:"size:128"`          // 签名密钥
+	Enabled   bool           `json:"enabled" gorm:"default:true"`
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (m *WebhookSubscription) TableName() string {
+	return "webhook_subscription"
+}
+
+// Topics 事件主题模式列表
+func (m *WebhookSubscription) Topics() []string {
+	var topics []string
+	for _, topic := range strings.Split(m.Events, ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+// SetTopics 设置事件主题模式
+func (m *WebhookSubscription) SetTopics(topics []string) {
+	m.Events = strings.Join(topics, ",")
+}
+
+// Create 新建订阅
+func (m *WebhookSubscription) Create() error {
+	return database.DB.Create(m).Error
+}
+
+// Save 更新订阅
+func (m *WebhookSubscription) Save() error {
+	return database.DB.Save(m).Error
+}
+
+// Delete 删除订阅
+func (m *WebhookSubscription) Delete(id uint) error {
+	return database.DB.Delete(&WebhookSubscription{}, id).Error
+}
+
+// Find 按ID查询订阅，不存在时返回 gorm.ErrRecordNotFound
+func (m *WebhookSubscription) Find(id uint) (*WebhookSubscription, error) {
+	subscription := &WebhookSubscription{}
+	err := database.DB.First(subscription, id).Error
+	return subscription, err
+}
+
+// List 查询项目的订阅
+func (m *WebhookSubscription) List(projectID uint) ([]WebhookSubscription, error) {
+	var subscriptions []WebhookSubscription
+	err := database.DB.Model(&WebhookSubscription{}).Where("project_id = ?", projectID).Order("id asc").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// All 查询全部项目的订阅
+func (m *WebhookSubscription) All(onlyEnabled bool) ([]WebhookSubscription, error) {
+	var subscriptions []WebhookSubscription
+	query := database.DB.Model(&WebhookSubscription{}).Order("id asc")
+	if onlyEnabled {
+		query = query.Where("enabled = ?", true)
+	}
+	err := query.Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// WebhookDelivery Webhook 投递记录表
+type WebhookDelivery struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	DeliveryID     string    `json:"delivery_id" gorm:"uniqueIndex;size:64"`
+	SubscriptionID uint      `json:"subscription_id" gorm:"index"`
+	ProjectID      uint      `json:"project_id" gorm:"index;default:0"` // 所属项目，与订阅一致
+	RedeliveryOf   string    `json:"redelivery_of" gorm:"size:64"`      // 重新投递时记录原投递ID
+	Topic          string    `json:"topic" gorm:"size:64;index"`
+	JobID          string    `json:"job_id" gorm:"size:64;index"`
+	Payload        string    `json:"payload" gorm:"type:text"`
+	Status         string    `json:"status" gorm:"size:16;index"`
+	Attempts       int       `json:"attempts" gorm:"default:0"`
+	ResponseCode   int       `json:"response_code"`
+	ResponseBody   string    `json:"response_body" gorm:"type:text"`
+	Error          string    `json:"error" gorm:"type:text"`
+	NextAttemptAt  time.Time `json:"next_attempt_at" gorm:"index"`
+	DeliveredAt    time.Time `json:"delivered_at"`
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (m *WebhookDelivery) TableName() string {
+	return "webhook_delivery"
+}
+
+// WebhookDeliveryQueryParams 查询参数
+type WebhookDeliveryQueryParams struct {
+	ProjectID      uint // 所属项目，始终过滤
+	SubscriptionID uint
+	Status         string
+	Topic          string
+	JobID          string
+}
+
+// Create 新建投递记录
+func (m *WebhookDelivery) Create() error {
+	return database.DB.Create(m).Error
+}
+
+// Save 更新投递结果
+func (m *WebhookDelivery) Save() error {
+	return database.DB.Save(m).Error
+}
+
+// Find 按ID查询投递记录，不存在时返回 gorm.ErrRecordNotFound
+func (m *WebhookDelivery) Find(id uint) (*WebhookDelivery, error) {
+	delivery := &WebhookDelivery{}
+	err := database.DB.First(delivery, id).Error
+	return delivery, err
+}
+
+// Due 查询到期待投递的记录
+func (m *WebhookDelivery) Due(now time.Time, limit int) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	err := database.DB.Model(&WebhookDelivery{}).
+		Where("status IN ?", []string{WebhookDeliveryPending, WebhookDeliveryRetrying}).
+		Where("next_attempt_at <= ?", now).
+		Order("next_attempt_at asc").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// List 分页查询投递记录
+func (m *WebhookDelivery) List(params *WebhookDeliveryQueryParams, page, pageSize int) (database.PageResult[WebhookDelivery], error) {
+	query := database.DB.Model(&WebhookDelivery{}).Where("project_id = ?", params.ProjectID)
+	if params.SubscriptionID > 0 {
+		query = query.Where("subscription_id = ?", params.SubscriptionID)
+	}
+	if params.Status != "" {
+		query = query.Where("status = ?", params.Status)
+	}
+	if params.Topic != "" {
+		query = query.Where("topic = ?", params.Topic)
+	}
+	if params.JobID != "" {
+		query = query.Where("job_id = ?", params.JobID)
+	}
+	return database.Paginate[WebhookDelivery](query, database.ListOptions{
+		Page:     page,
+		PageSize: pageSize,
+		Sort:     "id",
+		Order:    "desc",
+	})
+}
@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+)
+
+// ChannelConfig 动态或静态代理配置
+type ChannelConfig struct {
+	Enabled bool     `mapstructure:"enabled"`
+	Min     int      `mapstructure:"min"`     // 各区域保持的空闲代理数，0 为 1
+	Regions []string `mapstructure:"regions"` // 启动及配置更新后预先补充的区域，其他区域在首次获取时补充
+}
+
+// ProxyPoolConfig 代理池配置，对应 config/proxy.yaml 中的 proxy 配置项
+type ProxyPoolConfig struct {
+	Dynamic         ChannelConfig    `mapstructure:"dynamic"`
+	Static          ChannelConfig    `mapstructure:"static"`
+	BatchSize       int              `mapstructure:"batch_size"`       // 单次向供应商获取的代理数
+	ChannelCapacity int              `mapstructure:"channel_capacity"` // 各区域通道可容纳的空闲代理数
+	Providers       []ProviderConfig `mapstructure:"providers"`        // 代理供应商，按顺序获取，失败或数量不足时使用下一个
+	Health          HealthConfig     `mapstructure:"health"`           // 健康检查和熔断
+}
+
+// withDefaults 补全未配置的项
+func (c ProxyPoolConfig) withDefaults() ProxyPoolConfig {
+	if c.Dynamic.Min == 0 {
+		c.Dynamic.Min = 1
+	}
+	if c.Static.Min == 0 {
+		c.Static.Min = 1
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = DefaultBatchSize
+	}
+	if c.ChannelCapacity <= 0 {
+		c.ChannelCapacity = ProxyChannelCap
+	}
+	c.Health = c.Health.withDefaults()
+	return c
+}
+
+// Validate 校验配置，返回全部错误
+func (c ProxyPoolConfig) Validate() error {
+	var errs []error
+	if c.BatchSize < 0 {
+		errs = append(errs, errors.New("proxy.batch_size must not be negative"))
+	}
+	if c.ChannelCapacity < 0 {
+		errs = append(errs, errors.New("proxy.channel_capacity must not be negative"))
+	}
+	capacity := c.withDefaults().ChannelCapacity
+	for _, proxyType := range []string{"dynamic", "static"} {
+		channel := c.Dynamic
+		if proxyType == "static" {
+			channel = c.Static
+		}
+		if channel.Min < 0 || channel.Min > capacity {
+			errs = append(errs, fmt.Errorf("proxy.%s.min must be between 0 and channel capacity %d", proxyType, capacity))
+		}
+		if slices.Contains(channel.Regions, "") {
+			errs = append(errs, fmt.Errorf("proxy.%s.regions must not contain empty region", proxyType))
+		}
+		if channel.Enabled && !slices.ContainsFunc(c.Providers, func(provider ProviderConfig) bool {
+			return provider.Enabled && (len(provider.Types) == 0 || slices.Contains(provider.Types, proxyType))
+		}) {
+			errs = append(errs, fmt.Errorf("proxy.%s is enabled but no enabled provider supplies %s proxies", proxyType, proxyType))
+		}
+	}
+	names := make(map[string]bool)
+	for i, provider := range c.Providers {
+		if names[provider.Name] {
+			errs = append(errs, fmt.Errorf("proxy.providers[%d]: duplicate name %s", i, provider.Name))
+		}
+		names[provider.Name] = true
+		for _, err := range provider.validate() {
+			errs = append(errs, fmt.Errorf("proxy.providers[%d]: %w", i, err))
+		}
+	}
+	for _, err := range c.Health.validate() {
+		errs = append(errs, fmt.Errorf("proxy.health: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// withDefaults 补全未配置的有效期
+func (c ProviderConfig) withDefaults() ProviderConfig {
+	if c.TTL <= 0 {
+		c.TTL = DefaultProxyTTL
+	}
+	return c
+}
+
+// validate 校验供应商配置，内置类型校验必填项
+func (c ProviderConfig) validate() []error {
+	var errs []error
+	if c.Name == "" {
+		errs = append(errs, errors.New("name can not be empty"))
+	}
+	providerFactories.RLock()
+	_, registered := providerFactories.items[c.Type]
+	providerFactories.RUnlock()
+	if !registered {
+		errs = append(errs, fmt.Errorf("unsupported type %q", c.Type))
+	}
+	for _, proxyType := range c.Types {
+		if proxyType != "dynamic" && proxyType != "static" {
+			errs = append(errs, fmt.Errorf("unsupported proxy type %q, expect dynamic or static", proxyType))
+		}
+	}
+	if c.TTL < 0 || c.Timeout < 0 {
+		errs = append(errs, errors.New("ttl and timeout must not be negative"))
+	}
+	switch c.Type {
+	case "http":
+		if err := validateURL(c.URL); err != nil {
+			errs = append(errs, fmt.Errorf("url %w", err))
+		}
+	case "static":
+		if c.Path == "" {
+			errs = append(errs, errors.New("path can not be empty"))
+		}
+	case "exec":
+		if c.Command == "" {
+			errs = append(errs, errors.New("command can not be empty"))
+		}
+	}
+	return errs
+}
+
+// validate 校验健康检查配置
+func (c HealthConfig) validate() []error {
+	var errs []error
+	if c.CheckURL != "" {
+		if err := validateURL(c.CheckURL); err != nil {
+			errs = append(errs, fmt.Errorf("check_url %w", err))
+		}
+	}
+	if c.CheckTimeout < 0 || c.CheckInterval < 0 || c.BreakerCooldown < 0 || c.LatencyTarget < 0 {
+		errs = append(errs, errors.New("durations must not be negative"))
+	}
+	if c.FailureThreshold < 0 || c.MinSamples < 0 {
+		errs = append(errs, errors.New("failure_threshold and min_samples must not be negative"))
+	}
+	if c.MinScore < 0 || c.MinScore > 100 {
+		errs = append(errs, errors.New("min_score must be between 0 and 100"))
+	}
+	return errs
+}
+
+// validateURL 校验 http / https 地址
+func validateURL(value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("must be a http or https address: %q", value)
+	}
+	return nil
+}
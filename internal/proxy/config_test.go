@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"context"
+	"noctua/types"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigValidate(t *testing.T) {
+	valid := ProxyPoolConfig{
+		Dynamic:   ChannelConfig{Enabled: true, Min: 2, Regions: []string{"440000"}},
+		Providers: []ProviderConfig{{Name: "api", Type: "http", Enabled: true, URL: "http://127.0.0.1/proxy", Types: []string{"dynamic"}}},
+		Health:    HealthConfig{CheckURL: "https://cip.cc", MinScore: 60},
+	}
+	assert.NoError(t, valid.Validate())
+
+	invalid := ProxyPoolConfig{
+		Dynamic:         ChannelConfig{Min: 20},
+		Static:          ChannelConfig{Enabled: true, Regions: []string{""}},
+		BatchSize:       -1,
+		ChannelCapacity: 10,
+		Providers: []ProviderConfig{
+			{Name: "api", Type: "http", Enabled: true, URL: "127.0.0.1/proxy", Types: []string{"dynamic"}},
+			{Name: "api", Type: "ftp"},
+			{Name: "list", Type: "static", Types: []string{"rotating"}},
+			{Type: "exec", TTL: -time.Second},
+		},
+		Health: HealthConfig{CheckURL: "cip.cc", MinScore: 120, BreakerCooldown: -time.Second},
+	}
+	err := invalid.Validate()
+	require.Error(t, err)
+	for _, message := range []string{
+		"proxy.batch_size must not be negative",
+		"proxy.dynamic.min must be between 0 and channel capacity 10",
+		"proxy.static.regions must not contain empty region",
+		"proxy.static is enabled but no enabled provider supplies static proxies",
+		`proxy.providers[0]: url must be a http or https address: "127.0.0.1/proxy"`,
+		"proxy.providers[1]: duplicate name api",
+		`proxy.providers[1]: unsupported type "ftp"`,
+		`proxy.providers[2]: unsupported proxy type "rotating", expect dynamic or static`,
+		"proxy.providers[2]: path can not be empty",
+		"proxy.providers[3]: name can not be empty",
+		"proxy.providers[3]: ttl and timeout must not be negative",
+		"proxy.providers[3]: command can not be empty",
+		`proxy.health: check_url must be a http or https address: "cip.cc"`,
+		"proxy.health: durations must not be negative",
+		"proxy.health: min_score must be between 0 and 100",
+	} {
+		assert.ErrorContains(t, err, message)
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proxies.txt")
+	require.NoError(t, os.WriteFile(path, []byte("1.1.1.1:80 dynamic 440000\n2.2.2.2:80 dynamic 440000\n3.3.3.3:80 static 110000\n"), 0600))
+	list := ProviderConfig{Name: "list", Type: "static", Enabled: true, Path: path}
+	config := ProxyPoolConfig{
+		Dynamic:   ChannelConfig{Enabled: true, Regions: []string{"440000"}},
+		Providers: []ProviderConfig{list},
+	}
+	pool := NewProxyPool(context.Background(), config)
+	defer pool.Stop()
+	recorder := &eventRecorder{}
+	pool.SetEmitter(recorder.emit)
+	manual := &fakeProvider{name: "manual"}
+	pool.AddProvider(manual, ProviderConfig{Types: []string{"static"}})
+
+	// 启动时补充配置的区域
+	assert.Eventually(t, func() bool {
+		return pool.Status().DynamicProxies["440000"].Length == 2
+	}, time.Second, 10*time.Millisecond)
+
+	// 配置未变化的供应商保留统计，AddProvider 添加的供应商保留
+	require.NoError(t, pool.ApplyConfig(config))
+	status := pool.Status()
+	require.Len(t, status.Providers, 2)
+	assert.Equal(t, ProviderStatus{Name: "list", Fetched: 2}, status.Providers[0])
+	assert.Equal(t, "manual", status.Providers[1].Name)
+
+	// 供应商创建失败时保持原配置
+	broken := config
+	broken.Dynamic.Enabled = false
+	broken.Providers = []ProviderConfig{list, {Name: "missing", Type: "static", Enabled: true, Path: filepath.Join(t.TempDir(), "none.txt")}}
+	assert.ErrorContains(t, pool.ApplyConfig(broken), "build proxy provider missing failed")
+	status = pool.Status()
+	assert.True(t, status.DynamicEnabled)
+	assert.Len(t, status.Providers, 2)
+	assert.Equal(t, 2, status.TotalProxies)
+
+	// 停用动态代理后移除其空闲代理，启用静态代理并按新的通道容量补充
+	require.NoError(t, pool.ApplyConfig(ProxyPoolConfig{
+		Static:          ChannelConfig{Enabled: true, Regions: []string{"110000"}},
+		ChannelCapacity: 5,
+		Providers:       []ProviderConfig{list},
+	}))
+	assert.Eventually(t, func() bool {
+		return pool.Status().StaticProxies["110000"].Length == 1
+	}, time.Second, 10*time.Millisecond)
+	status = pool.Status()
+	assert.False(t, status.DynamicEnabled)
+	assert.Empty(t, status.DynamicProxies)
+	assert.Equal(t, 5, status.StaticProxies["110000"].Capacity)
+	assert.Equal(t, 1, status.TotalProxies)
+	assert.Equal(t, []string{types.TopicProxyRemoved, types.TopicProxyRemoved}, recorder.topics())
+	assert.Empty(t, manual.received())
+	proxy, err := pool.GetAvailableProxy(types.ProxyRequest{Type: "dynamic", Region: "440000"})
+	require.NoError(t, err)
+	assert.False(t, proxy.Useable)
+}
@@ -0,0 +1,54 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"noctua/types"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExecProvider 执行命令获取代理，请求参数通过环境变量 PROXY_NUM、PROXY_TYPE、PROXY_REGION 传入，
+// 命令输出 ProxyResponse、ProxyInfo 数组或每行一个代理地址
+type ExecProvider struct {
+	name    string
+	command string
+	args    []string
+	timeout time.Duration
+}
+
+func NewExecProvider(name, command string, args []string, timeout time.Duration) (*ExecProvider, error) {
+	if command == "" {
+		return nil, fmt.Errorf("proxy provider %s command can not be empty", name)
+	}
+	if timeout <= 0 {
+		timeout = DefaultProviderTimeout
+	}
+	return &ExecProvider{name: name, command: command, args: args, timeout: timeout}, nil
+}
+
+func (e *ExecProvider) Name() string {
+	return e.name
+}
+
+func (e *ExecProvider) Fetch(ctx context.Context, req types.ProxyRequest) ([]*types.ProxyInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, e.command, e.args...)
+	cmd.Env = append(os.Environ(),
+		"PROXY_NUM="+strconv.Itoa(req.Num),
+		"PROXY_TYPE="+req.Type,
+		"PROXY_REGION="+req.Region,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("proxy provider %s command failed: %w: %s", e.name, err, strings.TrimSpace(stderr.String()))
+	}
+	return parseProxyList(output)
+}
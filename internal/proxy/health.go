@@ -0,0 +1,189 @@
+package proxy
+
+import (
+	"sync"
+	"time"
+)
+
+const DefaultCheckTimeout = 5 * time.Second
+const DefaultCheckInterval = 5 * time.Minute
+const DefaultFailureThreshold = 3
+const DefaultBreakerCooldown = time.Minute
+const DefaultLatencyTarget = 2 * time.Second
+const DefaultMinSamples = 10
+
+// 熔断状态
+const (
+	BreakerClosed   = "closed"    // 正常使用
+	BreakerOpen     = "open"      // 熔断中，不会被获取
+	BreakerHalfOpen = "half_open" // 熔断到期，下一次请求或检查成功后恢复，失败则再次熔断
+)
+
+// 评分权重，成功率、平均延迟和连续失败次数
+const (
+	scoreWeightSuccess   = 0.6
+	scoreWeightLatency   = 0.3
+	scoreWeightStability = 0.1
+	latencyEWMAAlpha     = 0.3
+)
+
+// HealthConfig 代理健康检查和熔断配置
+type HealthConfig struct {
+	CheckURL         string        `mapstructure:"check_url"`         // 健康检查地址，通过代理请求返回 2xx 视为可用，为空时不检查
+	CheckTimeout     time.Duration `mapstructure:"check_timeout"`     // 健康检查超时时间
+	CheckInterval    time.Duration `mapstructure:"check_interval"`    // 获取代理时距上次检查超过该时间则重新检查
+	FailureThreshold int           `mapstructure:"failure_threshold"` // 连续失败次数达到后熔断
+	BreakerCooldown  time.Duration `mapstructure:"breaker_cooldown"`  // 熔断时长，到期后检查通过恢复使用
+	LatencyTarget    time.Duration `mapstructure:"latency_target"`    // 平均延迟不超过该值时延迟得分为满分
+	MinScore         float64       `mapstructure:"min_score"`         // 请求数达到 MinSamples 后低于该分数的代理移出代理池，0 为不移除
+	MinSamples       int64         `mapstructure:"min_samples"`
+}
+
+// withDefaults 补全未配置的项
+func (c HealthConfig) withDefaults() HealthConfig {
+	if c.CheckTimeout <= 0 {
+		c.CheckTimeout = DefaultCheckTimeout
+	}
+	if c.CheckInterval <= 0 {
+		c.CheckInterval = DefaultCheckInterval
+	}
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = DefaultFailureThreshold
+	}
+	if c.BreakerCooldown <= 0 {
+		c.BreakerCooldown = DefaultBreakerCooldown
+	}
+	if c.LatencyTarget <= 0 {
+		c.LatencyTarget = DefaultLatencyTarget
+	}
+	if c.MinSamples <= 0 {
+		c.MinSamples = DefaultMinSamples
+	}
+	return c
+}
+
+// ProxyHealthStatus 代理健康状态
+type ProxyHealthStatus struct {
+	ProxyKey            string    `json:"proxyKey"`
+	Score               float64   `json:"score"`       // 综合评分 0-100
+	SuccessRate         float64   `json:"successRate"` // 请求成功率，无请求时为 1
+	Successes           int64     `json:"successes"`
+	Failures            int64     `json:"failures"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	AvgLatencyMs        int64     `json:"avgLatencyMs"` // 成功请求的加权平均延迟
+	Breaker             string    `json:"breaker"`      // closed | open | half_open
+	OpenUntil           time.Time `json:"openUntil"`    // 熔断截止时间
+	Trips               int64     `json:"trips"`        // 熔断次数
+	LastError           string    `json:"lastError"`
+	LastChecked         time.Time `json:"lastChecked"`
+	InUse               bool      `json:"inUse"`
+}
+
+// proxyHealth 单个代理的请求统计和熔断状态，请求结果由 API 客户端上报，健康检查结果同样计入
+type proxyHealth struct {
+	mu          sync.Mutex
+	successes   int64
+	failures    int64
+	consecutive int
+	latency     time.Duration // 成功请求的指数加权平均延迟
+	lastError   string
+	lastChecked time.Time
+	openUntil   time.Time // 熔断截止时间，为零时未熔断
+	trips       int64
+}
+
+// record 记录一次请求结果，连续失败达到阈值时熔断并返回 true
+func (h *proxyHealth) record(cfg HealthConfig, now time.Time, latency time.Duration, err error) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err == nil {
+		h.successes++
+		h.consecutive = 0
+		h.openUntil = time.Time{}
+		if h.latency == 0 {
+			h.latency = latency
+		} else {
+			h.latency = time.Duration(latencyEWMAAlpha*float64(latency) + (1-latencyEWMAAlpha)*float64(h.latency))
+		}
+		return false
+	}
+	h.failures++
+	h.consecutive++
+	h.lastError = err.Error()
+	if h.consecutive < cfg.FailureThreshold || now.Before(h.openUntil) {
+		return false
+	}
+	h.openUntil = now.Add(cfg.BreakerCooldown)
+	h.trips++
+	return true
+}
+
+// breaker 返回熔断状态
+func (h *proxyHealth) breaker(now time.Time) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.breakerLocked(now)
+}
+
+func (h *proxyHealth) breakerLocked(now time.Time) string {
+	switch {
+	case h.openUntil.IsZero():
+		return BreakerClosed
+	case now.Before(h.openUntil):
+		return BreakerOpen
+	default:
+		return BreakerHalfOpen
+	}
+}
+
+// needsCheck 半开状态或距上次检查超过 interval 时需要健康检查
+func (h *proxyHealth) needsCheck(now time.Time, interval time.Duration) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.breakerLocked(now) == BreakerHalfOpen || now.Sub(h.lastChecked) >= interval
+}
+
+// markChecked 记录检查时间
+func (h *proxyHealth) markChecked(now time.Time) {
+	h.mu.Lock()
+	h.lastChecked = now
+	h.mu.Unlock()
+}
+
+// lowScore 请求数达到最小样本数且评分低于 MinScore
+func (h *proxyHealth) lowScore(cfg HealthConfig) bool {
+	if cfg.MinScore <= 0 {
+		return false
+	}
+	status := h.status(cfg, time.Now())
+	return status.Successes+status.Failures >= cfg.MinSamples && status.Score < cfg.MinScore
+}
+
+// status 计算评分，成功率、延迟和连续失败分别按权重计分，无请求记录的代理视为满分
+func (h *proxyHealth) status(cfg HealthConfig, now time.Time) ProxyHealthStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	successRate := 1.0
+	if total := h.successes + h.failures; total > 0 {
+		successRate = float64(h.successes) / float64(total)
+	}
+	latencyScore := 1.0
+	if h.latency > cfg.LatencyTarget {
+		latencyScore = float64(cfg.LatencyTarget) / float64(h.latency)
+	}
+	stability := 1 - float64(min(h.consecutive, cfg.FailureThreshold))/float64(cfg.FailureThreshold)
+	score := 100 * (scoreWeightSuccess*successRate + scoreWeightLatency*latencyScore + scoreWeightStability*stability)
+	return ProxyHealthStatus{
+		Score:               float64(int(score*100+0.5)) / 100,
+		SuccessRate:         successRate,
+		Successes:           h.successes,
+		Failures:            h.failures,
+		ConsecutiveFailures: h.consecutive,
+		AvgLatencyMs:        h.latency.Milliseconds(),
+		Breaker:             h.breakerLocked(now),
+		OpenUntil:           h.openUntil,
+		Trips:               h.trips,
+		LastError:           h.lastError,
+		LastChecked:         h.lastChecked,
+	}
+}
@@ -0,0 +1,225 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"noctua/types"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listProvider 每次返回同一组代理
+type listProvider struct {
+	proxyVals []string
+}
+
+func (l *listProvider) Name() string { return "list" }
+
+func (l *listProvider) Fetch(_ context.Context, _ types.ProxyRequest) ([]*types.ProxyInfo, error) {
+	proxies := make([]*types.ProxyInfo, 0, len(l.proxyVals))
+	for _, val := range l.proxyVals {
+		proxies = append(proxies, &types.ProxyInfo{ProxyVal: val})
+	}
+	return proxies, nil
+}
+
+// eventRecorder 记录代理池发布的事件
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []interface{}
+}
+
+func (e *eventRecorder) emit(event interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, event)
+}
+
+func (e *eventRecorder) topics() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	topics := make([]string, 0, len(e.events))
+	for _, event := range e.events {
+		topics = append(topics, event.(types.LifecycleEvent).Topic())
+	}
+	return topics
+}
+
+func TestProxyHealthScore(t *testing.T) {
+	cfg := HealthConfig{LatencyTarget: time.Second, FailureThreshold: 2, BreakerCooldown: time.Minute, MinScore: 60, MinSamples: 4}.withDefaults()
+	now := time.Now()
+	health := &proxyHealth{}
+	assert.Equal(t, 100.0, health.status(cfg, now).Score)
+
+	assert.False(t, health.record(cfg, now, time.Second, nil))
+	assert.False(t, health.record(cfg, now, 3*time.Second, nil))
+	status := health.status(cfg, now)
+	assert.Equal(t, int64(1600), status.AvgLatencyMs)
+	// 成功率 60 + 延迟 30*1000/1600 + 稳定性 10
+	assert.Equal(t, 88.75, status.Score)
+	assert.Equal(t, BreakerClosed, status.Breaker)
+
+	// 连续失败达到阈值后熔断，熔断期间再失败不重复计入熔断次数
+	assert.False(t, health.record(cfg, now, 0, errors.New("timeout")))
+	assert.True(t, health.record(cfg, now, 0, errors.New("timeout")))
+	assert.False(t, health.record(cfg, now, 0, errors.New("timeout")))
+	status = health.status(cfg, now)
+	assert.Equal(t, BreakerOpen, status.Breaker)
+	assert.Equal(t, int64(1), status.Trips)
+	assert.Equal(t, "timeout", status.LastError)
+	assert.Equal(t, 0.4, status.SuccessRate)
+	assert.True(t, health.lowScore(cfg))
+
+	// 熔断到期后半开，需要检查，失败再次熔断，成功恢复
+	later := now.Add(cfg.BreakerCooldown)
+	health.markChecked(later)
+	assert.Equal(t, BreakerHalfOpen, health.breaker(later))
+	assert.True(t, health.needsCheck(later, cfg.CheckInterval))
+	assert.True(t, health.record(cfg, later, 0, errors.New("refused")))
+	assert.Equal(t, int64(2), health.status(cfg, later).Trips)
+	assert.False(t, health.record(cfg, later.Add(cfg.BreakerCooldown), time.Second, nil))
+	assert.Equal(t, BreakerClosed, health.breaker(later.Add(cfg.BreakerCooldown)))
+	assert.False(t, health.needsCheck(later.Add(time.Minute), cfg.CheckInterval))
+}
+
+func TestPoolCircuitBreaker(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	var checks atomic.Int32
+	// 作为 http 代理，检查请求经由该服务转发
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "check.test", r.URL.Host)
+		checks.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer proxyServer.Close()
+	serverURL, err := url.Parse(proxyServer.URL)
+	require.NoError(t, err)
+
+	pool := NewProxyPool(context.Background(), ProxyPoolConfig{
+		Dynamic:   ChannelConfig{Enabled: true},
+		BatchSize: 1,
+		Health: HealthConfig{
+			CheckURL:         "http://check.test/ip",
+			FailureThreshold: 2,
+			BreakerCooldown:  50 * time.Millisecond,
+		},
+	})
+	defer pool.Stop()
+	recorder := &eventRecorder{}
+	pool.SetEmitter(recorder.emit)
+	pool.AddProvider(&listProvider{proxyVals: []string{serverURL.Host}}, ProviderConfig{})
+
+	// 首次获取时检查
+	proxy, err := pool.GetAvailableProxy(types.ProxyRequest{Num: 1, Type: "dynamic", Region: "440000", JobID: "job-1"})
+	require.NoError(t, err)
+	require.True(t, proxy.Useable)
+	assert.Equal(t, int32(1), checks.Load())
+	status := pool.Status().Proxies
+	require.Len(t, status, 1)
+	assert.Equal(t, proxy.ProxyKey, status[0].ProxyKey)
+	assert.True(t, status[0].InUse)
+	assert.Equal(t, int64(1), status[0].Successes)
+	assert.False(t, status[0].LastChecked.IsZero())
+
+	// 连续失败后熔断，释放后暂存不放回通道
+	pool.ReportResult(proxy, 0, errors.New("connection reset"))
+	pool.ReportResult(proxy, 0, errors.New("connection reset"))
+	assert.Equal(t, BreakerOpen, pool.Status().Proxies[0].Breaker)
+	assert.Equal(t, []string{types.TopicProxyAcquired, types.TopicProxyCircuitOpen}, recorder.topics())
+	pool.ReleaseProxy(proxy)
+	status = pool.Status().Proxies
+	require.Len(t, status, 1)
+	assert.False(t, status[0].InUse)
+	assert.Equal(t, 0, pool.Status().DynamicProxies["440000"].Length)
+
+	// 熔断期间不检查
+	pool.recoverProxies()
+	assert.Equal(t, int32(1), checks.Load())
+
+	// 到期后检查失败再次熔断
+	healthy.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	pool.recoverProxies()
+	status = pool.Status().Proxies
+	assert.Equal(t, BreakerOpen, status[0].Breaker)
+	assert.Equal(t, int64(2), status[0].Trips)
+	assert.Equal(t, "check proxy responded 502", status[0].LastError)
+	assert.Equal(t, 0, pool.Status().DynamicProxies["440000"].Length)
+
+	// 到期后检查通过放回通道
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	pool.recoverProxies()
+	status = pool.Status().Proxies
+	assert.Equal(t, BreakerClosed, status[0].Breaker)
+	assert.Equal(t, 0, status[0].ConsecutiveFailures)
+	assert.Equal(t, int64(2), status[0].Successes)
+	assert.Equal(t, 1, pool.Status().DynamicProxies["440000"].Length)
+
+	// 刚检查过的代理获取时不再检查
+	proxy, err = pool.GetAvailableProxy(types.ProxyRequest{Type: "dynamic", Region: "440000"})
+	require.NoError(t, err)
+	assert.True(t, proxy.Useable)
+	assert.Equal(t, int32(3), checks.Load())
+}
+
+func TestPoolLowScoreRemoval(t *testing.T) {
+	pool := NewProxyPool(context.Background(), ProxyPoolConfig{
+		Dynamic:   ChannelConfig{Enabled: true},
+		BatchSize: 1,
+		Health:    HealthConfig{MinScore: 80, MinSamples: 2},
+	})
+	defer pool.Stop()
+	recorder := &eventRecorder{}
+	pool.SetEmitter(recorder.emit)
+	pool.AddProvider(&listProvider{proxyVals: []string{"1.1.1.1:80"}}, ProviderConfig{})
+
+	proxy, err := pool.GetAvailableProxy(types.ProxyRequest{Num: 1, Type: "dynamic", Region: "440000"})
+	require.NoError(t, err)
+	pool.ReportResult(proxy, 100*time.Millisecond, nil)
+	assert.Equal(t, 100.0, pool.Status().Proxies[0].Score)
+
+	// 样本数达到后评分低于最小分数时移除，释放后不再放回
+	pool.ReportResult(proxy, 0, errors.New("timeout"))
+	assert.Empty(t, pool.Status().Proxies)
+	assert.Equal(t, []string{types.TopicProxyAcquired, types.TopicProxyRemoved}, recorder.topics())
+	pool.ReleaseProxy(proxy)
+	assert.Equal(t, 0, pool.Status().DynamicProxies["440000"].Length)
+	assert.Equal(t, 0, pool.Status().TotalProxies)
+}
+
+func TestPoolAcquireBounded(t *testing.T) {
+	pool := NewProxyPool(context.Background(), ProxyPoolConfig{Dynamic: ChannelConfig{Enabled: true}})
+	ch := pool.getChannel("dynamic", "440000")
+	require.NotNil(t, ch)
+	// 通道中都是已过期的代理，跳过有限次后返回错误，不等待超时
+	for i := 0; i <= ProxyMaxAttempts; i++ {
+		ch.queue <- &types.ProxyInfo{ProxyType: "dynamic", ProxyKey: fmt.Sprintf("dynamic_440000_%d", i), Useable: true}
+	}
+	started := time.Now()
+	_, err := pool.GetAvailableProxy(types.ProxyRequest{Type: "dynamic", Region: "440000"})
+	assert.ErrorContains(t, err, "after 10 attempts")
+	assert.Less(t, time.Since(started), ProxyTimeout)
+	assert.Len(t, ch.queue, 1)
+
+	// 停止后获取代理和补充不会向已关闭的通道发送
+	pool.Stop()
+	_, err = pool.GetAvailableProxy(types.ProxyRequest{Num: 1, Type: "dynamic", Region: "110000"})
+	assert.ErrorIs(t, err, context.Canceled)
+	pool.topUp()
+	pool.Stop()
+}
@@ -0,0 +1,54 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"noctua/types"
+	"strconv"
+	"time"
+)
+
+// HTTPProvider 从返回 ProxyResponse 的接口获取代理
+type HTTPProvider struct {
+	name   string
+	url    string
+	client *resty.Client
+}
+
+func NewHTTPProvider(name, url string, headers map[string]string, timeout time.Duration) (*HTTPProvider, error) {
+	if url == "" {
+		return nil, fmt.Errorf("proxy provider %s url can not be empty", name)
+	}
+	if timeout <= 0 {
+		timeout = DefaultProviderTimeout
+	}
+	client := resty.New().SetTimeout(timeout)
+	if len(headers) > 0 {
+		client.SetHeaders(headers)
+	}
+	return &HTTPProvider{name: name, url: url, client: client}, nil
+}
+
+func (h *HTTPProvider) Name() string {
+	return h.name
+}
+
+// Fetch 请求接口，参数为 num、type、region
+func (h *HTTPProvider) Fetch(ctx context.Context, req types.ProxyRequest) ([]*types.ProxyInfo, error) {
+	resp, err := h.client.R().
+		SetContext(ctx).
+		SetQueryParams(map[string]string{
+			"num":    strconv.Itoa(req.Num),
+			"type":   req.Type,
+			"region": req.Region,
+		}).
+		Get(h.url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() < 200 || resp.StatusCode() >= 300 {
+		return nil, fmt.Errorf("proxy provider %s responded %d: %s", h.name, resp.StatusCode(), resp.String())
+	}
+	return parseProxyList(resp.Body())
+}
@@ -6,21 +6,26 @@ import (
 	"noctua/pkg/logger"
 	"noctua/pkg/utils/math"
 	"noctua/types"
+	"reflect"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 )
 
 const ProxyTimeout = 10 * time.Second
+const ProxyMaxAttempts = 10 // 获取代理时跳过不可用代理的最大次数
 const ProxyChannelCap = 100
 const CheckWorkerInterval = 10 * time.Second
 
-// ProxyPoolConfig 配置
-type ProxyPoolConfig struct {
-	MinDynamic     int
-	MinStatic      int
-	DynamicEnabled bool
-	StaticEnabled  bool
+// providerSlot 代理池使用的供应商及其配置
+type providerSlot struct {
+	provider   Provider
+	config     ProviderConfig
+	configured bool // 由配置创建，配置更新时重建，通过 AddProvider 添加的保留
+	fetched    int64
+	failures   int64
+	lastError  string
 }
 
 // channel 代理通道
@@ -39,6 +44,16 @@ type ProxyPoolStatus struct {
 	StaticProxies     map[string]*ChannelStatus `json:"staticProxies"`     // 静态代理通道状态
 	EventQueueLength  int                       `json:"eventQueueLength"`  // 事件队列长度
 	EnsureQueueLength int                       `json:"ensureQueueLength"` // 补充队列长度
+	Providers         []ProviderStatus          `json:"providers"`         // 代理供应商状态
+	Proxies           []ProxyHealthStatus       `json:"proxies"`           // 代理健康状态，按评分从高到低
+}
+
+// ProviderStatus 代理供应商状态
+type ProviderStatus struct {
+	Name      string `json:"name"`
+	Fetched   int64  `json:"fetched"`   // 已加入代理池的代理数
+	Failures  int64  `json:"failures"`  // 获取失败次数
+	LastError string `json:"lastError"` // 最近一次获取失败原因
 }
 
 // ChannelStatus 定义通道状态
@@ -53,22 +68,25 @@ type ProxyPool struct {
 	ctx        context.Context
 	cancel     context.CancelFunc
 	wg         sync.WaitGroup
+	configMu   sync.RWMutex
 	config     ProxyPoolConfig
 	proxies    *sync.Map               // 所有代理
 	inUse      *sync.Map               // 使用中的代理
+	jobs       *sync.Map               // 使用中的代理所属的采集任务ID
+	health     *sync.Map               // 代理健康状态
+	parked     *sync.Map               // 熔断中的空闲代理，熔断到期检查通过后放回通道
+	channelMu  sync.Mutex              // 保护 dynamic 和 static
 	dynamic    map[string]*channel     // 动态代理通道
 	static     map[string]*channel     // 静态代理通道
 	ensureChan chan types.ProxyRequest // 代理补充请求
+	providerMu sync.Mutex
+	providers  []*providerSlot
+	emit       types.EventEmitter
 }
 
 // NewProxyPool 初始化代理池
 func NewProxyPool(parentCtx context.Context, config ProxyPoolConfig) *ProxyPool {
-	if config.MinStatic == 0 {
-		config.MinStatic = 1
-	}
-	if config.MinDynamic == 0 {
-		config.MinDynamic = 1
-	}
+	config = config.withDefaults()
 	ctx, cancel := context.WithCancel(parentCtx)
 	pool := &ProxyPool{
 		ctx:        ctx,
@@ -76,17 +94,141 @@ func NewProxyPool(parentCtx context.Context, config ProxyPoolConfig) *ProxyPool
 		config:     config,
 		proxies:    &sync.Map{},
 		inUse:      &sync.Map{},
+		jobs:       &sync.Map{},
+		health:     &sync.Map{},
+		parked:     &sync.Map{},
 		dynamic:    make(map[string]*channel),
 		static:     make(map[string]*channel),
 		ensureChan: make(chan types.ProxyRequest, 20),
 	}
+	for _, providerConfig := range config.Providers {
+		if !providerConfig.Enabled {
+			continue
+		}
+		slot, err := newProviderSlot(providerConfig)
+		if err != nil {
+			logger.Log.Errorf("Build proxy provider %s failed: %v", providerConfig.Name, err)
+			continue
+		}
+		pool.providers = append(pool.providers, slot)
+	}
 	pool.wg.Add(2)
 
 	go pool.ensureWorker()
 	go pool.checkWorker()
+	pool.warmUp()
 	return pool
 }
 
+// settings 返回当前配置
+func (p *ProxyPool) settings() ProxyPoolConfig {
+	p.configMu.RLock()
+	defer p.configMu.RUnlock()
+	return p.config
+}
+
+// SetEmitter 设置代理事件的发布函数
+func (p *ProxyPool) SetEmitter(emit types.EventEmitter) {
+	p.emit = emit
+}
+
+// AddProvider 添加代理供应商，config 中的 Types、Regions 和 TTL 用于筛选和标记代理
+func (p *ProxyPool) AddProvider(provider Provider, config ProviderConfig) {
+	p.providerMu.Lock()
+	defer p.providerMu.Unlock()
+	p.providers = append(p.providers, &providerSlot{provider: provider, config: config.withDefaults()})
+}
+
+// newProviderSlot 按配置创建供应商
+func newProviderSlot(config ProviderConfig) (*providerSlot, error) {
+	provider, err := BuildProvider(config)
+	if err != nil {
+		return nil, err
+	}
+	return &providerSlot{provider: provider, config: config.withDefaults(), configured: true}, nil
+}
+
+// ApplyConfig 应用新的配置，用于配置热更新。配置未变化的供应商保留实例和统计，任一供应商创建失败时保持原配置；
+// 停用类型的空闲代理移出代理池，使用中的在释放时移出；通道容量对之后新建的区域通道生效
+func (p *ProxyPool) ApplyConfig(config ProxyPoolConfig) error {
+	config = config.withDefaults()
+	p.providerMu.Lock()
+	current := make(map[string]*providerSlot)
+	var manual []*providerSlot
+	for _, slot := range p.providers {
+		if slot.configured {
+			current[slot.config.Name] = slot
+		} else {
+			manual = append(manual, slot)
+		}
+	}
+	var providers []*providerSlot
+	for _, providerConfig := range config.Providers {
+		if !providerConfig.Enabled {
+			continue
+		}
+		if slot, ok := current[providerConfig.Name]; ok && reflect.DeepEqual(slot.config, providerConfig.withDefaults()) {
+			providers = append(providers, slot)
+			continue
+		}
+		slot, err := newProviderSlot(providerConfig)
+		if err != nil {
+			p.providerMu.Unlock()
+			return fmt.Errorf("build proxy provider %s failed: %w", providerConfig.Name, err)
+		}
+		providers = append(providers, slot)
+	}
+	p.providers = append(providers, manual...)
+	p.providerMu.Unlock()
+
+	p.configMu.Lock()
+	previous := p.config
+	p.config = config
+	p.configMu.Unlock()
+	if previous.Dynamic.Enabled && !config.Dynamic.Enabled {
+		p.drain("dynamic")
+	}
+	if previous.Static.Enabled && !config.Static.Enabled {
+		p.drain("static")
+	}
+	p.warmUp()
+	return nil
+}
+
+// drain 移除停用类型的通道及其中的空闲代理
+func (p *ProxyPool) drain(proxyType string) {
+	p.channelMu.Lock()
+	channels := p.dynamic
+	if proxyType == "dynamic" {
+		p.dynamic = make(map[string]*channel)
+	} else {
+		channels = p.static
+		p.static = make(map[string]*channel)
+	}
+	p.channelMu.Unlock()
+	for _, ch := range channels {
+		for len(ch.queue) > 0 {
+			select {
+			case proxy := <-ch.queue:
+				p.RemoveProxy(proxy, "disabled")
+			default:
+			}
+		}
+	}
+}
+
+// warmUp 创建配置的区域通道，并为空闲代理不足的通道发起补充
+func (p *ProxyPool) warmUp() {
+	config := p.settings()
+	for _, region := range config.Dynamic.Regions {
+		p.getChannel("dynamic", region)
+	}
+	for _, region := range config.Static.Regions {
+		p.getChannel("static", region)
+	}
+	p.topUp()
+}
+
 // ensureWorker 代理补充协程
 func (p *ProxyPool) ensureWorker() {
 	defer p.wg.Done()
@@ -95,39 +237,118 @@ func (p *ProxyPool) ensureWorker() {
 		case <-p.ctx.Done():
 			logger.Log.Info("Ensure worker stopped")
 			return
-		case _ = <-p.ensureChan:
+		case req := <-p.ensureChan:
+			p.replenish(req)
 		}
 	}
 }
 
-// addToChannel 将代理添加到对应通道
-func (p *ProxyPool) addToChannel(proxy *types.ProxyInfo) {
+// replenish 向供应商批量获取代理，补充到通道中至少有 req.Num 个空闲代理
+func (p *ProxyPool) replenish(req types.ProxyRequest) {
+	ch := p.getChannel(req.Type, req.Region)
+	if ch == nil {
+		return
+	}
+	need := req.Num - len(ch.queue)
+	if need <= 0 {
+		return
+	}
+	p.providerMu.Lock()
+	providers := append([]*providerSlot(nil), p.providers...)
+	p.providerMu.Unlock()
+	for _, slot := range providers {
+		if !slot.config.supports(req) {
+			continue
+		}
+		batch := math.Min(math.Max(need, p.settings().BatchSize), cap(ch.queue)-len(ch.queue))
+		if batch <= 0 {
+			return
+		}
+		proxies, err := slot.provider.Fetch(p.ctx, types.ProxyRequest{Num: batch, Type: req.Type, Region: req.Region})
+		if err != nil {
+			if p.ctx.Err() != nil {
+				return
+			}
+			logger.Log.Warnf("Fetch %s proxies in %s from provider %s failed: %v", req.Type, req.Region, slot.provider.Name(), err)
+			p.providerMu.Lock()
+			slot.failures++
+			slot.lastError = err.Error()
+			p.providerMu.Unlock()
+			continue
+		}
+		added := 0
+		now := time.Now()
+		for _, proxy := range proxies {
+			p.tagProxy(proxy, req, slot.config.TTL, now)
+			if !proxy.GetExpireTime().After(now) {
+				continue
+			}
+			if _, loaded := p.proxies.LoadOrStore(proxy.ProxyKey, proxy); loaded {
+				continue
+			}
+			if !p.addToChannel(proxy) {
+				p.proxies.Delete(proxy.ProxyKey)
+				continue
+			}
+			added++
+		}
+		p.providerMu.Lock()
+		slot.fetched += int64(added)
+		p.providerMu.Unlock()
+		logger.Log.Infof("Added %d %s proxies in %s from provider %s", added, req.Type, req.Region, slot.provider.Name())
+		if need -= added; need <= 0 {
+			return
+		}
+	}
+	logger.Log.Warnf("Proxy providers are short of %d %s proxies in %s", need, req.Type, req.Region)
+}
+
+// tagProxy 按请求标记代理的类型和区域，供应商未返回过期时间时使用 ttl
+func (p *ProxyPool) tagProxy(proxy *types.ProxyInfo, req types.ProxyRequest, ttl time.Duration, now time.Time) {
+	proxy.Useable = true
+	proxy.ProxyType = req.Type
+	if proxy.Protocol == "" {
+		proxy.Protocol = "http"
+	}
+	if proxy.ExpireTime <= 0 {
+		proxy.ExpireTime = now.Add(ttl).Unix()
+	}
+	proxy.ProxyKey = fmt.Sprintf("%s_%s_%s", req.Type, req.Region, proxy.ProxyVal)
+}
+
+// addToChannel 将代理添加到对应通道，通道不存在或已满时返回 false
+func (p *ProxyPool) addToChannel(proxy *types.ProxyInfo) bool {
 	region := p.getRegionFromKey(proxy.ProxyKey)
 	if region == "" {
 		logger.Log.Warnf("Invalid region for proxy %s", proxy.ProxyKey)
-		return
+		return false
 	}
 
 	ch := p.getChannel(proxy.ProxyType, region)
 	if ch == nil {
-		return
+		return false
 	}
 
 	ch.mu.Lock()
 	defer ch.mu.Unlock()
 	select {
 	case ch.queue <- proxy:
+		return true
 	default:
 		logger.Log.Warnf("Channel full for %s in %s, dropping %s", proxy.ProxyType, region, proxy.ProxyKey)
+		return false
 	}
 }
 
 // getChannel 获取或创建通道
 func (p *ProxyPool) getChannel(proxyType, region string) *channel {
+	config := p.settings()
+	p.channelMu.Lock()
+	defer p.channelMu.Unlock()
 	var m map[string]*channel
-	if proxyType == "dynamic" && p.config.DynamicEnabled {
+	if proxyType == "dynamic" && config.Dynamic.Enabled {
 		m = p.dynamic
-	} else if proxyType == "static" && p.config.StaticEnabled {
+	} else if proxyType == "static" && config.Static.Enabled {
 		m = p.static
 	} else {
 		return nil
@@ -136,7 +357,7 @@ func (p *ProxyPool) getChannel(proxyType, region string) *channel {
 	ch, exists := m[region]
 	if !exists {
 		ch = &channel{
-			queue: make(chan *types.ProxyInfo, ProxyChannelCap),
+			queue: make(chan *types.ProxyInfo, config.ChannelCapacity),
 		}
 		m[region] = ch
 	}
@@ -156,6 +377,8 @@ func (p *ProxyPool) checkWorker() {
 			return
 		case <-ticker.C:
 			p.checkProxies()
+			p.recoverProxies()
+			p.topUp()
 		}
 	}
 }
@@ -164,14 +387,39 @@ func (p *ProxyPool) checkWorker() {
 func (p *ProxyPool) checkProxies() {
 	p.proxies.Range(func(key, value interface{}) bool {
 		proxy := value.(*types.ProxyInfo)
-		if !proxy.GetExpireTime().After(time.Now()) || !proxy.Useable {
-			p.RemoveProxy(proxy)
+		if !proxy.GetExpireTime().After(time.Now()) {
+			p.RemoveProxy(proxy, "expired")
+		} else if !proxy.Useable {
+			p.RemoveProxy(proxy, "unusable")
 		}
 		return true
 	})
 }
 
-// GetAvailableProxy 获取可用代理
+// topUp 空闲代理少于最小数量的通道发起补充
+func (p *ProxyPool) topUp() {
+	var requests []types.ProxyRequest
+	p.channelMu.Lock()
+	for proxyType, channels := range map[string]map[string]*channel{"dynamic": p.dynamic, "static": p.static} {
+		for region, ch := range channels {
+			if minCount := p.getMinProxyCount(proxyType); len(ch.queue) < minCount {
+				requests = append(requests, types.ProxyRequest{Num: minCount, Type: proxyType, Region: region})
+			}
+		}
+	}
+	p.channelMu.Unlock()
+	for _, req := range requests {
+		select {
+		case <-p.ctx.Done():
+			return
+		case p.ensureChan <- req:
+		default:
+			return
+		}
+	}
+}
+
+// GetAvailableProxy 获取可用代理，跳过不可用的代理最多 ProxyMaxAttempts 次，总等待时间不超过 ProxyTimeout
 func (p *ProxyPool) GetAvailableProxy(req types.ProxyRequest) (*types.ProxyInfo, error) {
 	if req.Region == "" {
 		req.Region = "000000"
@@ -181,81 +429,230 @@ func (p *ProxyPool) GetAvailableProxy(req types.ProxyRequest) (*types.ProxyInfo,
 	if ch == nil {
 		return &types.ProxyInfo{}, nil
 	}
-	// 触发补充（异步）
-	if req.Num > 0 || len(ch.queue) < p.getMinProxyCount(req.Type) {
-		reqData := types.ProxyRequest{
-			Num:    math.Max(req.Num, p.getMinProxyCount(req.Type)),
-			Type:   req.Type,
-			Region: req.Region,
+	timeout := time.NewTimer(ProxyTimeout)
+	defer timeout.Stop()
+	for attempt := 0; attempt < ProxyMaxAttempts; attempt++ {
+		// 触发补充（异步）
+		if req.Num > 0 || len(ch.queue) < p.getMinProxyCount(req.Type) {
+			reqData := types.ProxyRequest{
+				Num:    math.Max(req.Num, p.getMinProxyCount(req.Type)),
+				Type:   req.Type,
+				Region: req.Region,
+			}
+			select {
+			case <-p.ctx.Done():
+				return nil, p.ctx.Err()
+			case p.ensureChan <- reqData:
+			default:
+				logger.Log.Warnf("Ensure channel full, dropping request for %d %s proxies in %s", reqData.Num, reqData.Type, reqData.Region)
+			}
 		}
 		select {
 		case <-p.ctx.Done():
 			return nil, p.ctx.Err()
-		case p.ensureChan <- reqData:
-		default:
-			logger.Log.Warnf("Ensure channel full, dropping request for %d %s proxies in %s", reqData.Num, reqData.Type, reqData.Region)
-		}
-	}
-	select {
-	case <-p.ctx.Done():
-		return nil, p.ctx.Err()
-	case <-time.After(ProxyTimeout):
-		return nil, fmt.Errorf("timeout waiting for %s proxy in %s after %v", req.Type, req.Region, ProxyTimeout)
-	case proxy := <-ch.queue:
-		if p.isProxyAvailable(proxy) {
+		case <-timeout.C:
+			return nil, fmt.Errorf("timeout waiting for %s proxy in %s after %v", req.Type, req.Region, ProxyTimeout)
+		case proxy := <-ch.queue:
+			if !p.isProxyAvailable(proxy) {
+				p.RemoveProxy(proxy, "unavailable")
+				continue
+			}
+			if !p.isProxyHealthy(proxy) {
+				continue
+			}
 			p.inUse.Store(proxy.ProxyKey, proxy)
+			p.jobs.Store(proxy.ProxyKey, req.JobID)
+			p.emit.Emit(types.ProxyAcquiredEvent{
+				EventMeta:      types.NewEventMeta(req.JobID),
+				ProxyEventInfo: p.eventInfo(proxy),
+			})
 			return proxy, nil
 		}
-		p.RemoveProxy(proxy)
-		return p.GetAvailableProxy(req) // 递归获取下一个
 	}
+	return nil, fmt.Errorf("no available %s proxy in %s after %d attempts", req.Type, req.Region, ProxyMaxAttempts)
 }
 
 // isProxyAvailable 检查代理是否可用
 func (p *ProxyPool) isProxyAvailable(proxy *types.ProxyInfo) bool {
 	_, inUse := p.inUse.Load(proxy.ProxyKey)
-	if inUse || !proxy.Useable || !proxy.GetExpireTime().After(time.Now()) {
+	return !inUse && proxy.Useable && proxy.GetExpireTime().After(time.Now())
+}
+
+// isProxyHealthy 熔断中的代理暂存等待恢复，半开或超过检查间隔的代理通过检查地址检查，检查失败的代理不会被获取
+func (p *ProxyPool) isProxyHealthy(proxy *types.ProxyInfo) bool {
+	now := time.Now()
+	health := p.healthOf(proxy.ProxyKey)
+	if health.breaker(now) == BreakerOpen {
+		p.parked.Store(proxy.ProxyKey, proxy)
 		return false
 	}
-	valid, err := isValidProxy(proxy)
-	return err == nil && valid
+	config := p.settings().Health
+	if config.CheckURL == "" || !health.needsCheck(now, config.CheckInterval) {
+		return true
+	}
+	if p.check(proxy, health) {
+		return true
+	}
+	p.settle(proxy, health)
+	return false
 }
 
-// ReleaseProxy 释放代理
+// check 通过检查地址检查代理，结果计入健康状态
+func (p *ProxyPool) check(proxy *types.ProxyInfo, health *proxyHealth) bool {
+	config := p.settings().Health
+	latency, err := checkProxy(proxy, config.CheckURL, config.CheckTimeout)
+	now := time.Now()
+	health.markChecked(now)
+	p.record(proxy, health, now, latency, err)
+	if err != nil {
+		logger.Log.Warnf("Check proxy %s failed: %v", proxy.ProxyKey, err)
+		return false
+	}
+	return true
+}
+
+// settle 处理检查失败的空闲代理，评分过低的移除，熔断的暂存，其余放回通道
+func (p *ProxyPool) settle(proxy *types.ProxyInfo, health *proxyHealth) {
+	switch {
+	case health.lowScore(p.settings().Health):
+		p.RemoveProxy(proxy, "low score")
+	case health.breaker(time.Now()) == BreakerOpen:
+		p.parked.Store(proxy.ProxyKey, proxy)
+	default:
+		p.requeue(proxy)
+	}
+}
+
+// requeue 将空闲代理放回通道，通道已满时移出代理池
+func (p *ProxyPool) requeue(proxy *types.ProxyInfo) {
+	if !p.addToChannel(proxy) {
+		p.proxies.Delete(proxy.ProxyKey)
+		p.health.Delete(proxy.ProxyKey)
+	}
+}
+
+// record 记录请求结果，熔断时发布事件
+func (p *ProxyPool) record(proxy *types.ProxyInfo, health *proxyHealth, now time.Time, latency time.Duration, err error) {
+	config := p.settings().Health
+	if !health.record(config, now, latency, err) {
+		return
+	}
+	until := now.Add(config.BreakerCooldown)
+	logger.Log.Warnf("Proxy %s circuit open until %s after %d consecutive failures: %v",
+		proxy.ProxyKey, until.Format(time.DateTime), config.FailureThreshold, err)
+	jobID, _ := p.jobs.Load(proxy.ProxyKey)
+	jobIDString, _ := jobID.(string)
+	p.emit.Emit(types.ProxyCircuitOpenEvent{
+		EventMeta:      types.NewEventMeta(jobIDString),
+		ProxyEventInfo: p.eventInfo(proxy),
+		Failures:       config.FailureThreshold,
+		Until:          until,
+		Error:          err.Error(),
+	})
+}
+
+// healthOf 获取代理的健康状态，不存在时创建
+func (p *ProxyPool) healthOf(key string) *proxyHealth {
+	health, _ := p.health.LoadOrStore(key, &proxyHealth{})
+	return health.(*proxyHealth)
+}
+
+// ReportResult 上报通过代理请求的结果，latency 为成功请求的耗时，用于评分和熔断，评分过低的代理移出代理池
+func (p *ProxyPool) ReportResult(proxy *types.ProxyInfo, latency time.Duration, err error) {
+	if proxy == nil || proxy.ProxyKey == "" {
+		return
+	}
+	if _, ok := p.proxies.Load(proxy.ProxyKey); !ok {
+		return
+	}
+	health := p.healthOf(proxy.ProxyKey)
+	p.record(proxy, health, time.Now(), latency, err)
+	if err != nil && health.lowScore(p.settings().Health) {
+		p.RemoveProxy(proxy, "low score")
+	}
+}
+
+// recoverProxies 熔断到期的暂存代理检查通过后放回通道，未配置检查地址时直接放回，由下一次请求决定是否恢复
+func (p *ProxyPool) recoverProxies() {
+	now := time.Now()
+	p.parked.Range(func(key, value interface{}) bool {
+		proxy := value.(*types.ProxyInfo)
+		health := p.healthOf(proxy.ProxyKey)
+		if health.breaker(now) == BreakerOpen {
+			return true
+		}
+		p.parked.Delete(key)
+		if p.settings().Health.CheckURL != "" && !p.check(proxy, health) {
+			p.settle(proxy, health)
+			return true
+		}
+		p.requeue(proxy)
+		return true
+	})
+}
+
+// ReleaseProxy 释放代理，已移出代理池的代理不再放回，熔断中的代理暂存等待恢复
 func (p *ProxyPool) ReleaseProxy(proxy *types.ProxyInfo) {
 	if !proxy.Useable || proxy.GetExpireTime().Before(time.Now()) {
-		p.RemoveProxy(proxy)
+		p.RemoveProxy(proxy, "expired")
 		return
 	}
 	p.inUse.Delete(proxy.ProxyKey)
-	p.addToChannel(proxy)
+	p.jobs.Delete(proxy.ProxyKey)
+	if _, ok := p.proxies.Load(proxy.ProxyKey); !ok {
+		return
+	}
+	if p.healthOf(proxy.ProxyKey).breaker(time.Now()) == BreakerOpen {
+		p.parked.Store(proxy.ProxyKey, proxy)
+		return
+	}
+	p.requeue(proxy)
 }
 
-// RemoveProxy 移除代理
-func (p *ProxyPool) RemoveProxy(proxy *types.ProxyInfo) {
+// RemoveProxy 移除代理，reason 为移除原因
+func (p *ProxyPool) RemoveProxy(proxy *types.ProxyInfo, reason string) {
+	if proxy == nil {
+		return
+	}
 	p.proxies.Delete(proxy.ProxyKey)
 	p.inUse.Delete(proxy.ProxyKey)
+	p.health.Delete(proxy.ProxyKey)
+	p.parked.Delete(proxy.ProxyKey)
+	jobID, _ := p.jobs.LoadAndDelete(proxy.ProxyKey)
+	if proxy.ProxyKey == "" {
+		return
+	}
+	jobIDString, _ := jobID.(string)
+	p.emit.Emit(types.ProxyRemovedEvent{
+		EventMeta:      types.NewEventMeta(jobIDString),
+		ProxyEventInfo: p.eventInfo(proxy),
+		Reason:         reason,
+	})
+}
+
+// eventInfo 事件中的代理信息
+func (p *ProxyPool) eventInfo(proxy *types.ProxyInfo) types.ProxyEventInfo {
+	return types.ProxyEventInfo{
+		ProxyKey:  proxy.ProxyKey,
+		ProxyType: proxy.ProxyType,
+		Region:    p.getRegionFromKey(proxy.ProxyKey),
+	}
 }
 
 // Stop 停止代理池
 func (p *ProxyPool) Stop() {
+	// 不关闭通道，停止后仍可能有获取代理或补充的调用向通道发送
 	p.cancel()
 	p.wg.Wait()
-	for _, ch := range p.dynamic {
-		close(ch.queue)
-	}
-	for _, ch := range p.static {
-		close(ch.queue)
-	}
-	close(p.ensureChan)
 }
 
 // getMinProxyCount 获取最小代理数量
 func (p *ProxyPool) getMinProxyCount(proxyType string) int {
+	config := p.settings()
 	if proxyType == "dynamic" {
-		return p.config.MinDynamic
+		return config.Dynamic.Min
 	}
-	return p.config.MinStatic
+	return config.Static.Min
 }
 
 // getRegionFromKey 从 ProxyKey 中提取区域
@@ -281,6 +678,7 @@ func (p *ProxyPool) Status() *ProxyPoolStatus {
 		return true
 	})
 
+	p.channelMu.Lock()
 	dynamicProxies := make(map[string]*ChannelStatus)
 	for region, ch := range p.dynamic {
 		ch.mu.Lock()
@@ -302,14 +700,50 @@ func (p *ProxyPool) Status() *ProxyPoolStatus {
 		}
 		ch.mu.Unlock()
 	}
+	p.channelMu.Unlock()
+
+	p.providerMu.Lock()
+	providers := make([]ProviderStatus, 0, len(p.providers))
+	for _, slot := range p.providers {
+		providers = append(providers, ProviderStatus{
+			Name:      slot.provider.Name(),
+			Fetched:   slot.fetched,
+			Failures:  slot.failures,
+			LastError: slot.lastError,
+		})
+	}
+	p.providerMu.Unlock()
+
+	config := p.settings()
+	now := time.Now()
+	proxies := make([]ProxyHealthStatus, 0, totalProxies)
+	p.proxies.Range(func(key, _ interface{}) bool {
+		health, ok := p.health.Load(key)
+		if !ok {
+			health = &proxyHealth{}
+		}
+		status := health.(*proxyHealth).status(config.Health, now)
+		status.ProxyKey = key.(string)
+		_, status.InUse = p.inUse.Load(key)
+		proxies = append(proxies, status)
+		return true
+	})
+	sort.Slice(proxies, func(i, j int) bool {
+		if proxies[i].Score != proxies[j].Score {
+			return proxies[i].Score > proxies[j].Score
+		}
+		return proxies[i].ProxyKey < proxies[j].ProxyKey
+	})
 
 	return &ProxyPoolStatus{
-		DynamicEnabled:    p.config.DynamicEnabled,
-		StaticEnabled:     p.config.StaticEnabled,
+		DynamicEnabled:    config.Dynamic.Enabled,
+		StaticEnabled:     config.Static.Enabled,
 		TotalProxies:      totalProxies,
 		InUseProxies:      inUseProxies,
 		DynamicProxies:    dynamicProxies,
 		StaticProxies:     staticProxies,
 		EnsureQueueLength: len(p.ensureChan),
+		Providers:         providers,
+		Proxies:           proxies,
 	}
 }
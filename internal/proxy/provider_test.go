@@ -0,0 +1,185 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"noctua/pkg/logger"
+	"noctua/types"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	logger.Init(&logger.LoggerConfig{Level: "error"})
+}
+
+// fakeProvider 按请求生成代理，记录收到的请求
+type fakeProvider struct {
+	mu       sync.Mutex
+	name     string
+	err      error
+	requests []types.ProxyRequest
+}
+
+func (f *fakeProvider) Name() string { return f.name }
+
+func (f *fakeProvider) Fetch(_ context.Context, req types.ProxyRequest) ([]*types.ProxyInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, req)
+	if f.err != nil {
+		return nil, f.err
+	}
+	proxies := make([]*types.ProxyInfo, 0, req.Num)
+	for i := 0; i < req.Num; i++ {
+		proxies = append(proxies, &types.ProxyInfo{ProxyVal: fmt.Sprintf("%s-%d:%d", f.name, len(f.requests), 8000+i)})
+	}
+	return proxies, nil
+}
+
+func (f *fakeProvider) received() []types.ProxyRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]types.ProxyRequest(nil), f.requests...)
+}
+
+func TestParseProxyList(t *testing.T) {
+	proxies, err := parseProxyList([]byte(`{"code":0,"data":[{"proxyVal":"1.1.1.1:80","username":"u"}]}`))
+	require.NoError(t, err)
+	require.Len(t, proxies, 1)
+	assert.Equal(t, "1.1.1.1:80", proxies[0].ProxyVal)
+
+	_, err = parseProxyList([]byte(`{"code":500,"msg":"no balance"}`))
+	assert.ErrorContains(t, err, "no balance")
+
+	proxies, err = parseProxyList([]byte(`[{"proxyVal":"2.2.2.2:80"}]`))
+	require.NoError(t, err)
+	assert.Equal(t, "2.2.2.2:80", proxies[0].ProxyVal)
+
+	proxies, err = parseProxyList([]byte("# comment\nsocks5://u:p@3.3.3.3:1080\n4.4.4.4:8080\n"))
+	require.NoError(t, err)
+	require.Len(t, proxies, 2)
+	assert.Equal(t, types.ProxyInfo{Protocol: "socks5", ProxyVal: "3.3.3.3:1080", Username: "u", Password: "p"}, *proxies[0])
+	assert.Equal(t, types.ProxyInfo{Protocol: "http", ProxyVal: "4.4.4.4:8080"}, *proxies[1])
+}
+
+func TestHTTPProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Token"))
+		query := r.URL.Query()
+		_, _ = w.Write([]byte(`{"code":0,"data":[{"proxyVal":"` + query.Get("type") + `.` + query.Get("region") + `:` + query.Get("num") + `"}]}`))
+	}))
+	defer server.Close()
+
+	provider, err := BuildProvider(ProviderConfig{Name: "api", Type: "http", URL: server.URL, Headers: map[string]string{"X-Token": "secret"}})
+	require.NoError(t, err)
+	proxies, err := provider.Fetch(context.Background(), types.ProxyRequest{Num: 3, Type: "dynamic", Region: "440000"})
+	require.NoError(t, err)
+	require.Len(t, proxies, 1)
+	assert.Equal(t, "dynamic.440000:3", proxies[0].ProxyVal)
+}
+
+func TestStaticProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proxies.txt")
+	require.NoError(t, os.WriteFile(path, []byte("1.1.1.1:80 dynamic 440000\n2.2.2.2:80 static\n3.3.3.3:80\n"), 0600))
+	provider, err := BuildProvider(ProviderConfig{Name: "list", Type: "static", Path: path})
+	require.NoError(t, err)
+	proxies, err := provider.Fetch(context.Background(), types.ProxyRequest{Num: 1, Type: "dynamic", Region: "440000"})
+	require.NoError(t, err)
+	vals := make([]string, 0, len(proxies))
+	for _, proxy := range proxies {
+		vals = append(vals, proxy.ProxyVal)
+	}
+	assert.Equal(t, []string{"1.1.1.1:80", "3.3.3.3:80"}, vals)
+
+	yamlPath := filepath.Join(t.TempDir(), "proxies.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte("proxies:\n  - url: socks5://u:p@5.5.5.5:1080\n    type: static\n    expire_time: 4102444800\n"), 0600))
+	provider, err = BuildProvider(ProviderConfig{Name: "yaml", Type: "static", Path: yamlPath})
+	require.NoError(t, err)
+	proxies, err = provider.Fetch(context.Background(), types.ProxyRequest{Type: "static", Region: "000000"})
+	require.NoError(t, err)
+	require.Len(t, proxies, 1)
+	assert.Equal(t, types.ProxyInfo{Protocol: "socks5", ProxyVal: "5.5.5.5:1080", Username: "u", Password: "p", ExpireTime: 4102444800}, *proxies[0])
+
+	// 文件修改后重新加载
+	require.NoError(t, os.WriteFile(yamlPath, []byte("proxies: []\n"), 0600))
+	require.NoError(t, os.Chtimes(yamlPath, time.Now(), time.Now().Add(time.Minute)))
+	proxies, err = provider.Fetch(context.Background(), types.ProxyRequest{Type: "static", Region: "000000"})
+	require.NoError(t, err)
+	assert.Empty(t, proxies)
+
+	_, err = BuildProvider(ProviderConfig{Name: "missing", Type: "static", Path: filepath.Join(t.TempDir(), "none.txt")})
+	assert.Error(t, err)
+}
+
+func TestExecProvider(t *testing.T) {
+	provider, err := BuildProvider(ProviderConfig{
+		Name:    "script",
+		Type:    "exec",
+		Command: "sh",
+		Args:    []string{"-c", `echo "$PROXY_TYPE.$PROXY_REGION:$PROXY_NUM"`},
+	})
+	require.NoError(t, err)
+	proxies, err := provider.Fetch(context.Background(), types.ProxyRequest{Num: 2, Type: "static", Region: "110000"})
+	require.NoError(t, err)
+	require.Len(t, proxies, 1)
+	assert.Equal(t, "static.110000:2", proxies[0].ProxyVal)
+
+	provider, err = BuildProvider(ProviderConfig{Name: "broken", Type: "exec", Command: "sh", Args: []string{"-c", "echo failed >&2; exit 3"}})
+	require.NoError(t, err)
+	_, err = provider.Fetch(context.Background(), types.ProxyRequest{Num: 1})
+	assert.ErrorContains(t, err, "failed")
+}
+
+func TestPoolReplenish(t *testing.T) {
+	pool := NewProxyPool(context.Background(), ProxyPoolConfig{Dynamic: ChannelConfig{Enabled: true, Min: 2}, BatchSize: 3})
+	defer pool.Stop()
+	broken := &fakeProvider{name: "broken", err: errors.New("no balance")}
+	staticOnly := &fakeProvider{name: "static"}
+	dynamic := &fakeProvider{name: "dynamic"}
+	pool.AddProvider(broken, ProviderConfig{})
+	pool.AddProvider(staticOnly, ProviderConfig{Types: []string{"static"}})
+	pool.AddProvider(dynamic, ProviderConfig{Regions: []string{"440000"}, TTL: time.Hour})
+
+	proxy, err := pool.GetAvailableProxy(types.ProxyRequest{Num: 1, Type: "dynamic", Region: "440000", JobID: "job-1"})
+	require.NoError(t, err)
+	assert.True(t, proxy.Useable)
+	assert.Equal(t, "dynamic", proxy.ProxyType)
+	assert.Equal(t, "http", proxy.Protocol)
+	assert.Equal(t, "dynamic_440000_"+proxy.ProxyVal, proxy.ProxyKey)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), proxy.GetExpireTime(), time.Minute)
+
+	// 失败的供应商跳过，不提供该类型的供应商不请求，单次至少获取 BatchSize 个
+	assert.Len(t, broken.received(), 1)
+	assert.Empty(t, staticOnly.received())
+	assert.Equal(t, []types.ProxyRequest{{Num: 3, Type: "dynamic", Region: "440000"}}, dynamic.received())
+	assert.Eventually(t, func() bool {
+		return pool.Status().Providers[2].Fetched == 3
+	}, time.Second, 10*time.Millisecond)
+	status := pool.Status()
+	assert.Equal(t, 3, status.TotalProxies)
+	assert.Equal(t, 1, status.InUseProxies)
+	assert.Equal(t, 2, status.DynamicProxies["440000"].Length)
+	assert.Equal(t, ProviderStatus{Name: "broken", Failures: 1, LastError: "no balance"}, status.Providers[0])
+
+	// 空闲代理不少于最小数量时不再请求供应商
+	pool.replenish(types.ProxyRequest{Num: 2, Type: "dynamic", Region: "440000"})
+	assert.Len(t, dynamic.received(), 1)
+
+	// 未启用的类型及无供应商的区域不会获取到代理
+	pool.replenish(types.ProxyRequest{Num: 2, Type: "dynamic", Region: "110000"})
+	assert.Len(t, dynamic.received(), 1)
+	assert.Equal(t, 0, pool.Status().DynamicProxies["110000"].Length)
+	empty, err := pool.GetAvailableProxy(types.ProxyRequest{Num: 1, Type: "static", Region: "440000"})
+	require.NoError(t, err)
+	assert.False(t, empty.Useable)
+}
@@ -1,33 +1,25 @@
 package proxy
 
 import (
+	"fmt"
 	"github.com/go-resty/resty/v2"
-	"noctua/pkg/logger"
 	"noctua/types"
 	"time"
 )
 
-const TEST_PROXY_IP_URL = "https://cip.cc"
-
-func isValidProxy(proxyInfo *types.ProxyInfo) (bool, error) {
-	if proxyInfo.GetExpireTime().After(time.Now()) {
-		return true, nil
-	}
+// checkProxy 通过代理请求检查地址，返回 2xx 视为可用，返回请求耗时
+func checkProxy(proxyInfo *types.ProxyInfo, checkURL string, timeout time.Duration) (time.Duration, error) {
+	start := time.Now()
 	resp, err := resty.New().
-		SetTimeout(5 * time.Second).
+		SetTimeout(timeout).
 		SetProxy(proxyInfo.BuildProtocol()).
-		SetRetryCount(2).
-		SetRetryWaitTime(1 * time.Second).
-		SetRetryMaxWaitTime(3 * time.Second).
-		AddRetryCondition(func(r *resty.Response, err error) bool {
-			return r.StatusCode() == 500
-		}).
 		R().
-		Get(TEST_PROXY_IP_URL)
+		Get(checkURL)
 	if err != nil {
-		logger.Log.Errorf("Checking proxy IP failed: %s, err: %v", proxyInfo.ProxyVal, err)
-		return false, err
+		return 0, err
+	}
+	if !resp.IsSuccess() {
+		return 0, fmt.Errorf("check proxy responded %d", resp.StatusCode())
 	}
-	logger.Log.Infof("Checking proxy IP success: %s", proxyInfo.ProxyVal)
-	return resp.StatusCode() == 200, nil
+	return time.Since(start), nil
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math"
 	"noctua/internal/queue"
+	"noctua/types"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -72,7 +73,10 @@ type Scheduler struct {
 	dispatcherWg sync.WaitGroup
 	wg           sync.WaitGroup
 	mu           sync.Mutex
+	startMu      sync.Mutex  // 保护停止检查与分发、worker 协程的启动，停止后不再向等待中的 WaitGroup 添加协程
+	treeMu       sync.Mutex  // 保护任务树状态（IsActive、IsFinished、Children），worker、状态检查与查询并发访问
 	isPaused     atomic.Bool // 新增：暂停状态
+	emit         types.EventEmitter
 }
 
 type TaskHandler func(*Task) error
@@ -121,6 +125,22 @@ func New(mainCtx context.Context, cfg Config) *Scheduler {
 	return s
 }
 
+// SetEmitter 设置任务生命周期事件的发布函数
+func (s *Scheduler) SetEmitter(emit types.EventEmitter) {
+	s.emit = emit
+}
+
+// taskInfo 事件中的任务信息
+func taskInfo(task *Task) types.TaskInfo {
+	return types.TaskInfo{
+		TaskID:       task.ID,
+		ParentTaskID: task.ParentTaskID,
+		SourceTaskID: task.SourceTaskID,
+		QueueKey:     task.QueueKey,
+		Attempt:      task.CurrentRetry,
+	}
+}
+
 func (s *Scheduler) Context() context.Context {
 	return s.ctx
 }
@@ -135,6 +155,7 @@ func (s *Scheduler) Resume() {
 	s.isPaused.Store(false)
 }
 
+// recursionDeleteTask 删除任务及其子任务，调用方需持有 treeMu
 func (s *Scheduler) recursionDeleteTask(task *Task) {
 	if len(task.Children) > 0 {
 		for _, subTask := range task.Children {
@@ -145,11 +166,13 @@ func (s *Scheduler) recursionDeleteTask(task *Task) {
 }
 
 func (s *Scheduler) processTask(task *Task) {
+	startedAt := time.Now()
 	defer func() {
 		if r := recover(); r != nil {
 			s.mu.Lock()
 			s.recordFailed(task)
 			s.mu.Unlock()
+			s.emitFailed(task, startedAt, fmt.Errorf("panic: %v", r))
 		}
 	}()
 
@@ -158,8 +181,13 @@ func (s *Scheduler) processTask(task *Task) {
 		s.mu.Lock()
 		s.recordFailed(task)
 		s.mu.Unlock()
+		s.emitFailed(task, startedAt, fmt.Errorf("no handler for queue %s", task.QueueKey))
 		return
 	}
+	s.emit.Emit(types.TaskStartedEvent{
+		EventMeta: types.NewEventMeta(task.JobID),
+		TaskInfo:  taskInfo(task),
+	})
 	handler := handlerVal.(TaskHandler)
 
 	ctx, cancel := context.WithTimeout(s.ctx, task.Timeout)
@@ -180,20 +208,43 @@ func (s *Scheduler) processTask(task *Task) {
 	}
 
 	if err == nil {
+		s.treeMu.Lock()
 		task.IsFinished = true
+		s.treeMu.Unlock()
 		s.taskIndex.Store(task.ID, task)
 		s.recordSuccess(task)
+		s.emit.Emit(types.TaskSucceededEvent{
+			EventMeta: types.NewEventMeta(task.JobID),
+			TaskInfo:  taskInfo(task),
+			Duration:  time.Since(startedAt),
+		})
 		return
 	}
 
 	if task.CurrentRetry < task.MaxRetries {
 		task.CurrentRetry++
+		s.emit.Emit(types.TaskRetriedEvent{
+			EventMeta: types.NewEventMeta(task.JobID),
+			TaskInfo:  taskInfo(task),
+			Delay:     s.retryDelay(task),
+			Error:     err.Error(),
+		})
 		go s.retryTask(task)
 	} else {
 		s.recordFailed(task)
+		s.emitFailed(task, startedAt, err)
 	}
 }
 
+func (s *Scheduler) emitFailed(task *Task, startedAt time.Time, err error) {
+	s.emit.Emit(types.TaskFailedEvent{
+		EventMeta: types.NewEventMeta(task.JobID),
+		TaskInfo:  taskInfo(task),
+		Duration:  time.Since(startedAt),
+		Error:     err.Error(),
+	})
+}
+
 func (s *Scheduler) recordSuccess(task *Task) {
 	s.updateMetric(s.metrics.ProcessedTasks, task.QueueKey, 1)
 }
@@ -202,6 +253,7 @@ func (s *Scheduler) recordFailed(task *Task) {
 	s.updateMetric(s.metrics.FailedTasks, task.QueueKey, 1)
 }
 
+// allSubTasksInactive 子任务是否均不活跃，调用方需持有 treeMu
 func (s *Scheduler) allSubTasksInactive(task *Task) bool {
 	if len(task.Children) == 0 {
 		return true // 无子任务，直接认为不活跃
@@ -214,6 +266,7 @@ func (s *Scheduler) allSubTasksInactive(task *Task) bool {
 	return true
 }
 
+// updateParentTask 子任务全部完成时标记父任务完成，调用方需持有 treeMu
 func (s *Scheduler) updateParentTask(task *Task) {
 	if parentTask, err := s.getTaskByID(task.ParentTaskID); err == nil {
 		allFinished := true
@@ -303,13 +356,30 @@ func (s *Scheduler) dispatchTasks(queueKey string) {
 
 // 提交任务并返回任务ID
 func (s *Scheduler) SubmitTask(task Task) (string, error) {
+	s.startMu.Lock()
 	if s.ctx.Err() != nil {
+		s.startMu.Unlock()
 		return "", fmt.Errorf("Scheduler has been stopped...")
 	}
 	s.initQueue(task.QueueKey)
+	s.startMu.Unlock()
 	if depth, _ := s.metrics.QueueDepths.Load(task.QueueKey); depth.(int) >= s.config.MaxQueueDepth {
 		return "", fmt.Errorf("queue %s is full", task.QueueKey)
 	}
+	// 子任务继承父任务的 JobID 及会话亲和标识，任务树使用同一账号和代理
+	if task.ParentTaskID != "" {
+		if parent, err := s.getTaskByID(task.ParentTaskID); err == nil {
+			if task.JobID == "" {
+				task.JobID = parent.JobID
+			}
+			if task.AffinityKey == "" {
+				task.AffinityKey = parent.AffinityKey
+			}
+		}
+	}
+	if task.AffinityKey == "" {
+		task.AffinityKey = task.SourceTaskID
+	}
 	item := &TaskItem{
 		Task:       &task,
 		EnqueuedAt: time.Now(),
@@ -325,6 +395,10 @@ func (s *Scheduler) SubmitTask(task Task) (string, error) {
 	if task.ParentTaskID != "" {
 		s.markParentHasSubTask(task.ParentTaskID, &task)
 	}
+	s.emit.Emit(types.TaskSubmittedEvent{
+		EventMeta: types.NewEventMeta(task.JobID),
+		TaskInfo:  taskInfo(&task),
+	})
 	return task.ID, nil
 }
 
@@ -334,8 +408,10 @@ func (s *Scheduler) markParentHasSubTask(parentTaskID string, subTask *Task) {
 		return
 	}
 	parent := parentTask.(*Task)
+	s.treeMu.Lock()
 	parent.Children = append(parent.Children, subTask)
 	parent.IsActive = true
+	s.treeMu.Unlock()
 	s.taskIndex.Store(parentTaskID, parent)
 }
 
@@ -412,6 +488,11 @@ func (s *Scheduler) GetQueueQPS(queueKey string) int {
 }
 
 func (s *Scheduler) scaleUp(queueKey string, count int) {
+	s.startMu.Lock()
+	defer s.startMu.Unlock()
+	if s.ctx.Err() != nil {
+		return
+	}
 	qps := s.GetQueueQPS(queueKey) // 使用队列特定的 QPS
 	for i := 0; i < count; i++ {
 		w := newWorker(queueKey, s.config.WorkerIdleTimeout*time.Second, qps)
@@ -433,7 +514,7 @@ func (s *Scheduler) scaleDown(queueKey string, count int) {
 	var retain []*worker
 	toRemove := count
 	for _, w := range workers {
-		if toRemove > 0 && !w.IsActive() && time.Since(w.lastActive) > w.idleTimeout {
+		if toRemove > 0 && !w.IsActive() && w.idleFor() > w.idleTimeout {
 			w.stop()
 			toRemove--
 		} else {
@@ -457,14 +538,14 @@ func (s *Scheduler) runWorker(w *worker) {
 				atomic.StoreInt32(&w.active, 1)
 				s.processTask(task)
 				atomic.StoreInt32(&w.active, 0)
-				w.lastActive = time.Now()
+				w.touch()
 			}
 		case <-w.quitChan:
 			return
 			// 检查 worker 是否空闲超过 idleTimeout
 		case <-time.After(w.idleTimeout):
 			// 如果 worker 已经空闲超过了指定时间
-			if time.Since(w.lastActive) > w.idleTimeout && !s.isPaused.Load() {
+			if w.idleFor() > w.idleTimeout && !s.isPaused.Load() {
 				// 关闭该 worker
 				s.scaleDown(w.queue, 1) // 调用 scaleDown 停止该 worker
 				return
@@ -487,11 +568,19 @@ func (s *Scheduler) updateQueueDepth(queueKey string, delta int) {
 	s.metrics.QueueDepths.Store(queueKey, newVal)
 }
 
+// retryDelay 按重试次数指数退避
+func (s *Scheduler) retryDelay(task *Task) time.Duration {
+	return s.config.BaseRetryDelay * time.Second * time.Duration(math.Pow(2, float64(task.CurrentRetry)))
+}
+
 func (s *Scheduler) retryTask(task *Task) {
-	delay := s.config.BaseRetryDelay * time.Second * time.Duration(math.Pow(2, float64(task.CurrentRetry)))
-	time.Sleep(delay)
-	if _, err := s.SubmitTask(*task); err != nil {
+	time.Sleep(s.retryDelay(task))
+	s.treeMu.Lock()
+	retry := *task
+	s.treeMu.Unlock()
+	if _, err := s.SubmitTask(retry); err != nil {
 		s.recordFailed(task)
+		s.emitFailed(task, time.Now(), err)
 	}
 }
 
@@ -512,6 +601,8 @@ func (s *Scheduler) taskStateChecker() {
 }
 
 func (s *Scheduler) checkTaskStates() {
+	s.treeMu.Lock()
+	defer s.treeMu.Unlock()
 	s.taskIndex.Range(func(key, value interface{}) bool {
 		task := value.(*Task)
 		if task.IsFinished && s.allSubTasksInactive(task) {
@@ -591,6 +682,8 @@ func (s *Scheduler) GetTaskTree() map[string]interface{} {
 		tasks = append(tasks, value.(*Task))
 		return true
 	})
+	s.treeMu.Lock()
+	defer s.treeMu.Unlock()
 	root := buildTaskTree(tasks)
 	return taskToJSON(root)
 }
@@ -629,11 +722,11 @@ func (s *Scheduler) Reset() {
 	defer s.mu.Unlock()
 
 	// 取消现有上下文并创建新的
-	if s.ctx.Err() == nil {
-		s.cancel()
-	}
+	s.stop()
 	s.wg.Wait() // 确保所有旧 goroutine 结束
+	s.startMu.Lock()
 	s.ctx, s.cancel = context.WithCancel(s.mainCtx)
+	s.startMu.Unlock()
 
 	// 清空现有数据
 	s.queues.Clear()
@@ -647,15 +740,22 @@ func (s *Scheduler) Reset() {
 	go s.taskStateChecker()
 }
 
+// stop 取消上下文，之后提交的任务被拒绝，不再启动新的协程
+func (s *Scheduler) stop() {
+	s.startMu.Lock()
+	defer s.startMu.Unlock()
+	if s.ctx.Err() == nil {
+		s.cancel()
+	}
+}
+
 // Shutdown 停止调度器
 func (s *Scheduler) Shutdown() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	// 取消上下文，触发所有 goroutine 退出
-	if s.ctx.Err() == nil {
-		s.cancel()
-	}
+	s.stop()
 
 	// 停止所有 worker
 	s.workers.Range(func(key, value interface{}) bool {
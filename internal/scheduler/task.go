@@ -16,8 +16,10 @@ const (
 // Task 定义任务结构
 type Task struct {
 	ID           string
+	JobID        string  // 所属采集任务ID，子任务未指定时继承父任务
 	ParentTaskID string  // 父级任务ID，如果是主任务则为""，子任务为主任务的ID
 	SourceTaskID string  // 原始任务ID
+	AffinityKey  string  // 会话亲和标识，同一标识的任务使用同一账号和代理
 	IsActive     bool    // 是否激活或还有子任务
 	IsFinished   bool    // 当前任务是否完成
 	Children     []*Task // 子任务列表
@@ -64,8 +66,10 @@ type TaskNode struct {
 
 // TaskOptions 用于定义任务的可选参数
 type TaskOptions struct {
+	JobID        string        // 所属采集任务ID
 	ParentTaskID string        // 父级任务ID，如果是主任务则为""，子任务为主任务的ID
 	SourceTaskID string        // 原始任务ID
+	AffinityKey  string        // 会话亲和标识，为空时继承父任务，根任务使用 SourceTaskID
 	Priority     int           // 优先级
 	Payload      interface{}   // 任务负载
 	MaxRetries   int           // 最大重试次数
@@ -96,10 +100,12 @@ func NewTask(queueKey string, payload interface{}, options TaskOptions) (Task, e
 
 	return Task{
 		ID:           taskID,
+		JobID:        options.JobID,
 		QueueKey:     queueKey,
 		Payload:      payload,
 		ParentTaskID: options.ParentTaskID,
 		SourceTaskID: sourceTaskID,
+		AffinityKey:  options.AffinityKey,
 		Children:     make([]*Task, 0),
 		IsFinished:   false,
 		IsActive:     true,
@@ -15,21 +15,32 @@ type worker struct {
 	quitChan    chan struct{}
 	limiter     *rate.Limiter
 	active      int32
-	lastActive  time.Time
+	lastActive  atomic.Int64 // 最后一次处理完任务的时间（UnixNano），调度协程与 worker 并发读写
 	idleTimeout time.Duration
 	once        sync.Once // 新增：保护关闭
 }
 
 func newWorker(queue string, idleTimeout time.Duration, qps int) *worker {
-	return &worker{
+	w := &worker{
 		id:          fmt.Sprintf("%s-%d", queue, time.Now().UnixNano()),
 		queue:       queue,
 		taskChan:    make(chan *Task, 100),
 		quitChan:    make(chan struct{}),
 		limiter:     rate.NewLimiter(rate.Every(time.Minute/time.Duration(qps)), 1),
-		lastActive:  time.Now(),
 		idleTimeout: idleTimeout,
 	}
+	w.touch()
+	return w
+}
+
+// touch 记录最后活跃时间
+func (w *worker) touch() {
+	w.lastActive.Store(time.Now().UnixNano())
+}
+
+// idleFor 距最后活跃的时间
+func (w *worker) idleFor() time.Duration {
+	return time.Since(time.Unix(0, w.lastActive.Load()))
 }
 
 func (w *worker) IsActive() bool {
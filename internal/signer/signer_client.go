@@ -3,6 +3,7 @@ package signer
 import (
 	"fmt"
 	"github.com/go-resty/resty/v2"
+	"net/http"
 )
 
 // SignServerClient 负责与签名服务器通信
@@ -19,6 +20,13 @@ func NewSignServerClient(endpoint string) *SignServerClient {
 	}
 }
 
+// WithTransport 返回使用指定 Transport 的副本，用于录制回放
+func (s *SignServerClient) WithTransport(transport http.RoundTripper) *SignServerClient {
+	client := NewSignServerClient(s.Endpoint)
+	client.HttpClient.SetTransport(transport)
+	return client
+}
+
 // XiaohongshuSign 发送小红书签名请求
 func (s *SignServerClient) XiaohongshuSign(reqData *XhsSignRequest) (*XhsSignResponse, error) {
 	result := &XhsSignResponse{}
@@ -1,12 +1,23 @@
 package kernel
 
 import (
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 	"noctua/internal/model"
 	"noctua/internal/proxy"
 	"noctua/internal/scheduler"
+	"noctua/kernel/broadcast"
+	"noctua/kernel/flow"
+	"noctua/kernel/journal"
+	"noctua/kernel/notify"
+	"noctua/kernel/session"
+	"noctua/kernel/sink"
+	"noctua/kernel/stream"
+	"noctua/kernel/webhook"
 	"noctua/pkg/database"
 	"noctua/pkg/logger"
+	"noctua/pkg/utils/file"
+	"path/filepath"
 )
 
 func LoadConfig() KernelConfig {
@@ -15,9 +26,44 @@ func LoadConfig() KernelConfig {
 	// 爬虫管理配置
 	crawlerConfig := CrawlerManagerConfig{
 		SignServEndpoint: signEndpoint,
+		Channels:         map[string]flow.Options{},
+	}
+	// 数据通道背压配置
+	if err := viper.UnmarshalKey("crawler.channels", &crawlerConfig.Channels); err != nil {
+		logger.Log.Errorf("Load crawler channel config failed: %v", err)
+	}
+	crawlerConfig.RoundMax = viper.GetInt("crawler.round_max")
+	crawlerConfig.RoundSleep = viper.GetDuration("crawler.round_sleep")
+	crawlerConfig.ProjectJobs = viper.GetInt("crawler.project_jobs")
+	crawlerConfig.QueueQPS = map[string]int{}
+	if err := viper.UnmarshalKey("crawler.queue_qps", &crawlerConfig.QueueQPS); err != nil {
+		logger.Log.Errorf("Load crawler queue qps config failed: %v", err)
+	}
+	if err := viper.UnmarshalKey("crawler.endpoints", &crawlerConfig.Endpoints); err != nil {
+		logger.Log.Errorf("Load crawler endpoints config failed: %v", err)
+	}
+	// HTTP 录制回放配置
+	if err := viper.UnmarshalKey("crawler.recorder", &crawlerConfig.Recorder); err != nil {
+		logger.Log.Errorf("Load crawler recorder config failed: %v", err)
+	}
+	if crawlerConfig.Recorder.Dir == "" {
+		crawlerConfig.Recorder.Dir = "fixtures"
+	}
+	if !filepath.IsAbs(crawlerConfig.Recorder.Dir) {
+		crawlerConfig.Recorder.Dir = filepath.Join(file.GetRuntimeDir(), crawlerConfig.Recorder.Dir)
+	}
+	for name, opts := range crawlerConfig.Channels {
+		if opts.SpillDir == "" {
+			opts.SpillDir = "spill"
+		}
+		if !filepath.IsAbs(opts.SpillDir) {
+			opts.SpillDir = filepath.Join(file.GetRuntimeDir(), opts.SpillDir)
+		}
+		crawlerConfig.Channels[name] = opts
 	}
 
-	schedulerConfig := scheduler.Config{
+	// 调度器配置，各采集任务按此配置创建调度器
+	crawlerConfig.SchedulerConfig = scheduler.Config{
 		MaxWorkersPerQueue: viper.GetInt("scheduler.max_workers_per_queue"),
 		WorkerIdleTimeout:  viper.GetDuration("scheduler.worker_idle_timeout"),
 		AutoScaleInterval:  viper.GetDuration("scheduler.auto_scale_interval"),
@@ -26,27 +72,118 @@ func LoadConfig() KernelConfig {
 		DefaultQPS:         viper.GetInt("scheduler.default_qps"),
 	}
 
-	// 代理配置
-	proxyPoolConfig := proxy.ProxyPoolConfig{
-		MinDynamic: 1,
-		MinStatic:  1,
+	// 代理配置，配置错误时代理池无法正常工作，直接退出
+	proxyPoolConfig, err := loadProxyConfig(viper.GetViper())
+	if err != nil {
+		logger.Log.Fatalf("Invalid proxy config: %v", err)
+	}
+	// 数据输出配置，路由引用不存在的 sink 时数据会丢失，直接退出
+	sinkConfig, err := loadSinkConfig(viper.GetViper())
+	if err != nil {
+		logger.Log.Fatalf("Invalid sink config: %v", err)
+	}
+	// 事件日志配置
+	journalConfig := journal.Config{}
+	if err := viper.UnmarshalKey("journal", &journalConfig); err != nil {
+		logger.Log.Errorf("Load journal config failed: %v", err)
+	}
+	// 实时推送配置
+	streamConfig := stream.Config{}
+	if err := viper.UnmarshalKey("stream", &streamConfig); err != nil {
+		logger.Log.Errorf("Load stream config failed: %v", err)
+	}
+	// Webhook 配置
+	webhookConfig := webhook.Config{}
+	if err := viper.UnmarshalKey("webhook", &webhookConfig); err != nil {
+		logger.Log.Errorf("Load webhook config failed: %v", err)
+	}
+	// 消息中心配置
+	notifyConfig := notify.Config{}
+	if err := viper.UnmarshalKey("notify", &notifyConfig); err != nil {
+		logger.Log.Errorf("Load notify config failed: %v", err)
+	}
+	// 会话管理配置
+	sessionConfig := session.Config{}
+	if err := viper.UnmarshalKey("session", &sessionConfig); err != nil {
+		logger.Log.Errorf("Load session config failed: %v", err)
+	}
+	// 运行时数据分发配置
+	runtimeConfig := broadcast.Config{}
+	if err := viper.UnmarshalKey("runtime", &runtimeConfig); err != nil {
+		logger.Log.Errorf("Load runtime config failed: %v", err)
 	}
 	return KernelConfig{
-		SchedulerConfig: schedulerConfig,
-		ProxyConfig:     proxyPoolConfig,
-		CrawlerConfig:   crawlerConfig,
+		RuntimeConfig: runtimeConfig,
+		JournalConfig: journalConfig,
+		NotifyConfig:  notifyConfig,
+		StreamConfig:  streamConfig,
+		WebhookConfig: webhookConfig,
+		SessionConfig: sessionConfig,
+		ProxyConfig:   proxyPoolConfig,
+		CrawlerConfig: crawlerConfig,
+		SinkConfig:    sinkConfig,
 	}
 }
 
-func MigrateModels() {
-	// 在 GetDB 中调用 Migrate，确保初始化的同时完成迁移
-	if err := database.Migrate(database.DB, []interface{}{
+// loadProxyConfig 读取并校验 proxy 配置项，未知的配置项视为错误，供应商的相对路径基于程序资源目录
+func loadProxyConfig(v *viper.Viper) (proxy.ProxyPoolConfig, error) {
+	config := proxy.ProxyPoolConfig{}
+	if err := v.UnmarshalKey("proxy", &config, func(c *mapstructure.DecoderConfig) {
+		c.ErrorUnused = true
+	}); err != nil {
+		return config, err
+	}
+	for i := range config.Providers {
+		if path := config.Providers[i].Path; path != "" && !filepath.IsAbs(path) {
+			config.Providers[i].Path = file.GetResourcePath(path)
+		}
+	}
+	return config, config.Validate()
+}
+
+// loadSinkConfig 读取 sink 配置，相对路径基于 runtime 目录，未配置时写入数据库，并校验默认路由
+func loadSinkConfig(v *viper.Viper) (sink.Config, error) {
+	config := sink.Config{}
+	if err := v.UnmarshalKey("sink", &config); err != nil {
+		return config, err
+	}
+	for i := range config.Sinks {
+		if config.Sinks[i].Path != "" && !filepath.IsAbs(config.Sinks[i].Path) {
+			config.Sinks[i].Path = filepath.Join(file.GetRuntimeDir(), config.Sinks[i].Path)
+		}
+	}
+	// 未配置时保持原有的数据库写入
+	if len(config.Sinks) == 0 {
+		config.Sinks = []sink.SinkConfig{{Name: "db", Type: "db", Enabled: true}}
+	}
+	if len(config.Default) == 0 {
+		config.Default = []string{"db"}
+	}
+	return config, config.Validate()
+}
+
+// migrationModels 需要迁移的数据表
+func migrationModels() []interface{} {
+	return []interface{}{
+		&model.Project{},
 		&model.MediaAccount{},
 		&model.CrawlTask{},
 		&model.CrawlMedia{},
 		&model.CrawlComment{},
 		&model.CrawlUser{},
-	}); err != nil {
+		&model.EventJournal{},
+		&model.WebhookSubscription{},
+		&model.WebhookDelivery{},
+		&model.Notification{},
+	}
+}
+
+func MigrateModels() {
+	// 在 GetDB 中调用 Migrate，确保初始化的同时完成迁移
+	if err := database.Migrate(database.DB, migrationModels()); err != nil {
 		logger.Log.Errorf("Initial migration failed: %v", err)
 	}
+	if err := model.MigrateProjectScope(); err != nil {
+		logger.Log.Errorf("Migrate project scope failed: %v", err)
+	}
 }
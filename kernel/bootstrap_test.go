@@ -0,0 +1,35 @@
+package kernel
+
+import (
+	"github.com/spf13/viper"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSinkConfig(t *testing.T) {
+	// 默认配置文件可以通过校验
+	v := viper.New()
+	v.SetConfigFile("../config/sink.yaml")
+	require.NoError(t, v.ReadInConfig())
+	config, err := loadSinkConfig(v)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db"}, config.Default)
+
+	// 默认路由引用未配置或未启用的 sink
+	v = viper.New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(strings.NewReader(`
+sink:
+  default: [ jsonl, kafka ]
+  sinks:
+    - name: jsonl
+      type: jsonl
+      path: export/jsonl
+`)))
+	_, err = loadSinkConfig(v)
+	assert.ErrorContains(t, err, "sink.default: sink jsonl is not enabled")
+	assert.ErrorContains(t, err, "sink.default: sink kafka is not configured")
+}
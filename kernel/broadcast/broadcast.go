@@ -0,0 +1,304 @@
+// Package broadcast 将运行时数据分发给各个处理函数，每个处理函数拥有独立的协程和队列，发布方不会被阻塞
+package broadcast
+
+import (
+	"fmt"
+	"noctua/pkg/logger"
+	"noctua/types"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Policy 队列写满时的处理策略
+type Policy string
+
+const (
+	PolicyDropOldest Policy = "drop_oldest" // 丢弃最旧的数据，默认策略
+	PolicyDropNewest Policy = "drop_newest" // 丢弃新到的数据
+	PolicyBlock      Policy = "block"       // 最多等待 Timeout，超时后丢弃新到的数据
+)
+
+const (
+	DefaultBuffer       = 1000
+	DefaultBlockTimeout = 100 * time.Millisecond
+)
+
+// Options 处理函数的队列配置
+type Options struct {
+	Name    string             `mapstructure:"name"`    // 名称，用于状态展示
+	Buffer  int                `mapstructure:"buffer"`  // 队列长度
+	Policy  Policy             `mapstructure:"policy"`  // 队列写满时的策略
+	Timeout time.Duration      `mapstructure:"timeout"` // block 策略的最长等待时间
+	OnDrop  func(total uint64) `mapstructure:"-"`       // 丢弃数据时回调，参数为累计丢弃数，在发布方协程中执行
+}
+
+// Config 分发配置，Handlers 按名称覆盖单个处理函数的队列配置
+type Config struct {
+	Buffer   int                `mapstructure:"buffer"`
+	Policy   Policy             `mapstructure:"policy"`
+	Timeout  time.Duration      `mapstructure:"timeout"`
+	Handlers map[string]Options `mapstructure:"handlers"`
+}
+
+// resolve 按 配置文件 > 注册参数 > 全局默认 的顺序合并队列配置
+func (c Config) resolve(options Options) Options {
+	if override, ok := c.Handlers[options.Name]; ok && options.Name != "" {
+		if override.Buffer > 0 {
+			options.Buffer = override.Buffer
+		}
+		if override.Policy != "" {
+			options.Policy = override.Policy
+		}
+		if override.Timeout > 0 {
+			options.Timeout = override.Timeout
+		}
+	}
+	if options.Buffer <= 0 {
+		options.Buffer = c.Buffer
+	}
+	if options.Policy == "" {
+		options.Policy = c.Policy
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = c.Timeout
+	}
+	if options.Buffer <= 0 {
+		options.Buffer = DefaultBuffer
+	}
+	if options.Policy == "" {
+		options.Policy = PolicyDropOldest
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultBlockTimeout
+	}
+	return options
+}
+
+// Handle 注册处理函数后返回的句柄，用于移除
+type Handle uint64
+
+// HandlerStats 处理函数的统计
+type HandlerStats struct {
+	Handle     Handle        `json:"handle"`
+	Name       string        `json:"name"`
+	Policy     Policy        `json:"policy"`
+	Capacity   int           `json:"capacity"`
+	Queued     int           `json:"queued"`
+	Delivered  uint64        `json:"delivered"`
+	Dropped    uint64        `json:"dropped"`
+	Panics     uint64        `json:"panics"`
+	MaxLatency time.Duration `json:"maxLatency"` // 单次处理的最长耗时
+}
+
+// Stats 分发统计
+type Stats struct {
+	Published uint64         `json:"published"`
+	Unrouted  uint64         `json:"unrouted"` // 没有处理函数时发布的数据
+	Handlers  []HandlerStats `json:"handlers"`
+}
+
+type handler struct {
+	handle     Handle
+	options    Options
+	fn         func(types.RuntimeData)
+	queue      chan types.RuntimeData
+	delivered  atomic.Uint64
+	dropped    atomic.Uint64
+	panics     atomic.Uint64
+	maxLatency atomic.Int64
+}
+
+// drop 记录一次丢弃并回调
+func (h *handler) drop() {
+	total := h.dropped.Add(1)
+	if h.options.OnDrop != nil {
+		h.options.OnDrop(total)
+	}
+}
+
+// offer 按策略写入队列，不会无限期阻塞
+func (h *handler) offer(data types.RuntimeData) {
+	select {
+	case h.queue <- data:
+		return
+	default:
+	}
+	switch h.options.Policy {
+	case PolicyDropNewest:
+		h.drop()
+	case PolicyBlock:
+		timer := time.NewTimer(h.options.Timeout)
+		defer timer.Stop()
+		select {
+		case h.queue <- data:
+		case <-timer.C:
+			h.drop()
+		}
+	default:
+		// 并发发布时腾出的位置可能被抢占，最多重试一次后丢弃
+		for i := 0; i < 2; i++ {
+			select {
+			case <-h.queue:
+				h.drop()
+			default:
+			}
+			select {
+			case h.queue <- data:
+				return
+			default:
+			}
+		}
+		h.drop()
+	}
+}
+
+func (h *handler) run(wg *sync.WaitGroup) {
+	defer wg.Done()
+	for data := range h.queue {
+		h.call(data)
+	}
+}
+
+func (h *handler) call(data types.RuntimeData) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			h.panics.Add(1)
+			logger.Log.Errorf("Runtime handler %s panicked: %v", h.options.Name, r)
+		}
+		latency := int64(time.Since(start))
+		for {
+			current := h.maxLatency.Load()
+			if latency <= current || h.maxLatency.CompareAndSwap(current, latency) {
+				break
+			}
+		}
+	}()
+	h.fn(data)
+	h.delivered.Add(1)
+}
+
+func (h *handler) stats() HandlerStats {
+	return HandlerStats{
+		Handle:     h.handle,
+		Name:       h.options.Name,
+		Policy:     h.options.Policy,
+		Capacity:   cap(h.queue),
+		Queued:     len(h.queue),
+		Delivered:  h.delivered.Load(),
+		Dropped:    h.dropped.Load(),
+		Panics:     h.panics.Load(),
+		MaxLatency: time.Duration(h.maxLatency.Load()),
+	}
+}
+
+// Broadcaster 运行时数据分发器
+type Broadcaster struct {
+	config    Config
+	mu        sync.RWMutex
+	handlers  map[Handle]*handler
+	nextID    Handle
+	closed    bool
+	published atomic.Uint64
+	unrouted  atomic.Uint64
+	wg        sync.WaitGroup
+}
+
+// New 创建分发器
+func New(config Config) *Broadcaster {
+	return &Broadcaster{config: config, handlers: make(map[Handle]*handler)}
+}
+
+// Add 注册处理函数，opts 为空时使用默认配置，分发器已关闭时返回 0
+func (b *Broadcaster) Add(fn func(types.RuntimeData), opts ...Options) Handle {
+	var options Options
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	options = b.config.resolve(options)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0
+	}
+	b.nextID++
+	if options.Name == "" {
+		options.Name = fmt.Sprintf("handler-%d", b.nextID)
+	}
+	h := &handler{
+		handle:  b.nextID,
+		options: options,
+		fn:      fn,
+		queue:   make(chan types.RuntimeData, options.Buffer),
+	}
+	b.handlers[h.handle] = h
+	b.wg.Add(1)
+	go h.run(&b.wg)
+	return h.handle
+}
+
+// Remove 移除处理函数，已入队的数据仍会处理完，返回句柄是否存在
+func (b *Broadcaster) Remove(handle Handle) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h, ok := b.handlers[handle]
+	if !ok {
+		return false
+	}
+	delete(b.handlers, handle)
+	close(h.queue)
+	return true
+}
+
+// Publish 将数据写入所有处理函数的队列，可作为 types.RuntimeEmitter 注入
+func (b *Broadcaster) Publish(data types.RuntimeData) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+	b.published.Add(1)
+	if len(b.handlers) == 0 {
+		b.unrouted.Add(1)
+		return
+	}
+	for _, h := range b.handlers {
+		h.offer(data)
+	}
+}
+
+// Stats 返回分发统计
+func (b *Broadcaster) Stats() Stats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	stats := Stats{
+		Published: b.published.Load(),
+		Unrouted:  b.unrouted.Load(),
+		Handlers:  make([]HandlerStats, 0, len(b.handlers)),
+	}
+	for _, h := range b.handlers {
+		stats.Handlers = append(stats.Handlers, h.stats())
+	}
+	sort.Slice(stats.Handlers, func(i, j int) bool {
+		return stats.Handlers[i].Handle < stats.Handlers[j].Handle
+	})
+	return stats
+}
+
+// Close 停止接收数据，等待各处理函数处理完已入队的数据
+func (b *Broadcaster) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	for handle, h := range b.handlers {
+		delete(b.handlers, handle)
+		close(h.queue)
+	}
+	b.mu.Unlock()
+	b.wg.Wait()
+}
@@ -0,0 +1,137 @@
+package broadcast
+
+import (
+	"noctua/pkg/logger"
+	"noctua/types"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func data(title string) types.RuntimeData {
+	return types.NewRuntimeData(types.RuntimeEventCodeNotification, types.EventData{Title: title})
+}
+
+func TestBroadcastFanOut(t *testing.T) {
+	b := New(Config{})
+	var mu sync.Mutex
+	var first, second []string
+	b.Add(func(d types.RuntimeData) {
+		mu.Lock()
+		first = append(first, d.EventData.Title)
+		mu.Unlock()
+	})
+	handle := b.Add(func(d types.RuntimeData) {
+		mu.Lock()
+		second = append(second, d.EventData.Title)
+		mu.Unlock()
+	})
+	b.Publish(data("a"))
+	b.Publish(data("b"))
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(first) == 2 && len(second) == 2
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"a", "b"}, first)
+
+	assert.True(t, b.Remove(handle))
+	assert.False(t, b.Remove(handle))
+	b.Publish(data("c"))
+	b.Close()
+	assert.Equal(t, []string{"a", "b", "c"}, first)
+	assert.Equal(t, []string{"a", "b"}, second)
+
+	stats := b.Stats()
+	assert.Equal(t, uint64(3), stats.Published)
+	assert.Empty(t, stats.Handlers)
+	// 关闭后发布和注册均被忽略
+	b.Publish(data("d"))
+	assert.Equal(t, Handle(0), b.Add(func(types.RuntimeData) {}))
+}
+
+// blockedHandler 处理第一条数据后阻塞，直到 release 关闭
+func blockedHandler(started chan<- struct{}, release <-chan struct{}, seen *[]string, mu *sync.Mutex) func(types.RuntimeData) {
+	var once sync.Once
+	return func(d types.RuntimeData) {
+		once.Do(func() {
+			close(started)
+			<-release
+		})
+		mu.Lock()
+		*seen = append(*seen, d.EventData.Title)
+		mu.Unlock()
+	}
+}
+
+func TestBroadcastOverflowPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		policy Policy
+		want   []string
+	}{
+		{PolicyDropOldest, []string{"0", "3", "4"}},
+		{PolicyDropNewest, []string{"0", "1", "2"}},
+		{PolicyBlock, []string{"0", "1", "2"}},
+	} {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			b := New(Config{Timeout: 10 * time.Millisecond})
+			started, release := make(chan struct{}), make(chan struct{})
+			var mu sync.Mutex
+			var seen []string
+			var reported atomic.Uint64
+			b.Add(blockedHandler(started, release, &seen, &mu), Options{Name: "slow", Buffer: 2, Policy: tc.policy, OnDrop: reported.Store})
+
+			b.Publish(data("0"))
+			<-started
+			begin := time.Now()
+			for _, title := range []string{"1", "2", "3", "4"} {
+				b.Publish(data(title))
+			}
+			// 发布方不会因处理函数阻塞而停顿
+			assert.Less(t, time.Since(begin), time.Second)
+			stats := b.Stats()
+			require.Len(t, stats.Handlers, 1)
+			assert.Equal(t, uint64(2), stats.Handlers[0].Dropped)
+			assert.Equal(t, uint64(2), reported.Load())
+			assert.Equal(t, 2, stats.Handlers[0].Queued)
+			assert.Equal(t, "slow", stats.Handlers[0].Name)
+
+			close(release)
+			b.Close()
+			assert.Equal(t, tc.want, seen)
+		})
+	}
+}
+
+func TestBroadcastPanicAndConfig(t *testing.T) {
+	logger.Init(&logger.LoggerConfig{Level: "fatal"})
+	b := New(Config{Buffer: 8, Handlers: map[string]Options{"journal": {Buffer: 32, Policy: PolicyBlock}}})
+	var calls atomic.Int32
+	b.Add(func(d types.RuntimeData) {
+		calls.Add(1)
+		if d.EventData.Title == "boom" {
+			panic("boom")
+		}
+	}, Options{Name: "journal", Policy: PolicyDropNewest})
+	b.Add(func(types.RuntimeData) {})
+
+	b.Publish(data("boom"))
+	b.Publish(data("ok"))
+	assert.Eventually(t, func() bool { return calls.Load() == 2 }, time.Second, 5*time.Millisecond)
+
+	stats := b.Stats()
+	require.Len(t, stats.Handlers, 2)
+	journal := stats.Handlers[0]
+	assert.Equal(t, 32, journal.Capacity)
+	assert.Equal(t, PolicyBlock, journal.Policy)
+	assert.Equal(t, uint64(1), journal.Panics)
+	assert.Equal(t, uint64(1), journal.Delivered)
+	assert.Equal(t, 8, stats.Handlers[1].Capacity)
+	assert.Equal(t, PolicyDropOldest, stats.Handlers[1].Policy)
+	assert.Equal(t, "handler-2", stats.Handlers[1].Name)
+	b.Close()
+}
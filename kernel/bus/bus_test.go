@@ -0,0 +1,139 @@
+package bus
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type jobEvent struct{ ID int }
+
+func (jobEvent) Topic() string { return "job.started" }
+
+type taskEvent struct{ ID int }
+
+func (taskEvent) Topic() string { return "task.failed" }
+
+func TestMatchTopic(t *testing.T) {
+	assert.True(t, MatchTopic("#", "task.failed"))
+	assert.True(t, MatchTopic("task.*", "task.failed"))
+	assert.False(t, MatchTopic("task.*", "task.failed.retry"))
+	assert.True(t, MatchTopic("task.#", "task.failed.retry"))
+	assert.True(t, MatchTopic("#.failed", "task.failed"))
+	assert.False(t, MatchTopic("job.*", "task.failed"))
+}
+
+func TestSubscribeTypedAndWildcard(t *testing.T) {
+	eb := NewEventBus(10)
+	defer eb.Close()
+	jobs := Subscribe[jobEvent](eb, Options{})
+	all := Subscribe[any](eb, Options{Topic: "#", Mode: ModeUnbounded})
+
+	eb.Publish(jobEvent{ID: 1})
+	eb.Publish(taskEvent{ID: 2})
+
+	assert.Equal(t, jobEvent{ID: 1}, <-jobs.C())
+	assert.Equal(t, jobEvent{ID: 1}, <-all.C())
+	assert.Equal(t, taskEvent{ID: 2}, <-all.C())
+
+	jobs.Cancel()
+	_, ok := <-jobs.C()
+	assert.False(t, ok)
+	eb.Publish(jobEvent{ID: 3})
+	assert.Equal(t, jobEvent{ID: 3}, <-all.C())
+	assert.Len(t, eb.Stats().Subscriptions, 1)
+}
+
+func TestDropOldest(t *testing.T) {
+	eb := NewEventBus(2)
+	defer eb.Close()
+	sub := Subscribe[jobEvent](eb, Options{})
+	// 首个事件被 pump 取出后阻塞在写入，队列中只保留最新的 2 个
+	eb.Publish(jobEvent{ID: 0})
+	assert.Eventually(t, func() bool { return sub.Stats().Queued == 0 }, time.Second, time.Millisecond)
+	for i := 1; i < 6; i++ {
+		eb.Publish(jobEvent{ID: i})
+	}
+	assert.Equal(t, int64(3), sub.Dropped())
+	assert.Equal(t, int64(3), eb.Stats().Dropped)
+	assert.Equal(t, 0, (<-sub.C()).ID)
+	assert.Equal(t, 4, (<-sub.C()).ID)
+	assert.Equal(t, 5, (<-sub.C()).ID)
+}
+
+func TestBlockTimeout(t *testing.T) {
+	eb := NewEventBus(1)
+	defer eb.Close()
+	sub := Subscribe[jobEvent](eb, Options{Mode: ModeBlock, Timeout: 20 * time.Millisecond})
+	eb.Publish(jobEvent{ID: 1})
+	assert.Eventually(t, func() bool { return sub.Stats().Queued == 0 }, time.Second, time.Millisecond)
+	eb.Publish(jobEvent{ID: 2})
+
+	start := time.Now()
+	eb.Publish(jobEvent{ID: 3})
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.Equal(t, int64(1), sub.Dropped())
+
+	// 消费后阻塞的发布方可以继续写入
+	done := make(chan struct{})
+	go func() {
+		eb.Publish(jobEvent{ID: 4})
+		close(done)
+	}()
+	assert.Equal(t, 1, (<-sub.C()).ID)
+	<-done
+	assert.Equal(t, 2, (<-sub.C()).ID)
+	assert.Equal(t, 4, (<-sub.C()).ID)
+}
+
+func TestPublishSync(t *testing.T) {
+	eb := NewEventBus(1)
+	sub := Subscribe[jobEvent](eb, Options{})
+	for i := 0; i < 3; i++ {
+		eb.Publish(jobEvent{ID: i})
+	}
+	received := make(chan jobEvent, 10)
+	go func() {
+		for event := range sub.C() {
+			received <- event
+		}
+	}()
+	// 同步发布不受容量限制，返回时事件已被取走
+	assert.NoError(t, eb.PublishSync(context.Background(), jobEvent{ID: 99}))
+	stats := sub.Stats()
+	assert.Equal(t, 0, stats.Queued)
+	assert.Equal(t, int64(4), stats.Delivered+stats.Dropped)
+
+	eb.Close()
+	assert.ErrorIs(t, eb.PublishSync(context.Background(), jobEvent{}), ErrClosed)
+}
+
+func TestClosePublishRace(t *testing.T) {
+	for round := 0; round < 20; round++ {
+		eb := NewEventBus(4)
+		subs := []*Subscription[jobEvent]{
+			Subscribe[jobEvent](eb, Options{}),
+			Subscribe[jobEvent](eb, Options{Mode: ModeBlock, Timeout: time.Millisecond}),
+			Subscribe[jobEvent](eb, Options{Mode: ModeUnbounded}),
+		}
+		wg := &sync.WaitGroup{}
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					eb.Publish(jobEvent{ID: j})
+				}
+			}()
+		}
+		go eb.Close()
+		wg.Wait()
+		for _, sub := range subs {
+			for range sub.C() {
+			}
+		}
+	}
+}
@@ -1,83 +1,147 @@
 package bus
 
 import (
-	"noctua/pkg/logger"
-	"reflect"
+	"context"
+	"errors"
 	"sync"
+	"sync/atomic"
 )
 
+var ErrClosed = errors.New("event bus closed")
+
+// Stats 事件总线统计
+type Stats struct {
+	Published     int64               `json:"published"`     // 发布总数
+	Unrouted      int64               `json:"unrouted"`      // 无订阅者的事件数
+	Dropped       int64               `json:"dropped"`       // 全部订阅累计丢弃数
+	Subscriptions []SubscriptionStats `json:"subscriptions"` // 当前订阅
+}
+
+// EventBus 按主题分发事件，每个订阅独立排队，互不阻塞
 type EventBus struct {
-	events chan interface{}
-	subs   map[reflect.Type][]chan interface{} // 按事件类型存储订阅通道
-	mu     sync.RWMutex
-	closed bool
+	mu            sync.RWMutex
+	subs          map[uint64]*subscriber
+	nextID        uint64
+	closed        bool
+	defaultBuffer int
+	published     atomic.Int64
+	unrouted      atomic.Int64
+	dropped       atomic.Int64
 }
 
+// NewEventBus 创建事件总线，bufferSize 为订阅未指定容量时的默认队列长度
 func NewEventBus(bufferSize int) *EventBus {
-	eb := &EventBus{
-		events: make(chan interface{}, bufferSize),
-		subs:   make(map[reflect.Type][]chan interface{}),
+	if bufferSize <= 0 {
+		bufferSize = DefaultBuffer
+	}
+	return &EventBus{
+		subs:          make(map[uint64]*subscriber),
+		defaultBuffer: bufferSize,
 	}
-	go eb.run()
-	return eb
 }
 
+// Publish 异步发布事件，按各订阅的投递模式入队
 func (eb *EventBus) Publish(event interface{}) {
+	subs, _ := eb.match(event)
+	for _, s := range subs {
+		s.enqueue(&envelope{event: event}, false)
+	}
+}
+
+// PublishSync 同步发布关键事件，忽略订阅容量限制，等待所有订阅者取走事件后返回
+func (eb *EventBus) PublishSync(ctx context.Context, event interface{}) error {
+	subs, ok := eb.match(event)
+	if !ok {
+		return ErrClosed
+	}
+	acks := make([]chan struct{}, 0, len(subs))
+	for _, s := range subs {
+		env := &envelope{event: event, ack: make(chan struct{})}
+		if s.enqueue(env, true) {
+			acks = append(acks, env.ack)
+		}
+	}
+	for _, ack := range acks {
+		select {
+		case <-ack:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// match 返回主题和类型都匹配的订阅，总线已关闭时返回 false
+func (eb *EventBus) match(event interface{}) ([]*subscriber, bool) {
 	eb.mu.RLock()
+	defer eb.mu.RUnlock()
 	if eb.closed {
-		eb.mu.RUnlock()
-		return
+		return nil, false
 	}
-	select {
-	case eb.events <- event:
-	default:
-		logger.Log.Errorf("EventBus full, dropping event: %T", event)
+	eb.published.Add(1)
+	topic := TopicOf(event)
+	var subs []*subscriber
+	for _, s := range eb.subs {
+		if MatchTopic(s.pattern, topic) && s.accept(event) {
+			subs = append(subs, s)
+		}
+	}
+	if len(subs) == 0 {
+		eb.unrouted.Add(1)
 	}
-	eb.mu.RUnlock()
+	return subs, true
 }
 
-// SubscribeToType 订阅特定类型的事件
-func (eb *EventBus) SubscribeToType(eventType interface{}, eventSize int) <-chan interface{} {
+// add 注册订阅，总线已关闭时返回 false
+func (eb *EventBus) add(s *subscriber) bool {
 	eb.mu.Lock()
 	defer eb.mu.Unlock()
 	if eb.closed {
-		return nil
+		return false
 	}
-	ch := make(chan interface{}, eventSize)
-	typ := reflect.TypeOf(eventType)
-	eb.subs[typ] = append(eb.subs[typ], ch)
-	return ch
+	eb.nextID++
+	s.id = eb.nextID
+	s.onDrop = func() { eb.dropped.Add(1) }
+	eb.subs[s.id] = s
+	return true
 }
 
-func (eb *EventBus) Close() {
+// remove 取消订阅
+func (eb *EventBus) remove(s *subscriber) {
 	eb.mu.Lock()
-	defer eb.mu.Unlock()
-	if !eb.closed {
-		close(eb.events)
-		for _, chans := range eb.subs {
-			for _, ch := range chans {
-				close(ch)
-			}
-		}
-		eb.closed = true
+	delete(eb.subs, s.id)
+	eb.mu.Unlock()
+	s.close()
+}
+
+// Stats 事件总线统计
+func (eb *EventBus) Stats() Stats {
+	eb.mu.RLock()
+	defer eb.mu.RUnlock()
+	stats := Stats{
+		Published:     eb.published.Load(),
+		Unrouted:      eb.unrouted.Load(),
+		Dropped:       eb.dropped.Load(),
+		Subscriptions: make([]SubscriptionStats, 0, len(eb.subs)),
 	}
+	for _, s := range eb.subs {
+		stats.Subscriptions = append(stats.Subscriptions, s.stats())
+	}
+	return stats
 }
 
-func (eb *EventBus) run() {
-	for event := range eb.events {
-		eb.mu.RLock()
-		typ := reflect.TypeOf(event)
-		for subType, chans := range eb.subs {
-			if subType == typ { // 只分发匹配类型的事件
-				for _, ch := range chans {
-					select {
-					case ch <- event:
-					default:
-						logger.Log.Warnf("Subscriber channel full, dropping event: %T", event)
-					}
-				}
-			}
-		}
-		eb.mu.RUnlock()
+// Close 关闭总线及全部订阅，之后的发布将被忽略
+func (eb *EventBus) Close() {
+	eb.mu.Lock()
+	if eb.closed {
+		eb.mu.Unlock()
+		return
+	}
+	eb.closed = true
+	subs := eb.subs
+	eb.subs = make(map[uint64]*subscriber)
+	eb.mu.Unlock()
+	for _, s := range subs {
+		s.close()
 	}
 }
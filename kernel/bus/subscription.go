@@ -0,0 +1,296 @@
+package bus
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Mode 订阅队列写满时的投递模式
+type Mode string
+
+const (
+	ModeDropOldest Mode = "drop_oldest" // 丢弃最早的事件并计数
+	ModeBlock      Mode = "block"       // 阻塞发布方，超时后丢弃并计数
+	ModeUnbounded  Mode = "unbounded"   // 不限制队列长度
+)
+
+const (
+	DefaultBuffer       = 100
+	DefaultBlockTimeout = 5 * time.Second
+)
+
+// Options 订阅配置
+type Options struct {
+	Topic   string        `mapstructure:"topic"`   // 主题模式，支持 * 和 # 通配，为空时使用事件类型的主题
+	Mode    Mode          `mapstructure:"mode"`    // 投递模式，默认 drop_oldest
+	Buffer  int           `mapstructure:"buffer"`  // 队列长度，默认使用总线配置
+	Timeout time.Duration `mapstructure:"timeout"` // block 模式的等待时间
+}
+
+// SubscriptionStats 订阅统计
+type SubscriptionStats struct {
+	ID        uint64 `json:"id"`
+	Topic     string `json:"topic"`
+	Type      string `json:"type"`
+	Mode      Mode   `json:"mode"`
+	Buffer    int    `json:"buffer"`
+	Queued    int    `json:"queued"`
+	Delivered int64  `json:"delivered"`
+	Dropped   int64  `json:"dropped"`
+}
+
+// envelope 队列中的事件，ack 非空时在事件被取走或丢弃后关闭
+type envelope struct {
+	event interface{}
+	ack   chan struct{}
+}
+
+func (e *envelope) release() {
+	if e.ack != nil {
+		close(e.ack)
+	}
+}
+
+// Subscription 类型化订阅句柄
+type Subscription[T any] struct {
+	bus *EventBus
+	sub *subscriber
+	out chan T
+}
+
+// Subscribe 订阅类型为 T 的事件，T 为接口类型时可配合通配主题订阅多种事件
+func Subscribe[T any](eb *EventBus, opts Options) *Subscription[T] {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if opts.Topic == "" {
+		opts.Topic = WildcardAll
+		if typ.Kind() != reflect.Interface {
+			opts.Topic = topicOfType(typ)
+		}
+	}
+	if opts.Mode == "" {
+		opts.Mode = ModeDropOldest
+	}
+	if opts.Buffer <= 0 {
+		opts.Buffer = eb.defaultBuffer
+	}
+	if opts.Mode == ModeBlock && opts.Timeout <= 0 {
+		opts.Timeout = DefaultBlockTimeout
+	}
+	s := &subscriber{
+		pattern:  opts.Topic,
+		typeName: typ.String(),
+		mode:     opts.Mode,
+		buffer:   opts.Buffer,
+		timeout:  opts.Timeout,
+		accept: func(event interface{}) bool {
+			_, ok := event.(T)
+			return ok
+		},
+		notify: make(chan struct{}, 1),
+		space:  make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	subscription := &Subscription[T]{bus: eb, sub: s, out: make(chan T)}
+	if !eb.add(s) {
+		close(s.done)
+		close(subscription.out)
+		return subscription
+	}
+	go subscription.pump()
+	return subscription
+}
+
+// C 事件通道，取消订阅或总线关闭后关闭
+func (s *Subscription[T]) C() <-chan T {
+	return s.out
+}
+
+// Cancel 取消订阅，未投递的事件将被丢弃
+func (s *Subscription[T]) Cancel() {
+	s.bus.remove(s.sub)
+}
+
+// Dropped 丢弃的事件数
+func (s *Subscription[T]) Dropped() int64 {
+	return s.sub.dropped.Load()
+}
+
+// Stats 订阅统计
+func (s *Subscription[T]) Stats() SubscriptionStats {
+	return s.sub.stats()
+}
+
+// pump 将队列中的事件逐个写入 out，是 out 的唯一写入方
+func (s *Subscription[T]) pump() {
+	defer close(s.out)
+	for {
+		env, ok := s.sub.next()
+		if !ok {
+			return
+		}
+		select {
+		case s.out <- env.event.(T):
+			s.sub.delivered.Add(1)
+			env.release()
+		case <-s.sub.done:
+			env.release()
+			s.sub.drain()
+			return
+		}
+	}
+}
+
+// subscriber 与类型无关的订阅队列
+type subscriber struct {
+	id        uint64
+	pattern   string
+	typeName  string
+	mode      Mode
+	buffer    int
+	timeout   time.Duration
+	accept    func(event interface{}) bool
+	onDrop    func()
+	mu        sync.Mutex
+	queue     []*envelope
+	closed    bool
+	notify    chan struct{} // 有新事件
+	space     chan struct{} // 队列有空位
+	done      chan struct{}
+	delivered atomic.Int64
+	dropped   atomic.Int64
+}
+
+// enqueue 事件入队，force 为 true 时忽略容量限制
+func (s *subscriber) enqueue(env *envelope, force bool) bool {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		env.release()
+		return false
+	}
+	if force || s.mode == ModeUnbounded || len(s.queue) < s.buffer {
+		s.push(env)
+		return true
+	}
+	if s.mode == ModeBlock {
+		return s.waitAndPush(env)
+	}
+	// drop_oldest
+	oldest := s.queue[0]
+	s.queue = s.queue[1:]
+	s.drop(oldest)
+	s.push(env)
+	return true
+}
+
+// push 写入队列并解锁
+func (s *subscriber) push(env *envelope) {
+	s.queue = append(s.queue, env)
+	s.mu.Unlock()
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// waitAndPush 等待队列空位，调用时持有锁
+func (s *subscriber) waitAndPush(env *envelope) bool {
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+	for len(s.queue) >= s.buffer {
+		s.mu.Unlock()
+		select {
+		case <-s.space:
+		case <-timer.C:
+			s.drop(env)
+			return false
+		case <-s.done:
+			env.release()
+			return false
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			env.release()
+			return false
+		}
+	}
+	s.push(env)
+	return true
+}
+
+func (s *subscriber) drop(env *envelope) {
+	s.dropped.Add(1)
+	if s.onDrop != nil {
+		s.onDrop()
+	}
+	env.release()
+}
+
+// next 取出队首事件，订阅关闭后返回 false
+func (s *subscriber) next() (*envelope, bool) {
+	for {
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return nil, false
+		}
+		if len(s.queue) > 0 {
+			env := s.queue[0]
+			s.queue[0] = nil
+			s.queue = s.queue[1:]
+			s.mu.Unlock()
+			select {
+			case s.space <- struct{}{}:
+			default:
+			}
+			return env, true
+		}
+		s.mu.Unlock()
+		select {
+		case <-s.notify:
+		case <-s.done:
+		}
+	}
+}
+
+func (s *subscriber) close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	s.mu.Unlock()
+	close(s.done)
+	s.drain()
+}
+
+// drain 释放队列中未投递的事件
+func (s *subscriber) drain() {
+	s.mu.Lock()
+	queue := s.queue
+	s.queue = nil
+	s.mu.Unlock()
+	for _, env := range queue {
+		env.release()
+	}
+}
+
+func (s *subscriber) stats() SubscriptionStats {
+	s.mu.Lock()
+	queued := len(s.queue)
+	s.mu.Unlock()
+	return SubscriptionStats{
+		ID:        s.id,
+		Topic:     s.pattern,
+		Type:      s.typeName,
+		Mode:      s.mode,
+		Buffer:    s.buffer,
+		Queued:    queued,
+		Delivered: s.delivered.Load(),
+		Dropped:   s.dropped.Load(),
+	}
+}
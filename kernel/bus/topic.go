@@ -0,0 +1,69 @@
+package bus
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Topical 事件实现该接口时使用自定义主题，否则使用类型名，如 types.RuntimeData
+type Topical interface {
+	Topic() string
+}
+
+// 主题通配符，主题按 "." 分段
+const (
+	WildcardOne = "*" // 匹配单个分段，如 task.* 匹配 task.failed
+	WildcardAll = "#" // 匹配零个或多个分段，如 # 匹配全部事件
+)
+
+// TopicOf 事件主题
+func TopicOf(event interface{}) string {
+	if topical, ok := event.(Topical); ok {
+		return topical.Topic()
+	}
+	return reflect.TypeOf(event).String()
+}
+
+// topicOfType 类型的默认主题，指针和接口类型无法取得零值主题时使用类型名
+func topicOfType(typ reflect.Type) string {
+	if typ.Kind() != reflect.Pointer && typ.Kind() != reflect.Interface && typ.Implements(reflect.TypeOf((*Topical)(nil)).Elem()) {
+		return reflect.Zero(typ).Interface().(Topical).Topic()
+	}
+	return typ.String()
+}
+
+// MatchTopic 判断主题是否匹配订阅模式
+func MatchTopic(pattern, topic string) bool {
+	if pattern == topic || pattern == WildcardAll {
+		return true
+	}
+	return matchSegments(strings.Split(pattern, "."), strings.Split(topic, "."))
+}
+
+func matchSegments(pattern, topic []string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case WildcardAll:
+			// # 之后无更多分段时匹配剩余全部
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(topic); i++ {
+				if matchSegments(pattern[1:], topic[i:]) {
+					return true
+				}
+			}
+			return false
+		case WildcardOne:
+			if len(topic) == 0 {
+				return false
+			}
+		default:
+			if len(topic) == 0 || pattern[0] != topic[0] {
+				return false
+			}
+		}
+		pattern, topic = pattern[1:], topic[1:]
+	}
+	return len(topic) == 0
+}
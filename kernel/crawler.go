@@ -4,17 +4,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/google/uuid"
 	"noctua/internal/constants"
+	"noctua/internal/model"
 	"noctua/internal/scheduler"
 	"noctua/internal/signer"
 	"noctua/kernel/bus"
 	"noctua/kernel/crawls/douyin"
+	"noctua/kernel/flow"
 	"noctua/kernel/reference"
 	"noctua/kernel/session"
+	"noctua/kernel/sink"
+	"noctua/pkg/httpx"
 	"noctua/pkg/logger"
 	"noctua/pkg/utils/encrypt"
 	"noctua/pkg/utils/str"
 	"noctua/types"
+	"os"
+	"path/filepath"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -24,19 +32,44 @@ const ROUND_MAX = 10
 const ROUND_SLEEP = 20
 const ROUND_INCR = 48
 
-// CrawlerStatus 定义爬虫管理器的状态
+// PROJECT_JOBS 项目未设置上限时同时运行的采集任务数
+const PROJECT_JOBS = 1
+
+var (
+	ErrProjectDisabled = errors.New("project is disabled")
+	ErrProjectJobLimit = errors.New("project concurrent job limit reached")
+)
+
+// CrawlerStatus 定义项目采集任务的状态
 type CrawlerStatus struct {
-	Running        bool                    `json:"running"`        // 是否正在运行
-	ContextActive  bool                    `json:"contextActive"`  // 上下文是否活跃
-	CrawlerActive  bool                    `json:"crawlerActive"`  // 是否有爬虫实例
-	Channels       map[string]*ChannelInfo `json:"channels"`       // 各通道状态
-	SupportedMedia []string                `json:"supportedMedia"` // 支持的媒体平台
+	Running        bool         `json:"running"`        // 项目是否有运行中的任务
+	ProjectID      uint         `json:"projectId"`      // 所属项目
+	Jobs           []*JobStatus `json:"jobs"`           // 运行中的采集任务，按启动时间排序
+	SupportedMedia []string     `json:"supportedMedia"` // 支持的媒体平台
+}
+
+// JobStatus 定义运行中采集任务的状态
+type JobStatus struct {
+	JobID     string                  `json:"jobId"`
+	MediaCode string                  `json:"mediaCode"`
+	CrawlType string                  `json:"crawlType"`
+	StartedAt time.Time               `json:"startedAt"`
+	Paused    bool                    `json:"paused"`   // 调度是否暂停
+	Channels  map[string]*ChannelInfo `json:"channels"` // 各通道状态
 }
 
 // ChannelInfo 定义通道状态
 type ChannelInfo struct {
-	Length   int `json:"length"`
-	Capacity int `json:"capacity"`
+	Length     int         `json:"length"`
+	Capacity   int         `json:"capacity"`
+	Policy     flow.Policy `json:"policy"`     // 写满策略
+	Saturation float64     `json:"saturation"` // 占用率 0-1
+	Saturated  bool        `json:"saturated"`  // 是否饱和
+	Sent       int64       `json:"sent"`       // 成功写入数
+	Dropped    int64       `json:"dropped"`    // 丢弃数
+	TimedOut   int64       `json:"timedOut"`   // 阻塞超时数
+	Spilled    int64       `json:"spilled"`    // 溢写到磁盘数
+	SpillDepth int         `json:"spillDepth"` // 磁盘待回填数
 }
 
 // CrawlerCreator 用于创建爬虫实例
@@ -46,28 +79,55 @@ type CrawlerCreator func(
 	sessionManager *session.Manager,
 	signClient *signer.SignServerClient,
 	eventer *bus.EventBus,
+	sinks *sink.Manager,
+	options reference.CrawlerOptions,
 ) reference.Crawler
 
 type CrawlerManagerConfig struct {
 	SignServEndpoint string
-	SchedulerConfig  scheduler.Config
+	SchedulerConfig  scheduler.Config             // 各采集任务的调度器配置
+	Channels         map[string]flow.Options      // 数据通道背压策略
+	Recorder         httpx.RecordConfig           // HTTP 录制回放
+	Endpoints        map[string]map[string]string // 各平台接口地址覆盖
+	QueueQPS         map[string]int               // 各任务队列每分钟请求数
+	RoundMax         int                          // 最大轮次
+	RoundSleep       time.Duration                // 轮次间隔
+	ProjectJobs      int                          // 项目未设置上限时同时运行的采集任务数
+}
+
+// crawlJob 运行中的采集任务，各任务使用独立的调度器、数据通道和爬虫实例
+type crawlJob struct {
+	id        string
+	projectID uint
+	params    *types.CrawlParams
+	startedAt time.Time
+	scheduler *scheduler.Scheduler
+	crawler   reference.Crawler
+	channels  map[string]*flow.Channel
+	wg        sync.WaitGroup
 }
 
 // Manager 负责管理爬虫任务
 type CrawlerManager struct {
-	ctx                context.Context
-	mu                 sync.RWMutex
-	wg                 *sync.WaitGroup
-	running            atomic.Bool
-	crawlerInstance    reference.Crawler
-	signServer         *signer.SignServerClient
-	eventBus           *bus.EventBus
-	sessionManager     *session.Manager
-	scheduler          *scheduler.Scheduler
-	runtimeChannel     chan types.RuntimeData
-	crawlers           map[constants.MediaCode]CrawlerCreator
-	mapDataChannel     map[string]chan types.FetchItemChan
-	currentCrawlParams *types.CrawlParams // 当前轮次参数
+	ctx             context.Context
+	mu              sync.RWMutex
+	signServer      *signer.SignServerClient
+	eventBus        *bus.EventBus
+	sinkManager     *sink.Manager
+	sessionManager  *session.Manager
+	emitRuntime     types.RuntimeEmitter
+	crawlers        map[constants.MediaCode]CrawlerCreator
+	specs           map[constants.MediaCode]reference.CrawlSpec
+	schedulerConfig scheduler.Config
+	channelOptions  map[string]flow.Options
+	recordConfig    httpx.RecordConfig
+	endpoints       map[string]map[string]string
+	queueQPS        map[string]int
+	roundMax        int
+	roundSleep      time.Duration
+	projectJobs     int                  // 项目未设置上限时同时运行的采集任务数
+	running         map[string]*crawlJob // 运行中的采集任务
+	jobs            *jobProjects         // 采集任务所属项目
 }
 
 // NewManager 创建爬虫管理器
@@ -76,56 +136,168 @@ func NewCrawlerManager(
 	config CrawlerManagerConfig,
 	sessionManager *session.Manager,
 	eventBus *bus.EventBus,
-	scheduler *scheduler.Scheduler,
-	runtimeChannel chan types.RuntimeData,
+	sinkManager *sink.Manager,
+	emitRuntime types.RuntimeEmitter,
 ) *CrawlerManager {
+	if config.RoundMax <= 0 {
+		config.RoundMax = ROUND_MAX
+	}
+	if config.RoundSleep <= 0 {
+		config.RoundSleep = time.Duration(ROUND_SLEEP) * time.Second
+	}
+	if config.ProjectJobs <= 0 {
+		config.ProjectJobs = PROJECT_JOBS
+	}
+	// 默认队列速率，配置中的值覆盖默认值
+	queueQPS := map[string]int{"search": 2, "media": 6, "user": 10, "comment": 6}
+	for taskType, qps := range config.QueueQPS {
+		if qps > 0 {
+			queueQPS[taskType] = qps
+		}
+	}
 	cm := &CrawlerManager{
-		ctx:                ctx,
-		running:            atomic.Bool{},
-		wg:                 &sync.WaitGroup{},
-		sessionManager:     sessionManager,
-		eventBus:           eventBus,
-		runtimeChannel:     runtimeChannel,
-		mapDataChannel:     map[string]chan types.FetchItemChan{},
-		crawlers:           make(map[constants.MediaCode]CrawlerCreator),
-		signServer:         signer.NewSignServerClient(config.SignServEndpoint),
-		scheduler:          scheduler,
-		currentCrawlParams: &types.CrawlParams{},
+		ctx:             ctx,
+		sessionManager:  sessionManager,
+		eventBus:        eventBus,
+		sinkManager:     sinkManager,
+		emitRuntime:     emitRuntime,
+		schedulerConfig: config.SchedulerConfig,
+		channelOptions:  config.Channels,
+		recordConfig:    config.Recorder,
+		endpoints:       config.Endpoints,
+		queueQPS:        queueQPS,
+		roundMax:        config.RoundMax,
+		roundSleep:      config.RoundSleep,
+		projectJobs:     config.ProjectJobs,
+		crawlers:        make(map[constants.MediaCode]CrawlerCreator),
+		specs:           make(map[constants.MediaCode]reference.CrawlSpec),
+		signServer:      signer.NewSignServerClient(config.SignServEndpoint),
+		running:         make(map[string]*crawlJob),
+		jobs:            newJobProjects(DefaultJobProjects),
 	}
 
 	// 注册抖音爬虫
-	cm.Register(constants.MediaCodeDouyin, douyin.NewDouyinCrawler)
+	cm.Register(constants.MediaCodeDouyin, douyin.NewDouyinCrawler, douyin.Spec)
+	sessionManager.RegisterProber(constants.MediaCodeDouyin.String(), douyin.NewProber(cm.signServer, cm.endpoints[constants.MediaCodeDouyin.String()]))
 
 	return cm
 }
 
-// Register 注册爬虫
-func (cm *CrawlerManager) Register(media constants.MediaCode, creator CrawlerCreator) {
+// Register 注册爬虫及其参数约束
+func (cm *CrawlerManager) Register(media constants.MediaCode, creator CrawlerCreator, spec reference.CrawlSpec) {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 	cm.crawlers[media] = creator
+	cm.specs[media] = spec
+}
+
+// Validate 按平台约束校验采集参数，参数错误时返回 reference.ParamErrors
+func (cm *CrawlerManager) Validate(crawlParams *types.CrawlParams) error {
+	cm.mu.RLock()
+	spec, exists := cm.specs[constants.MediaCode(crawlParams.MediaCode)]
+	cm.mu.RUnlock()
+	if !exists {
+		return reference.ParamErrors{{
+			Field:   "mediaCode",
+			Message: fmt.Sprintf("不支持的平台 %s", crawlParams.MediaCode),
+		}}
+	}
+	var errs reference.ParamErrors
+	if err := spec.Validate(crawlParams); err != nil {
+		errs = append(errs, err.(reference.ParamErrors)...)
+	}
+	if len(crawlParams.Sinks) > 0 && cm.sinkManager != nil {
+		registered := cm.sinkManager.Status()
+		for _, name := range crawlParams.Sinks {
+			if _, ok := registered[name]; !ok {
+				errs = append(errs, reference.ParamError{
+					Field:   "sinks",
+					Message: fmt.Sprintf("未注册的 sink %s", name),
+				})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
 }
 
 // Create 通过平台名称创建爬虫实例
-func (cm *CrawlerManager) Create(media constants.MediaCode, sessionRegion string) reference.Crawler {
+func (cm *CrawlerManager) Create(ctx context.Context, media constants.MediaCode, sessionRegion string, recorder *httpx.Recorder, jobID string, projectID uint) (reference.Crawler, error) {
 	cm.mu.RLock()
 	defer cm.mu.RUnlock()
 
 	creator, exists := cm.crawlers[media]
 	if !exists {
-		panic(fmt.Sprintf("Invalid Platform: %s", media))
+		return nil, fmt.Errorf("invalid platform: %s", media)
+	}
+	signClient := cm.signServer
+	if recorder != nil {
+		signClient = signClient.WithTransport(recorder.Wrap(nil))
 	}
-	return creator(cm.ctx, sessionRegion, cm.sessionManager, cm.signServer, cm.eventBus)
+	options := reference.CrawlerOptions{
+		JobID:     jobID,
+		ProjectID: projectID,
+		Recorder:  recorder,
+		Endpoints: cm.endpoints[media.String()],
+	}
+	return creator(ctx, sessionRegion, cm.sessionManager, signClient, cm.eventBus, cm.sinkManager, options), nil
 }
 
-// Run 启动爬虫任务
+// buildRecorder 合并全局配置与任务参数创建录制回放器
+func (cm *CrawlerManager) buildRecorder(crawlParams *types.CrawlParams) (*httpx.Recorder, error) {
+	config := cm.recordConfig
+	if crawlParams.Record != nil {
+		if crawlParams.Record.Mode != "" {
+			config.Mode = httpx.RecordMode(crawlParams.Record.Mode)
+		}
+		if crawlParams.Record.Fixture != "" {
+			config.Dir = filepath.Join(config.Dir, filepath.Base(crawlParams.Record.Fixture))
+		}
+	}
+	return httpx.NewRecorder(config)
+}
+
+// Run 启动爬虫任务并等待任务结束，项目已禁用或运行中的任务达到上限时返回错误
 func (cm *CrawlerManager) Run(crawlParams *types.CrawlParams) error {
-	if cm.running.Load() {
-		logger.Log.Infof("Crawler %s is already running", crawlParams.MediaCode)
-		return nil
+	if err := cm.Validate(crawlParams); err != nil {
+		return err
+	}
+	// 检查项目状态并登记任务
+	project, err := (&model.Project{}).Find(crawlParams.ProjectID)
+	if err != nil {
+		return fmt.Errorf("find project %d failed: %w", crawlParams.ProjectID, err)
+	}
+	job, err := cm.acquireJob(project, crawlParams)
+	if err != nil {
+		return err
+	}
+	defer cm.releaseJob(job)
+	// 启动任务的调度器
+	job.scheduler.Reset()
+	ctx := job.scheduler.Context()
+	// 设置采集QPS
+	for taskType, qps := range cm.queueQPS {
+		job.scheduler.SetQueueQPS(str.GenerateStringKey(crawlParams.MediaCode, taskType), qps)
+	}
+	// 初始化录制回放
+	recorder, err := cm.buildRecorder(crawlParams)
+	if err != nil {
+		cm.cleanup(job)
+		return err
+	}
+	if recorder != nil {
+		logger.Log.Infof("Crawler %s http %s mode, fixtures: %s", crawlParams.MediaCode, recorder.Mode(), recorder.Dir())
+	}
+	// 初始化channel
+	channels, err := cm.buildChannels(job.id)
+	if err != nil {
+		cm.cleanup(job)
+		return err
 	}
 	// 发送通知
-	cm.runtimeChannel <- types.NewRuntimeData(types.RuntimeEventCodeNotification, types.EventData{
+	cm.emitRuntime.Emit(types.NewRuntimeData(types.RuntimeEventCodeNotification, types.EventData{
 		Title:     "数据洞察",
 		CheckHash: encrypt.Md5(time.Now().Format("2006-01-02 15:04:05")),
 		Message:   "🧠智识引擎上线，开始处理任务...",
@@ -134,36 +306,44 @@ func (cm *CrawlerManager) Run(crawlParams *types.CrawlParams) error {
 			IsStore:  true,
 			ShowType: "notification",
 		},
-	})
-	// 重置调度器状态
-	cm.scheduler.Reset()
-	// 检查是否已在运行
-	if cm.ctx.Err() != nil {
-		cm.ctx = cm.scheduler.Context()
-	}
-	// 修改running为运行中
-	cm.running.Store(true)
-	// 设置采集QPS
-	cm.scheduler.SetQueueQPS(str.GenerateStringKey(crawlParams.MediaCode, "search"), 2)
-	cm.scheduler.SetQueueQPS(str.GenerateStringKey(crawlParams.MediaCode, "media"), 6)
-	cm.scheduler.SetQueueQPS(str.GenerateStringKey(crawlParams.MediaCode, "user"), 10)
-	cm.scheduler.SetQueueQPS(str.GenerateStringKey(crawlParams.MediaCode, "comment"), 6)
-	// 初始化channel
-	cm.mapDataChannel = map[string]chan types.FetchItemChan{
-		"media":   make(chan types.FetchItemChan, 1000),
-		"comment": make(chan types.FetchItemChan, 1000),
-		"user":    make(chan types.FetchItemChan, 1000),
+	}).WithJob(job.id))
+	for name, ch := range channels {
+		cm.watchSaturation(job.id, name, ch)
 	}
+	cm.mu.Lock()
+	job.channels = channels
+	cm.mu.Unlock()
 	// 获取爬虫实例
-	cm.crawlerInstance = cm.Create(constants.MediaCode(crawlParams.MediaCode), crawlParams.Region)
+	crawlerInstance, err := cm.Create(ctx, constants.MediaCode(crawlParams.MediaCode), crawlParams.Region, recorder, job.id, crawlParams.ProjectID)
+	if err != nil {
+		cm.cleanup(job)
+		return err
+	}
+	job.crawler = crawlerInstance
 	// 初始化爬虫
-	cm.crawlerInstance.Initialize(cm.scheduler, cm.runtimeChannel, cm.mapDataChannel)
-	// 设置初始采集参数
-	cm.currentCrawlParams = crawlParams
+	job.crawler.Initialize(job.scheduler, cm.emitRuntime, channels)
+	// 记录结束原因，轮次正常结束时为 RoundMaxed
+	endCode := &atomic.Int64{}
+	endCode.Store(int64(types.CrawlEndCodeRoundMaxed))
+	// 先记录结束原因再关闭调度器，确保任务结束事件携带该原因
+	endSub := bus.Subscribe[types.JobStoppingEvent](cm.eventBus, bus.Options{Mode: bus.ModeUnbounded})
+	go func() {
+		for event := range endSub.C() {
+			if event.JobID == job.id {
+				endCode.Store(int64(event.Code))
+				job.scheduler.Shutdown()
+			}
+		}
+	}()
+	defer endSub.Cancel()
+	cm.eventBus.Publish(types.JobStartedEvent{
+		EventMeta: types.NewEventMeta(job.id),
+		Params:    crawlParams,
+	})
 	// 启动处理数据线程
-	for taskType := range cm.mapDataChannel {
-		cm.wg.Add(1)
-		go cm.processChannel(cm.mapDataChannel[taskType])
+	for _, ch := range channels {
+		job.wg.Add(1)
+		go cm.processChannel(ctx, job, ch)
 	}
 	// 定义是否有子任务参数
 	var jobPayloads []interface{}
@@ -199,10 +379,13 @@ func (cm *CrawlerManager) Run(crawlParams *types.CrawlParams) error {
 			})
 		}
 	default:
-		return errors.New("Unsupport CrawlerType")
+		err := errors.New("Unsupport CrawlerType")
+		cm.cleanup(job)
+		cm.finishJob(job, types.CrawlEndCode(endCode.Load()), err)
+		return err
 	}
 
-	roundSignal := make(chan struct{}, ROUND_MAX*2)
+	roundSignal := make(chan struct{}, cm.roundMax*2)
 	roundSignal <- struct{}{}
 
 	roundWg := &sync.WaitGroup{}
@@ -215,34 +398,34 @@ func (cm *CrawlerManager) Run(crawlParams *types.CrawlParams) error {
 			select {
 			case <-roundSignal:
 				// 超出次数，跳出循环
-				if currentRound >= ROUND_MAX {
+				if currentRound >= cm.roundMax {
 					return
 				}
-				logger.Log.Infof("Start crawl task round check %d，MediaCode %s", currentRound, crawlParams.MediaCode)
+				logger.Log.Infof("Start crawl task round check %d，MediaCode %s, JobID %s", currentRound, crawlParams.MediaCode, job.id)
 				// 提交任务
 				for _, payload := range jobPayloads {
-					err := cm.crawlerInstance.SubmitJob(
-						crawlParams.CrawlType, payload, scheduler.TaskOptions{},
+					err := job.crawler.SubmitJob(
+						crawlParams.CrawlType, payload, scheduler.TaskOptions{JobID: job.id},
 					)
 					if err != nil {
 						logger.Log.Error(err.Error())
 					}
 				}
 				// 等待本轮任务完成
-				cm.scheduler.WaitUntilEmpty()
+				job.scheduler.WaitUntilEmpty()
 				// 增加轮次计数
 				currentRound++
 				// 如果还有下一轮，暂停一段时间（可配置）
-				if currentRound < ROUND_MAX {
+				if currentRound < cm.roundMax {
 					select {
-					case <-time.After(time.Duration(ROUND_SLEEP) * time.Second):
-					case <-cm.ctx.Done():
+					case <-time.After(cm.roundSleep):
+					case <-ctx.Done():
 						return
 					}
 				}
 				// 添加信号
 				roundSignal <- struct{}{}
-			case <-cm.ctx.Done():
+			case <-ctx.Done():
 				return
 			}
 		}
@@ -250,54 +433,191 @@ func (cm *CrawlerManager) Run(crawlParams *types.CrawlParams) error {
 
 	roundWg.Wait()
 
-	if cm.ctx.Err() == nil {
-		cm.eventBus.Publish(types.CrawlEndEvent{
-			Code:      types.CrawlEndCodeRoundMaxed,
-			ReceiveAt: time.Now(),
-		})
+	if ctx.Err() == nil {
+		cm.publishEnd(job.id, types.CrawlEndCodeRoundMaxed)
 	}
 
-	cm.cleanup()
+	cm.cleanup(job)
+	cm.finishJob(job, types.CrawlEndCode(endCode.Load()), nil)
 
 	return nil
 }
 
+// CheckProject 检查项目能否启动采集任务，已禁用时返回 ErrProjectDisabled，运行中的任务达到上限时返回 ErrProjectJobLimit
+func (cm *CrawlerManager) CheckProject(project *model.Project) error {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.checkProject(project)
+}
+
+// checkProject 同 CheckProject，调用方需持有 mu
+func (cm *CrawlerManager) checkProject(project *model.Project) error {
+	if project.Status == model.ProjectStatusDisabled {
+		return ErrProjectDisabled
+	}
+	limit := project.MaxConcurrentJobs
+	if limit <= 0 {
+		limit = cm.projectJobs
+	}
+	if len(cm.jobsOf(project.ID)) >= limit {
+		return ErrProjectJobLimit
+	}
+	return nil
+}
+
+// acquireJob 检查项目并登记采集任务，任务结束时调用 releaseJob
+func (cm *CrawlerManager) acquireJob(project *model.Project, crawlParams *types.CrawlParams) (*crawlJob, error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if err := cm.checkProject(project); err != nil {
+		return nil, err
+	}
+	job := &crawlJob{
+		id:        uuid.New().String(),
+		projectID: project.ID,
+		params:    crawlParams,
+		startedAt: time.Now(),
+		scheduler: scheduler.New(cm.ctx, cm.schedulerConfig),
+	}
+	job.scheduler.SetEmitter(cm.eventBus.Publish)
+	cm.running[job.id] = job
+	cm.jobs.bind(job.id, project.ID)
+	return job, nil
+}
+
+// releaseJob 移除已结束的采集任务
+func (cm *CrawlerManager) releaseJob(job *crawlJob) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	delete(cm.running, job.id)
+}
+
+// jobsOf 项目运行中的采集任务，按启动时间排序，调用方需持有 mu
+func (cm *CrawlerManager) jobsOf(projectID uint) []*crawlJob {
+	var jobs []*crawlJob
+	for _, job := range cm.running {
+		if job.projectID == projectID {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].startedAt.Before(jobs[j].startedAt)
+	})
+	return jobs
+}
+
+// ProjectJobs 项目运行中的采集任务数
+func (cm *CrawlerManager) ProjectJobs(projectID uint) int {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return len(cm.jobsOf(projectID))
+}
+
+// JobProject 采集任务所属的项目，包括已结束的任务
+func (cm *CrawlerManager) JobProject(jobID string) uint {
+	return cm.jobs.Project(jobID)
+}
+
+// finishJob 发布采集任务结束事件
+func (cm *CrawlerManager) finishJob(job *crawlJob, code types.CrawlEndCode, err error) {
+	event := types.JobFinishedEvent{
+		EventMeta: types.NewEventMeta(job.id),
+		Code:      code,
+		StartedAt: job.startedAt,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	cm.eventBus.Publish(event)
+}
+
+// watchSaturation 通道饱和时发布事件
+func (cm *CrawlerManager) watchSaturation(jobID, name string, ch *flow.Channel) {
+	ch.OnSaturated(func(stats flow.Stats) {
+		cm.eventBus.Publish(types.ChannelSaturatedEvent{
+			EventMeta:  types.NewEventMeta(jobID),
+			Channel:    name,
+			Length:     stats.Length,
+			Capacity:   stats.Capacity,
+			Saturation: stats.Saturation,
+			SpillDepth: stats.SpillDepth,
+		})
+	})
+}
+
+// buildChannels 按配置创建任务的数据通道，溢写文件放在任务各自的目录下
+func (cm *CrawlerManager) buildChannels(jobID string) (map[string]*flow.Channel, error) {
+	channels := make(map[string]*flow.Channel)
+	for _, name := range []string{"media", "comment", "user"} {
+		opts := cm.channelOptions[name]
+		if opts.SpillDir != "" {
+			opts.SpillDir = filepath.Join(opts.SpillDir, jobID)
+		}
+		ch, err := flow.NewChannel(name, opts)
+		if err != nil {
+			for _, created := range channels {
+				created.Close()
+			}
+			return nil, fmt.Errorf("build %s channel failed: %v", name, err)
+		}
+		channels[name] = ch
+	}
+	return channels, nil
+}
+
 // processChannel 通用通道处理函数
-func (cm *CrawlerManager) processChannel(ch chan types.FetchItemChan) {
-	defer cm.wg.Done()
+func (cm *CrawlerManager) processChannel(ctx context.Context, job *crawlJob, ch *flow.Channel) {
+	defer job.wg.Done()
 	for {
 		select {
-		case item, ok := <-ch:
+		case item, ok := <-ch.C():
 			if !ok {
 				return
 			}
 			if item.Data == nil {
 				continue
 			}
-			cm.mu.RLock()
-			params := cm.currentCrawlParams
-			cm.mu.RUnlock()
-			if err := cm.crawlerInstance.HandleChannel(item, params); err != nil {
+			if err := job.crawler.HandleChannel(item, job.params); err != nil {
 				logger.Log.Errorf("TaskID=%s: SubmitSubTasks error: %v", item.TaskId, err)
 			}
-		case <-cm.ctx.Done():
+		case <-ctx.Done():
 			return
 		}
 	}
 }
 
-// Status 返回爬虫管理器的当前状态
-func (cm *CrawlerManager) Status() *CrawlerStatus {
+// Status 返回项目运行中采集任务的状态
+func (cm *CrawlerManager) Status(projectID uint) *CrawlerStatus {
 	cm.mu.RLock()
 	defer cm.mu.RUnlock()
 
-	// 通道状态
-	channels := make(map[string]*ChannelInfo)
-	for key, ch := range cm.mapDataChannel {
-		channels[key] = &ChannelInfo{
-			Length:   len(ch),
-			Capacity: cap(ch),
+	jobs := make([]*JobStatus, 0)
+	for _, job := range cm.jobsOf(projectID) {
+		// 通道状态
+		channels := make(map[string]*ChannelInfo)
+		for key, ch := range job.channels {
+			stats := ch.Stats()
+			channels[key] = &ChannelInfo{
+				Length:     stats.Length,
+				Capacity:   stats.Capacity,
+				Policy:     stats.Policy,
+				Saturation: stats.Saturation,
+				Saturated:  stats.Saturated,
+				Sent:       stats.Sent,
+				Dropped:    stats.Dropped,
+				TimedOut:   stats.TimedOut,
+				Spilled:    stats.Spilled,
+				SpillDepth: stats.SpillDepth,
+			}
 		}
+		jobs = append(jobs, &JobStatus{
+			JobID:     job.id,
+			MediaCode: job.params.MediaCode,
+			CrawlType: job.params.CrawlType,
+			StartedAt: job.startedAt,
+			Paused:    job.scheduler.IsPaused(),
+			Channels:  channels,
+		})
 	}
 
 	// 支持的媒体平台
@@ -307,40 +627,80 @@ func (cm *CrawlerManager) Status() *CrawlerStatus {
 	}
 
 	return &CrawlerStatus{
-		Running:        cm.running.Load(),
-		ContextActive:  cm.ctx.Err() == nil,
-		CrawlerActive:  cm.crawlerInstance != nil,
-		Channels:       channels,
+		Running:        len(jobs) > 0,
+		ProjectID:      projectID,
+		Jobs:           jobs,
 		SupportedMedia: supportedMedia,
 	}
 }
 
-// Stop 停止爬虫任务
-func (cm *CrawlerManager) Stop() {
-	if !cm.running.Load() {
-		return
+// Pause 暂停项目运行中任务的调度
+func (cm *CrawlerManager) Pause(projectID uint) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	for _, job := range cm.jobsOf(projectID) {
+		job.scheduler.Pause()
 	}
-	cm.eventBus.Publish(types.CrawlEndEvent{
-		Code:      types.CrawlEndCodeForcedStop,
-		ReceiveAt: time.Now(),
-	})
 }
 
-// cleanup 清理资源
-func (cm *CrawlerManager) cleanup() {
-	// 关闭所有chan
-	for _, channel := range cm.mapDataChannel {
-		close(channel)
+// Resume 恢复项目运行中任务的调度
+func (cm *CrawlerManager) Resume(projectID uint) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	for _, job := range cm.jobsOf(projectID) {
+		job.scheduler.Resume()
 	}
-	cm.mapDataChannel = make(map[string]chan types.FetchItemChan)
-	// 等待采集程序process结束
-	cm.wg.Wait()
-	// 删除当前爬虫实例
-	cm.crawlerInstance = nil
-	cm.running.Store(false)
+}
+
+// TaskTree 项目运行中各任务的任务树，按任务ID索引
+func (cm *CrawlerManager) TaskTree(projectID uint) map[string]interface{} {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	trees := make(map[string]interface{})
+	for _, job := range cm.jobsOf(projectID) {
+		trees[job.id] = job.scheduler.GetTaskTree()
+	}
+	return trees
+}
+
+// Stop 停止项目运行中的采集任务
+func (cm *CrawlerManager) Stop(projectID uint) {
+	cm.mu.RLock()
+	jobs := cm.jobsOf(projectID)
+	cm.mu.RUnlock()
+	for _, job := range jobs {
+		cm.publishEnd(job.id, types.CrawlEndCodeForcedStop)
+	}
+}
 
-	// 清除采集参数
+// publishEnd 同步发布结束采集任务的请求，确保监听方收到后再返回
+func (cm *CrawlerManager) publishEnd(jobID string, code types.CrawlEndCode) {
+	err := cm.eventBus.PublishSync(cm.ctx, types.JobStoppingEvent{
+		EventMeta: types.NewEventMeta(jobID),
+		Code:      code,
+	})
+	if err != nil {
+		logger.Log.Warnf("Publish job %s stopping event %d failed: %v", jobID, code, err)
+	}
+}
+
+// cleanup 停止任务的调度器，关闭数据通道并等待处理协程结束
+func (cm *CrawlerManager) cleanup(job *crawlJob) {
+	job.scheduler.Shutdown()
 	cm.mu.Lock()
-	cm.currentCrawlParams = nil
+	channels := job.channels
+	job.channels = nil
 	cm.mu.Unlock()
+	// 关闭所有chan
+	for _, channel := range channels {
+		channel.Close()
+	}
+	// 等待采集程序process结束
+	job.wg.Wait()
+	// 删除任务的溢写目录，目录非空时保留
+	for _, opts := range cm.channelOptions {
+		if opts.SpillDir != "" {
+			_ = os.Remove(filepath.Join(opts.SpillDir, job.id))
+		}
+	}
 }
@@ -0,0 +1,347 @@
+package kernel
+
+import (
+	"context"
+	"noctua/internal/media/douyin/douyintest"
+	"noctua/internal/model"
+	"noctua/internal/proxy"
+	"noctua/kernel/broadcast"
+	"noctua/kernel/bus"
+	"noctua/kernel/flow"
+	"noctua/kernel/session"
+	"noctua/kernel/sink"
+	"noctua/pkg/database"
+	"noctua/types"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countSink 按类型统计写入数量
+type countSink struct {
+	mu     sync.Mutex
+	counts map[sink.Kind]int
+}
+
+func (c *countSink) Name() string { return "count" }
+
+func (c *countSink) Write(_ context.Context, records []sink.Record) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, record := range records {
+		c.counts[record.Kind]++
+	}
+	return nil
+}
+
+func (c *countSink) Close() error { return nil }
+
+func (c *countSink) count(kind sink.Kind) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[kind]
+}
+
+// offlineEnv 连接假抖音接口的爬虫管理器及其依赖
+type offlineEnv struct {
+	cm       *CrawlerManager
+	eventBus *bus.EventBus
+	server   *douyintest.Server
+	counter  *countSink
+}
+
+// createOfflineAccount 在项目下创建可用的抖音账号
+func createOfflineAccount(t *testing.T, projectID uint) {
+	account := &model.MediaAccount{
+		ProjectID: projectID,
+		MediaCode: "douyin",
+		Type:      1,
+		UserID:    "test-account",
+		UID:       "10000",
+		Nickname:  "tester",
+		Cookie:    `[{"name":"sessionid","value":"x"}]`,
+		Status:    10,
+	}
+	assert.NoError(t, database.DB.Create(account).Error)
+}
+
+func newOfflineEnv(t *testing.T, roundMax int, roundSleep time.Duration) *offlineEnv {
+	createOfflineAccount(t, model.DefaultProjectID)
+
+	server := douyintest.NewServer(douyintest.Options{Awemes: 20, Comments: 6, CommentPageSize: 4})
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	counter := &countSink{counts: make(map[sink.Kind]int)}
+	sinks := sink.NewManager(ctx, sink.Config{Default: []string{"count"}})
+	assert.NoError(t, sinks.Register(counter, sink.SinkConfig{BatchSize: 10, FlushInterval: 10 * time.Millisecond}))
+	t.Cleanup(sinks.Close)
+
+	proxyPool := proxy.NewProxyPool(ctx, proxy.ProxyPoolConfig{})
+	t.Cleanup(proxyPool.Stop)
+	runtime := broadcast.New(broadcast.Config{})
+	t.Cleanup(runtime.Close)
+
+	eventBus := bus.NewEventBus(10)
+	t.Cleanup(eventBus.Close)
+	sessionManager := session.NewManager(proxyPool)
+	sessionManager.SetEmitter(eventBus.Publish)
+
+	cm := NewCrawlerManager(
+		ctx,
+		CrawlerManagerConfig{
+			SignServEndpoint: server.URL,
+			Endpoints: map[string]map[string]string{
+				"douyin": {
+					"api":      server.URL,
+					"index":    server.URL,
+					"ms_token": server.MsTokenURL(),
+					"webid":    server.WebIDURL(),
+				},
+			},
+			QueueQPS:   map[string]int{"search": 6000, "media": 6000, "user": 6000, "comment": 6000},
+			RoundMax:   roundMax,
+			RoundSleep: roundSleep,
+		},
+		sessionManager,
+		eventBus,
+		sinks,
+		runtime.Publish,
+	)
+	return &offlineEnv{cm: cm, eventBus: eventBus, server: server, counter: counter}
+}
+
+func TestCrawlerManagerRunOffline(t *testing.T) {
+	env := newOfflineEnv(t, 1, 0)
+	cm, server, counter := env.cm, env.server, env.counter
+	events := bus.Subscribe[types.LifecycleEvent](env.eventBus, bus.Options{Topic: "#", Mode: bus.ModeUnbounded})
+
+	// 后台收集生命周期事件，结束任务的请求同步发布，需有订阅方持续消费
+	topics := make(map[string]int)
+	jobIDs := make(map[string]bool)
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		for event := range events.C() {
+			topics[event.Topic()]++
+			jobIDs[event.Meta().JobID] = true
+			if event.Topic() == types.TopicJobFinished {
+				return
+			}
+		}
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cm.Run(&types.CrawlParams{
+			MediaCode:   "douyin",
+			CrawlType:   "search",
+			Keywords:    []string{"golang"},
+			MaxCount:    20,
+			WithComment: true,
+		})
+	}()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(60 * time.Second):
+		t.Fatal("crawler run timeout")
+	}
+
+	// 搜索两页，每个作品两页评论
+	assert.Equal(t, 2, server.Hits(douyintest.PathSearch))
+	assert.Equal(t, 40, server.Hits(douyintest.PathComment))
+	assert.Equal(t, server.Hits(douyintest.PathSearch), server.Signed(douyintest.PathSearch))
+	assert.Eventually(t, func() bool {
+		return counter.count(sink.KindMedia) == 20 && counter.count(sink.KindComment) == 120
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.False(t, cm.Status(model.DefaultProjectID).Running)
+
+	// 生命周期事件均携带同一个 JobID，共 2 个搜索任务和 40 个评论任务
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("wait job finished event timeout")
+	}
+	assert.Equal(t, 1, topics[types.TopicJobStarted])
+	assert.Equal(t, 1, topics[types.TopicJobStopping])
+	assert.Equal(t, 42, topics[types.TopicTaskSubmitted])
+	assert.Equal(t, 42, topics[types.TopicTaskSucceeded])
+	assert.Equal(t, topics[types.TopicTaskStarted], topics[types.TopicTaskSucceeded])
+	assert.Greater(t, topics[types.TopicSessionAcquired], 0)
+	assert.Len(t, jobIDs, 1)
+	assert.NotContains(t, jobIDs, "")
+}
+
+func TestCrawlerManagerStop(t *testing.T) {
+	env := newOfflineEnv(t, 2, time.Minute)
+	started := bus.Subscribe[types.JobStartedEvent](env.eventBus, bus.Options{Mode: bus.ModeUnbounded})
+	defer started.Cancel()
+	finished := bus.Subscribe[types.JobFinishedEvent](env.eventBus, bus.Options{Mode: bus.ModeUnbounded})
+	defer finished.Cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- env.cm.Run(&types.CrawlParams{MediaCode: "douyin", CrawlType: "search", Keywords: []string{"golang"}, MaxCount: 20})
+	}()
+	var jobID string
+	select {
+	case event := <-started.C():
+		jobID = event.JobID
+	case <-time.After(10 * time.Second):
+		t.Fatal("wait job started timeout")
+	}
+
+	// 用户停止的任务以 ForcedStop 结束，不等待下一轮
+	env.cm.Stop(model.DefaultProjectID)
+	select {
+	case event := <-finished.C():
+		assert.Equal(t, jobID, event.JobID)
+		assert.Equal(t, types.CrawlEndCodeForcedStop, event.Code)
+	case <-time.After(10 * time.Second):
+		t.Fatal("wait job finished timeout")
+	}
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("crawler run timeout")
+	}
+	assert.False(t, env.cm.Status(model.DefaultProjectID).Running)
+}
+
+func TestProjectJobScope(t *testing.T) {
+	project := &model.Project{Code: "scoped" + strconv.FormatInt(time.Now().UnixNano(), 36), Status: model.ProjectStatusNormal}
+	assert.NoError(t, project.Create())
+	cm := &CrawlerManager{ctx: context.Background(), projectJobs: 1, running: make(map[string]*crawlJob), jobs: newJobProjects(2)}
+	assert.NoError(t, cm.CheckProject(project))
+
+	// 只向任务所属项目返回任务和通道信息
+	job, err := cm.acquireJob(project, &types.CrawlParams{MediaCode: "douyin", CrawlType: "search"})
+	assert.NoError(t, err)
+	channel, err := flow.NewChannel("media", flow.Options{Capacity: 1})
+	assert.NoError(t, err)
+	job.channels = map[string]*flow.Channel{"media": channel}
+	status := cm.Status(project.ID)
+	assert.True(t, status.Running)
+	assert.Len(t, status.Jobs, 1)
+	assert.Equal(t, job.id, status.Jobs[0].JobID)
+	assert.Len(t, status.Jobs[0].Channels, 1)
+	status = cm.Status(model.DefaultProjectID)
+	assert.False(t, status.Running)
+	assert.Empty(t, status.Jobs)
+
+	// 未设置上限的项目使用默认上限，设置后按项目上限
+	assert.ErrorIs(t, cm.CheckProject(project), ErrProjectJobLimit)
+	_, err = cm.acquireJob(project, &types.CrawlParams{})
+	assert.ErrorIs(t, err, ErrProjectJobLimit)
+	assert.NoError(t, cm.CheckProject(model.DefaultProject()))
+	project.MaxConcurrentJobs = 2
+	assert.NoError(t, cm.CheckProject(project))
+	project.Status = model.ProjectStatusDisabled
+	assert.ErrorIs(t, cm.CheckProject(project), ErrProjectDisabled)
+	cm.releaseJob(job)
+	assert.Zero(t, cm.ProjectJobs(project.ID))
+
+	// 已结束任务的所属项目保留到被新任务挤出
+	assert.Equal(t, project.ID, cm.JobProject(job.id))
+	cm.jobs.bind("job-2", 2)
+	cm.jobs.bind("job-3", 3)
+	assert.Zero(t, cm.JobProject(job.id))
+	assert.Equal(t, uint(3), cm.JobProject("job-3"))
+}
+
+func TestProjectConcurrentJobs(t *testing.T) {
+	env := newOfflineEnv(t, 2, time.Minute)
+	project := &model.Project{Code: "concurrent" + strconv.FormatInt(time.Now().UnixNano(), 36), Status: model.ProjectStatusNormal}
+	assert.NoError(t, project.Create())
+	createOfflineAccount(t, project.ID)
+	started := bus.Subscribe[types.JobStartedEvent](env.eventBus, bus.Options{Mode: bus.ModeUnbounded})
+	defer started.Cancel()
+	finished := bus.Subscribe[types.JobFinishedEvent](env.eventBus, bus.Options{Mode: bus.ModeUnbounded})
+	defer finished.Cancel()
+
+	// 两个项目同时运行任务，各自等待下一轮
+	done := make(chan error, 2)
+	for _, projectID := range []uint{model.DefaultProjectID, project.ID} {
+		go func(projectID uint) {
+			done <- env.cm.Run(&types.CrawlParams{ProjectID: projectID, MediaCode: "douyin", CrawlType: "search", Keywords: []string{"golang"}, MaxCount: 20})
+		}(projectID)
+	}
+	jobIDs := make(map[uint]string)
+	for len(jobIDs) < 2 {
+		select {
+		case event := <-started.C():
+			jobIDs[env.cm.JobProject(event.JobID)] = event.JobID
+		case <-time.After(10 * time.Second):
+			t.Fatalf("wait jobs started timeout, got %v", jobIDs)
+		}
+	}
+	for projectID, jobID := range jobIDs {
+		status := env.cm.Status(projectID)
+		assert.Len(t, status.Jobs, 1)
+		assert.Equal(t, jobID, status.Jobs[0].JobID)
+	}
+	// 两个任务各搜索两页
+	assert.Eventually(t, func() bool {
+		return env.server.Hits(douyintest.PathSearch) == 4
+	}, 10*time.Second, 10*time.Millisecond)
+
+	// 达到项目上限时拒绝新任务，不影响其他项目
+	err := env.cm.Run(&types.CrawlParams{ProjectID: project.ID, MediaCode: "douyin", CrawlType: "search", Keywords: []string{"golang"}, MaxCount: 20})
+	assert.ErrorIs(t, err, ErrProjectJobLimit)
+
+	// 停止一个项目的任务，另一个项目的任务继续运行
+	env.cm.Stop(project.ID)
+	select {
+	case event := <-finished.C():
+		assert.Equal(t, jobIDs[project.ID], event.JobID)
+		assert.Equal(t, types.CrawlEndCodeForcedStop, event.Code)
+	case <-time.After(10 * time.Second):
+		t.Fatal("wait job finished timeout")
+	}
+	assert.Eventually(t, func() bool {
+		return env.cm.ProjectJobs(project.ID) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, env.cm.ProjectJobs(model.DefaultProjectID))
+
+	env.cm.Stop(model.DefaultProjectID)
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("crawler run timeout")
+		}
+	}
+}
+
+func TestCrawlDataProjectScope(t *testing.T) {
+	// 清理本次写入的数据，-count 多次运行时旧索引仍可创建
+	mediaID := "scoped-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	t.Cleanup(func() {
+		database.DB.Unscoped().Where("media_id = ?", mediaID).Delete(&model.CrawlMedia{})
+	})
+	// 旧库的全局唯一索引在迁移时删除，不同项目可以保存同一条数据
+	assert.NoError(t, database.DB.Exec("CREATE UNIQUE INDEX idx_crawl_media_media_id ON crawl_media(media_id)").Error)
+	assert.NoError(t, model.MigrateProjectScope())
+	assert.False(t, database.DB.Migrator().HasIndex(&model.CrawlMedia{}, "idx_crawl_media_media_id"))
+
+	for _, projectID := range []uint{1, 2, 2} {
+		media := &model.CrawlMedia{ProjectID: projectID, MediaCode: "douyin", MediaID: mediaID, Title: "v1"}
+		assert.NoError(t, media.UpsertModel())
+	}
+	for _, projectID := range []uint{1, 2} {
+		result, err := (&model.CrawlMedia{}).List(&model.CrawlMediaQueryParams{ProjectID: projectID, MediaID: mediaID}, "id", "asc", 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), result.Total)
+	}
+	result, err := (&model.CrawlMedia{}).List(&model.CrawlMediaQueryParams{ProjectID: 3, MediaID: mediaID}, "id", "asc", 1, 10)
+	assert.NoError(t, err)
+	assert.Zero(t, result.Total)
+}
@@ -3,32 +3,48 @@ package douyin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
+	"net/http"
 	"noctua/internal/constants"
 	"noctua/internal/media/douyin"
 	"noctua/internal/model"
 	"noctua/internal/scheduler"
 	"noctua/internal/signer"
 	"noctua/kernel/bus"
+	"noctua/kernel/flow"
 	"noctua/kernel/reference"
 	"noctua/kernel/session"
+	"noctua/kernel/sink"
 	"noctua/pkg/logger"
 	"noctua/pkg/utils/str"
 	"noctua/types"
 	"time"
 )
 
+// leaseWaitInterval 可用账号均被租用时重新获取会话的间隔
+const leaseWaitInterval = 100 * time.Millisecond
+
 // DouyinCrawlerCrawler 具体的抖音爬虫
 type DouyinCrawler struct {
-	ctx            context.Context
-	mediaCode      constants.MediaCode
-	scheduler      *scheduler.Scheduler
-	eventBus       *bus.EventBus
-	dataFetcher    *DouyinFetcher
-	dataSaver      *DouyinDataSaver
-	channels       map[string]chan types.FetchItemChan
-	runtimeChannel chan types.RuntimeData
+	ctx         context.Context
+	jobID       string
+	projectID   uint
+	mediaCode   constants.MediaCode
+	scheduler   *scheduler.Scheduler
+	eventBus    *bus.EventBus
+	dataFetcher *DouyinFetcher
+	dataSaver   *DouyinDataSaver
+	channels    map[string]*flow.Channel
+	emitRuntime types.RuntimeEmitter
+}
+
+func init() {
+	// 注册通道数据类型，供磁盘溢写使用
+	flow.RegisterType(douyin.Aweme{})
+	flow.RegisterType(douyin.Comment{})
+	flow.RegisterType(douyin.User{})
 }
 
 // NewDouyinCrawler 创建 DouyinCrawler 实例
@@ -38,74 +54,118 @@ func NewDouyinCrawler(
 	sessionManager *session.Manager,
 	signClient *signer.SignServerClient,
 	eventBus *bus.EventBus,
+	sinks *sink.Manager,
+	options reference.CrawlerOptions,
 ) reference.Crawler {
 	dc := &DouyinCrawler{
 		ctx:       ctx,
+		jobID:     options.JobID,
+		projectID: options.ProjectID,
 		eventBus:  eventBus,
 		mediaCode: constants.MediaCodeDouyin,
-		dataSaver: &DouyinDataSaver{},
+		dataSaver: NewDouyinDataSaver(sinks, options.ProjectID),
 	}
-	// 创建data fetcher
-	dataFetcher := NewDouyinFetcher(dc.ctx, signClient)
-	// 设置获取session callback func
-	dataFetcher.dataClient.OnAcquireSession(func(nowSession *types.Session) (*types.Session, error) {
-		var currSession *types.Session
-		params := &session.SessionParams{MediaCode: "douyin", SessionRegion: sessionRegion, AccountType: 1}
-		if nowSession != nil && nowSession.Enabled {
-			params.UserID = nowSession.Account.UserID
-		}
-		for {
+	// 每个任务从池中借用绑定单个会话的客户端，借用期间持有会话租约，客户端之间不共享会话，同一任务树使用同一账号和代理
+	clients := NewClientPool(func(pc *pooledClient) {
+		client := douyin.NewDouYinApiClient(signClient)
+		client.SetRecorder(options.Recorder)
+		client.SetEndpoints(douyin.Endpoints{
+			API:     options.Endpoints["api"],
+			Index:   options.Endpoints["index"],
+			MsToken: options.Endpoints["ms_token"],
+			WebID:   options.Endpoints["webid"],
+		})
+		// 设置获取session callback func，获取的会话持有租约，归还客户端时释放
+		client.OnAcquireSession(func(nowSession *types.Session) (*types.Session, error) {
+			params := &session.SessionParams{
+				MediaCode:     "douyin",
+				SessionRegion: sessionRegion,
+				AccountType:   1,
+				ProjectID:     options.ProjectID,
+				JobID:         options.JobID,
+				AffinityKey:   pc.affinityKey,
+			}
+			if nowSession != nil && nowSession.Enabled {
+				params.UserID = nowSession.Account.UserID
+			}
+			for {
+				newSession, _, err := sessionManager.Acquire(params)
+				if !errors.Is(err, session.ErrAllLeased) {
+					return newSession, err
+				}
+				// 可用账号均被其他客户端租用时等待归还
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(leaseWaitInterval):
+				}
+			}
+		})
+		// 释放会话租约，账号失效时租约已随会话移除
+		client.OnReleaseSession(func(leaseID string) {
+			if err := sessionManager.Release(leaseID); err != nil && !errors.Is(err, session.ErrLeaseNotFound) {
+				logger.Log.Warnf("Release session lease %s failed: %v", leaseID, err)
+			}
+		})
+		// 设置刷新session callback func
+		client.OnRefreshSession(func(nowSession *types.Session) (*types.Session, error) {
+			// 参数校验
+			if nowSession == nil || nowSession.Account == nil || nowSession.Account.UserID == "" {
+				return nil, fmt.Errorf("invalid session: session and account details are required")
+			}
 			// 增加判断当前可用账号统计的判断
-			newSession, err := sessionManager.GetSession(params)
+			newSession, err := sessionManager.ReplaceSession(
+				nowSession.Account.MediaCode,
+				nowSession.Account.UserID,
+				sessionRegion,
+			)
 			if err != nil {
 				return nil, err
 			}
-			currSession = newSession
-			break
-		}
-		return currSession, nil
-	})
-	// 设置刷新session callback func
-	dataFetcher.dataClient.OnRefreshSession(func(nowSession *types.Session) (*types.Session, error) {
-		// 参数校验
-		if nowSession == nil || nowSession.Account == nil || nowSession.Account.UserID == "" {
-			return nil, fmt.Errorf("invalid session: session and account details are required")
-		}
-		// 增加判断当前可用账号统计的判断
-		newSession, err := sessionManager.ReplaceSession(
-			nowSession.Account.MediaCode,
-			nowSession.Account.UserID,
-			sessionRegion,
-		)
-		if err != nil {
-			return nil, err
-		}
-		return newSession, nil
-	})
-	// 设置丢弃session callback func
-	dataFetcher.dataClient.OnDiscardSession(func(currSession *types.Session) {
-		err := sessionManager.InvalidateSession(currSession)
-		if err != nil {
-			logger.Log.Errorf("sessionManager.InvalidateSession err: %v", err)
-			return
-		}
-	})
-	// 处理session无法找到有效账号
-	dataFetcher.dataClient.OnMissingSession(func() {
-		dc.eventBus.Publish(types.CrawlEndEvent{
-			Code:      10,
-			ReceiveAt: time.Now(),
+			return newSession, nil
 		})
-	})
-	dc.dataFetcher = dataFetcher
+		// 设置丢弃session callback func
+		client.OnDiscardSession(func(currSession *types.Session) {
+			if currSession != nil && currSession.Account != nil {
+				dc.eventBus.Publish(types.AccountBlockedEvent{
+					EventMeta: types.NewEventMeta(options.JobID),
+					MediaCode: currSession.Account.MediaCode,
+					UserID:    currSession.Account.UserID,
+					Reason:    "blocked or empty response",
+				})
+			}
+			err := sessionManager.InvalidateSession(currSession, "blocked")
+			if err != nil {
+				logger.Log.Errorf("sessionManager.InvalidateSession err: %v", err)
+				return
+			}
+		})
+		// 账号触发限流后进入冷却，后续请求更换账号
+		client.OnRateLimited(sessionManager.ReportRateLimit)
+		// 上报代理请求结果，用于代理评分和熔断
+		client.OnProxyResult(sessionManager.ReportProxyResult)
+		// 合并响应中更新的 Cookie
+		client.OnResponseCookies(func(currSession *types.Session, cookies []*http.Cookie) {
+			sessionManager.MergeCookies(currSession, cookies)
+		})
+		// 处理session无法找到有效账号
+		client.OnMissingSession(func() {
+			dc.eventBus.Publish(types.JobStoppingEvent{
+				EventMeta: types.NewEventMeta(options.JobID),
+				Code:      types.CrawlEndCodeNilSession,
+			})
+		})
+		pc.DouYinApiClient = client
+	}, sessionManager.AffinityUser)
+	dc.dataFetcher = NewDouyinFetcher(dc.ctx, clients)
 
 	return dc
 }
 
-func (d *DouyinCrawler) Initialize(scheduler *scheduler.Scheduler, runtimeChannel chan types.RuntimeData, channels map[string]chan types.FetchItemChan) {
+func (d *DouyinCrawler) Initialize(scheduler *scheduler.Scheduler, emitRuntime types.RuntimeEmitter, channels map[string]*flow.Channel) {
 	d.scheduler = scheduler
 	d.channels = channels
-	d.runtimeChannel = runtimeChannel
+	d.emitRuntime = emitRuntime
 	// 初始化dataFetcher
 	d.dataFetcher.Initialize()
 	// 初始化handler
@@ -115,7 +175,7 @@ func (d *DouyinCrawler) Initialize(scheduler *scheduler.Scheduler, runtimeChanne
 	d.scheduler.RegisterHandler(str.GenerateStringKey(d.mediaCode.String(), "comment"), d.handleComment)
 }
 
-// SubmitSubTasks 提交子任务（集中在 crawler 中）
+// SubmitSubTasks 提交子任务（集中在 crawler 中），并同步写入数据，写入失败时返回错误
 func (d *DouyinCrawler) HandleChannel(item types.FetchItemChan, params *types.CrawlParams) error {
 	switch data := item.Data.(type) {
 	case douyin.Aweme:
@@ -151,12 +211,9 @@ func (d *DouyinCrawler) HandleChannel(item types.FetchItemChan, params *types.Cr
 				return err
 			}
 		}
-		go func() {
-			err := d.dataSaver.HandleMedia(data, item.TaskId, item.SourceTaskId, item.Source)
-			if err != nil {
-				logger.Log.Errorf("TaskID=%s: SaveMedia error: %v", item.TaskId, err)
-			}
-		}()
+		if err := d.dataSaver.HandleMedia(data, item.TaskId, item.SourceTaskId, item.Source, params.Sinks); err != nil {
+			return fmt.Errorf("SaveMedia error: %v", err)
+		}
 		return nil
 	case douyin.Comment:
 		// 提交采集用户信息任务
@@ -175,7 +232,7 @@ func (d *DouyinCrawler) HandleChannel(item types.FetchItemChan, params *types.Cr
 		if len(data.Text) == 0 {
 			return nil
 		}
-		d.runtimeChannel <- types.NewRuntimeData(
+		d.emitRuntime.Emit(types.NewRuntimeData(
 			types.RuntimeEventCodeCrawl,
 			types.EventData{
 				MetaData: map[string]string{
@@ -184,25 +241,19 @@ func (d *DouyinCrawler) HandleChannel(item types.FetchItemChan, params *types.Cr
 					"createdAt": time.Unix(data.CreateTime, 0).Format("2006-01-02 15:04:05"),
 				},
 			},
-		)
+		).WithJob(d.jobID))
 		// 评论内容
-		go func() {
-			err := d.dataSaver.HandleComment(data, item.TaskId, item.SourceTaskId, item.Source)
-			if err != nil {
-				logger.Log.Errorf("TaskID=%s: SaveComment error: %v", item.TaskId, err)
-			}
-		}()
+		if err := d.dataSaver.HandleComment(data, item.TaskId, item.SourceTaskId, item.Source, params.Sinks); err != nil {
+			return fmt.Errorf("SaveComment error: %v", err)
+		}
 		return nil
 	case douyin.User:
 		if params.WithAllCreations {
 			// TODO: 提交用户作品采集任务
 		}
-		go func() {
-			err := d.dataSaver.HandleUser(data, item.TaskId, item.SourceTaskId, item.Source)
-			if err != nil {
-				logger.Log.Errorf("TaskID=%s: SaveUser error: %v", item.TaskId, err)
-			}
-		}()
+		if err := d.dataSaver.HandleUser(data, item.TaskId, item.SourceTaskId, item.Source, params.Sinks); err != nil {
+			return fmt.Errorf("SaveUser error: %v", err)
+		}
 		return nil
 	default:
 		return fmt.Errorf("unsupported data type: %T", item.Data)
@@ -211,6 +262,9 @@ func (d *DouyinCrawler) HandleChannel(item types.FetchItemChan, params *types.Cr
 
 // SubmitTask 提交爬虫任务
 func (d *DouyinCrawler) SubmitJob(taskType string, payload interface{}, options scheduler.TaskOptions) error {
+	if options.JobID == "" {
+		options.JobID = d.jobID
+	}
 	// 创建任务
 	task, err := scheduler.NewTask(str.GenerateStringKey(d.mediaCode.String(), taskType), payload, options)
 	if err != nil {
@@ -231,6 +285,7 @@ func (d *DouyinCrawler) SubmitJob(taskType string, payload interface{}, options
 	// 创建任务记录 (Pending)
 	taskRecord := &model.CrawlTask{
 		TaskId:       taskId, // 临时 ID
+		ProjectID:    d.projectID,
 		MediaCode:    string(d.mediaCode),
 		Type:         taskType,
 		Payload:      string(payloadJSON),
@@ -251,7 +306,7 @@ func (d *DouyinCrawler) handleSearch(t *scheduler.Task) error {
 		return fmt.Errorf("data type error, expected: types.SearchParams, got: %T", t.Payload)
 	}
 	params.TaskId = t.ID
-	verify, hasMore, count, err := d.dataFetcher.HandleSearch(&params, d.channels["media"])
+	verify, hasMore, count, err := d.dataFetcher.HandleSearch(&params, t.AffinityKey, d.channels["media"])
 	if err != nil {
 		return err
 	}
@@ -284,14 +339,14 @@ func (d *DouyinCrawler) handleComment(t *scheduler.Task) error {
 	}
 	params.TaskId = t.ID
 	params.SourceTaskId = t.SourceTaskID
-	hasMore, count, err := d.dataFetcher.HandleComments(&params, d.channels["comment"])
+	hasMore, count, err := d.dataFetcher.HandleComments(&params, t.AffinityKey, d.channels["comment"])
 	if err != nil {
 		return err
 	}
 	if params.Cursor == 0 && count == 0 {
-		d.eventBus.Publish(types.CrawlEndEvent{
+		d.eventBus.Publish(types.JobStoppingEvent{
+			EventMeta: types.NewEventMeta(d.jobID),
 			Code:      types.CrawlEndCodeOverdLimit,
-			ReceiveAt: time.Now(),
 		})
 		return fmt.Errorf("Account execeed limit...")
 	}
@@ -317,7 +372,7 @@ func (d *DouyinCrawler) handleMedia(t *scheduler.Task) error {
 	}
 	params.TaskId = t.ID
 	params.SourceTaskId = t.SourceTaskID
-	err := d.dataFetcher.HandleMedia(&params, d.channels["media"])
+	err := d.dataFetcher.HandleMedia(&params, t.AffinityKey, d.channels["media"])
 	if err != nil {
 		return err
 	}
@@ -331,7 +386,7 @@ func (d *DouyinCrawler) handleUser(t *scheduler.Task) error {
 	}
 	params.TaskId = t.ID
 	params.SourceTaskId = t.SourceTaskID
-	err := d.dataFetcher.HandleUser(&params, d.channels["user"])
+	err := d.dataFetcher.HandleUser(&params, t.AffinityKey, d.channels["user"])
 	if err != nil {
 		return err
 	}
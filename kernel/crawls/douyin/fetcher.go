@@ -4,21 +4,21 @@ import (
 	"context"
 	"fmt"
 	"noctua/internal/media/douyin"
-	"noctua/internal/signer"
+	"noctua/kernel/flow"
 	"noctua/pkg/logger"
 	"noctua/types"
 )
 
 // DouyinFetcherCrawler 具体的抖音爬虫
 type DouyinFetcher struct {
-	ctx        context.Context
-	dataClient *douyin.DouYinApiClient
+	ctx     context.Context
+	clients *ClientPool
 }
 
-func NewDouyinFetcher(ctx context.Context, signClient *signer.SignServerClient) *DouyinFetcher {
+func NewDouyinFetcher(ctx context.Context, clients *ClientPool) *DouyinFetcher {
 	return &DouyinFetcher{
-		ctx:        ctx,
-		dataClient: douyin.NewDouYinApiClient(signClient),
+		ctx:     ctx,
+		clients: clients,
 	}
 }
 
@@ -27,7 +27,7 @@ func (d *DouyinFetcher) Initialize() {
 }
 
 // HandleSearch 使用泛型处理不同类型的通道
-func (d *DouyinFetcher) HandleSearch(params *types.SearchParams, mediaChan chan types.FetchItemChan) (bool, bool, int, error) {
+func (d *DouyinFetcher) HandleSearch(params *types.SearchParams, affinityKey string, mediaChan *flow.Channel) (bool, bool, int, error) {
 	searchParams := &douyin.SearchParams{
 		Keyword:         params.Keyword,
 		SearchChannel:   douyin.SearchChannelVideo,
@@ -39,7 +39,9 @@ func (d *DouyinFetcher) HandleSearch(params *types.SearchParams, mediaChan chan
 	}
 	logger.Log.Infof("Douyin.search: Keyword=%s, Page=%d", params.Keyword, params.Page+1)
 	// 判断总数设置的查询总计记录数
-	searchResult, err := d.dataClient.SearchInfoByKeyword(searchParams)
+	client := d.clients.Borrow(affinityKey)
+	searchResult, err := client.SearchInfoByKeyword(searchParams)
+	d.clients.Release(client)
 	if err != nil {
 		logger.Log.Errorf("Douyin.search: Keyword=%s, Page=%d, err：%s", params.Keyword, params.Page, err.Error())
 		return false, false, 0, err
@@ -74,14 +76,9 @@ func (d *DouyinFetcher) HandleSearch(params *types.SearchParams, mediaChan chan
 			if d.ctx.Err() != nil {
 				return false, false, len(searchResult.Data), d.ctx.Err()
 			}
-			select {
-			case <-d.ctx.Done():
-				logger.Log.Infof("Douyin.fetcher-search: stopped due to context cancellation")
-				return false, false, len(searchResult.Data), d.ctx.Err()
-			case mediaChan <- item:
-			default:
-				logger.Log.Warnf("Douyin.fetcher-search: mediaChan closed or full for Keyword=%s, Page=%d", params.Keyword, params.Page)
-				return false, false, len(searchResult.Data), fmt.Errorf("media channel closed or full")
+			if err := mediaChan.Send(d.ctx, item); err != nil {
+				logger.Log.Warnf("Douyin.fetcher-search: send to mediaChan failed for Keyword=%s, Page=%d, err：%s", params.Keyword, params.Page, err.Error())
+				return false, false, len(searchResult.Data), err
 			}
 		}
 	}
@@ -89,8 +86,10 @@ func (d *DouyinFetcher) HandleSearch(params *types.SearchParams, mediaChan chan
 }
 
 // HandleComments 使用泛型处理评论通道
-func (d *DouyinFetcher) HandleComments(params *types.CommentParams, commentChan chan types.FetchItemChan) (bool, int, error) {
-	commentResult, err := d.dataClient.GetAwemeComments(params.Id, params.Cursor, params.SourceKeyword)
+func (d *DouyinFetcher) HandleComments(params *types.CommentParams, affinityKey string, commentChan *flow.Channel) (bool, int, error) {
+	client := d.clients.Borrow(affinityKey)
+	commentResult, err := client.GetAwemeComments(params.Id, params.Cursor, params.SourceKeyword)
+	d.clients.Release(client)
 	if err != nil {
 		logger.Log.Errorf("Douyin.fetcher-comment，get media comment %s failed, err：%s", params.Id, err.Error())
 		return false, 0, err
@@ -110,14 +109,9 @@ func (d *DouyinFetcher) HandleComments(params *types.CommentParams, commentChan
 			if d.ctx.Err() != nil {
 				return false, 0, d.ctx.Err()
 			}
-			select {
-			case <-d.ctx.Done():
-				logger.Log.Infof("Douyin.fetcher-comment: stopped due to context cancellation")
-				return false, 0, d.ctx.Err()
-			case commentChan <- item:
-			default:
-				logger.Log.Warnf("Douyin.fetcher-comment: commentChan closed or full for ID=%s", params.Id)
-				return false, 0, fmt.Errorf("comment channel closed or full")
+			if err := commentChan.Send(d.ctx, item); err != nil {
+				logger.Log.Warnf("Douyin.fetcher-comment: send to commentChan failed for ID=%s, err：%s", params.Id, err.Error())
+				return false, 0, err
 			}
 		}
 	}
@@ -125,9 +119,11 @@ func (d *DouyinFetcher) HandleComments(params *types.CommentParams, commentChan
 }
 
 // HandleMedia 使用泛型处理视频通道
-func (d *DouyinFetcher) HandleMedia(params *types.MediaParams, mediaChan chan types.FetchItemChan) error {
+func (d *DouyinFetcher) HandleMedia(params *types.MediaParams, affinityKey string, mediaChan *flow.Channel) error {
 	logger.Log.Infof("Douyin.fetcher-media, search media: %s", params.Id)
-	mediaResult, err := d.dataClient.GetVideoByID(params.Id)
+	client := d.clients.Borrow(affinityKey)
+	mediaResult, err := client.GetVideoByID(params.Id)
+	d.clients.Release(client)
 	if err != nil {
 		return fmt.Errorf("Douyin.fetcher-media, err：%s", err.Error())
 	}
@@ -138,24 +134,21 @@ func (d *DouyinFetcher) HandleMedia(params *types.MediaParams, mediaChan chan ty
 			Source:       "media:" + params.Id,
 			Data:         v,
 		}
-		select {
-		case <-d.ctx.Done():
-			logger.Log.Infof("Douyin.fetcher-media: stopped due to context cancellation")
-			return d.ctx.Err()
-		case mediaChan <- item:
-		default:
-			logger.Log.Warnf("Douyin.fetcher-media: mediaChan closed or full for ID=%s", params.Id)
-			return fmt.Errorf("media channel closed or full")
+		if err := mediaChan.Send(d.ctx, item); err != nil {
+			logger.Log.Warnf("Douyin.fetcher-media: send to mediaChan failed for ID=%s, err：%s", params.Id, err.Error())
+			return err
 		}
 	}
 	return nil
 }
 
 // HandleUser 使用泛型处理用户通道
-func (d *DouyinFetcher) HandleUser(params *types.UserParams, userChan chan types.FetchItemChan) error {
+func (d *DouyinFetcher) HandleUser(params *types.UserParams, affinityKey string, userChan *flow.Channel) error {
 	// todo 临时测试
 	logger.Log.Infof("Douyin.fetcher-user, search user: %s", params.UserId)
-	userResult, err := d.dataClient.GetUserInfo(params.UserId)
+	client := d.clients.Borrow(affinityKey)
+	userResult, err := client.GetUserInfo(params.UserId)
+	d.clients.Release(client)
 	if err != nil {
 		return fmt.Errorf("Douyin.fetcher-user, err：%s", err.Error())
 	}
@@ -168,14 +161,9 @@ func (d *DouyinFetcher) HandleUser(params *types.UserParams, userChan chan types
 		if d.ctx.Err() != nil {
 			return d.ctx.Err()
 		}
-		select {
-		case <-d.ctx.Done():
-			logger.Log.Infof("Douyin.fetcher-user: stopped due to context cancellation")
-			return d.ctx.Err()
-		case userChan <- item:
-		default:
-			logger.Log.Warnf("Douyin.fetcher-user: userChan closed or full for UserID=%s", params.UserId)
-			return fmt.Errorf("user channel closed or full")
+		if err := userChan.Send(d.ctx, item); err != nil {
+			logger.Log.Warnf("Douyin.fetcher-user: send to userChan failed for UserID=%s, err：%s", params.UserId, err.Error())
+			return err
 		}
 	}
 	return nil
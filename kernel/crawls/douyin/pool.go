@@ -0,0 +1,81 @@
+package douyin
+
+import (
+	"noctua/internal/media/douyin"
+	"sync"
+)
+
+// DefaultMaxIdleClients 保留的空闲客户端数，超过时丢弃最早归还的
+const DefaultMaxIdleClients = 16
+
+// pooledClient 绑定单个会话的 API 客户端，同一时间只由一个任务使用，借用期间持有该会话的租约
+type pooledClient struct {
+	*douyin.DouYinApiClient
+	affinityKey string // 借用的任务的会话亲和标识，获取会话时使用
+}
+
+// userID 客户端当前绑定的账号
+func (pc *pooledClient) userID() string {
+	current := pc.CurrentSession()
+	if current == nil || current.Account == nil {
+		return ""
+	}
+	return current.Account.UserID
+}
+
+// ClientPool 按会话复用的 API 客户端，每个任务借用一个客户端，优先借出已绑定任务亲和账号的客户端
+type ClientPool struct {
+	mu        sync.Mutex
+	newClient func(pc *pooledClient)
+	resolve   func(affinityKey string) string
+	idle      []*pooledClient
+	maxIdle   int
+}
+
+// NewClientPool 创建客户端池，newClient 用于初始化新建的客户端，resolve 返回亲和标识绑定的账号
+func NewClientPool(newClient func(pc *pooledClient), resolve func(affinityKey string) string) *ClientPool {
+	return &ClientPool{
+		newClient: newClient,
+		resolve:   resolve,
+		maxIdle:   DefaultMaxIdleClients,
+	}
+}
+
+// Borrow 借用客户端，用完后需调用 Release
+func (p *ClientPool) Borrow(affinityKey string) *pooledClient {
+	userID := ""
+	if affinityKey != "" && p.resolve != nil {
+		userID = p.resolve(affinityKey)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	index := len(p.idle) - 1
+	for i, pc := range p.idle {
+		if userID != "" && pc.userID() == userID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		pc := &pooledClient{affinityKey: affinityKey}
+		p.newClient(pc)
+		return pc
+	}
+	// 未绑定该账号的客户端在获取会话时按亲和标识更换账号
+	pc := p.idle[index]
+	p.idle = append(p.idle[:index], p.idle[index+1:]...)
+	pc.affinityKey = affinityKey
+	return pc
+}
+
+// Release 归还客户端并释放其会话租约
+func (p *ClientPool) Release(pc *pooledClient) {
+	pc.ReleaseSession()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pc.affinityKey = ""
+	if len(p.idle) >= p.maxIdle {
+		p.idle = p.idle[1:]
+	}
+	p.idle = append(p.idle, pc)
+}
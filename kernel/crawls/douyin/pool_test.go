@@ -0,0 +1,151 @@
+package douyin
+
+import (
+	"fmt"
+	"noctua/internal/media/douyin"
+	"noctua/internal/media/douyin/douyintest"
+	"noctua/internal/model"
+	"noctua/internal/signer"
+	"noctua/pkg/logger"
+	"noctua/types"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLeases 记录模拟会话租约的获取和释放
+type testLeases struct {
+	acquired int
+	released []string
+}
+
+// newTestPool 创建指向模拟服务的客户端池，亲和标识已绑定账号时使用该账号，否则沿用当前账号或分配新账号
+func newTestPool(t *testing.T) (*ClientPool, map[string]string, *testLeases) {
+	logger.Init(&logger.LoggerConfig{Level: "error"})
+	server := douyintest.NewServer(douyintest.Options{Comments: 4})
+	t.Cleanup(server.Close)
+	var mu sync.Mutex
+	pins := make(map[string]string)
+	accounts := 0
+	leases := &testLeases{}
+	resolve := func(key string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		return pins[key]
+	}
+	pool := NewClientPool(func(pc *pooledClient) {
+		client := douyin.NewDouYinApiClient(signer.NewSignServerClient(server.URL))
+		client.SetEndpoints(douyin.Endpoints{API: server.URL, Index: server.URL, MsToken: server.MsTokenURL(), WebID: server.WebIDURL()})
+		client.OnAcquireSession(func(now *types.Session) (*types.Session, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			userID := pins[pc.affinityKey]
+			if userID == "" && now != nil {
+				userID = now.Account.UserID
+			}
+			if userID == "" {
+				accounts++
+				userID = fmt.Sprintf("u%d", accounts)
+			}
+			if pc.affinityKey != "" {
+				pins[pc.affinityKey] = userID
+			}
+			leases.acquired++
+			return &types.Session{
+				Enabled:   true,
+				LeaseID:   fmt.Sprintf("lease-%d", leases.acquired),
+				Account:   &model.MediaAccount{UserID: userID, MediaCode: "douyin", Cookie: `[{"name":"sessionid","value":"x"}]`},
+				ProxyInfo: &types.ProxyInfo{},
+			}, nil
+		})
+		client.OnReleaseSession(func(leaseID string) {
+			mu.Lock()
+			defer mu.Unlock()
+			leases.released = append(leases.released, leaseID)
+		})
+		client.OnMissingSession(func() {})
+		pc.DouYinApiClient = client
+	}, resolve)
+	return pool, pins, leases
+}
+
+func TestClientPoolAffinity(t *testing.T) {
+	pool, pins, _ := newTestPool(t)
+
+	// 并发借用时各自绑定不同的会话
+	first := pool.Borrow("key-a")
+	second := pool.Borrow("key-b")
+	assert.NotSame(t, first, second)
+	_, err := first.GetAwemeComments("a", 0, "")
+	require.NoError(t, err)
+	_, err = second.GetAwemeComments("b", 0, "")
+	require.NoError(t, err)
+	assert.Equal(t, "u1", first.userID())
+	assert.Equal(t, "u2", second.userID())
+	assert.Equal(t, map[string]string{"key-a": "u1", "key-b": "u2"}, pins)
+	pool.Release(first)
+	pool.Release(second)
+	assert.Empty(t, first.affinityKey)
+
+	// 同一亲和标识的后续请求借用到绑定亲和账号的客户端
+	next := pool.Borrow("key-a")
+	assert.Same(t, first, next)
+	assert.Equal(t, "key-a", next.affinityKey)
+	pool.Release(next)
+
+	// 没有绑定该账号的空闲客户端时，借出的客户端按亲和标识更换账号
+	busy := pool.Borrow("key-b")
+	assert.Same(t, second, busy)
+	other := pool.Borrow("key-b")
+	assert.Same(t, first, other)
+	_, err = other.GetAwemeComments("b", 1, "")
+	require.NoError(t, err)
+	assert.Equal(t, "u2", other.userID())
+	pool.Release(busy)
+	pool.Release(other)
+
+	// 新的亲和标识沿用空闲客户端的账号
+	fresh := pool.Borrow("key-c")
+	_, err = fresh.GetAwemeComments("c", 0, "")
+	require.NoError(t, err)
+	assert.Equal(t, "u2", pins["key-c"])
+	pool.Release(fresh)
+}
+
+func TestClientPoolMaxIdle(t *testing.T) {
+	pool, _, _ := newTestPool(t)
+	pool.maxIdle = 1
+	first, second := pool.Borrow(""), pool.Borrow("")
+	pool.Release(first)
+	pool.Release(second)
+	assert.Equal(t, []*pooledClient{second}, pool.idle)
+}
+
+func TestClientPoolLease(t *testing.T) {
+	pool, _, leases := newTestPool(t)
+
+	// 借用期间的请求沿用同一租约
+	client := pool.Borrow("key-a")
+	_, err := client.GetAwemeComments("a", 0, "")
+	require.NoError(t, err)
+	_, err = client.GetAwemeComments("a", 1, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, leases.acquired)
+	assert.Empty(t, leases.released)
+
+	// 归还时释放租约，再次借用时重新获取
+	pool.Release(client)
+	assert.Equal(t, []string{"lease-1"}, leases.released)
+	pool.Release(pool.Borrow("key-a"))
+	assert.Equal(t, []string{"lease-1"}, leases.released)
+	next := pool.Borrow("key-a")
+	assert.Same(t, client, next)
+	_, err = next.GetAwemeComments("a", 2, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, leases.acquired)
+	assert.Equal(t, "u1", next.userID())
+	pool.Release(next)
+	assert.Equal(t, []string{"lease-1", "lease-2"}, leases.released)
+}
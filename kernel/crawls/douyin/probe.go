@@ -0,0 +1,36 @@
+package douyin
+
+import (
+	"context"
+	"errors"
+	"noctua/internal/media/douyin"
+	"noctua/internal/signer"
+	"noctua/kernel/session"
+	"noctua/types"
+)
+
+var ErrNotLoggedIn = errors.New("douyin account is not logged in")
+
+// NewProber 创建抖音账号探测方法，查询当前登录用户，未返回用户 UID 时视为登录态失效
+func NewProber(signClient *signer.SignServerClient, endpoints map[string]string) session.ProbeFunc {
+	return func(ctx context.Context, probeSession *types.Session) error {
+		client := douyin.NewDouYinApiClient(signClient)
+		client.SetEndpoints(douyin.Endpoints{
+			API:     endpoints["api"],
+			Index:   endpoints["index"],
+			MsToken: endpoints["ms_token"],
+			WebID:   endpoints["webid"],
+		})
+		client.OnAcquireSession(func(*types.Session) (*types.Session, error) {
+			return probeSession, nil
+		})
+		pong, err := client.Pong(ctx)
+		if err != nil {
+			return err
+		}
+		if pong.UserUID == "" {
+			return ErrNotLoggedIn
+		}
+		return nil
+	}
+}
@@ -5,13 +5,32 @@ import (
 	"fmt"
 	"noctua/internal/media/douyin"
 	"noctua/internal/model"
+	"noctua/kernel/sink"
 	"time"
 )
 
 type DouyinDataSaver struct {
+	sinks     *sink.Manager
+	projectID uint // 采集数据所属项目
 }
 
-func (d *DouyinDataSaver) HandleMedia(aweme douyin.Aweme, taskId, sourceTaskId, source string) error {
+func NewDouyinDataSaver(sinks *sink.Manager, projectID uint) *DouyinDataSaver {
+	return &DouyinDataSaver{sinks: sinks, projectID: projectID}
+}
+
+// record 构建 sink 数据
+func (d *DouyinDataSaver) record(kind sink.Kind, taskId, sourceTaskId string, data interface{}) sink.Record {
+	return sink.Record{
+		Kind:         kind,
+		ProjectID:    d.projectID,
+		MediaCode:    "douyin",
+		TaskId:       taskId,
+		SourceTaskId: sourceTaskId,
+		Data:         data,
+	}
+}
+
+func (d *DouyinDataSaver) HandleMedia(aweme douyin.Aweme, taskId, sourceTaskId, source string, route []string) error {
 	// 处理视频数据入库
 	var awemeUrl string
 	if aweme.AwemeType == douyin.DOUYIN_NOTE_TYPE {
@@ -20,6 +39,7 @@ func (d *DouyinDataSaver) HandleMedia(aweme douyin.Aweme, taskId, sourceTaskId,
 		awemeUrl = fmt.Sprintf("%s/video/%s", douyin.DOUYIN_INDEX_URL, aweme.AwemeID)
 	}
 	modelMedia := &model.CrawlMedia{
+		ProjectID:      d.projectID,
 		MediaCode:      "douyin",
 		TaskId:         taskId,
 		SourceTaskId:   sourceTaskId,
@@ -37,15 +57,12 @@ func (d *DouyinDataSaver) HandleMedia(aweme douyin.Aweme, taskId, sourceTaskId,
 		URL:            awemeUrl,
 		Source:         source,
 	}
-	err := modelMedia.UpsertModel()
-	if err != nil {
-		return err
-	}
 	var avatarUrl = ""
 	if len(aweme.Author.AvatarThumb.URLList) > 0 {
 		avatarUrl = aweme.Author.AvatarThumb.URLList[0]
 	}
 	modelCrawlUser := &model.CrawlUser{
+		ProjectID:    d.projectID,
 		MediaCode:    "douyin",
 		TaskId:       taskId,
 		SourceTaskId: sourceTaskId,
@@ -57,16 +74,16 @@ func (d *DouyinDataSaver) HandleMedia(aweme douyin.Aweme, taskId, sourceTaskId,
 		Avatar:       avatarUrl,
 		Signature:    aweme.Author.Signature,
 	}
-	err = modelCrawlUser.UpsertModel()
-	if err != nil {
-		return err
-	}
-	return nil
+	return d.sinks.Write(route,
+		d.record(sink.KindMedia, taskId, sourceTaskId, modelMedia),
+		d.record(sink.KindUser, taskId, sourceTaskId, modelCrawlUser),
+	)
 }
 
-func (d *DouyinDataSaver) HandleComment(comment douyin.Comment, taskId, sourceTaskId, source string) error {
+func (d *DouyinDataSaver) HandleComment(comment douyin.Comment, taskId, sourceTaskId, source string, route []string) error {
 	pictures, _ := json.Marshal(comment.ImageList)
 	modelCrawlComment := &model.CrawlComment{
+		ProjectID:       d.projectID,
 		MediaCode:       "douyin",
 		TaskId:          taskId,
 		SourceTaskId:    sourceTaskId,
@@ -83,15 +100,12 @@ func (d *DouyinDataSaver) HandleComment(comment douyin.Comment, taskId, sourceTa
 		Pictures:        string(pictures),
 		Source:          source,
 	}
-	err := modelCrawlComment.UpsertModel()
-	if err != nil {
-		return err
-	}
 	var avatarUrl = ""
 	if len(comment.User.AvatarThumb.URLList) > 0 {
 		avatarUrl = comment.User.AvatarThumb.URLList[0]
 	}
 	modelCrawlUser := &model.CrawlUser{
+		ProjectID:    d.projectID,
 		MediaCode:    "douyin",
 		TaskId:       taskId,
 		SourceTaskId: sourceTaskId,
@@ -104,14 +118,13 @@ func (d *DouyinDataSaver) HandleComment(comment douyin.Comment, taskId, sourceTa
 		Signature:    comment.User.Signature,
 		Location:     comment.IPLabel,
 	}
-	err = modelCrawlUser.UpsertModel()
-	if err != nil {
-		return err
-	}
-	return nil
+	return d.sinks.Write(route,
+		d.record(sink.KindComment, taskId, sourceTaskId, modelCrawlComment),
+		d.record(sink.KindUser, taskId, sourceTaskId, modelCrawlUser),
+	)
 }
 
-func (d *DouyinDataSaver) HandleUser(user douyin.User, taskId, sourceTaskId, source string) error {
+func (d *DouyinDataSaver) HandleUser(user douyin.User, taskId, sourceTaskId, source string, route []string) error {
 	// TODU: 待调试查询用户信息
 	return nil
 }
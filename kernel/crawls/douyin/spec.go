@@ -0,0 +1,38 @@
+package douyin
+
+import (
+	"noctua/internal/constants"
+	"noctua/kernel/reference"
+)
+
+// Spec 抖音支持的采集类型及参数约束
+var Spec = reference.CrawlSpec{
+	MediaCode: constants.MediaCodeDouyin.String(),
+	CrawlTypes: map[string]reference.CrawlTypeSpec{
+		constants.CrawlerTypeSearch.String(): {
+			MaxCount:    2000,
+			MaxKeywords: 50,
+			Options: []string{
+				reference.OptionWithUser,
+				reference.OptionWithComment,
+				reference.OptionWithCommentUser,
+			},
+		},
+		constants.CrawlerTypeMedia.String(): {
+			MaxKeywords: 200,
+			Options: []string{
+				reference.OptionWithUser,
+				reference.OptionWithComment,
+				reference.OptionWithCommentUser,
+			},
+		},
+		constants.CrawlerTypeUser.String(): {
+			MaxKeywords: 100,
+			Options: []string{
+				reference.OptionWithAllCreations,
+				reference.OptionWithComment,
+				reference.OptionWithCommentUser,
+			},
+		},
+	},
+}
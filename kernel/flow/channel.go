@@ -0,0 +1,272 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"noctua/pkg/logger"
+	"noctua/types"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Policy 通道写满时的处理策略
+type Policy string
+
+const (
+	PolicyBlock Policy = "block" // 阻塞等待，超时后返回错误
+	PolicySpill Policy = "spill" // 溢写到磁盘队列，有空位后回填
+	PolicyDrop  Policy = "drop"  // 丢弃并计数
+)
+
+const (
+	DefaultCapacity     = 1000
+	DefaultBlockTimeout = 5 * time.Second
+	// SaturationThreshold 通道占用率超过该值视为饱和
+	SaturationThreshold = 0.9
+)
+
+var (
+	ErrClosed  = errors.New("channel closed")
+	ErrTimeout = errors.New("channel send timeout")
+)
+
+// Options 通道配置
+type Options struct {
+	Capacity      int           `mapstructure:"capacity"`
+	Policy        Policy        `mapstructure:"policy"`
+	Timeout       time.Duration `mapstructure:"timeout"`         // block 策略的等待时间
+	SpillDir      string        `mapstructure:"spill_dir"`       // spill 策略的磁盘目录
+	SpillMaxBytes int64         `mapstructure:"spill_max_bytes"` // spill 文件上限，0 为不限制
+}
+
+// Stats 通道统计
+type Stats struct {
+	Length     int     `json:"length"`
+	Capacity   int     `json:"capacity"`
+	Policy     Policy  `json:"policy"`
+	Saturation float64 `json:"saturation"` // 占用率 0-1
+	Saturated  bool    `json:"saturated"`
+	Sent       int64   `json:"sent"`
+	Dropped    int64   `json:"dropped"`
+	TimedOut   int64   `json:"timedOut"`
+	Spilled    int64   `json:"spilled"`
+	Restored   int64   `json:"restored"`
+	SpillDepth int     `json:"spillDepth"` // 磁盘中待回填的数量
+}
+
+// Channel 带背压策略的数据通道
+type Channel struct {
+	name     string
+	opts     Options
+	ch       chan types.FetchItemChan
+	spill    *spillQueue
+	mu       sync.RWMutex
+	done     chan struct{}
+	once     sync.Once
+	closed   bool
+	wakeup   chan struct{}
+	drainWg  sync.WaitGroup
+	sent     atomic.Int64
+	dropped  atomic.Int64
+	timedOut atomic.Int64
+	spilled  atomic.Int64
+	restored atomic.Int64
+	// 饱和回调，占用率回落到阈值一半以下后重新触发
+	onSaturated func(stats Stats)
+	saturated   atomic.Bool
+}
+
+// NewChannel 创建通道
+func NewChannel(name string, opts Options) (*Channel, error) {
+	if opts.Capacity <= 0 {
+		opts.Capacity = DefaultCapacity
+	}
+	if opts.Policy == "" {
+		opts.Policy = PolicyBlock
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultBlockTimeout
+	}
+	c := &Channel{
+		name:   name,
+		opts:   opts,
+		ch:     make(chan types.FetchItemChan, opts.Capacity),
+		done:   make(chan struct{}),
+		wakeup: make(chan struct{}, 1),
+	}
+	switch opts.Policy {
+	case PolicyBlock, PolicyDrop:
+	case PolicySpill:
+		if opts.SpillDir == "" {
+			return nil, fmt.Errorf("channel %s: spill_dir is required for spill policy", name)
+		}
+		spill, err := newSpillQueue(opts.SpillDir, name, opts.SpillMaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		c.spill = spill
+		c.drainWg.Add(1)
+		go c.drain()
+	default:
+		return nil, fmt.Errorf("channel %s: unsupported policy %s", name, opts.Policy)
+	}
+	return c, nil
+}
+
+// OnSaturated 设置通道饱和回调，需在写入前调用
+func (c *Channel) OnSaturated(fn func(stats Stats)) {
+	c.onSaturated = fn
+}
+
+// checkSaturation 按占用率边沿触发饱和回调
+func (c *Channel) checkSaturation() {
+	if c.onSaturated == nil {
+		return
+	}
+	stats := c.Stats()
+	if stats.Saturated {
+		if c.saturated.CompareAndSwap(false, true) {
+			c.onSaturated(stats)
+		}
+	} else if stats.Saturation < SaturationThreshold/2 {
+		c.saturated.Store(false)
+	}
+}
+
+// C 读取端
+func (c *Channel) C() <-chan types.FetchItemChan {
+	return c.ch
+}
+
+// Send 按策略写入数据
+func (c *Channel) Send(ctx context.Context, item types.FetchItemChan) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return ErrClosed
+	}
+	defer c.checkSaturation()
+	// 磁盘中仍有积压时保持顺序，直接追加到磁盘
+	if c.spill == nil || c.spill.Len() == 0 {
+		select {
+		case c.ch <- item:
+			c.sent.Add(1)
+			return nil
+		default:
+		}
+	}
+
+	switch c.opts.Policy {
+	case PolicyDrop:
+		c.dropped.Add(1)
+		logger.Log.Debugf("Channel %s full, dropped item of task %s", c.name, item.TaskId)
+		return nil
+	case PolicySpill:
+		if err := c.spill.Push(item); err != nil {
+			c.dropped.Add(1)
+			return fmt.Errorf("channel %s spill failed: %v", c.name, err)
+		}
+		c.spilled.Add(1)
+		select {
+		case c.wakeup <- struct{}{}:
+		default:
+		}
+		return nil
+	default:
+		timer := time.NewTimer(c.opts.Timeout)
+		defer timer.Stop()
+		select {
+		case c.ch <- item:
+			c.sent.Add(1)
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.done:
+			return ErrClosed
+		case <-timer.C:
+			c.timedOut.Add(1)
+			return fmt.Errorf("%w: %s full after %v", ErrTimeout, c.name, c.opts.Timeout)
+		}
+	}
+}
+
+// drain 将磁盘积压回填到通道
+func (c *Channel) drain() {
+	defer c.drainWg.Done()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-c.wakeup:
+		}
+		for {
+			item, ok, err := c.spill.Pop()
+			if err != nil {
+				c.dropped.Add(1)
+				logger.Log.Errorf("Channel %s restore spilled item failed: %v", c.name, err)
+				continue
+			}
+			if !ok {
+				break
+			}
+			select {
+			case c.ch <- item:
+				c.restored.Add(1)
+				c.sent.Add(1)
+				c.spill.Done()
+			case <-c.done:
+				c.dropped.Add(1)
+				c.spill.Done()
+				return
+			}
+		}
+	}
+}
+
+// Stats 返回通道统计
+func (c *Channel) Stats() Stats {
+	length, capacity := len(c.ch), cap(c.ch)
+	saturation := 0.0
+	if capacity > 0 {
+		saturation = float64(length) / float64(capacity)
+	}
+	spillDepth := 0
+	if c.spill != nil {
+		spillDepth = c.spill.Len()
+	}
+	return Stats{
+		Length:     length,
+		Capacity:   capacity,
+		Policy:     c.opts.Policy,
+		Saturation: saturation,
+		Saturated:  saturation >= SaturationThreshold || spillDepth > 0,
+		Sent:       c.sent.Load(),
+		Dropped:    c.dropped.Load(),
+		TimedOut:   c.timedOut.Load(),
+		Spilled:    c.spilled.Load(),
+		Restored:   c.restored.Load(),
+		SpillDepth: spillDepth,
+	}
+}
+
+// Close 关闭通道，丢弃磁盘中未回填的数据
+func (c *Channel) Close() {
+	c.once.Do(func() {
+		// 先唤醒阻塞中的写入方，再等待其释放读锁
+		close(c.done)
+		c.mu.Lock()
+		c.closed = true
+		c.mu.Unlock()
+
+		c.drainWg.Wait()
+		if c.spill != nil {
+			if left := c.spill.Close(); left > 0 {
+				c.dropped.Add(int64(left))
+				logger.Log.Warnf("Channel %s closed with %d spilled items discarded", c.name, left)
+			}
+		}
+		close(c.ch)
+	})
+}
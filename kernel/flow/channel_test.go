@@ -0,0 +1,82 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"noctua/pkg/logger"
+	"noctua/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	logger.Init(&logger.LoggerConfig{Level: "error"})
+}
+
+type payload struct {
+	Index int
+}
+
+func init() {
+	RegisterType(payload{})
+}
+
+func TestChannelDrop(t *testing.T) {
+	c, err := NewChannel("drop", Options{Capacity: 1, Policy: PolicyDrop})
+	assert.NoError(t, err)
+	defer c.Close()
+
+	assert.NoError(t, c.Send(context.Background(), types.FetchItemChan{TaskId: "1"}))
+	assert.NoError(t, c.Send(context.Background(), types.FetchItemChan{TaskId: "2"}))
+
+	stats := c.Stats()
+	assert.Equal(t, int64(1), stats.Sent)
+	assert.Equal(t, int64(1), stats.Dropped)
+	assert.True(t, stats.Saturated)
+}
+
+func TestChannelBlockTimeout(t *testing.T) {
+	c, err := NewChannel("block", Options{Capacity: 1, Policy: PolicyBlock, Timeout: 20 * time.Millisecond})
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Send(context.Background(), types.FetchItemChan{TaskId: "1"}))
+	err = c.Send(context.Background(), types.FetchItemChan{TaskId: "2"})
+	assert.True(t, errors.Is(err, ErrTimeout))
+	assert.Equal(t, int64(1), c.Stats().TimedOut)
+
+	// 消费后可继续写入
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		<-c.C()
+	}()
+	assert.NoError(t, c.Send(context.Background(), types.FetchItemChan{TaskId: "3"}))
+
+	c.Close()
+	assert.ErrorIs(t, c.Send(context.Background(), types.FetchItemChan{}), ErrClosed)
+}
+
+func TestChannelSpillKeepsOrder(t *testing.T) {
+	c, err := NewChannel("spill", Options{Capacity: 2, Policy: PolicySpill, SpillDir: t.TempDir()})
+	assert.NoError(t, err)
+	defer c.Close()
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, c.Send(context.Background(), types.FetchItemChan{Data: payload{Index: i}}))
+	}
+	assert.Greater(t, c.Stats().Spilled, int64(0))
+
+	for i := 0; i < 10; i++ {
+		select {
+		case item := <-c.C():
+			assert.Equal(t, payload{Index: i}, item.Data)
+		case <-time.After(time.Second):
+			t.Fatalf("item %d not restored", i)
+		}
+	}
+	assert.Eventually(t, func() bool {
+		stats := c.Stats()
+		return stats.Sent == 10 && stats.Spilled == stats.Restored && stats.SpillDepth == 0
+	}, time.Second, 5*time.Millisecond)
+}
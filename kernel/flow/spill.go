@@ -0,0 +1,164 @@
+package flow
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"noctua/types"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// RegisterType 注册通道数据的具体类型，溢写到磁盘时 gob 需要知道 interface 的实现
+func RegisterType(value interface{}) {
+	gob.Register(value)
+}
+
+// spillQueue 基于单个文件的先进先出磁盘队列
+// 每条记录为 4 字节长度 + gob 编码的 FetchItemChan
+type spillQueue struct {
+	mu       sync.Mutex
+	path     string
+	writer   *os.File
+	reader   *os.File
+	bufRd    *bufio.Reader
+	pending  int
+	inflight int // 已读出但尚未投递的记录数
+	written  int64
+	maxBytes int64
+}
+
+func newSpillQueue(dir, name string, maxBytes int64) (*spillQueue, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create spill directory %s failed: %v", dir, err)
+	}
+	path := filepath.Join(dir, name+".spill")
+	writer, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open spill file %s failed: %v", path, err)
+	}
+	reader, err := os.Open(path)
+	if err != nil {
+		_ = writer.Close()
+		return nil, fmt.Errorf("open spill file %s failed: %v", path, err)
+	}
+	return &spillQueue{
+		path:     path,
+		writer:   writer,
+		reader:   reader,
+		bufRd:    bufio.NewReader(reader),
+		maxBytes: maxBytes,
+	}, nil
+}
+
+// Push 追加一条记录
+func (q *spillQueue) Push(item types.FetchItemChan) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&item); err != nil {
+		return fmt.Errorf("encode spill item failed: %v", err)
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.maxBytes > 0 && q.written+int64(buf.Len())+4 > q.maxBytes {
+		return fmt.Errorf("spill file %s exceeds %d bytes", q.path, q.maxBytes)
+	}
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(buf.Len()))
+	if _, err := q.writer.Write(size[:]); err != nil {
+		return err
+	}
+	if _, err := q.writer.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	q.written += int64(buf.Len()) + 4
+	q.pending++
+	return nil
+}
+
+// Pop 读取最早的一条记录，队列为空时返回 false
+func (q *spillQueue) Pop() (types.FetchItemChan, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var item types.FetchItemChan
+	if q.pending == 0 {
+		return item, false, nil
+	}
+	var size [4]byte
+	if _, err := io.ReadFull(q.bufRd, size[:]); err != nil {
+		return item, false, q.corrupted(err)
+	}
+	data := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(q.bufRd, data); err != nil {
+		return item, false, q.corrupted(err)
+	}
+	q.pending--
+	q.inflight++
+	if q.pending == 0 {
+		// 读写位置归零，避免文件无限增长
+		if err := q.reset(); err != nil {
+			return item, false, err
+		}
+	}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&item); err != nil {
+		q.inflight--
+		return item, true, fmt.Errorf("decode spill item failed: %v", err)
+	}
+	return item, true, nil
+}
+
+// corrupted 文件无法继续读取时清空队列
+func (q *spillQueue) corrupted(err error) error {
+	lost := q.pending
+	q.pending = 0
+	if resetErr := q.reset(); resetErr != nil {
+		return resetErr
+	}
+	return fmt.Errorf("spill file %s corrupted, %d items lost: %v", q.path, lost, err)
+}
+
+func (q *spillQueue) reset() error {
+	if err := q.writer.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := q.writer.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := q.reader.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	q.bufRd.Reset(q.reader)
+	q.written = 0
+	return nil
+}
+
+// Done 标记一条 Pop 出的记录已处理完毕
+func (q *spillQueue) Done() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.inflight > 0 {
+		q.inflight--
+	}
+}
+
+// Len 待投递的记录数，包含已读出尚未投递的记录
+func (q *spillQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pending + q.inflight
+}
+
+// Close 关闭并删除文件，返回未读取的记录数
+func (q *spillQueue) Close() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	left := q.pending
+	q.pending = 0
+	_ = q.writer.Close()
+	_ = q.reader.Close()
+	_ = os.Remove(q.path)
+	return left
+}
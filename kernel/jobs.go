@@ -0,0 +1,42 @@
+package kernel
+
+import "sync"
+
+// DefaultJobProjects 记录任务所属项目的最大任务数，超过时丢弃最早的记录
+const DefaultJobProjects = 1024
+
+// jobProjects 记录采集任务所属的项目，供事件日志、推送、回调和通知按项目隔离
+type jobProjects struct {
+	mu       sync.RWMutex
+	max      int
+	order    []string
+	projects map[string]uint
+}
+
+func newJobProjects(max int) *jobProjects {
+	if max <= 0 {
+		max = DefaultJobProjects
+	}
+	return &jobProjects{max: max, projects: make(map[string]uint)}
+}
+
+// bind 记录任务所属的项目
+func (j *jobProjects) bind(jobID string, projectID uint) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, ok := j.projects[jobID]; !ok {
+		j.order = append(j.order, jobID)
+	}
+	j.projects[jobID] = projectID
+	for len(j.order) > j.max {
+		delete(j.projects, j.order[0])
+		j.order = j.order[1:]
+	}
+}
+
+// Project 任务所属的项目，未知任务返回 0
+func (j *jobProjects) Project(jobID string) uint {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.projects[jobID]
+}
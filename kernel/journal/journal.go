@@ -0,0 +1,399 @@
+// Package journal 将总线事件和运行时数据追加写入事件日志表，支持按序列号续读
+package journal
+
+import (
+	"context"
+	"encoding/json"
+	"noctua/internal/model"
+	"noctua/kernel/bus"
+	"noctua/pkg/logger"
+	"noctua/types"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	KindEvent   = "event"   // 总线事件
+	KindRuntime = "runtime" // 运行时数据
+
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = 500 * time.Millisecond
+	DefaultPruneInterval = time.Hour
+	DefaultRetryDelay    = time.Second
+	DefaultMaxRetryDelay = time.Minute
+	DefaultMaxPending    = 10000
+	DefaultLimit         = 200
+	MaxLimit             = 1000
+)
+
+// Config 事件日志配置
+type Config struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	BatchSize     int           `mapstructure:"batch_size"`      // 单次写入条数
+	FlushInterval time.Duration `mapstructure:"flush_interval"`  // 最长写入间隔
+	Retention     time.Duration `mapstructure:"retention"`       // 保留时长，0 为永久保留
+	PruneInterval time.Duration `mapstructure:"prune_interval"`  // 清理间隔
+	RetryDelay    time.Duration `mapstructure:"retry_delay"`     // 写入失败后首次重试的间隔，之后指数退避
+	MaxRetryDelay time.Duration `mapstructure:"max_retry_delay"` // 重试间隔上限
+	MaxPending    int           `mapstructure:"max_pending"`     // 写入失败时最多保留的待写记录数，超出时丢弃最早的记录
+}
+
+// store 事件日志存储
+type store interface {
+	Append(entries []*model.EventJournal) error
+	Since(params *model.EventJournalQueryParams) ([]model.EventJournal, error)
+	LastSeq() (uint64, error)
+	Prune(before time.Time) (int64, error)
+}
+
+// Entry 事件日志记录
+type Entry struct {
+	Seq        uint64          `json:"seq"`
+	Kind       string          `json:"kind"`
+	Topic      string          `json:"topic"`
+	JobID      string          `json:"jobId"`
+	ProjectID  uint            `json:"projectId"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurredAt"`
+}
+
+// Filter 查询过滤条件
+type Filter struct {
+	Kind      string // event / runtime，为空不过滤
+	Topic     string // 主题模式，支持 * 和 # 通配
+	JobID     string
+	ProjectID uint // 所属项目，只返回该项目的记录
+}
+
+func (f Filter) match(entry *model.EventJournal) bool {
+	return f.Topic == "" || bus.MatchTopic(f.Topic, entry.Topic)
+}
+
+// Journal 事件日志
+type Journal struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	config    Config
+	sub       *bus.Subscription[any]
+	records   chan *model.EventJournal
+	wg        sync.WaitGroup
+	lastSeq   atomic.Uint64
+	mu        sync.Mutex
+	changed   chan struct{} // 每次写入后关闭并替换，用于唤醒续读方
+	store     store
+	projectOf types.ProjectResolver // 确定事件所属项目
+	dropped   atomic.Int64          // 写入持续失败时丢弃的记录数
+}
+
+// New 创建事件日志并订阅总线全部事件，projectOf 用于确定事件所属项目
+func New(ctx context.Context, eventBus *bus.EventBus, config Config, projectOf types.ProjectResolver) *Journal {
+	return newJournal(ctx, eventBus, config, &model.EventJournal{}, projectOf)
+}
+
+func newJournal(ctx context.Context, eventBus *bus.EventBus, config Config, store store, projectOf types.ProjectResolver) *Journal {
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultFlushInterval
+	}
+	if config.PruneInterval <= 0 {
+		config.PruneInterval = DefaultPruneInterval
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = DefaultRetryDelay
+	}
+	if config.MaxRetryDelay < config.RetryDelay {
+		config.MaxRetryDelay = max(DefaultMaxRetryDelay, config.RetryDelay)
+	}
+	if config.MaxPending < config.BatchSize {
+		config.MaxPending = max(DefaultMaxPending, config.BatchSize)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	j := &Journal{
+		ctx:       ctx,
+		cancel:    cancel,
+		config:    config,
+		sub:       bus.Subscribe[any](eventBus, bus.Options{Topic: bus.WildcardAll, Mode: bus.ModeUnbounded}),
+		records:   make(chan *model.EventJournal, config.BatchSize*10),
+		changed:   make(chan struct{}),
+		store:     store,
+		projectOf: projectOf,
+	}
+	if seq, err := j.store.LastSeq(); err == nil {
+		j.lastSeq.Store(seq)
+	} else {
+		logger.Log.Errorf("Load event journal last seq failed: %v", err)
+	}
+	j.wg.Add(1)
+	go j.writeLoop()
+	if config.Retention > 0 {
+		j.wg.Add(1)
+		go j.pruneLoop()
+	}
+	return j
+}
+
+// RecordRuntime 记录运行时数据，可注册为 Kernel 的 RuntimeHandler
+func (j *Journal) RecordRuntime(data types.RuntimeData) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logger.Log.Errorf("Marshal runtime data failed: %v", err)
+		return
+	}
+	record := &model.EventJournal{
+		Kind:       KindRuntime,
+		Topic:      "runtime." + string(data.EventCode),
+		JobID:      data.JobID,
+		ProjectID:  j.projectOf.Runtime(data),
+		Payload:    string(payload),
+		OccurredAt: data.EventTime,
+	}
+	select {
+	case j.records <- record:
+	case <-j.ctx.Done():
+	}
+}
+
+// RuntimeDropped 运行时数据队列已满丢弃记录时回调，可注册为 broadcast.Options.OnDrop
+func (j *Journal) RuntimeDropped(total uint64) {
+	j.dropped.Add(1)
+	// 持续丢弃时每 100 条记录一次日志
+	if total == 1 || total%100 == 0 {
+		logger.Log.Errorf("Event journal runtime queue full, dropped %d records in total", total)
+	}
+}
+
+// Dropped 写入持续失败、待写记录超过 MaxPending 或运行时数据队列已满时丢弃的记录数
+func (j *Journal) Dropped() int64 {
+	return j.dropped.Load()
+}
+
+// LastSeq 最新写入的序列号
+func (j *Journal) LastSeq() uint64 {
+	return j.lastSeq.Load()
+}
+
+// Since 返回序列号大于 since 的记录，next 为已扫描到的最大序列号，续读时作为下一次的 since
+func (j *Journal) Since(since uint64, limit int, filter Filter) (entries []Entry, next uint64, err error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	next = since
+	params := &model.EventJournalQueryParams{Kind: filter.Kind, JobID: filter.JobID, ProjectID: filter.ProjectID, Limit: limit}
+	// 不含通配符时由数据库过滤主题
+	if !strings.ContainsAny(filter.Topic, bus.WildcardOne+bus.WildcardAll) {
+		params.Topic = filter.Topic
+	}
+	for len(entries) < limit {
+		params.Since = next
+		records, err := j.store.Since(params)
+		if err != nil {
+			return entries, next, err
+		}
+		for i := range records {
+			next = records[i].Seq
+			if !filter.match(&records[i]) {
+				continue
+			}
+			entries = append(entries, toEntry(&records[i]))
+			if len(entries) >= limit {
+				break
+			}
+		}
+		if len(records) < params.Limit {
+			break
+		}
+	}
+	return entries, next, nil
+}
+
+// Follow 从 since 之后开始续读，追上后等待新记录，ctx 结束或日志关闭后关闭通道
+func (j *Journal) Follow(ctx context.Context, since uint64, filter Filter) <-chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		cursor := since
+		for {
+			// 先取等待信号再查询，避免查询与写入之间的记录被遗漏
+			changed := j.waitChanged()
+			entries, next, err := j.Since(cursor, DefaultLimit, filter)
+			if err != nil {
+				logger.Log.Errorf("Follow event journal failed: %v", err)
+			}
+			for _, entry := range entries {
+				select {
+				case out <- entry:
+				case <-ctx.Done():
+					return
+				case <-j.ctx.Done():
+					return
+				}
+			}
+			cursor = next
+			// 返回满一页说明仍有积压，继续读取
+			if err == nil && len(entries) >= DefaultLimit {
+				continue
+			}
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return
+			case <-j.ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Close 停止订阅并写入剩余记录
+func (j *Journal) Close() {
+	j.sub.Cancel()
+	j.cancel()
+	j.wg.Wait()
+}
+
+func (j *Journal) waitChanged() <-chan struct{} {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.changed
+}
+
+// writeLoop 批量写入记录，写入失败时保留待写记录并按指数退避重试，待写记录超过 MaxPending 时丢弃最早的记录
+func (j *Journal) writeLoop() {
+	defer j.wg.Done()
+	ticker := time.NewTicker(j.config.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]*model.EventJournal, 0, j.config.BatchSize)
+	failures := 0
+	var retryAt time.Time
+	// force 为 true 时忽略退避，关闭前做最后一次尝试
+	flush := func(force bool) {
+		if len(batch) == 0 || (!force && time.Now().Before(retryAt)) {
+			return
+		}
+		// 失败的写入可能已回填序列号，重试前清空由数据库重新分配
+		for _, record := range batch {
+			record.Seq = 0
+		}
+		if err := j.store.Append(batch); err != nil {
+			failures++
+			delay := min(j.config.RetryDelay<<min(failures-1, 16), j.config.MaxRetryDelay)
+			retryAt = time.Now().Add(delay)
+			logger.Log.Errorf("Write %d event journal records failed (attempt %d), retry in %s: %v", len(batch), failures, delay, err)
+			return
+		}
+		failures = 0
+		retryAt = time.Time{}
+		j.lastSeq.Store(batch[len(batch)-1].Seq)
+		j.mu.Lock()
+		close(j.changed)
+		j.changed = make(chan struct{})
+		j.mu.Unlock()
+		batch = make([]*model.EventJournal, 0, j.config.BatchSize)
+	}
+	add := func(record *model.EventJournal) {
+		batch = append(batch, record)
+		if overflow := len(batch) - j.config.MaxPending; overflow > 0 {
+			j.dropped.Add(int64(overflow))
+			logger.Log.Errorf("Event journal pending records exceed %d, dropped %d oldest records", j.config.MaxPending, overflow)
+			batch = append(batch[:0], batch[overflow:]...)
+		}
+		if len(batch) >= j.config.BatchSize {
+			flush(false)
+		}
+	}
+	for {
+		select {
+		case event, ok := <-j.sub.C():
+			if !ok {
+				j.drainRecords(add)
+				flush(true)
+				return
+			}
+			if record := j.eventRecord(event); record != nil {
+				add(record)
+			}
+		case record := <-j.records:
+			add(record)
+		case <-ticker.C:
+			flush(false)
+		case <-j.ctx.Done():
+			j.drainRecords(add)
+			flush(true)
+			return
+		}
+	}
+}
+
+// drainRecords 写入已排队的运行时数据
+func (j *Journal) drainRecords(add func(*model.EventJournal)) {
+	for {
+		select {
+		case record := <-j.records:
+			add(record)
+		default:
+			return
+		}
+	}
+}
+
+func (j *Journal) pruneLoop() {
+	defer j.wg.Done()
+	ticker := time.NewTicker(j.config.PruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if removed, err := j.store.Prune(time.Now().Add(-j.config.Retention)); err != nil {
+				logger.Log.Errorf("Prune event journal failed: %v", err)
+			} else if removed > 0 {
+				logger.Log.Infof("Pruned %d event journal records", removed)
+			}
+		case <-j.ctx.Done():
+			return
+		}
+	}
+}
+
+// eventRecord 将总线事件转换为日志记录
+func (j *Journal) eventRecord(event interface{}) *model.EventJournal {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		logger.Log.Errorf("Marshal event %T failed: %v", event, err)
+		return nil
+	}
+	record := &model.EventJournal{
+		Kind:       KindEvent,
+		Topic:      bus.TopicOf(event),
+		ProjectID:  j.projectOf.Event(event),
+		Payload:    string(payload),
+		OccurredAt: time.Now(),
+	}
+	if lifecycle, ok := event.(types.LifecycleEvent); ok {
+		meta := lifecycle.Meta()
+		record.JobID = meta.JobID
+		if !meta.OccurredAt.IsZero() {
+			record.OccurredAt = meta.OccurredAt
+		}
+	}
+	return record
+}
+
+func toEntry(record *model.EventJournal) Entry {
+	return Entry{
+		Seq:        record.Seq,
+		Kind:       record.Kind,
+		Topic:      record.Topic,
+		JobID:      record.JobID,
+		ProjectID:  record.ProjectID,
+		Payload:    json.RawMessage(record.Payload),
+		OccurredAt: record.OccurredAt,
+	}
+}
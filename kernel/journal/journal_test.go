@@ -0,0 +1,167 @@
+package journal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"noctua/internal/model"
+	"noctua/kernel/bus"
+	"noctua/pkg/database/dbtest"
+	"noctua/types"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain 数据库只能初始化一次，所有测试共享同一个库
+func TestMain(m *testing.M) {
+	dbtest.Main(m, &model.EventJournal{})
+}
+
+// setupJournal 返回创建时的最新序列号作为基准
+func setupJournal(t *testing.T) (*bus.EventBus, *Journal, uint64) {
+	eventBus := bus.NewEventBus(0)
+	j := New(context.Background(), eventBus, Config{Enabled: true, BatchSize: 10, FlushInterval: 10 * time.Millisecond}, nil)
+	t.Cleanup(func() {
+		j.Close()
+		eventBus.Close()
+	})
+	return eventBus, j, j.LastSeq()
+}
+
+func waitSeq(t *testing.T, j *Journal, seq uint64) {
+	assert.Eventually(t, func() bool { return j.LastSeq() >= seq }, 2*time.Second, 5*time.Millisecond)
+}
+
+func TestJournalSince(t *testing.T) {
+	eventBus, j, base := setupJournal(t)
+	eventBus.Publish(types.JobStartedEvent{EventMeta: types.NewEventMeta("job-1")})
+	eventBus.Publish(types.TaskSubmittedEvent{EventMeta: types.NewEventMeta("job-1"), TaskInfo: types.TaskInfo{TaskID: "t1"}})
+	eventBus.Publish(types.TaskSucceededEvent{EventMeta: types.NewEventMeta("job-2"), TaskInfo: types.TaskInfo{TaskID: "t2"}})
+	j.RecordRuntime(types.RuntimeData{EventCode: "crawl", EventTime: time.Now()})
+	waitSeq(t, j, base+4)
+
+	all, next, err := j.Since(base, 0, Filter{})
+	require.NoError(t, err)
+	assert.Len(t, all, 4)
+	assert.Equal(t, base+4, next)
+
+	tasks, _, err := j.Since(base, 0, Filter{Topic: "task.*"})
+	require.NoError(t, err)
+	assert.Len(t, tasks, 2)
+
+	job, _, err := j.Since(base, 0, Filter{JobID: "job-1"})
+	require.NoError(t, err)
+	require.Len(t, job, 2)
+	assert.Equal(t, types.TopicJobStarted, job[0].Topic)
+
+	runtime, _, err := j.Since(base, 0, Filter{Kind: KindRuntime})
+	require.NoError(t, err)
+	require.Len(t, runtime, 1)
+	assert.Equal(t, "runtime.crawl", runtime[0].Topic)
+
+	// 分页续读
+	page, next, err := j.Since(base, 2, Filter{})
+	require.NoError(t, err)
+	assert.Len(t, page, 2)
+	rest, _, err := j.Since(next, 0, Filter{})
+	require.NoError(t, err)
+	assert.Len(t, rest, 2)
+	assert.Greater(t, rest[0].Seq, page[1].Seq)
+}
+
+func TestJournalFollow(t *testing.T) {
+	eventBus, j, base := setupJournal(t)
+	eventBus.Publish(types.JobStartedEvent{EventMeta: types.NewEventMeta("job-1")})
+	eventBus.Publish(types.TaskStartedEvent{EventMeta: types.NewEventMeta("job-1")})
+	waitSeq(t, j, base+2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	entries := j.Follow(ctx, base+1, Filter{})
+
+	next := func() Entry {
+		select {
+		case entry := <-entries:
+			return entry
+		case <-time.After(2 * time.Second):
+			t.Fatal("follow timeout")
+			return Entry{}
+		}
+	}
+	assert.Equal(t, types.TopicTaskStarted, next().Topic)
+
+	eventBus.Publish(types.JobFinishedEvent{EventMeta: types.NewEventMeta("job-1")})
+	entry := next()
+	assert.Equal(t, types.TopicJobFinished, entry.Topic)
+	assert.Equal(t, base+3, entry.Seq)
+	assert.Equal(t, "job-1", entry.JobID)
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		_, ok := <-entries
+		return !ok
+	}, 2*time.Second, 5*time.Millisecond)
+}
+
+// failingStore 在 fail 为 true 时写入失败
+type failingStore struct {
+	*model.EventJournal
+	fail     atomic.Bool
+	attempts atomic.Int32
+}
+
+func (s *failingStore) Append(entries []*model.EventJournal) error {
+	s.attempts.Add(1)
+	if s.fail.Load() {
+		return errors.New("database is locked")
+	}
+	return s.EventJournal.Append(entries)
+}
+
+func TestJournalRetryFailedAppend(t *testing.T) {
+	store := &failingStore{EventJournal: &model.EventJournal{}}
+	store.fail.Store(true)
+	eventBus := bus.NewEventBus(0)
+	j := newJournal(context.Background(), eventBus, Config{
+		Enabled:       true,
+		BatchSize:     2,
+		FlushInterval: 5 * time.Millisecond,
+		RetryDelay:    5 * time.Millisecond,
+		MaxRetryDelay: 20 * time.Millisecond,
+		MaxPending:    3,
+	}, store, nil)
+	defer eventBus.Close()
+	defer j.Close()
+	base := j.LastSeq()
+
+	// 写入失败时保留批次并重试
+	for i := 0; i < 2; i++ {
+		eventBus.Publish(types.TaskSubmittedEvent{EventMeta: types.NewEventMeta("retry"), TaskInfo: types.TaskInfo{TaskID: fmt.Sprintf("t%d", i)}})
+	}
+	assert.Eventually(t, func() bool { return store.attempts.Load() >= 3 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, base, j.LastSeq())
+
+	// 待写记录超过上限时丢弃最早的记录
+	for i := 2; i < 5; i++ {
+		eventBus.Publish(types.TaskSubmittedEvent{EventMeta: types.NewEventMeta("retry"), TaskInfo: types.TaskInfo{TaskID: fmt.Sprintf("t%d", i)}})
+	}
+	assert.Eventually(t, func() bool { return j.Dropped() == 2 }, time.Second, 5*time.Millisecond)
+
+	// 恢复后写入保留的记录
+	store.fail.Store(false)
+	waitSeq(t, j, base+3)
+	entries, _, err := j.Since(base, 0, Filter{JobID: "retry"})
+	require.NoError(t, err)
+	var tasks []string
+	for _, entry := range entries {
+		var event types.TaskSubmittedEvent
+		require.NoError(t, json.Unmarshal(entry.Payload, &event))
+		tasks = append(tasks, event.TaskID)
+	}
+	assert.Equal(t, []string{"t2", "t3", "t4"}, tasks)
+}
@@ -3,37 +3,46 @@ package kernel
 import (
 	"context"
 	"noctua/internal/proxy"
-	"noctua/internal/scheduler"
+	"noctua/kernel/broadcast"
 	"noctua/kernel/bus"
+	"noctua/kernel/journal"
+	"noctua/kernel/notify"
 	"noctua/kernel/session"
+	"noctua/kernel/sink"
+	"noctua/kernel/stream"
+	"noctua/kernel/webhook"
 	"noctua/pkg/logger"
 	"noctua/types"
-	"reflect"
 	systemRuntime "runtime"
-	"sync"
+	"time"
 )
 
 type KernelConfig struct {
-	ProxyConfig     proxy.ProxyPoolConfig
-	SchedulerConfig scheduler.Config
-	CrawlerConfig   CrawlerManagerConfig
+	ProxyConfig   proxy.ProxyPoolConfig
+	CrawlerConfig CrawlerManagerConfig
+	SinkConfig    sink.Config
+	JournalConfig journal.Config
+	RuntimeConfig broadcast.Config
+	NotifyConfig  notify.Config
+	StreamConfig  stream.Config
+	WebhookConfig webhook.Config
+	SessionConfig session.Config
 }
 
 type Kernel struct {
-	Ctx             context.Context
-	Version         string
-	OS              string
-	Scheduler       *scheduler.Scheduler
-	EventBus        *bus.EventBus
-	EventListener   *EventListener
-	SessionManager  *session.Manager
-	CrawlerManager  *CrawlerManager
-	runtimeStarted  bool
-	RuntimeChannel  chan types.RuntimeData
-	runtimeHandlers struct {
-		sync.RWMutex
-		handlers []func(types.RuntimeData) // 回调函数列表
-	}
+	Ctx            context.Context
+	Version        string
+	OS             string
+	EventBus       *bus.EventBus
+	EventListener  *EventListener
+	Journal        *journal.Journal    // 事件日志，未启用时为 nil
+	Stream         *stream.Hub         // 实时推送
+	Notify         *notify.Center      // 消息中心，未启用时为 nil
+	Webhook        *webhook.Dispatcher // Webhook 投递，未启用时为 nil
+	SessionManager *session.Manager
+	SinkManager    *sink.Manager
+	CrawlerManager *CrawlerManager
+	Runtime        *broadcast.Broadcaster // 运行时数据分发
 }
 
 func NewKernel(ctx context.Context, version string) *Kernel {
@@ -50,77 +59,81 @@ func NewKernel(ctx context.Context, version string) *Kernel {
 	}
 	// 处理核心属性
 	k := &Kernel{
-		Ctx:            ctx,
-		Version:        version,
-		OS:             OS,
-		RuntimeChannel: make(chan types.RuntimeData, 5000),
+		Ctx:     ctx,
+		Version: version,
+		OS:      OS,
+		Runtime: broadcast.New(config.RuntimeConfig),
 	}
 	// 加载事件总线
 	k.EventBus = bus.NewEventBus(2000)
-	// 加载调度器
-	k.Scheduler = scheduler.New(k.Ctx, config.SchedulerConfig)
+	// 采集任务所属项目，供下列组件按项目隔离事件
+	jobs := newJobProjects(DefaultJobProjects)
+	projectOf := types.ProjectResolver(jobs.Project)
+	// 加载事件日志，需在其他组件发布事件前订阅
+	if config.JournalConfig.Enabled {
+		k.Journal = journal.New(k.Ctx, k.EventBus, config.JournalConfig, projectOf)
+		// 事件日志不应丢失记录，队列满时阻塞发布方，超时丢弃的记录计入 Dropped
+		k.AddRuntimeHandler(k.Journal.RecordRuntime, broadcast.Options{
+			Name:    "journal",
+			Buffer:  5000,
+			Policy:  broadcast.PolicyBlock,
+			Timeout: time.Second,
+			OnDrop:  k.Journal.RuntimeDropped,
+		})
+	}
+	// 加载实时推送
+	k.Stream = stream.NewHub(k.Ctx, k.EventBus, config.StreamConfig, projectOf)
+	k.AddRuntimeHandler(k.Stream.PublishRuntime, broadcast.Options{Name: "stream"})
+	// 加载消息中心
+	if config.NotifyConfig.Enabled {
+		k.Notify = notify.New(k.Ctx, config.NotifyConfig, projectOf)
+		k.AddRuntimeHandler(k.Notify.Record, broadcast.Options{Name: "notify"})
+	}
+	// 加载 Webhook 投递
+	if config.WebhookConfig.Enabled {
+		k.Webhook = webhook.New(k.Ctx, k.EventBus, config.WebhookConfig, projectOf)
+	}
+	// 加载代理池
+	proxyPool := proxy.NewProxyPool(k.Ctx, config.ProxyConfig)
+	proxyPool.SetEmitter(k.EventBus.Publish)
+	k.watchProxyConfig(proxyPool)
 	// 加载sessionManager
-	k.SessionManager = session.NewManager(proxy.NewProxyPool(k.Ctx, config.ProxyConfig))
-	// 创建爬虫管理器
-	k.CrawlerManager = NewCrawlerManager(k.Scheduler.Context(), config.CrawlerConfig, k.SessionManager, k.EventBus, k.Scheduler, k.RuntimeChannel)
+	k.SessionManager = session.NewManager(proxyPool)
+	k.SessionManager.SetEmitter(k.EventBus.Publish)
+	k.SessionManager.StartCookieCheck(k.Ctx, config.SessionConfig.CookieCheck)
+	k.SessionManager.StartLeases(k.Ctx, config.SessionConfig.Lease)
+	k.SessionManager.StartCookieJar(k.Ctx, config.SessionConfig.CookieJar)
+	k.SessionManager.SetAffinity(config.SessionConfig.Affinity)
+	if err := k.SessionManager.StartSelection(k.Ctx, config.SessionConfig.Selection); err != nil {
+		logger.Log.Errorf("Start account selection failed, fallback to lru: %v", err)
+	}
+	// 加载数据输出
+	k.SinkManager = sink.NewManager(k.Ctx, config.SinkConfig)
+	// 创建爬虫管理器，各采集任务使用独立的调度器
+	k.CrawlerManager = NewCrawlerManager(k.Ctx, config.CrawlerConfig, k.SessionManager, k.EventBus, k.SinkManager, k.Runtime.Publish)
+	k.CrawlerManager.jobs = jobs
+	// 探测账号登录态，需在爬虫管理器注册探测方法后启动
+	k.SessionManager.StartProbe(k.Ctx, config.SessionConfig.Probe)
 	// 加载Listener
-	k.EventListener = NewEventListener(k.Ctx, k.EventBus, k.Scheduler, k.SessionManager, k.RuntimeChannel)
+	k.EventListener = NewEventListener(k.Ctx, k.EventBus, k.SessionManager, k.Runtime.Publish)
 	// 启动listener
 	k.EventListener.Start()
 	return k
 }
 
-// AddRuntimeHandler 添加一个运行时数据处理回调
-func (k *Kernel) AddRuntimeHandler(handler func(types.RuntimeData)) {
-	k.runtimeHandlers.Lock()
-	defer k.runtimeHandlers.Unlock()
-	k.runtimeHandlers.handlers = append(k.runtimeHandlers.handlers, handler)
+// AddRuntimeHandler 添加一个运行时数据处理回调，回调在独立协程中执行，返回的句柄用于移除
+func (k *Kernel) AddRuntimeHandler(handler func(types.RuntimeData), opts ...broadcast.Options) broadcast.Handle {
+	return k.Runtime.Add(handler, opts...)
 }
 
-func (k *Kernel) RemoveRuntimeHandler(handler func(types.RuntimeData)) {
-	k.runtimeHandlers.Lock()
-	defer k.runtimeHandlers.Unlock()
-	for i, h := range k.runtimeHandlers.handlers {
-		if reflect.ValueOf(h).Pointer() == reflect.ValueOf(handler).Pointer() {
-			k.runtimeHandlers.handlers = append(k.runtimeHandlers.handlers[:i], k.runtimeHandlers.handlers[i+1:]...)
-			return
-		}
-	}
+// RemoveRuntimeHandler 移除运行时数据处理回调
+func (k *Kernel) RemoveRuntimeHandler(handle broadcast.Handle) bool {
+	return k.Runtime.Remove(handle)
 }
 
-// ProcessRuntime 处理 RuntimeChannel 的数据并分发给所有注册的回调
-func (k *Kernel) ProcessRuntime() {
-	k.runtimeHandlers.Lock()
-	if k.runtimeStarted {
-		k.runtimeHandlers.Unlock()
-		return
-	}
-	k.runtimeStarted = true
-	k.runtimeHandlers.Unlock()
-	go func() {
-		for {
-			select {
-			case runtimeData := <-k.RuntimeChannel:
-				k.runtimeHandlers.RLock()
-				for _, handler := range k.runtimeHandlers.handlers {
-					if handler != nil {
-						func() {
-							defer func() {
-								if r := recover(); r != nil {
-									logger.Log.Errorf("Runtime handler panicked: %v", r)
-								}
-							}()
-							handler(runtimeData)
-						}()
-					}
-				}
-				k.runtimeHandlers.RUnlock()
-			case <-k.Ctx.Done():
-				logger.Log.Info("Runtime channel processing stopped due to context cancellation")
-				return
-			}
-		}
-	}()
+// PublishRuntime 发布运行时数据，不会阻塞调用方
+func (k *Kernel) PublishRuntime(data types.RuntimeData) {
+	k.Runtime.Publish(data)
 }
 
 func (k *Kernel) Status() {
@@ -128,5 +141,25 @@ func (k *Kernel) Status() {
 }
 
 func (k *Kernel) Stop() {
-
+	// 保存账号使用计数及合并后的 Cookie
+	k.SessionManager.FlushUsage()
+	k.SessionManager.FlushCookies()
+	// 刷新并关闭数据输出
+	k.SinkManager.Close()
+	// 处理完已发布的运行时数据
+	k.Runtime.Close()
+	// 断开实时推送的客户端
+	k.Stream.Close()
+	// 停止 Webhook 投递，未完成的记录下次启动后继续
+	if k.Webhook != nil {
+		k.Webhook.Close()
+	}
+	// 停止消息中心
+	if k.Notify != nil {
+		k.Notify.Close()
+	}
+	// 写入剩余的事件日志
+	if k.Journal != nil {
+		k.Journal.Close()
+	}
 }
@@ -3,39 +3,37 @@ package kernel
 import (
 	"context"
 	"fmt"
-	"noctua/internal/scheduler"
 	"noctua/kernel/bus"
 	"noctua/kernel/session"
 	"noctua/pkg/logger"
+	"noctua/pkg/utils/encrypt"
 	"noctua/types"
+	"strings"
 )
 
 type EventListener struct {
-	eventBus       *bus.EventBus
-	scheduler      *scheduler.Scheduler
-	sm             *session.Manager
-	mainCtx        context.Context
-	ctx            context.Context    // 添加上下文用于控制关闭
-	cancel         context.CancelFunc // 取消函数
-	runtimeChannel chan types.RuntimeData
+	eventBus    *bus.EventBus
+	sm          *session.Manager
+	mainCtx     context.Context
+	ctx         context.Context    // 添加上下文用于控制关闭
+	cancel      context.CancelFunc // 取消函数
+	emitRuntime types.RuntimeEmitter
 }
 
 func NewEventListener(
 	mainCtx context.Context,
 	eventBus *bus.EventBus,
-	scheduler *scheduler.Scheduler,
 	sm *session.Manager,
-	runtimeChannel chan types.RuntimeData,
+	emitRuntime types.RuntimeEmitter,
 ) *EventListener {
 	ctx, cancel := context.WithCancel(mainCtx) // 创建可取消的上下文
 	return &EventListener{
-		sm:             sm,
-		ctx:            ctx,
-		mainCtx:        mainCtx,
-		cancel:         cancel,
-		eventBus:       eventBus,
-		scheduler:      scheduler,
-		runtimeChannel: runtimeChannel,
+		sm:          sm,
+		ctx:         ctx,
+		mainCtx:     mainCtx,
+		cancel:      cancel,
+		eventBus:    eventBus,
+		emitRuntime: emitRuntime,
 	}
 }
 
@@ -49,6 +47,12 @@ func (k *EventListener) Start() {
 	k.ListenCrawlStart()
 	// 订阅采集停止事件
 	k.ListenCrawlEnd()
+	// 订阅账号 Cookie 过期事件
+	k.ListenAccountCookie()
+	// 订阅账号探测事件
+	k.ListenAccountProbe()
+	// 订阅账号限流冷却事件
+	k.ListenAccountCooldown()
 }
 
 // Stop 停止监听并清理资源
@@ -57,13 +61,15 @@ func (k *EventListener) Stop() {
 	k.eventBus.Close() // 关闭事件总线，停止事件分发
 }
 
-func (k *EventListener) listenEvent(eventType interface{}, capacity int, handler func(event interface{})) {
-	sub := k.eventBus.SubscribeToType(eventType, capacity)
+// listenEvent 订阅类型为 T 的事件并在独立协程中处理
+func listenEvent[T any](k *EventListener, opts bus.Options, handler func(event T)) {
+	sub := bus.Subscribe[T](k.eventBus, opts)
 	go func() {
-		eventName := fmt.Sprintf("%T", eventType)
+		defer sub.Cancel()
+		eventName := fmt.Sprintf("%T", *new(T))
 		for {
 			select {
-			case event, ok := <-sub:
+			case event, ok := <-sub.C():
 				if !ok {
 					logger.Log.Infof("%s subscription closed", eventName)
 					return
@@ -79,14 +85,88 @@ func (k *EventListener) listenEvent(eventType interface{}, capacity int, handler
 
 // ListenCrawlStart
 func (k *EventListener) ListenCrawlStart() {
-	k.listenEvent(types.CrawlStartEvent{}, 100, func(event interface{}) {
-		logger.Log.Infof("Crawl started ....")
+	listenEvent(k, bus.Options{Buffer: 100}, func(event types.JobStartedEvent) {
+		logger.Log.Infof("Crawl job %s started ....", event.JobID)
 	})
 }
 
-// ListenCrawlEnd
+// ListenCrawlEnd 记录结束采集任务的请求，任务的调度器由爬虫管理器记录结束原因后关闭
 func (k *EventListener) ListenCrawlEnd() {
-	k.listenEvent(types.CrawlEndEvent{}, 100, func(event interface{}) {
-		k.scheduler.Shutdown()
+	listenEvent(k, bus.Options{Buffer: 100}, func(event types.JobStoppingEvent) {
+		logger.Log.Infof("Crawl job %s stopping, code %d", event.JobID, event.Code)
+	})
+}
+
+// ListenAccountCookie 账号 Cookie 即将过期或被禁用时保存通知，同一账号同一过期时间只通知一次
+func (k *EventListener) ListenAccountCookie() {
+	listenEvent(k, bus.Options{Buffer: 100}, func(event types.AccountCookieExpiringEvent) {
+		level, title := "warning", "账号 Cookie 即将过期"
+		if event.Disabled {
+			level, title = "error", "账号 Cookie 已失效，账号已禁用"
+		}
+		var reasons []string
+		if len(event.Missing) > 0 {
+			reasons = append(reasons, "缺失 "+strings.Join(event.Missing, ","))
+		}
+		if len(event.Expiring) > 0 {
+			reasons = append(reasons, fmt.Sprintf("%s 将于 %s 过期", strings.Join(event.Expiring, ","), event.ExpireAt.Format("2006-01-02 15:04:05")))
+		}
+		if event.Error != "" {
+			reasons = append(reasons, event.Error)
+		}
+		k.emitRuntime.Emit(types.NewRuntimeData(types.RuntimeEventCodeNotification, types.EventData{
+			Title:     title,
+			Level:     level,
+			CheckHash: encrypt.Md5(fmt.Sprintf("cookie|%s|%s|%d|%t", event.MediaCode, event.UserID, event.ExpireAt.Unix(), event.Disabled)),
+			Message:   fmt.Sprintf("%s 账号 %s：%s", event.MediaCode, event.UserID, strings.Join(reasons, "；")),
+			MetaData:  event,
+			Optional: types.MessageOptional{
+				IsNotify: true,
+				IsStore:  true,
+				ShowType: "notification",
+			},
+		}).WithProject(event.ProjectID))
+	})
+}
+
+// ListenAccountProbe 账号因探测被禁用或恢复时保存通知
+func (k *EventListener) ListenAccountProbe() {
+	listenEvent(k, bus.Options{Buffer: 100}, func(event types.AccountProbedEvent) {
+		level, title := "success", "账号已恢复"
+		message := fmt.Sprintf("%s 账号 %s 登录态校验通过，已重新启用", event.MediaCode, event.UserID)
+		if event.Action == session.ProbeActionDisabled {
+			level, title = "error", "账号已禁用"
+			message = fmt.Sprintf("%s 账号 %s 连续 %d 次登录态校验失败，已禁用：%s", event.MediaCode, event.UserID, event.Failures, event.Error)
+		}
+		k.emitRuntime.Emit(types.NewRuntimeData(types.RuntimeEventCodeNotification, types.EventData{
+			Title:     title,
+			Level:     level,
+			CheckHash: encrypt.Md5(fmt.Sprintf("probe|%s|%s|%s|%d", event.MediaCode, event.UserID, event.Action, event.OccurredAt.UnixNano())),
+			Message:   message,
+			MetaData:  event,
+			Optional: types.MessageOptional{
+				IsNotify: true,
+				IsStore:  true,
+				ShowType: "notification",
+			},
+		}).WithProject(event.ProjectID))
+	})
+}
+
+// ListenAccountCooldown 账号触发限流进入冷却时保存通知
+func (k *EventListener) ListenAccountCooldown() {
+	listenEvent(k, bus.Options{Buffer: 100}, func(event types.AccountCooldownEvent) {
+		k.emitRuntime.Emit(types.NewRuntimeData(types.RuntimeEventCodeNotification, types.EventData{
+			Title:     "账号触发限流",
+			Level:     "warning",
+			CheckHash: encrypt.Md5(fmt.Sprintf("cooldown|%s|%s|%d", event.MediaCode, event.UserID, event.Until.Unix())),
+			Message:   fmt.Sprintf("%s 账号 %s 触发限流（%s），冷却至 %s", event.MediaCode, event.UserID, event.Reason, event.Until.Format("2006-01-02 15:04:05")),
+			MetaData:  event,
+			Optional: types.MessageOptional{
+				IsNotify: true,
+				IsStore:  true,
+				ShowType: "notification",
+			},
+		}).WithProject(event.ProjectID))
 	})
 }
@@ -0,0 +1,11 @@
+package kernel
+
+import (
+	"noctua/pkg/database/dbtest"
+	"testing"
+)
+
+// TestMain 数据库只能初始化一次，所有测试共享同一个库
+func TestMain(m *testing.M) {
+	dbtest.Main(m, migrationModels()...)
+}
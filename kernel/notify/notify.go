@@ -0,0 +1,205 @@
+// Package notify 保存需要持久化的运行时通知，供前端消息中心查询
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"noctua/internal/model"
+	"noctua/pkg/database"
+	"noctua/pkg/logger"
+	"noctua/pkg/utils/encrypt"
+	"noctua/types"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultPruneInterval    = time.Hour
+	DefaultDeletedRetention = 30 * 24 * time.Hour
+)
+
+// Config 消息中心配置
+type Config struct {
+	Enabled          bool          `mapstructure:"enabled"`
+	DefaultTTL       time.Duration `mapstructure:"default_ttl"`       // 未设置 ExpiredAt 时的有效期，0 为不过期
+	PruneInterval    time.Duration `mapstructure:"prune_interval"`    // 过期清理间隔
+	DeletedRetention time.Duration `mapstructure:"deleted_retention"` // 删除的通知保留用于去重的时长，超过后物理删除
+}
+
+// Item 通知消息
+type Item struct {
+	ID        uint       `json:"id"`
+	ProjectID uint       `json:"projectId"`
+	EventCode string     `json:"eventCode"`
+	JobID     string     `json:"jobId,omitempty"`
+	ReadAt    *time.Time `json:"readAt,omitempty"`
+	types.EventData
+}
+
+// NewItem 将数据库记录转换为通知消息
+func NewItem(record *model.Notification) Item {
+	item := Item{
+		ID:        record.ID,
+		ProjectID: record.ProjectID,
+		EventCode: record.EventCode,
+		JobID:     record.JobID,
+		ReadAt:    record.ReadAt,
+		EventData: types.EventData{
+			CheckHash: record.CheckHash,
+			Level:     record.Level,
+			Title:     record.Title,
+			Message:   record.Message,
+			Optional: types.MessageOptional{
+				IsNotify: record.IsNotify,
+				IsStore:  true,
+				ShowType: record.ShowType,
+			},
+			IsRead:     record.IsRead,
+			ReceivedAt: record.ReceivedAt,
+		},
+	}
+	if record.MetaData != "" {
+		item.MetaData = json.RawMessage(record.MetaData)
+	}
+	if record.ExpiredAt != nil {
+		item.ExpiredAt = *record.ExpiredAt
+	}
+	return item
+}
+
+// Center 消息中心
+type Center struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	config    Config
+	wg        sync.WaitGroup
+	store     *model.Notification
+	projectOf types.ProjectResolver // 确定通知所属项目
+}
+
+// New 创建消息中心并启动过期清理，projectOf 用于确定通知所属项目
+func New(ctx context.Context, config Config, projectOf types.ProjectResolver) *Center {
+	if config.PruneInterval <= 0 {
+		config.PruneInterval = DefaultPruneInterval
+	}
+	if config.DeletedRetention <= 0 {
+		config.DeletedRetention = DefaultDeletedRetention
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	c := &Center{ctx: ctx, cancel: cancel, config: config, store: &model.Notification{}, projectOf: projectOf}
+	c.wg.Add(1)
+	go c.pruneLoop()
+	return c
+}
+
+// Record 保存 IsStore 的运行时数据，可注册为 Kernel 的 RuntimeHandler
+func (c *Center) Record(data types.RuntimeData) {
+	if !data.EventData.Optional.IsStore {
+		return
+	}
+	if _, err := c.Store(data); err != nil {
+		logger.Log.Errorf("Store notification %q failed: %v", data.EventData.Title, err)
+	}
+}
+
+// Store 保存通知，项目内 CheckHash 重复或已过期时忽略，返回是否新增
+func (c *Center) Store(data types.RuntimeData) (bool, error) {
+	event := data.EventData
+	now := time.Now()
+	record := &model.Notification{
+		ProjectID:  c.projectOf.Runtime(data),
+		CheckHash:  event.CheckHash,
+		EventCode:  string(data.EventCode),
+		JobID:      data.JobID,
+		Level:      event.Level,
+		Title:      event.Title,
+		Message:    event.Message,
+		IsNotify:   event.Optional.IsNotify,
+		ShowType:   event.Optional.ShowType,
+		IsRead:     event.IsRead,
+		ReceivedAt: event.ReceivedAt,
+	}
+	if record.ReceivedAt.IsZero() {
+		record.ReceivedAt = now
+	}
+	// 未指定 CheckHash 的消息不去重
+	if record.CheckHash == "" {
+		record.CheckHash = encrypt.Md5(fmt.Sprintf("%s|%s|%s|%d", record.EventCode, record.Title, record.Message, record.ReceivedAt.UnixNano()))
+	}
+	if !event.ExpiredAt.IsZero() {
+		if !event.ExpiredAt.After(now) {
+			return false, nil
+		}
+		expiredAt := event.ExpiredAt
+		record.ExpiredAt = &expiredAt
+	} else if c.config.DefaultTTL > 0 {
+		expiredAt := record.ReceivedAt.Add(c.config.DefaultTTL)
+		record.ExpiredAt = &expiredAt
+	}
+	if event.MetaData != nil {
+		metaData, err := json.Marshal(event.MetaData)
+		if err != nil {
+			return false, err
+		}
+		record.MetaData = string(metaData)
+	}
+	return record.Store()
+}
+
+// List 分页查询项目内未过期的通知
+func (c *Center) List(params *model.NotificationQueryParams, page, pageSize int) (database.PageResult[Item], error) {
+	result, err := c.store.List(params, page, pageSize)
+	if err != nil {
+		return database.PageResult[Item]{}, err
+	}
+	items := make([]Item, 0, len(result.Items))
+	for i := range result.Items {
+		items = append(items, NewItem(&result.Items[i]))
+	}
+	return database.PageResult[Item]{Page: result.Page, Total: result.Total, Items: items}, nil
+}
+
+// UnreadCount 项目内未读通知数
+func (c *Center) UnreadCount(projectID uint) (int64, error) {
+	return c.store.UnreadCount(projectID)
+}
+
+// MarkRead 标记项目内的通知已读，ids 为空时标记全部
+func (c *Center) MarkRead(projectID uint, ids []uint) (int64, error) {
+	return c.store.MarkRead(projectID, ids)
+}
+
+// Delete 删除项目内的通知
+func (c *Center) Delete(projectID uint, ids []uint) (int64, error) {
+	return c.store.Delete(projectID, ids)
+}
+
+// Close 停止过期清理
+func (c *Center) Close() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+// prune 物理删除已过期及超过保留期的已删除通知
+func (c *Center) prune(now time.Time) (int64, error) {
+	return c.store.Prune(now, now.Add(-c.config.DeletedRetention))
+}
+
+func (c *Center) pruneLoop() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.config.PruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if removed, err := c.prune(time.Now()); err != nil {
+				logger.Log.Errorf("Prune notifications failed: %v", err)
+			} else if removed > 0 {
+				logger.Log.Infof("Pruned %d expired or deleted notifications", removed)
+			}
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
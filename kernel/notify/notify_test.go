@@ -0,0 +1,235 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"noctua/internal/model"
+	"noctua/pkg/database"
+	"noctua/pkg/database/dbtest"
+	"noctua/types"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain 数据库只能初始化一次，所有测试共享同一个库
+func TestMain(m *testing.M) {
+	dbtest.Main(m, &model.Notification{})
+}
+
+// newRunID 数据库在 -count 多次运行间共享，以前缀区分每次运行的消息
+func newRunID() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36)
+}
+
+func notice(runID, hash, title string) types.RuntimeData {
+	return types.NewRuntimeData(types.RuntimeEventCodeNotification, types.EventData{
+		CheckHash: runID + hash,
+		Level:     "info",
+		Title:     title,
+		MetaData:  map[string]string{"user": "tester"},
+		Optional:  types.MessageOptional{IsNotify: true, IsStore: true, ShowType: "notification"},
+	})
+}
+
+func TestCenterStore(t *testing.T) {
+	runID := newRunID()
+	center := New(context.Background(), Config{DefaultTTL: time.Hour}, nil)
+	defer center.Close()
+
+	// 不需要保存的消息被忽略
+	transient := notice(runID, "store-transient", "transient")
+	transient.EventData.Optional.IsStore = false
+	center.Record(transient)
+
+	created, err := center.Store(notice(runID, "store-1", "first").WithJob(runID))
+	require.NoError(t, err)
+	assert.True(t, created)
+	created, err = center.Store(notice(runID, "store-1", "duplicate"))
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	expired := notice(runID, "store-expired", "expired")
+	expired.EventData.ExpiredAt = time.Now().Add(-time.Minute)
+	created, err = center.Store(expired)
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	result, err := center.List(&model.NotificationQueryParams{JobID: runID}, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.Total)
+	item := result.Items[0]
+	assert.Equal(t, "first", item.Title)
+	assert.Equal(t, types.RuntimeEventCodeNotification, item.EventCode)
+	assert.True(t, item.Optional.IsStore)
+	assert.WithinDuration(t, item.ReceivedAt.Add(time.Hour), item.ExpiredAt, time.Second)
+	metaData, err := json.Marshal(item.MetaData)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"user":"tester"}`, string(metaData))
+
+	var count int64
+	database.DB.Model(&model.Notification{}).Where("check_hash = ?", runID+"store-transient").Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestCenterReadAndDelete(t *testing.T) {
+	runID := newRunID()
+	center := New(context.Background(), Config{}, nil)
+	defer center.Close()
+	_, err := center.MarkRead(model.DefaultProjectID, nil)
+	require.NoError(t, err)
+
+	var ids []uint
+	for _, hash := range []string{"read-1", "read-2", "read-3"} {
+		center.Record(notice(runID, hash, hash))
+		record := &model.Notification{}
+		require.NoError(t, database.DB.Where("check_hash = ?", runID+hash).First(record).Error)
+		assert.Nil(t, record.ExpiredAt)
+		ids = append(ids, record.ID)
+	}
+	unread, err := center.UnreadCount(model.DefaultProjectID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), unread)
+
+	updated, err := center.MarkRead(model.DefaultProjectID, ids[:1])
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+	unread, err = center.UnreadCount(model.DefaultProjectID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), unread)
+
+	read := false
+	result, err := center.List(&model.NotificationQueryParams{IsRead: &read}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Total)
+
+	deleted, err := center.Delete(model.DefaultProjectID, ids[1:2])
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	// 已删除的消息不会因重复推送再次出现
+	created, err := center.Store(notice(runID, "read-2", "again"))
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	updated, err = center.MarkRead(model.DefaultProjectID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+	unread, err = center.UnreadCount(model.DefaultProjectID)
+	require.NoError(t, err)
+	assert.Zero(t, unread)
+}
+
+func TestPruneExpired(t *testing.T) {
+	runID := newRunID()
+	center := New(context.Background(), Config{}, nil)
+	defer center.Close()
+	data := notice(runID, "prune-1", "short")
+	data.EventData.ExpiredAt = time.Now().Add(50 * time.Millisecond)
+	created, err := center.Store(data)
+	require.NoError(t, err)
+	require.True(t, created)
+
+	time.Sleep(100 * time.Millisecond)
+	result, err := center.List(&model.NotificationQueryParams{}, 1, 100)
+	require.NoError(t, err)
+	for _, item := range result.Items {
+		assert.NotEqual(t, runID+"prune-1", item.CheckHash)
+	}
+	removed, err := center.prune(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+}
+
+func TestPruneDeleted(t *testing.T) {
+	runID := newRunID()
+	center := New(context.Background(), Config{DeletedRetention: time.Hour}, nil)
+	defer center.Close()
+	_, err := center.Store(notice(runID, "deleted-1", "deleted"))
+	require.NoError(t, err)
+	var stored model.Notification
+	require.NoError(t, database.DB.Where("check_hash = ?", runID+"deleted-1").First(&stored).Error)
+	_, err = center.Delete(model.DefaultProjectID, []uint{stored.ID})
+	require.NoError(t, err)
+
+	// 保留期内仍按 CheckHash 去重
+	removed, err := center.prune(time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+	created, err := center.Store(notice(runID, "deleted-1", "deleted"))
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	// 超过保留期后物理删除，相同的消息可再次保存
+	require.NoError(t, database.DB.Unscoped().Model(&model.Notification{}).
+		Where("id = ?", stored.ID).Update("deleted_at", time.Now().Add(-2*time.Hour)).Error)
+	removed, err = center.prune(time.Now())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, removed, int64(1))
+	var count int64
+	require.NoError(t, database.DB.Unscoped().Model(&model.Notification{}).Where("id = ?", stored.ID).Count(&count).Error)
+	assert.Zero(t, count)
+	created, err = center.Store(notice(runID, "deleted-1", "deleted"))
+	require.NoError(t, err)
+	assert.True(t, created)
+}
+
+func TestCenterProjectScope(t *testing.T) {
+	runID := newRunID()
+	projects := map[string]uint{runID + "-1": 1, runID + "-2": 2}
+	center := New(context.Background(), Config{}, func(jobID string) uint {
+		return projects[jobID]
+	})
+	defer center.Close()
+	for _, projectID := range []uint{1, 2} {
+		_, err := center.MarkRead(projectID, nil)
+		require.NoError(t, err)
+	}
+
+	// 相同的 CheckHash 在不同项目中各自保存
+	for jobID := range projects {
+		created, err := center.Store(notice(runID, "scope", jobID).WithJob(jobID))
+		require.NoError(t, err)
+		assert.True(t, created)
+	}
+	// 显式标记的项目优先于任务所属项目
+	created, err := center.Store(notice(runID, "scope-explicit", "explicit").WithJob(runID + "-1").WithProject(2))
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	items := map[uint][]Item{}
+	for _, projectID := range []uint{1, 2} {
+		result, err := center.List(&model.NotificationQueryParams{ProjectID: projectID}, 1, 100)
+		require.NoError(t, err)
+		for _, item := range result.Items {
+			if strings.HasPrefix(item.CheckHash, runID) {
+				assert.Equal(t, projectID, item.ProjectID)
+				items[projectID] = append(items[projectID], item)
+			}
+		}
+	}
+	require.Len(t, items[1], 1)
+	require.Len(t, items[2], 2)
+	assert.Equal(t, runID+"-1", items[1][0].Title)
+	unread, err := center.UnreadCount(1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), unread)
+
+	// 不能读取或删除其他项目的通知
+	other := []uint{items[2][0].ID, items[2][1].ID}
+	updated, err := center.MarkRead(1, other)
+	require.NoError(t, err)
+	assert.Zero(t, updated)
+	deleted, err := center.Delete(1, other)
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+	updated, err = center.MarkRead(1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+	unread, err = center.UnreadCount(2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), unread)
+}
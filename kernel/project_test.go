@@ -0,0 +1,104 @@
+package kernel
+
+import (
+	"context"
+	"noctua/internal/model"
+	"noctua/kernel/bus"
+	"noctua/kernel/journal"
+	"noctua/kernel/notify"
+	"noctua/kernel/stream"
+	"noctua/types"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiveJobs 收取 n 条消息，返回各消息所属的任务
+func receiveJobs(t *testing.T, client *stream.Client, n int) []string {
+	var jobIDs []string
+	for len(jobIDs) < n {
+		select {
+		case msg := <-client.C():
+			jobIDs = append(jobIDs, msg.JobID)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("receive timeout, got %v", jobIDs)
+		}
+	}
+	return jobIDs
+}
+
+func TestProjectIsolation(t *testing.T) {
+	runID := strconv.FormatInt(time.Now().UnixNano(), 36)
+	jobA, jobB := runID+"-a", runID+"-b"
+	jobs := newJobProjects(DefaultJobProjects)
+	jobs.bind(jobA, 1)
+	jobs.bind(jobB, 2)
+	projectOf := types.ProjectResolver(jobs.Project)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	eventBus := bus.NewEventBus(0)
+	defer eventBus.Close()
+	eventJournal := journal.New(ctx, eventBus, journal.Config{Enabled: true, BatchSize: 10, FlushInterval: 10 * time.Millisecond}, projectOf)
+	defer eventJournal.Close()
+	hub := stream.NewHub(ctx, eventBus, stream.Config{}, projectOf)
+	defer hub.Close()
+	center := notify.New(ctx, notify.Config{}, projectOf)
+	defer center.Close()
+	clientA, err := hub.Register("a", stream.Filter{ProjectID: 1})
+	require.NoError(t, err)
+	clientB, err := hub.Register("b", stream.Filter{ProjectID: 2})
+	require.NoError(t, err)
+
+	// 两个项目的任务发布相同 CheckHash 的通知，账号事件显式标记项目
+	for _, jobID := range []string{jobA, jobB} {
+		eventBus.Publish(types.JobStartedEvent{EventMeta: types.NewEventMeta(jobID)})
+		data := types.NewRuntimeData(types.RuntimeEventCodeNotification, types.EventData{
+			CheckHash: runID,
+			Title:     jobID,
+			Optional:  types.MessageOptional{IsStore: true},
+		}).WithJob(jobID)
+		eventJournal.RecordRuntime(data)
+		hub.PublishRuntime(data)
+		center.Record(data)
+	}
+	eventBus.Publish(types.AccountCooldownEvent{EventMeta: types.NewEventMeta("").WithProject(2), UserID: runID})
+
+	// 推送只发给所属项目的客户端
+	assert.ElementsMatch(t, []string{jobA, jobA}, receiveJobs(t, clientA, 2))
+	assert.ElementsMatch(t, []string{jobB, jobB, ""}, receiveJobs(t, clientB, 3))
+	assert.Empty(t, clientA.C())
+	assert.Len(t, hub.Status(1).Clients, 1)
+
+	// 事件日志按项目过滤
+	assert.Eventually(t, func() bool {
+		entries, _, err := eventJournal.Since(0, 0, journal.Filter{ProjectID: 1, JobID: jobA})
+		return err == nil && len(entries) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+	for _, filter := range []journal.Filter{{ProjectID: 1, JobID: jobB}, {ProjectID: 2, JobID: jobA}, {JobID: jobA}} {
+		entries, _, err := eventJournal.Since(0, 0, filter)
+		require.NoError(t, err)
+		assert.Empty(t, entries, "filter %+v", filter)
+	}
+	entries, _, err := eventJournal.Since(0, 0, journal.Filter{ProjectID: 2, Topic: types.TopicAccountCooldown})
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	assert.Equal(t, uint(2), entries[len(entries)-1].ProjectID)
+
+	// 通知按项目保存和查询，不能操作其他项目的通知
+	result, err := center.List(&model.NotificationQueryParams{ProjectID: 1, JobID: jobA}, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.Total)
+	result, err = center.List(&model.NotificationQueryParams{ProjectID: 1, JobID: jobB}, 1, 10)
+	require.NoError(t, err)
+	assert.Zero(t, result.Total)
+	result, err = center.List(&model.NotificationQueryParams{ProjectID: 2, JobID: jobB}, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.Total)
+	deleted, err := center.Delete(1, []uint{result.Items[0].ID})
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+}
@@ -0,0 +1,53 @@
+package kernel
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"noctua/internal/proxy"
+	"noctua/pkg/config"
+	"noctua/pkg/logger"
+	"noctua/pkg/utils/encrypt"
+	"noctua/pkg/utils/file"
+	"noctua/types"
+	"time"
+)
+
+// watchProxyConfig 监听 config/proxy.yaml，修改后校验并应用到代理池，配置错误时保持原配置并发送通知
+func (k *Kernel) watchProxyConfig(pool *proxy.ProxyPool) {
+	path := file.GetResourcePath("config/proxy.yaml")
+	err := config.WatchFile(k.Ctx, path, func() {
+		if err := reloadProxyConfig(pool, path); err != nil {
+			logger.Log.Errorf("Reload proxy config failed, keep previous config: %v", err)
+			k.Runtime.Publish(types.NewRuntimeData(types.RuntimeEventCodeNotification, types.EventData{
+				Title:     "代理配置更新失败",
+				Level:     "error",
+				CheckHash: encrypt.Md5(fmt.Sprintf("proxy-config|%d", time.Now().UnixNano())),
+				Message:   fmt.Sprintf("%s 修改后校验失败，继续使用原配置：%v", path, err),
+				Optional: types.MessageOptional{
+					IsNotify: true,
+					IsStore:  true,
+					ShowType: "notification",
+				},
+			}))
+			return
+		}
+		logger.Log.Infof("Proxy config reloaded from %s", path)
+	})
+	if err != nil {
+		logger.Log.Warnf("Watch proxy config %s failed, hot reload disabled: %v", path, err)
+	}
+}
+
+// reloadProxyConfig 重新读取代理配置文件并应用到代理池
+func reloadProxyConfig(pool *proxy.ProxyPool, path string) error {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return err
+	}
+	proxyConfig, err := loadProxyConfig(v)
+	if err != nil {
+		return err
+	}
+	return pool.ApplyConfig(proxyConfig)
+}
@@ -0,0 +1,93 @@
+package kernel
+
+import (
+	"context"
+	"github.com/spf13/viper"
+	"noctua/internal/proxy"
+	"noctua/pkg/utils/file"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readProxyConfig(t *testing.T, content string) (proxy.ProxyPoolConfig, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(strings.NewReader(content)))
+	return loadProxyConfig(v)
+}
+
+func TestLoadProxyConfig(t *testing.T) {
+	// 默认配置文件可以通过校验
+	v := viper.New()
+	v.SetConfigFile("../config/proxy.yaml")
+	require.NoError(t, v.ReadInConfig())
+	config, err := loadProxyConfig(v)
+	require.NoError(t, err)
+	assert.Len(t, config.Providers, 3)
+	assert.Equal(t, "https://cip.cc", config.Health.CheckURL)
+	assert.Equal(t, 5*time.Minute, config.Health.CheckInterval)
+
+	config, err = readProxyConfig(t, `
+proxy:
+  channel_capacity: 20
+  dynamic:
+    enabled: true
+    min: 3
+    regions: ["440000"]
+  providers:
+    - name: list
+      type: static
+      enabled: true
+      path: proxies.txt
+      ttl: 1h
+`)
+	require.NoError(t, err)
+	assert.True(t, config.Dynamic.Enabled)
+	assert.Equal(t, 3, config.Dynamic.Min)
+	assert.Equal(t, []string{"440000"}, config.Dynamic.Regions)
+	assert.Equal(t, 20, config.ChannelCapacity)
+	assert.Equal(t, file.GetResourcePath("proxies.txt"), config.Providers[0].Path)
+	assert.Equal(t, time.Hour, config.Providers[0].TTL)
+
+	// 未知配置项及校验失败
+	_, err = readProxyConfig(t, "proxy:\n  dynamic:\n    enable: true\n")
+	assert.ErrorContains(t, err, "enable")
+	_, err = readProxyConfig(t, "proxy:\n  static:\n    enabled: true\n")
+	assert.ErrorContains(t, err, "proxy.static is enabled but no enabled provider supplies static proxies")
+}
+
+func TestReloadProxyConfig(t *testing.T) {
+	dir := t.TempDir()
+	listPath := filepath.Join(dir, "proxies.txt")
+	require.NoError(t, os.WriteFile(listPath, []byte("1.1.1.1:80\n"), 0600))
+	configPath := filepath.Join(dir, "proxy.yaml")
+	pool := proxy.NewProxyPool(context.Background(), proxy.ProxyPoolConfig{})
+	defer pool.Stop()
+
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+proxy:
+  static:
+    enabled: true
+  providers:
+    - name: list
+      type: static
+      enabled: true
+      path: `+listPath+`
+`), 0600))
+	require.NoError(t, reloadProxyConfig(pool, configPath))
+	status := pool.Status()
+	assert.True(t, status.StaticEnabled)
+	require.Len(t, status.Providers, 1)
+	assert.Equal(t, "list", status.Providers[0].Name)
+
+	// 配置错误时保持原配置
+	require.NoError(t, os.WriteFile(configPath, []byte("proxy:\n  batch_size: -1\n"), 0600))
+	assert.ErrorContains(t, reloadProxyConfig(pool, configPath), "proxy.batch_size must not be negative")
+	assert.True(t, pool.Status().StaticEnabled)
+}
@@ -2,12 +2,22 @@ package reference
 
 import (
 	"noctua/internal/scheduler"
+	"noctua/kernel/flow"
+	"noctua/pkg/httpx"
 	"noctua/types"
 )
 
+// CrawlerOptions 创建爬虫实例时的可选项
+type CrawlerOptions struct {
+	JobID     string            // 采集任务ID，用于事件和任务归属
+	ProjectID uint              // 所属项目，会话只使用该项目的账号，采集数据归属该项目
+	Recorder  *httpx.Recorder   // HTTP 录制回放，为空时直连
+	Endpoints map[string]string // 平台接口地址覆盖，如 api、index，用于本地测试
+}
+
 // Crawler 爬虫接口，所有爬虫都必须实现
 type Crawler interface {
-	Initialize(scheduler *scheduler.Scheduler, runtimeChannel chan types.RuntimeData, channels map[string]chan types.FetchItemChan)
+	Initialize(scheduler *scheduler.Scheduler, emitRuntime types.RuntimeEmitter, channels map[string]*flow.Channel)
 	HandleChannel(item types.FetchItemChan, params *types.CrawlParams) error
 	SubmitJob(taskType string, payload interface{}, options scheduler.TaskOptions) error
 }
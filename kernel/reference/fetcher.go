@@ -1,16 +1,19 @@
 package reference
 
-import "noctua/types"
+import (
+	"noctua/kernel/flow"
+	"noctua/types"
+)
 
 // Fetcher 爬虫接口，所有爬虫都必须实现
 type Fetcher interface {
 	Initialize()
 
-	HandleSearch(params *types.SearchParams, mediaChan chan types.FetchItemChan) error
+	HandleSearch(params *types.SearchParams, mediaChan *flow.Channel) error
 
-	HandleMedia(params *types.MediaParams, mediaChan chan types.FetchItemChan) error
+	HandleMedia(params *types.MediaParams, mediaChan *flow.Channel) error
 
-	HandleComments(params *types.CommentParams, commentChan types.FetchItemChan) error
+	HandleComments(params *types.CommentParams, commentChan *flow.Channel) error
 
-	HandleUser(params *types.UserParams, userChan chan types.FetchItemChan) error
+	HandleUser(params *types.UserParams, userChan *flow.Channel) error
 }
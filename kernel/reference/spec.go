@@ -0,0 +1,114 @@
+package reference
+
+import (
+	"fmt"
+	"noctua/types"
+	"sort"
+	"strings"
+)
+
+// 采集参数中的可选开关，与 CrawlParams 的 json 字段名一致
+const (
+	OptionWithUser         = "withUser"
+	OptionWithComment      = "withComment"
+	OptionWithCommentUser  = "withCommentUser"
+	OptionWithAllCreations = "withAllCreations"
+	OptionAutoPagination   = "autoPagination"
+)
+
+// CrawlTypeSpec 单个采集类型的参数约束
+type CrawlTypeSpec struct {
+	MaxCount    int      // maxCount 上限，0 表示不限制
+	MaxKeywords int      // keywords 数量上限，0 表示不限制
+	Options     []string // 支持的可选开关
+}
+
+// CrawlSpec 平台支持的采集类型及参数约束
+type CrawlSpec struct {
+	MediaCode  string
+	CrawlTypes map[string]CrawlTypeSpec
+}
+
+// ParamError 单个参数的校验错误
+type ParamError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ParamErrors 采集参数校验错误
+type ParamErrors []ParamError
+
+func (e ParamErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, item := range e {
+		messages = append(messages, item.Field+": "+item.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// CrawlTypeNames 支持的采集类型，按名称排序
+func (s CrawlSpec) CrawlTypeNames() []string {
+	names := make([]string, 0, len(s.CrawlTypes))
+	for name := range s.CrawlTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate 按平台约束校验采集参数，返回 ParamErrors
+func (s CrawlSpec) Validate(params *types.CrawlParams) error {
+	var errs ParamErrors
+	typeSpec, ok := s.CrawlTypes[params.CrawlType]
+	if !ok {
+		errs = append(errs, ParamError{
+			Field:   "crawlType",
+			Message: fmt.Sprintf("平台 %s 不支持采集类型 %s，可选值: %s", s.MediaCode, params.CrawlType, strings.Join(s.CrawlTypeNames(), ", ")),
+		})
+		return errs
+	}
+	if typeSpec.MaxCount > 0 && params.MaxCount > typeSpec.MaxCount {
+		errs = append(errs, ParamError{
+			Field:   "maxCount",
+			Message: fmt.Sprintf("maxCount 不能大于 %d", typeSpec.MaxCount),
+		})
+	}
+	if typeSpec.MaxKeywords > 0 && len(params.Keywords) > typeSpec.MaxKeywords {
+		errs = append(errs, ParamError{
+			Field:   "keywords",
+			Message: fmt.Sprintf("keywords 数量不能超过 %d", typeSpec.MaxKeywords),
+		})
+	}
+	allowed := make(map[string]bool, len(typeSpec.Options))
+	for _, option := range typeSpec.Options {
+		allowed[option] = true
+	}
+	enabled := []struct {
+		name  string
+		value bool
+	}{
+		{OptionWithUser, params.WithUser},
+		{OptionWithComment, params.WithComment},
+		{OptionWithCommentUser, params.WithCommentUser},
+		{OptionWithAllCreations, params.WithAllCreations},
+		{OptionAutoPagination, params.AutoPagination},
+	}
+	for _, option := range enabled {
+		if option.value && !allowed[option.name] {
+			errs = append(errs, ParamError{
+				Field:   option.name,
+				Message: fmt.Sprintf("采集类型 %s 不支持 %s", params.CrawlType, option.name),
+			})
+		}
+	}
+	if params.WithCommentUser && !params.WithComment {
+		errs = append(errs, ParamError{
+			Field:   OptionWithCommentUser,
+			Message: "withCommentUser 需要同时开启 withComment",
+		})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package reference
+
+import (
+	"noctua/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testSpec = CrawlSpec{
+	MediaCode: "douyin",
+	CrawlTypes: map[string]CrawlTypeSpec{
+		"search": {MaxCount: 100, MaxKeywords: 2, Options: []string{OptionWithComment, OptionWithCommentUser}},
+		"user":   {Options: []string{OptionWithAllCreations}},
+	},
+}
+
+func TestCrawlSpecValidate(t *testing.T) {
+	params := &types.CrawlParams{MediaCode: "douyin", CrawlType: "search", Keywords: []string{"go"}, MaxCount: 50, WithComment: true}
+	assert.NoError(t, testSpec.Validate(params))
+
+	err := testSpec.Validate(&types.CrawlParams{CrawlType: "topic"})
+	assert.Equal(t, ParamErrors{{Field: "crawlType", Message: "平台 douyin 不支持采集类型 topic，可选值: search, user"}}, err)
+
+	err = testSpec.Validate(&types.CrawlParams{
+		CrawlType:       "search",
+		Keywords:        []string{"a", "b", "c"},
+		MaxCount:        200,
+		WithUser:        true,
+		WithCommentUser: true,
+	})
+	errs, ok := err.(ParamErrors)
+	assert.True(t, ok)
+	fields := make([]string, 0, len(errs))
+	for _, item := range errs {
+		fields = append(fields, item.Field)
+	}
+	assert.Equal(t, []string{"maxCount", "keywords", "withUser", "withCommentUser"}, fields)
+}
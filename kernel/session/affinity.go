@@ -0,0 +1,80 @@
+package session
+
+import (
+	"noctua/pkg/logger"
+	"noctua/types"
+	"time"
+)
+
+const DefaultAffinityTTL = 30 * time.Minute
+
+// AffinityConfig 会话亲和配置
+type AffinityConfig struct {
+	TTL time.Duration `mapstructure:"ttl"` // 亲和标识最后一次使用后的保留时间
+}
+
+// affinity 亲和标识绑定的账号
+type affinity struct {
+	userID    string
+	expiresAt time.Time
+}
+
+// SetAffinity 设置会话亲和配置
+func (sm *Manager) SetAffinity(config AffinityConfig) {
+	if config.TTL <= 0 {
+		config.TTL = DefaultAffinityTTL
+	}
+	sm.mu.Lock()
+	sm.affinityTTL = config.TTL
+	sm.mu.Unlock()
+}
+
+// AffinityUser 亲和标识绑定的账号，未绑定或已过期时返回空
+func (sm *Manager) AffinityUser(key string) string {
+	if key == "" {
+		return ""
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	entry, ok := sm.affinities[key]
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		return ""
+	}
+	return entry.userID
+}
+
+// bindAffinity 绑定亲和标识与获取到的会话，账号与之前绑定的不同时视为故障转移
+func (sm *Manager) bindAffinity(params *SessionParams, previous string, session *types.Session) {
+	if session == nil || session.Account == nil {
+		return
+	}
+	userID := session.Account.UserID
+	now := time.Now()
+	sm.mu.Lock()
+	ttl := sm.affinityTTL
+	if ttl <= 0 {
+		ttl = DefaultAffinityTTL
+	}
+	sm.affinities[params.AffinityKey] = &affinity{userID: userID, expiresAt: now.Add(ttl)}
+	sm.mu.Unlock()
+	if previous == "" || previous == userID {
+		return
+	}
+	logger.Log.Warnf("Session affinity %s failed over from account %s to %s for media %s",
+		params.AffinityKey, previous, userID, params.MediaCode)
+	sm.emit.Emit(types.SessionFailoverEvent{
+		EventMeta:      types.NewEventMeta(params.JobID).WithProject(session.Account.ProjectID),
+		SessionInfo:    sessionInfo(session, params.SessionRegion),
+		AffinityKey:    params.AffinityKey,
+		PreviousUserID: previous,
+	})
+}
+
+// pruneAffinitiesLocked 删除过期的亲和标识，调用方需持有 sm.mu
+func (sm *Manager) pruneAffinitiesLocked(now time.Time) {
+	for key, entry := range sm.affinities {
+		if !now.Before(entry.expiresAt) {
+			delete(sm.affinities, key)
+		}
+	}
+}
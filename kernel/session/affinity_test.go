@@ -0,0 +1,63 @@
+package session
+
+import (
+	"noctua/types"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionAffinity(t *testing.T) {
+	sm, mediaCode := newLeaseManager(t, 2)
+	var mu sync.Mutex
+	var failovers []types.SessionFailoverEvent
+	sm.SetEmitter(func(event interface{}) {
+		if e, ok := event.(types.SessionFailoverEvent); ok {
+			mu.Lock()
+			failovers = append(failovers, e)
+			mu.Unlock()
+		}
+	})
+	params := &SessionParams{MediaCode: mediaCode, AffinityKey: "key-1"}
+
+	first, err := sm.GetSession(params)
+	require.NoError(t, err)
+	pinned := first.Account.UserID
+	assert.Equal(t, pinned, sm.AffinityUser("key-1"))
+
+	// 亲和账号优先于调用方当前使用的账号
+	for i := 0; i < 3; i++ {
+		next, err := sm.GetSession(&SessionParams{MediaCode: mediaCode, AffinityKey: "key-1", UserID: "other"})
+		require.NoError(t, err)
+		assert.Equal(t, pinned, next.Account.UserID)
+		assert.Equal(t, first.ProxyInfo.ProxyKey, next.ProxyInfo.ProxyKey)
+	}
+
+	// 亲和账号不可用时更换账号并重新绑定
+	require.NoError(t, sm.InvalidateSession(first, "blocked"))
+	next, err := sm.GetSession(params)
+	require.NoError(t, err)
+	assert.NotEqual(t, pinned, next.Account.UserID)
+	assert.Equal(t, next.Account.UserID, sm.AffinityUser("key-1"))
+	mu.Lock()
+	require.Len(t, failovers, 1)
+	assert.Equal(t, "key-1", failovers[0].AffinityKey)
+	assert.Equal(t, pinned, failovers[0].PreviousUserID)
+	assert.Equal(t, next.Account.UserID, failovers[0].UserID)
+	mu.Unlock()
+
+	// 过期后不再绑定
+	sm.SetAffinity(AffinityConfig{TTL: time.Millisecond})
+	_, err = sm.GetSession(&SessionParams{MediaCode: mediaCode, AffinityKey: "key-2"})
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	assert.Empty(t, sm.AffinityUser("key-2"))
+	sm.ReclaimExpired()
+	sm.mu.Lock()
+	assert.NotContains(t, sm.affinities, "key-2")
+	assert.Contains(t, sm.affinities, "key-1")
+	sm.mu.Unlock()
+}
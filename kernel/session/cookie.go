@@ -0,0 +1,133 @@
+package session
+
+import (
+	"context"
+	"noctua/internal/model"
+	"noctua/pkg/cookie"
+	"noctua/pkg/logger"
+	"noctua/types"
+	"time"
+)
+
+const DefaultCookieCheckInterval = 10 * time.Minute
+
+// Config 会话管理配置
+type Config struct {
+	CookieCheck CookieCheckConfig `mapstructure:"cookie_check"`
+	Probe       ProbeConfig       `mapstructure:"probe"`
+	Selection   SelectionConfig   `mapstructure:"selection"`
+	Lease       LeaseConfig       `mapstructure:"lease"`
+	CookieJar   CookieJarConfig   `mapstructure:"cookie_jar"`
+	Affinity    AffinityConfig    `mapstructure:"affinity"`
+}
+
+// CookieCheckConfig 账号 Cookie 过期检查配置
+type CookieCheckConfig struct {
+	Enabled       bool                `mapstructure:"enabled"`
+	Interval      time.Duration       `mapstructure:"interval"`       // 检查间隔
+	WarnBefore    time.Duration       `mapstructure:"warn_before"`    // 关键 Cookie 在该时长内过期时告警
+	Disable       bool                `mapstructure:"disable"`        // 是否禁用关键 Cookie 缺失或即将过期的账号
+	DisableBefore time.Duration       `mapstructure:"disable_before"` // 关键 Cookie 在该时长内过期时禁用，0 为过期后禁用
+	Essential     map[string][]string `mapstructure:"essential"`      // 各平台的关键 Cookie，未配置时检查全部 Cookie
+}
+
+// CookieAlert 账号 Cookie 检查结果
+type CookieAlert struct {
+	MediaCode string `json:"mediaCode"`
+	UserID    string `json:"userId"`
+	cookie.ExpiryCheck
+	Error    string `json:"error,omitempty"` // Cookie 无法解析
+	Disabled bool   `json:"disabled"`        // 是否已禁用账号
+}
+
+// StartCookieCheck 定期检查正常账号的 Cookie，ctx 结束后停止
+func (sm *Manager) StartCookieCheck(ctx context.Context, config CookieCheckConfig) {
+	sm.cookieCheck = config
+	if !config.Enabled {
+		return
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultCookieCheckInterval
+	}
+	go func() {
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+		for {
+			if _, err := sm.CheckCookies(config, time.Now()); err != nil {
+				logger.Log.Errorf("Check account cookies failed: %v", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// EssentialCookies 平台的关键 Cookie 名称，未配置时为空
+func (sm *Manager) EssentialCookies(mediaCode string) []string {
+	return sm.cookieCheck.Essential[mediaCode]
+}
+
+// CheckCookies 检查正常账号的关键 Cookie，按配置告警或禁用，返回存在问题的账号
+func (sm *Manager) CheckCookies(config CookieCheckConfig, now time.Time) ([]CookieAlert, error) {
+	accounts, err := sm.mediaAccount.QueryMediaAccounts(&model.QueryMediaAccountParams{Status: model.MediaAccountStatusNormal})
+	if err != nil {
+		return nil, err
+	}
+	var alerts []CookieAlert
+	for _, account := range accounts {
+		alert, ok := checkAccountCookie(account, config, now)
+		if ok {
+			continue
+		}
+		if alert.Disabled {
+			if err := account.SetStatus(model.MediaAccountStatusDisabled, model.DisabledReasonCookie); err != nil {
+				logger.Log.Errorf("Disable account %s failed: %v", account.UserID, err)
+				alert.Disabled = false
+			} else {
+				sm.EvictAccount(account.MediaCode, account.UserID)
+				logger.Log.Warnf("Disabled account %s for media %s, cookie expired", account.UserID, account.MediaCode)
+			}
+		}
+		sm.emit.Emit(types.AccountCookieExpiringEvent{
+			EventMeta: types.NewEventMeta("").WithProject(account.ProjectID),
+			MediaCode: alert.MediaCode,
+			UserID:    alert.UserID,
+			Missing:   alert.Missing,
+			Expiring:  alert.Expiring,
+			ExpireAt:  alert.ExpireAt,
+			Error:     alert.Error,
+			Disabled:  alert.Disabled,
+		})
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
+
+// checkAccountCookie 检查单个账号，无需处理时返回 true
+func checkAccountCookie(account *model.MediaAccount, config CookieCheckConfig, now time.Time) (CookieAlert, bool) {
+	alert := CookieAlert{MediaCode: account.MediaCode, UserID: account.UserID}
+	// 未设置 Cookie 的账号不检查
+	if account.Cookie == "" {
+		return alert, true
+	}
+	cookies, _, err := cookie.Parse(account.Cookie)
+	if err != nil {
+		alert.Error = err.Error()
+		alert.Disabled = config.Disable
+		return alert, false
+	}
+	essential := config.Essential[account.MediaCode]
+	// 先按禁用的截止时间检查，未命中时再按告警时间检查
+	if config.Disable {
+		alert.ExpiryCheck = cookies.CheckExpiry(essential, now.Add(config.DisableBefore))
+		if !alert.OK() {
+			alert.Disabled = true
+			return alert, false
+		}
+	}
+	alert.ExpiryCheck = cookies.CheckExpiry(essential, now.Add(config.WarnBefore))
+	return alert, alert.OK()
+}
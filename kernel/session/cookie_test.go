@@ -0,0 +1,48 @@
+package session
+
+import (
+	"noctua/internal/model"
+	"noctua/pkg/cookie"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckAccountCookie(t *testing.T) {
+	now := time.Now()
+	value, err := cookie.Cookies{
+		{Name: "sessionid", Value: "a", Expires: now.Add(48 * time.Hour)},
+		{Name: "sid_guard", Value: "b", Expires: now.Add(2 * time.Hour)},
+	}.JSON()
+	assert.NoError(t, err)
+	account := &model.MediaAccount{MediaCode: "douyin", UserID: "u1", Cookie: value}
+	config := CookieCheckConfig{
+		WarnBefore:    24 * time.Hour,
+		Disable:       true,
+		DisableBefore: time.Hour,
+		Essential:     map[string][]string{"douyin": {"sessionid", "sid_guard"}},
+	}
+
+	alert, ok := checkAccountCookie(account, config, now)
+	assert.False(t, ok)
+	assert.False(t, alert.Disabled)
+	assert.Equal(t, []string{"sid_guard"}, alert.Expiring)
+
+	alert, ok = checkAccountCookie(account, config, now.Add(90*time.Minute))
+	assert.False(t, ok)
+	assert.True(t, alert.Disabled)
+
+	config.Disable = false
+	_, ok = checkAccountCookie(account, config, now.Add(-24*time.Hour))
+	assert.True(t, ok)
+
+	account.Cookie = "sessionid=a"
+	alert, ok = checkAccountCookie(account, config, now)
+	assert.False(t, ok)
+	assert.Equal(t, []string{"sid_guard"}, alert.Missing)
+
+	account.Cookie = ""
+	_, ok = checkAccountCookie(account, config, now)
+	assert.True(t, ok)
+}
@@ -0,0 +1,190 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"noctua/internal/model"
+	"noctua/pkg/cookie"
+	"noctua/pkg/logger"
+	"noctua/types"
+	"sync"
+	"time"
+)
+
+const DefaultCookieFlushInterval = time.Minute
+
+// CookieJarConfig 响应 Cookie 回写配置
+type CookieJarConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`        // 合并响应中的 Set-Cookie
+	FlushInterval time.Duration `mapstructure:"flush_interval"` // 合并结果的保存间隔
+}
+
+// cookieJar 账号的 Cookie，合并响应中的 Set-Cookie 后定期保存
+type cookieJar struct {
+	mu        sync.Mutex
+	accountID uint
+	cookies   cookie.Cookies
+	encoded   string      // 合并后的 Cookie，JSON 格式
+	version   int         // 基于的数据库版本号
+	diff      cookie.Diff // 未保存的变化
+	invalid   bool        // 原 Cookie 无法解析，不合并
+}
+
+func newCookieJar(account *model.MediaAccount) *cookieJar {
+	jar := &cookieJar{accountID: account.ID}
+	jar.reset(account.Cookie, account.CookieVersion)
+	return jar
+}
+
+// reset 以数据库中的 Cookie 为准，丢弃未保存的变化
+func (j *cookieJar) reset(value string, version int) {
+	cookies, _, err := cookie.Parse(value)
+	j.cookies = cookies
+	j.encoded = value
+	j.version = version
+	j.diff = cookie.Diff{}
+	j.invalid = err != nil && !errors.Is(err, cookie.ErrEmpty)
+}
+
+// sync 账号的 Cookie 被替换后版本号更大，以账号为准
+func (j *cookieJar) sync(account *model.MediaAccount) {
+	if account.CookieVersion > j.version {
+		j.reset(account.Cookie, account.CookieVersion)
+	}
+}
+
+// StartCookieJar 合并响应中的 Set-Cookie 并定期保存，ctx 结束后停止
+func (sm *Manager) StartCookieJar(ctx context.Context, config CookieJarConfig) {
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultCookieFlushInterval
+	}
+	sm.mu.Lock()
+	sm.cookieJar = config
+	sm.mu.Unlock()
+	if !config.Enabled {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(config.FlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				sm.FlushCookies()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// jar 返回账号的 Cookie，不存在时返回 nil
+func (sm *Manager) jar(account *model.MediaAccount) *cookieJar {
+	value, ok := sm.jars.Load(usageKey(account.MediaCode, account.UserID))
+	if !ok {
+		return nil
+	}
+	return value.(*cookieJar)
+}
+
+// applyJar 使用合并后的 Cookie 替换会话副本中的 Cookie
+func (sm *Manager) applyJar(account *model.MediaAccount) {
+	jar := sm.jar(account)
+	if jar == nil {
+		return
+	}
+	jar.mu.Lock()
+	defer jar.mu.Unlock()
+	jar.sync(account)
+	account.Cookie = jar.encoded
+	account.CookieVersion = jar.version
+}
+
+// MergeCookies 合并响应中的 Set-Cookie 到会话账号的 Cookie，后续获取的会话携带合并后的 Cookie，返回变化
+func (sm *Manager) MergeCookies(session *types.Session, cookies []*http.Cookie) cookie.Diff {
+	if session == nil || session.Account == nil || len(cookies) == 0 {
+		return cookie.Diff{}
+	}
+	sm.mu.Lock()
+	enabled := sm.cookieJar.Enabled
+	sm.mu.Unlock()
+	if !enabled {
+		return cookie.Diff{}
+	}
+	account := session.Account
+	value, _ := sm.jars.LoadOrStore(usageKey(account.MediaCode, account.UserID), newCookieJar(account))
+	jar := value.(*cookieJar)
+
+	now := time.Now()
+	jar.mu.Lock()
+	defer jar.mu.Unlock()
+	jar.sync(account)
+	if jar.invalid {
+		return cookie.Diff{}
+	}
+	merged, diff := jar.cookies.Merge(cookie.FromHTTP(cookies, now), now)
+	if diff.Empty() {
+		return diff
+	}
+	encoded, err := merged.JSON()
+	if err != nil {
+		logger.Log.Errorf("Encode cookies of account %s failed: %v", account.UserID, err)
+		return cookie.Diff{}
+	}
+	jar.cookies = merged
+	jar.encoded = encoded
+	jar.diff = jar.diff.Append(diff)
+	logger.Log.Debugf("Merged response cookies of account %s for media %s: %s", account.UserID, account.MediaCode, diff)
+	return diff
+}
+
+// FlushCookies 保存合并后有变化的 Cookie，数据库中的 Cookie 已被替换时放弃本次合并
+func (sm *Manager) FlushCookies() {
+	sm.jars.Range(func(key, value interface{}) bool {
+		jar := value.(*cookieJar)
+		jar.mu.Lock()
+		// 临时账号不保存
+		if jar.diff.Empty() || jar.accountID == 0 {
+			jar.mu.Unlock()
+			return true
+		}
+		version, diff := jar.version, jar.diff
+		account := &model.MediaAccount{ID: jar.accountID, Cookie: jar.encoded, CookieDiff: diff.String()}
+		jar.diff = cookie.Diff{}
+		jar.mu.Unlock()
+
+		saved, err := account.SaveMergedCookie(version)
+		jar.mu.Lock()
+		defer jar.mu.Unlock()
+		switch {
+		case err != nil:
+			jar.diff = diff.Append(jar.diff)
+			logger.Log.Errorf("Save cookies of account %d failed: %v", account.ID, err)
+		case saved:
+			if jar.version == version {
+				jar.version = account.CookieVersion
+			}
+			logger.Log.Infof("Saved cookies of account %d, version %d: %s", account.ID, account.CookieVersion, account.CookieDiff)
+		default:
+			latest, err := account.Find(account.ID)
+			// 账号已删除
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				sm.jars.Delete(key)
+				return true
+			}
+			if err != nil {
+				jar.diff = diff.Append(jar.diff)
+				logger.Log.Errorf("Reload cookies of account %d failed: %v", account.ID, err)
+				return true
+			}
+			if jar.version == version {
+				jar.reset(latest.Cookie, latest.CookieVersion)
+			}
+			logger.Log.Warnf("Cookies of account %d were replaced (version %d), discarded merged changes: %s",
+				account.ID, latest.CookieVersion, diff)
+		}
+		return true
+	})
+}
@@ -0,0 +1,93 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"noctua/internal/model"
+	"noctua/pkg/cookie"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeAndFlushCookies(t *testing.T) {
+	sm, mediaCode := newLeaseManager(t, 0)
+	value, err := cookie.Cookies{
+		{Name: "sessionid", Value: "s1", Domain: ".douyin.com", Path: "/"},
+		{Name: "ttwid", Value: "t1", Domain: ".douyin.com", Path: "/"},
+	}.JSON()
+	require.NoError(t, err)
+	account := &model.MediaAccount{MediaCode: mediaCode, UserID: "jar", UID: "jar", Nickname: "jar", Cookie: value}
+	require.NoError(t, account.Create())
+	updates := []*http.Cookie{
+		{Name: "ttwid", Value: "t2", Domain: ".douyin.com", Path: "/"},
+		{Name: "odin_tt", Value: "o1", Domain: ".douyin.com", Path: "/", Expires: time.Now().Add(time.Hour).Truncate(time.Second)},
+	}
+
+	session, err := sm.GetSession(&SessionParams{MediaCode: mediaCode})
+	require.NoError(t, err)
+	assert.True(t, sm.MergeCookies(session, updates).Empty(), "cookie jar is disabled by default")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sm.StartCookieJar(ctx, CookieJarConfig{Enabled: true, FlushInterval: time.Hour})
+	diff := sm.MergeCookies(session, updates)
+	assert.Equal(t, cookie.Diff{Added: []string{"odin_tt"}, Updated: []string{"ttwid"}}, diff)
+	assert.True(t, sm.MergeCookies(session, updates).Empty())
+
+	// 后续获取的会话携带合并后的 Cookie
+	next, err := sm.GetSession(&SessionParams{MediaCode: mediaCode})
+	require.NoError(t, err)
+	cookies, _, err := cookie.Parse(next.Account.Cookie)
+	require.NoError(t, err)
+	ttwid, _ := cookies.Get("ttwid")
+	assert.Equal(t, "t2", ttwid.Value)
+	assert.Equal(t, []string{"odin_tt", "sessionid", "ttwid"}, cookies.Names())
+
+	sm.FlushCookies()
+	saved, err := account.Find(account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, next.Account.Cookie, saved.Cookie)
+	assert.Equal(t, 1, saved.CookieVersion)
+	assert.Equal(t, "+odin_tt ~ttwid", saved.CookieDiff)
+	require.NotNil(t, saved.CookieSavedAt)
+
+	// Cookie 被替换后放弃未保存的合并，以替换后的 Cookie 为准
+	sm.MergeCookies(next, []*http.Cookie{{Name: "sessionid", Value: "s2"}})
+	require.NoError(t, saved.ReplaceCookie(`[{"name":"sessionid","value":"imported","domain":".douyin.com"}]`))
+	assert.Equal(t, 2, saved.CookieVersion)
+	sm.FlushCookies()
+	replaced, err := account.Find(account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, saved.Cookie, replaced.Cookie)
+	assert.Equal(t, 2, replaced.CookieVersion)
+	assert.Empty(t, replaced.CookieDiff)
+
+	latest, err := sm.GetSession(&SessionParams{MediaCode: mediaCode})
+	require.NoError(t, err)
+	assert.Equal(t, saved.Cookie, latest.Account.Cookie)
+	assert.Equal(t, 2, latest.Account.CookieVersion)
+}
+
+func TestInvalidateKeepsReplacedCookie(t *testing.T) {
+	sm, mediaCode := newLeaseManager(t, 0)
+	account := &model.MediaAccount{MediaCode: mediaCode, UserID: "invalidate", UID: "invalidate", Nickname: "invalidate", Cookie: `[{"name":"sessionid","value":"old"}]`}
+	require.NoError(t, account.Create())
+	session, err := sm.GetSession(&SessionParams{MediaCode: mediaCode})
+	require.NoError(t, err)
+
+	// 会话使用期间 Cookie 被替换，禁用账号时不能写回旧 Cookie
+	imported, err := account.Find(account.ID)
+	require.NoError(t, err)
+	require.NoError(t, imported.ReplaceCookie(`[{"name":"sessionid","value":"imported"}]`))
+	require.NoError(t, sm.InvalidateSession(session, "blocked"))
+
+	saved, err := account.Find(account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, imported.Cookie, saved.Cookie)
+	assert.Equal(t, imported.CookieVersion, saved.CookieVersion)
+	assert.Equal(t, model.MediaAccountStatusDisabled, saved.Status)
+	assert.Equal(t, model.DisabledReasonBlocked, saved.DisabledReason)
+}
@@ -0,0 +1,227 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"noctua/pkg/logger"
+	"noctua/types"
+	"sort"
+	"time"
+)
+
+const (
+	DefaultLeaseTTL          = 5 * time.Minute
+	DefaultLeaseReapInterval = 30 * time.Second
+)
+
+var (
+	ErrLeaseNotFound = errors.New("session lease not found or expired")
+	ErrAllLeased     = errors.New("all available sessions are leased") // 可用账号均被租用，释放后可重新获取
+)
+
+// LeaseConfig 会话租约配置
+type LeaseConfig struct {
+	TTL          time.Duration `mapstructure:"ttl"`           // 未指定时长时的默认租期
+	ReapInterval time.Duration `mapstructure:"reap_interval"` // 回收到期租约的间隔
+}
+
+// Lease 会话租约，持有期间其他调用方无法获取该账号的会话，到期未续期时自动回收
+type Lease struct {
+	ID         string    `json:"id"`
+	MediaCode  string    `json:"mediaCode"`
+	UserID     string    `json:"userId"`
+	ProjectID  uint      `json:"projectId"` // 会话账号所属项目
+	JobID      string    `json:"jobId,omitempty"`
+	Holder     string    `json:"holder,omitempty"`
+	TTL        int64     `json:"ttl"` // 最近一次获取或续期的租期，秒
+	AcquiredAt time.Time `json:"acquiredAt"`
+	RenewedAt  time.Time `json:"renewedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Renewals   int       `json:"renewals"`
+
+	ttl time.Duration
+}
+
+// expiredLease 被回收的租约及对应会话，用于在锁外发布事件
+type expiredLease struct {
+	lease Lease
+	info  types.SessionInfo
+}
+
+// StartLeases 设置默认租期并定期回收到期的租约，ctx 结束后停止
+func (sm *Manager) StartLeases(ctx context.Context, config LeaseConfig) {
+	if config.TTL <= 0 {
+		config.TTL = DefaultLeaseTTL
+	}
+	if config.ReapInterval <= 0 {
+		config.ReapInterval = DefaultLeaseReapInterval
+	}
+	sm.mu.Lock()
+	sm.leaseTTL = config.TTL
+	sm.mu.Unlock()
+	go func() {
+		ticker := time.NewTicker(config.ReapInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				sm.ReclaimExpired()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// newLeaseLocked 为会话创建租约，调用方需持有 sm.mu
+func (sm *Manager) newLeaseLocked(session *types.Session, params *SessionParams, now time.Time) Lease {
+	ttl := params.LeaseTTL
+	if ttl <= 0 {
+		ttl = sm.leaseTTL
+	}
+	if ttl <= 0 {
+		ttl = DefaultLeaseTTL
+	}
+	lease := &Lease{
+		ID:         uuid.NewString(),
+		MediaCode:  session.Account.MediaCode,
+		UserID:     session.Account.UserID,
+		ProjectID:  session.Account.ProjectID,
+		JobID:      params.JobID,
+		Holder:     params.Holder,
+		TTL:        int64(ttl / time.Second),
+		ttl:        ttl,
+		AcquiredAt: now,
+		RenewedAt:  now,
+		ExpiresAt:  now.Add(ttl),
+	}
+	sm.leases[lease.ID] = lease
+	session.LeaseID = lease.ID
+	return *lease
+}
+
+// removeLeaseLocked 删除租约并解除会话的占用，调用方需持有 sm.mu
+func (sm *Manager) removeLeaseLocked(lease *Lease) *types.Session {
+	delete(sm.leases, lease.ID)
+	session, ok := sm.sessionMap[lease.MediaCode][lease.UserID]
+	if !ok || session.LeaseID != lease.ID {
+		return nil
+	}
+	session.LeaseID = ""
+	return session
+}
+
+// reclaimLocked 回收到期的租约，调用方需持有 sm.mu
+func (sm *Manager) reclaimLocked(now time.Time) []expiredLease {
+	var expired []expiredLease
+	for _, lease := range sm.leases {
+		if now.Before(lease.ExpiresAt) {
+			continue
+		}
+		item := expiredLease{lease: *lease, info: types.SessionInfo{MediaCode: lease.MediaCode, UserID: lease.UserID}}
+		if session := sm.removeLeaseLocked(lease); session != nil {
+			item.info = sessionInfo(session, "")
+		}
+		expired = append(expired, item)
+	}
+	return expired
+}
+
+// emitExpired 记录并发布回收的租约，需在锁外调用
+func (sm *Manager) emitExpired(expired []expiredLease) {
+	for _, item := range expired {
+		sm.counter(item.lease.MediaCode, item.lease.UserID).released.Add(1)
+		logger.Log.Warnf("Reclaimed expired lease %s of account %s for media %s, holder: %s",
+			item.lease.ID, item.lease.UserID, item.lease.MediaCode, item.lease.Holder)
+		sm.emit.Emit(types.SessionLeaseExpiredEvent{
+			EventMeta:   types.NewEventMeta(item.lease.JobID).WithProject(item.lease.ProjectID),
+			SessionInfo: item.info,
+			LeaseID:     item.lease.ID,
+			Holder:      item.lease.Holder,
+			ExpiresAt:   item.lease.ExpiresAt,
+		})
+	}
+}
+
+// ReclaimExpired 回收到期的租约，返回回收的数量
+func (sm *Manager) ReclaimExpired() int {
+	now := time.Now()
+	sm.mu.Lock()
+	expired := sm.reclaimLocked(now)
+	sm.pruneAffinitiesLocked(now)
+	sm.mu.Unlock()
+	sm.emitExpired(expired)
+	return len(expired)
+}
+
+// Renew 续期租约，ttl 为 0 时沿用上次的租期，已到期的租约无法续期
+func (sm *Manager) Renew(leaseID string, ttl time.Duration) (Lease, error) {
+	now := time.Now()
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	lease, ok := sm.leases[leaseID]
+	if !ok || !now.Before(lease.ExpiresAt) {
+		return Lease{}, ErrLeaseNotFound
+	}
+	if ttl <= 0 {
+		ttl = lease.ttl
+	}
+	lease.ttl = ttl
+	lease.TTL = int64(ttl / time.Second)
+	lease.RenewedAt = now
+	lease.ExpiresAt = now.Add(ttl)
+	lease.Renewals++
+	return *lease, nil
+}
+
+// Release 释放租约，会话保留供后续获取
+func (sm *Manager) Release(leaseID string) error {
+	sm.mu.Lock()
+	lease, ok := sm.leases[leaseID]
+	if !ok {
+		sm.mu.Unlock()
+		return ErrLeaseNotFound
+	}
+	released := *lease
+	info := types.SessionInfo{MediaCode: lease.MediaCode, UserID: lease.UserID}
+	if session := sm.removeLeaseLocked(lease); session != nil {
+		info = sessionInfo(session, "")
+	}
+	sm.mu.Unlock()
+
+	sm.counter(released.MediaCode, released.UserID).released.Add(1)
+	logger.Log.Infof("Released lease %s of account %s", released.ID, released.UserID)
+	sm.emit.Emit(types.SessionReleasedEvent{
+		EventMeta:   types.NewEventMeta(released.JobID).WithProject(released.ProjectID),
+		SessionInfo: info,
+	})
+	return nil
+}
+
+// Lease 查询租约
+func (sm *Manager) Lease(leaseID string) (Lease, bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	lease, ok := sm.leases[leaseID]
+	if !ok {
+		return Lease{}, false
+	}
+	return *lease, true
+}
+
+// Leases 查询项目在平台的租约，mediaCode 为空时返回项目的全部租约，按获取时间排序
+func (sm *Manager) Leases(projectID uint, mediaCode string) []Lease {
+	sm.mu.Lock()
+	leases := make([]Lease, 0, len(sm.leases))
+	for _, lease := range sm.leases {
+		if lease.ProjectID == projectID && (mediaCode == "" || lease.MediaCode == mediaCode) {
+			leases = append(leases, *lease)
+		}
+	}
+	sm.mu.Unlock()
+	sort.Slice(leases, func(i, j int) bool {
+		return leases[i].AcquiredAt.Before(leases[j].AcquiredAt)
+	})
+	return leases
+}
@@ -0,0 +1,144 @@
+package session
+
+import (
+	"context"
+	"noctua/internal/model"
+	"noctua/internal/proxy"
+	"noctua/pkg/utils/str"
+	"noctua/types"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLeaseManager 创建带有指定数量账号的平台
+func newLeaseManager(t *testing.T, accounts int) (*Manager, string) {
+	pool := proxy.NewProxyPool(context.Background(), proxy.ProxyPoolConfig{})
+	t.Cleanup(pool.Stop)
+	mediaCode := "lease" + str.GenerateRandString(8)
+	for i := 0; i < accounts; i++ {
+		userID := str.GenerateRandString(8)
+		account := &model.MediaAccount{MediaCode: mediaCode, UserID: userID, UID: userID, Nickname: userID}
+		require.NoError(t, account.Create())
+	}
+	return NewManager(pool), mediaCode
+}
+
+func TestLeaseLifecycle(t *testing.T) {
+	sm, mediaCode := newLeaseManager(t, 1)
+	params := &SessionParams{MediaCode: mediaCode, Holder: "worker-1", LeaseTTL: time.Minute}
+
+	session, lease, err := sm.Acquire(params)
+	require.NoError(t, err)
+	assert.Equal(t, lease.ID, session.LeaseID)
+	assert.Equal(t, "worker-1", lease.Holder)
+	assert.Equal(t, int64(60), lease.TTL)
+	assert.Equal(t, []Lease{lease}, sm.Leases(model.DefaultProjectID, mediaCode))
+	assert.Equal(t, 1, sm.Status(model.DefaultProjectID).MediaDetails[mediaCode].ActiveCount)
+
+	// 租用中的会话不会被其他调用方获取
+	_, _, err = sm.Acquire(params)
+	assert.ErrorIs(t, err, ErrAllLeased)
+	_, err = sm.GetSession(&SessionParams{MediaCode: mediaCode})
+	assert.ErrorIs(t, err, ErrAllLeased)
+
+	renewed, err := sm.Renew(lease.ID, 2*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, renewed.Renewals)
+	assert.Equal(t, int64(120), renewed.TTL)
+	assert.True(t, renewed.ExpiresAt.After(lease.ExpiresAt))
+
+	// 调用方修改自己的副本不影响管理器
+	session.Account.Status = model.MediaAccountStatusDisabled
+	session.LeaseID = ""
+	current, ok := sm.Lease(lease.ID)
+	require.True(t, ok)
+	assert.Equal(t, renewed, current)
+
+	require.NoError(t, sm.Release(lease.ID))
+	assert.ErrorIs(t, sm.Release(lease.ID), ErrLeaseNotFound)
+	_, err = sm.Renew(lease.ID, 0)
+	assert.ErrorIs(t, err, ErrLeaseNotFound)
+	assert.Empty(t, sm.Leases(model.DefaultProjectID, mediaCode))
+
+	shared, err := sm.GetSession(&SessionParams{MediaCode: mediaCode})
+	require.NoError(t, err)
+	assert.Equal(t, lease.UserID, shared.Account.UserID)
+	assert.Empty(t, shared.LeaseID)
+}
+
+func TestLeaseExpired(t *testing.T) {
+	sm, mediaCode := newLeaseManager(t, 1)
+	var events []types.SessionLeaseExpiredEvent
+	var mu sync.Mutex
+	sm.SetEmitter(func(event interface{}) {
+		if e, ok := event.(types.SessionLeaseExpiredEvent); ok {
+			mu.Lock()
+			events = append(events, e)
+			mu.Unlock()
+		}
+	})
+
+	_, lease, err := sm.Acquire(&SessionParams{MediaCode: mediaCode, JobID: "job-1", LeaseTTL: 20 * time.Millisecond})
+	require.NoError(t, err)
+	_, err = sm.Renew(lease.ID, 0)
+	require.NoError(t, err)
+	time.Sleep(30 * time.Millisecond)
+
+	// 到期的租约无法续期，回收后可被重新获取
+	_, err = sm.Renew(lease.ID, time.Minute)
+	assert.ErrorIs(t, err, ErrLeaseNotFound)
+	assert.Equal(t, 1, sm.ReclaimExpired())
+	assert.Zero(t, sm.ReclaimExpired())
+	assert.Equal(t, int64(1), sm.Usage(mediaCode, lease.UserID).Released)
+
+	_, next, err := sm.Acquire(&SessionParams{MediaCode: mediaCode, LeaseTTL: 20 * time.Millisecond})
+	require.NoError(t, err)
+	assert.NotEqual(t, lease.ID, next.ID)
+	time.Sleep(30 * time.Millisecond)
+	// 获取会话时同样回收到期的租约
+	_, _, err = sm.Acquire(&SessionParams{MediaCode: mediaCode})
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, events, 2)
+	assert.Equal(t, lease.ID, events[0].LeaseID)
+	assert.Equal(t, "job-1", events[0].JobID)
+	assert.Equal(t, lease.UserID, events[0].UserID)
+}
+
+func TestLeaseConcurrent(t *testing.T) {
+	sm, mediaCode := newLeaseManager(t, 3)
+	var mu sync.Mutex
+	holders := make(map[string]int)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				session, lease, err := sm.Acquire(&SessionParams{MediaCode: mediaCode, LeaseTTL: time.Minute})
+				if err != nil {
+					continue
+				}
+				mu.Lock()
+				holders[lease.UserID]++
+				assert.Equal(t, 1, holders[lease.UserID], "account leased twice")
+				mu.Unlock()
+				_, _ = sm.Renew(lease.ID, time.Minute)
+				_ = sm.Usage(mediaCode, lease.UserID)
+				_ = sm.Status(model.DefaultProjectID)
+				mu.Lock()
+				holders[lease.UserID]--
+				mu.Unlock()
+				sm.ReleaseSession(session)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Empty(t, sm.Leases(model.DefaultProjectID, mediaCode))
+}
@@ -0,0 +1,12 @@
+package session
+
+import (
+	"noctua/internal/model"
+	"noctua/pkg/database/dbtest"
+	"testing"
+)
+
+// TestMain 数据库只能初始化一次，所有测试共享同一个库
+func TestMain(m *testing.M) {
+	dbtest.Main(m, &model.MediaAccount{})
+}
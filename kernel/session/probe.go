@@ -0,0 +1,322 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"noctua/internal/model"
+	"noctua/pkg/logger"
+	"noctua/types"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultProbeInterval    = 30 * time.Minute
+	DefaultProbeTimeout     = 15 * time.Second
+	DefaultProbeConcurrency = 2
+	DefaultProbeThreshold   = 3
+)
+
+// ProbeFunc 对账号执行一次轻量的登录态校验，session 携带账号及代理
+type ProbeFunc func(ctx context.Context, session *types.Session) error
+
+// ProbeConfig 账号探测配置
+type ProbeConfig struct {
+	Enabled          bool          `mapstructure:"enabled"`
+	Interval         time.Duration `mapstructure:"interval"`          // 探测间隔
+	Timeout          time.Duration `mapstructure:"timeout"`           // 单个账号的探测超时
+	Concurrency      int           `mapstructure:"concurrency"`       // 并发探测数
+	FailureThreshold int           `mapstructure:"failure_threshold"` // 连续失败达到该次数后禁用账号
+	UseProxy         bool          `mapstructure:"use_proxy"`         // 账号无会话时从代理池获取动态代理
+	Region           string        `mapstructure:"region"`            // 代理地区
+}
+
+// ProbeResult 单个账号的探测结果
+type ProbeResult struct {
+	MediaCode string    `json:"mediaCode"`
+	UserID    string    `json:"userId"`
+	ProjectID uint      `json:"projectId"`
+	OK        bool      `json:"ok"`
+	Latency   int64     `json:"latency"` // 毫秒
+	Error     string    `json:"error,omitempty"`
+	Failures  int       `json:"failures"`         // 连续失败次数
+	Action    string    `json:"action,omitempty"` // disabled 或 enabled
+	ProbedAt  time.Time `json:"probedAt"`
+}
+
+// ProbeStatus 最近一轮探测的汇总
+type ProbeStatus struct {
+	Enabled   bool          `json:"enabled"`
+	Running   bool          `json:"running"`
+	LastRunAt time.Time     `json:"lastRunAt"`
+	Duration  int64         `json:"duration"` // 毫秒
+	Checked   int           `json:"checked"`
+	Healthy   int           `json:"healthy"`
+	Failed    int           `json:"failed"`
+	Disabled  int           `json:"disabled"`  // 本轮禁用的账号数
+	Recovered int           `json:"recovered"` // 本轮恢复的账号数
+	Skipped   int           `json:"skipped"`   // 无法获取代理而跳过的账号数
+	LastError string        `json:"lastError,omitempty"`
+	Results   []ProbeResult `json:"results"`
+}
+
+// 探测后对账号执行的操作
+const (
+	ProbeActionDisabled = "disabled"
+	ProbeActionEnabled  = "enabled"
+)
+
+// prober 账号探测状态
+type prober struct {
+	mu      sync.Mutex
+	running bool
+	probes  map[string]ProbeFunc
+	status  ProbeStatus
+}
+
+// RegisterProber 注册平台的探测方法
+func (sm *Manager) RegisterProber(mediaCode string, probe ProbeFunc) {
+	sm.prober.mu.Lock()
+	defer sm.prober.mu.Unlock()
+	if sm.prober.probes == nil {
+		sm.prober.probes = make(map[string]ProbeFunc)
+	}
+	sm.prober.probes[mediaCode] = probe
+}
+
+// StartProbe 定期探测账号，ctx 结束后停止
+func (sm *Manager) StartProbe(ctx context.Context, config ProbeConfig) {
+	config = config.withDefaults()
+	sm.prober.mu.Lock()
+	sm.prober.status.Enabled = config.Enabled
+	sm.prober.mu.Unlock()
+	if !config.Enabled {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+		for {
+			if _, err := sm.ProbeAccounts(ctx, config); err != nil {
+				logger.Log.Errorf("Probe accounts failed: %v", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (c ProbeConfig) withDefaults() ProbeConfig {
+	if c.Interval <= 0 {
+		c.Interval = DefaultProbeInterval
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultProbeTimeout
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = DefaultProbeConcurrency
+	}
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = DefaultProbeThreshold
+	}
+	return c
+}
+
+var ErrProbeRunning = errors.New("account probe is already running")
+
+// ProbeAccounts 探测已注册平台的正常账号及可恢复的禁用账号，连续失败达到阈值时禁用，禁用账号探测成功后恢复
+func (sm *Manager) ProbeAccounts(ctx context.Context, config ProbeConfig) ([]ProbeResult, error) {
+	config = config.withDefaults()
+	sm.prober.mu.Lock()
+	if sm.prober.running {
+		sm.prober.mu.Unlock()
+		return nil, ErrProbeRunning
+	}
+	sm.prober.running = true
+	probes := make(map[string]ProbeFunc, len(sm.prober.probes))
+	mediaCodes := make([]string, 0, len(sm.prober.probes))
+	for mediaCode, probe := range sm.prober.probes {
+		probes[mediaCode] = probe
+		mediaCodes = append(mediaCodes, mediaCode)
+	}
+	sm.prober.mu.Unlock()
+
+	startedAt := time.Now()
+	accounts, err := sm.mediaAccount.QueryProbeAccounts(mediaCodes)
+	results := make([]ProbeResult, len(accounts))
+	if err == nil {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, config.Concurrency)
+		for i, account := range accounts {
+			if ctx.Err() != nil {
+				break
+			}
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int, account *model.MediaAccount) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				results[i] = sm.probeAccount(ctx, config, probes[account.MediaCode], account)
+			}(i, account)
+		}
+		wg.Wait()
+	}
+
+	status := ProbeStatus{
+		Enabled:   config.Enabled,
+		LastRunAt: startedAt,
+		Duration:  time.Since(startedAt).Milliseconds(),
+		Results:   make([]ProbeResult, 0, len(results)),
+	}
+	if err != nil {
+		status.LastError = err.Error()
+	}
+	for _, result := range results {
+		// 跳过或未完成的探测
+		if result.ProbedAt.IsZero() {
+			if result.Error != "" {
+				status.Skipped++
+			}
+			continue
+		}
+		status.Checked++
+		if result.OK {
+			status.Healthy++
+		} else {
+			status.Failed++
+		}
+		switch result.Action {
+		case ProbeActionDisabled:
+			status.Disabled++
+		case ProbeActionEnabled:
+			status.Recovered++
+		}
+		status.Results = append(status.Results, result)
+	}
+	sort.Slice(status.Results, func(i, j int) bool {
+		if status.Results[i].OK != status.Results[j].OK {
+			return !status.Results[i].OK
+		}
+		return status.Results[i].UserID < status.Results[j].UserID
+	})
+	sm.prober.mu.Lock()
+	sm.prober.running = false
+	sm.prober.status = status
+	sm.prober.mu.Unlock()
+	return status.Results, err
+}
+
+// probeAccount 探测单个账号并保存结果
+func (sm *Manager) probeAccount(ctx context.Context, config ProbeConfig, probe ProbeFunc, account *model.MediaAccount) ProbeResult {
+	result := ProbeResult{MediaCode: account.MediaCode, UserID: account.UserID, ProjectID: account.ProjectID}
+	session, release, err := sm.probeSession(config, account)
+	// 代理不可用不计入账号的失败次数
+	if err != nil {
+		result.Error = err.Error()
+		logger.Log.Warnf("Skip probing account %s: %v", account.UserID, err)
+		return result
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, config.Timeout)
+	startedAt := time.Now()
+	err = probe(probeCtx, session)
+	result.Latency = time.Since(startedAt).Milliseconds()
+	cancel()
+	release()
+	result.ProbedAt = time.Now()
+	result.OK = err == nil
+	if err != nil {
+		result.Error = err.Error()
+		result.Failures = account.ProbeFailures + 1
+	}
+
+	account.ProbeStatus = model.ProbeStatusOK
+	if !result.OK {
+		account.ProbeStatus = model.ProbeStatusFailed
+	}
+	account.ProbeLatency = result.Latency
+	account.ProbeError = result.Error
+	account.ProbeFailures = result.Failures
+	account.ProbedAt = &result.ProbedAt
+	if err := account.RecordProbe(); err != nil {
+		logger.Log.Errorf("Record probe result of account %s failed: %v", account.UserID, err)
+	}
+
+	switch {
+	case result.OK && account.Recoverable():
+		if err := account.SetStatus(model.MediaAccountStatusNormal, ""); err != nil {
+			logger.Log.Errorf("Enable account %s failed: %v", account.UserID, err)
+			break
+		}
+		result.Action = ProbeActionEnabled
+		logger.Log.Infof("Account %s for media %s recovered, enabled", account.UserID, account.MediaCode)
+	case !result.OK && account.Status == model.MediaAccountStatusNormal && result.Failures >= config.FailureThreshold:
+		if err := account.SetStatus(model.MediaAccountStatusDisabled, model.DisabledReasonProbe); err != nil {
+			logger.Log.Errorf("Disable account %s failed: %v", account.UserID, err)
+			break
+		}
+		sm.EvictAccount(account.MediaCode, account.UserID)
+		result.Action = ProbeActionDisabled
+		logger.Log.Warnf("Account %s for media %s failed %d probes, disabled: %s", account.UserID, account.MediaCode, result.Failures, result.Error)
+	}
+	if result.Action != "" {
+		sm.emit.Emit(types.AccountProbedEvent{
+			EventMeta: types.NewEventMeta("").WithProject(account.ProjectID),
+			MediaCode: result.MediaCode,
+			UserID:    result.UserID,
+			Action:    result.Action,
+			Failures:  result.Failures,
+			Error:     result.Error,
+		})
+	}
+	return result
+}
+
+// probeSession 构造探测用的会话，优先复用账号现有会话的代理，release 用于归还临时获取的代理
+func (sm *Manager) probeSession(config ProbeConfig, account *model.MediaAccount) (*types.Session, func(), error) {
+	session := &types.Session{Enabled: true, Account: account, ProxyInfo: &types.ProxyInfo{}}
+	release := func() {}
+	if current, ok := sm.session(account.MediaCode, account.UserID); ok && current.ProxyInfo != nil {
+		session.ProxyInfo = current.ProxyInfo
+		return session, release, nil
+	}
+	if !config.UseProxy {
+		return session, release, nil
+	}
+	proxyInfo, err := sm.proxyPool.GetAvailableProxy(types.ProxyRequest{Num: 1, Type: "dynamic", Region: config.Region})
+	if err != nil {
+		return nil, nil, err
+	}
+	session.ProxyInfo = proxyInfo
+	release = func() {
+		sm.proxyPool.ReleaseProxy(proxyInfo)
+	}
+	return session, release, nil
+}
+
+// ProbeStatus 最近一轮探测的汇总
+func (sm *Manager) ProbeStatus() ProbeStatus {
+	sm.prober.mu.Lock()
+	defer sm.prober.mu.Unlock()
+	status := sm.prober.status
+	status.Running = sm.prober.running
+	status.Results = append([]ProbeResult(nil), status.Results...)
+	return status
+}
+
+// project 只保留项目账号的探测结果，汇总计数为全部账号
+func (s ProbeStatus) project(projectID uint) ProbeStatus {
+	results := make([]ProbeResult, 0, len(s.Results))
+	for _, result := range s.Results {
+		if result.ProjectID == projectID {
+			results = append(results, result)
+		}
+	}
+	s.Results = results
+	return s
+}
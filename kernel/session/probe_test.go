@@ -0,0 +1,101 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"noctua/internal/model"
+	"noctua/internal/proxy"
+	"noctua/pkg/utils/str"
+	"noctua/types"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeAccounts(t *testing.T) {
+	pool := proxy.NewProxyPool(context.Background(), proxy.ProxyPoolConfig{})
+	defer pool.Stop()
+	sm := NewManager(pool)
+	var events []types.AccountProbedEvent
+	var mu sync.Mutex
+	sm.SetEmitter(func(event interface{}) {
+		if e, ok := event.(types.AccountProbedEvent); ok {
+			mu.Lock()
+			events = append(events, e)
+			mu.Unlock()
+		}
+	})
+
+	// 每个测试使用独立的平台，避免重复运行时相互影响
+	mediaCode := "probe" + str.GenerateRandString(8)
+	create := func(userID string, status int, reason string) *model.MediaAccount {
+		account := &model.MediaAccount{MediaCode: mediaCode, UserID: userID, UID: userID, Nickname: userID, Cookie: "sessionid=" + userID}
+		require.NoError(t, account.Create())
+		require.NoError(t, account.SetStatus(status, reason))
+		return account
+	}
+	healthy := create("healthy", model.MediaAccountStatusNormal, "")
+	failing := create("failing", model.MediaAccountStatusNormal, "")
+	blocked := create("blocked", model.MediaAccountStatusDisabled, model.DisabledReasonBlocked)
+	manual := create("manual", model.MediaAccountStatusDisabled, model.DisabledReasonManual)
+
+	var probed sync.Map
+	sm.RegisterProber(mediaCode, func(ctx context.Context, session *types.Session) error {
+		probed.Store(session.Account.UserID, true)
+		if session.Account.UserID == "failing" {
+			return errors.New("not logged in")
+		}
+		return nil
+	})
+	config := ProbeConfig{Enabled: true, FailureThreshold: 2}
+
+	results, err := sm.ProbeAccounts(context.Background(), config)
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+	_, ok := probed.Load("manual")
+	assert.False(t, ok, "manually disabled account should not be probed")
+
+	status := sm.Status(model.DefaultProjectID).Probe
+	assert.Equal(t, 3, status.Checked)
+	assert.Equal(t, 1, status.Failed)
+	assert.Equal(t, 1, status.Recovered)
+	assert.Equal(t, "failing", status.Results[0].UserID)
+
+	account, err := healthy.Find(blocked.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.MediaAccountStatusNormal, account.Status)
+	assert.Equal(t, "", account.DisabledReason)
+	assert.Equal(t, model.ProbeStatusOK, account.ProbeStatus)
+	assert.NotNil(t, account.ProbedAt)
+
+	account, err = healthy.Find(failing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.MediaAccountStatusNormal, account.Status)
+	assert.Equal(t, 1, account.ProbeFailures)
+	assert.Equal(t, "not logged in", account.ProbeError)
+
+	// 第二次失败达到阈值后禁用
+	_, err = sm.ProbeAccounts(context.Background(), config)
+	require.NoError(t, err)
+	account, err = healthy.Find(failing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.MediaAccountStatusDisabled, account.Status)
+	assert.Equal(t, model.DisabledReasonProbe, account.DisabledReason)
+	assert.Equal(t, 2, account.ProbeFailures)
+	assert.Equal(t, 1, sm.Status(model.DefaultProjectID).Probe.Disabled)
+
+	account, err = healthy.Find(manual.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.MediaAccountStatusDisabled, account.Status)
+	assert.Empty(t, account.ProbeStatus)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, events, 2)
+	assert.Equal(t, ProbeActionEnabled, events[0].Action)
+	assert.Equal(t, "blocked", events[0].UserID)
+	assert.Equal(t, ProbeActionDisabled, events[1].Action)
+	assert.Equal(t, "failing", events[1].UserID)
+}
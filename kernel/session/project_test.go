@@ -0,0 +1,59 @@
+package session
+
+import (
+	"noctua/internal/model"
+	"noctua/pkg/utils/str"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionProjectScope(t *testing.T) {
+	sm, mediaCode := newLeaseManager(t, 1)
+	teamUserID := str.GenerateRandString(8)
+	require.NoError(t, (&model.MediaAccount{
+		ProjectID: 7, MediaCode: mediaCode, UserID: teamUserID, UID: teamUserID, Nickname: teamUserID,
+	}).Create())
+
+	// 默认项目的会话不会被其他项目使用
+	defaultSession, err := sm.GetSession(&SessionParams{MediaCode: mediaCode})
+	require.NoError(t, err)
+	assert.NotEqual(t, teamUserID, defaultSession.Account.UserID)
+
+	for i := 0; i < 3; i++ {
+		session, err := sm.GetSession(&SessionParams{MediaCode: mediaCode, ProjectID: 7, UserID: defaultSession.Account.UserID})
+		require.NoError(t, err)
+		assert.Equal(t, teamUserID, session.Account.UserID)
+		assert.Equal(t, uint(7), session.Account.ProjectID)
+	}
+	again, err := sm.GetSession(&SessionParams{MediaCode: mediaCode})
+	require.NoError(t, err)
+	assert.Equal(t, defaultSession.Account.UserID, again.Account.UserID)
+
+	// 项目下没有账号时不借用其他项目的账号，临时账号归属请求的项目
+	_, err = sm.GetSession(&SessionParams{MediaCode: mediaCode, ProjectID: 8})
+	assert.Error(t, err)
+	temp, err := sm.GetSession(&SessionParams{MediaCode: mediaCode, ProjectID: 8, AllowNoneAccount: true})
+	require.NoError(t, err)
+	assert.Equal(t, uint(8), temp.Account.ProjectID)
+
+	// 租约和状态只包含请求项目的会话
+	_, lease, err := sm.Acquire(&SessionParams{MediaCode: mediaCode, ProjectID: 7})
+	require.NoError(t, err)
+	defer sm.Release(lease.ID)
+	assert.Equal(t, uint(7), lease.ProjectID)
+	assert.Equal(t, []Lease{lease}, sm.Leases(7, mediaCode))
+	assert.Empty(t, sm.Leases(model.DefaultProjectID, mediaCode))
+	assert.Equal(t, MediaStatus{SessionCount: 1, ActiveCount: 1}, sm.Status(7).MediaDetails[mediaCode])
+	assert.Zero(t, sm.Status(model.DefaultProjectID).MediaDetails[mediaCode].ActiveCount)
+
+	// 指定账号续建会话时不使用其他项目的账号
+	renewed, err := sm.RenewSession(&SessionParams{MediaCode: mediaCode, ProjectID: 7, UserID: teamUserID})
+	require.NoError(t, err)
+	assert.Equal(t, teamUserID, renewed.Account.UserID)
+	renewed, err = sm.RenewSession(&SessionParams{MediaCode: mediaCode, ProjectID: 8, UserID: teamUserID})
+	require.NoError(t, err)
+	assert.NotEqual(t, teamUserID, renewed.Account.UserID)
+	assert.Equal(t, uint(8), renewed.Account.ProjectID)
+}
@@ -0,0 +1,275 @@
+package session
+
+import (
+	"context"
+	"math"
+	"noctua/internal/model"
+	"noctua/pkg/logger"
+	"noctua/types"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultCooldown           = 30 * time.Minute
+	DefaultUsageFlushInterval = 30 * time.Second
+)
+
+// SelectionConfig 账号选择、配额及冷却配置
+type SelectionConfig struct {
+	Strategy      string           `mapstructure:"strategy"`       // lru、round_robin、weighted
+	Quotas        map[string]Quota `mapstructure:"quotas"`         // 各平台单个账号的请求配额
+	Cooldown      time.Duration    `mapstructure:"cooldown"`       // 触发限流后的冷却时长
+	FlushInterval time.Duration    `mapstructure:"flush_interval"` // 使用计数的保存间隔
+}
+
+// Quota 单个账号的请求配额，0 为不限制，每次获取会话计为一次请求
+type Quota struct {
+	Hourly int `mapstructure:"hourly" json:"hourly"`
+	Daily  int `mapstructure:"daily" json:"daily"`
+}
+
+// quotaState 账号的使用计数，从数据库载入后在内存中累加，定期保存
+type quotaState struct {
+	mu            sync.Mutex
+	accountID     uint
+	hourly        int
+	daily         int
+	rateLimits    int
+	lastUsed      time.Time
+	cooldownUntil time.Time
+	dirty         bool
+}
+
+func newQuotaState(account *model.MediaAccount, now time.Time) *quotaState {
+	state := &quotaState{
+		accountID:  account.ID,
+		hourly:     account.HourlyUsage,
+		daily:      account.DailyUsage,
+		rateLimits: account.RateLimits,
+		lastUsed:   account.LastUsed,
+	}
+	if account.CooldownUntil != nil {
+		state.cooldownUntil = *account.CooldownUntil
+	}
+	state.roll(now)
+	return state
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// roll 进入新的小时或新的一天后清零计数
+func (s *quotaState) roll(now time.Time) {
+	if !s.lastUsed.Truncate(time.Hour).Equal(now.Truncate(time.Hour)) {
+		s.hourly = 0
+	}
+	if !startOfDay(s.lastUsed).Equal(startOfDay(now)) {
+		s.daily = 0
+		s.rateLimits = 0
+	}
+}
+
+// available 是否未在冷却中且未超过配额
+func (s *quotaState) available(quota Quota, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.roll(now)
+	if now.Before(s.cooldownUntil) {
+		return false
+	}
+	if quota.Hourly > 0 && s.hourly >= quota.Hourly {
+		return false
+	}
+	if quota.Daily > 0 && s.daily >= quota.Daily {
+		return false
+	}
+	return true
+}
+
+func (s *quotaState) record(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.roll(now)
+	s.hourly++
+	s.daily++
+	s.lastUsed = now
+	s.dirty = true
+}
+
+// score 健康分，探测失败、当日限流及配额消耗都会降低分数
+func (s *quotaState) score(account *model.MediaAccount, quota Quota, now time.Time) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.roll(now)
+	score := 1 / float64(1+account.ProbeFailures) / float64(1+s.rateLimits)
+	if quota.Hourly > 0 {
+		score *= math.Max(0.05, 1-float64(s.hourly)/float64(quota.Hourly))
+	}
+	if quota.Daily > 0 {
+		score *= math.Max(0.05, 1-float64(s.daily)/float64(quota.Daily))
+	}
+	return score
+}
+
+// quotaState 返回账号的使用计数，临时账号返回 nil
+func (sm *Manager) quotaState(account *model.MediaAccount, now time.Time) *quotaState {
+	if account == nil || account.ID == 0 {
+		return nil
+	}
+	key := usageKey(account.MediaCode, account.UserID)
+	if value, ok := sm.quotas.Load(key); ok {
+		return value.(*quotaState)
+	}
+	value, _ := sm.quotas.LoadOrStore(key, newQuotaState(account, now))
+	return value.(*quotaState)
+}
+
+// accountAvailable 账号是否未在冷却中且未超过配额
+func (sm *Manager) accountAvailable(account *model.MediaAccount, quota Quota, now time.Time) bool {
+	state := sm.quotaState(account, now)
+	return state == nil || state.available(quota, now)
+}
+
+// recordUsage 记录一次使用并更新账号的最后使用时间
+func (sm *Manager) recordUsage(account *model.MediaAccount, now time.Time) {
+	if state := sm.quotaState(account, now); state != nil {
+		state.record(now)
+	}
+	account.LastUsed = now
+}
+
+// StartSelection 设置账号选择策略及配额，并定期保存使用计数，ctx 结束后停止
+func (sm *Manager) StartSelection(ctx context.Context, config SelectionConfig) error {
+	selector, err := NewSelector(config.Strategy)
+	if err != nil {
+		return err
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = DefaultCooldown
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultUsageFlushInterval
+	}
+	sm.mu.Lock()
+	sm.selector = selector
+	sm.selection = config
+	sm.mu.Unlock()
+	go func() {
+		ticker := time.NewTicker(config.FlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				sm.FlushUsage()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// FlushUsage 保存有变化的使用计数
+func (sm *Manager) FlushUsage() {
+	sm.quotas.Range(func(_, value interface{}) bool {
+		state := value.(*quotaState)
+		state.mu.Lock()
+		if !state.dirty {
+			state.mu.Unlock()
+			return true
+		}
+		account := &model.MediaAccount{
+			ID:          state.accountID,
+			LastUsed:    state.lastUsed,
+			HourlyUsage: state.hourly,
+			DailyUsage:  state.daily,
+			RateLimits:  state.rateLimits,
+		}
+		if !state.cooldownUntil.IsZero() {
+			cooldownUntil := state.cooldownUntil
+			account.CooldownUntil = &cooldownUntil
+		}
+		state.dirty = false
+		state.mu.Unlock()
+		if err := account.SaveUsage(); err != nil {
+			logger.Log.Errorf("Save usage of account %d failed: %v", account.ID, err)
+			state.mu.Lock()
+			state.dirty = true
+			state.mu.Unlock()
+		}
+		return true
+	})
+}
+
+// ReportRateLimit 账号触发平台限流，冷却期间不会被选中，正在使用的会话不受影响，冷却中再次限流时只延长冷却
+func (sm *Manager) ReportRateLimit(session *types.Session, reason string) {
+	if session == nil || session.Account == nil {
+		return
+	}
+	now := time.Now()
+	state := sm.quotaState(session.Account, now)
+	if state == nil {
+		return
+	}
+	sm.mu.Lock()
+	cooldown := sm.selection.Cooldown
+	sm.mu.Unlock()
+	if cooldown <= 0 {
+		cooldown = DefaultCooldown
+	}
+	state.mu.Lock()
+	state.roll(now)
+	cooling := now.Before(state.cooldownUntil)
+	state.rateLimits++
+	state.cooldownUntil = now.Add(cooldown)
+	state.dirty = true
+	until := state.cooldownUntil
+	state.mu.Unlock()
+	if cooling {
+		return
+	}
+	logger.Log.Warnf("Account %s for media %s rate limited (%s), cooldown until %s",
+		session.Account.UserID, session.Account.MediaCode, reason, until.Format(time.DateTime))
+	sm.emit.Emit(types.AccountCooldownEvent{
+		EventMeta: types.NewEventMeta(session.JobID).WithProject(session.Account.ProjectID),
+		MediaCode: session.Account.MediaCode,
+		UserID:    session.Account.UserID,
+		Reason:    reason,
+		Until:     until,
+	})
+}
+
+// QuotaUsage 账号当前的配额使用情况
+type QuotaUsage struct {
+	Quota         Quota      `json:"quota"`
+	Hourly        int        `json:"hourly"`
+	Daily         int        `json:"daily"`
+	RateLimits    int        `json:"rateLimits"`
+	CooldownUntil *time.Time `json:"cooldownUntil"`
+}
+
+// AccountQuota 查询账号的配额使用情况，未载入内存时以数据库记录为准
+func (sm *Manager) AccountQuota(account *model.MediaAccount) QuotaUsage {
+	now := time.Now()
+	sm.mu.Lock()
+	usage := QuotaUsage{Quota: sm.selection.Quotas[account.MediaCode]}
+	sm.mu.Unlock()
+	state := newQuotaState(account, now)
+	if value, ok := sm.quotas.Load(usageKey(account.MediaCode, account.UserID)); ok {
+		state = value.(*quotaState)
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.roll(now)
+	usage.Hourly = state.hourly
+	usage.Daily = state.daily
+	usage.RateLimits = state.rateLimits
+	if state.cooldownUntil.After(now) {
+		cooldownUntil := state.cooldownUntil
+		usage.CooldownUntil = &cooldownUntil
+	}
+	return usage
+}
@@ -0,0 +1,112 @@
+package session
+
+import (
+	"context"
+	"noctua/internal/model"
+	"noctua/internal/proxy"
+	"noctua/pkg/utils/str"
+	"noctua/types"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaStateRoll(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 30, 0, 0, time.Local)
+	state := newQuotaState(&model.MediaAccount{ID: 1, HourlyUsage: 5, DailyUsage: 20, RateLimits: 1, LastUsed: now.Add(-10 * time.Minute)}, now)
+	assert.False(t, state.available(Quota{Hourly: 5}, now))
+	assert.True(t, state.available(Quota{Hourly: 6, Daily: 21}, now))
+	assert.False(t, state.available(Quota{Daily: 20}, now))
+
+	// 进入新的小时清零小时计数
+	assert.True(t, state.available(Quota{Hourly: 5, Daily: 21}, now.Add(time.Hour)))
+	assert.Equal(t, 20, state.daily)
+	// 进入新的一天清零全部计数
+	state.record(now.Add(24 * time.Hour))
+	assert.Equal(t, 1, state.hourly)
+	assert.Equal(t, 1, state.daily)
+	assert.Zero(t, state.rateLimits)
+
+	state.cooldownUntil = now.Add(25 * time.Hour)
+	assert.False(t, state.available(Quota{}, now.Add(24*time.Hour)))
+	assert.True(t, state.available(Quota{}, now.Add(26*time.Hour)))
+}
+
+func TestGetSessionQuotaAndCooldown(t *testing.T) {
+	pool := proxy.NewProxyPool(context.Background(), proxy.ProxyPoolConfig{})
+	defer pool.Stop()
+	sm := NewManager(pool)
+	var events []types.AccountCooldownEvent
+	var mu sync.Mutex
+	sm.SetEmitter(func(event interface{}) {
+		if e, ok := event.(types.AccountCooldownEvent); ok {
+			mu.Lock()
+			events = append(events, e)
+			mu.Unlock()
+		}
+	})
+
+	mediaCode := "quota" + str.GenerateRandString(8)
+	for _, userID := range []string{"first", "second"} {
+		account := &model.MediaAccount{MediaCode: mediaCode, UserID: userID, UID: userID, Nickname: userID, Cookie: "sessionid=" + userID}
+		require.NoError(t, account.Create())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, sm.StartSelection(ctx, SelectionConfig{
+		Strategy: StrategyLRU,
+		Quotas:   map[string]Quota{mediaCode: {Hourly: 2}},
+		Cooldown: time.Hour,
+	}))
+
+	// 按最久未使用轮换账号，达到配额后不再选中
+	var picked []string
+	for i := 0; i < 4; i++ {
+		session, err := sm.GetSession(&SessionParams{MediaCode: mediaCode})
+		require.NoError(t, err)
+		picked = append(picked, session.Account.UserID)
+	}
+	assert.Equal(t, []string{"first", "second", "first", "second"}, picked)
+	_, err := sm.GetSession(&SessionParams{MediaCode: mediaCode})
+	assert.ErrorContains(t, err, "cooling down or over quota")
+
+	// 使用计数保存到数据库，重新载入后配额依然生效
+	sm.FlushUsage()
+	accounts, err := (&model.MediaAccount{}).QueryAvailableAccounts(&model.QueryMediaAccountParams{MediaCode: mediaCode})
+	require.NoError(t, err)
+	require.Len(t, accounts, 2)
+	assert.Equal(t, 2, accounts[0].HourlyUsage)
+	assert.Equal(t, 2, accounts[0].DailyUsage)
+	reloaded := NewManager(pool)
+	require.NoError(t, reloaded.StartSelection(ctx, SelectionConfig{Quotas: map[string]Quota{mediaCode: {Hourly: 2}}}))
+	_, err = reloaded.GetSession(&SessionParams{MediaCode: mediaCode})
+	assert.Error(t, err)
+
+	// 冷却中的账号不会被选中，优先账号冷却时更换账号
+	cooled := NewManager(pool)
+	cooled.SetEmitter(sm.emit)
+	require.NoError(t, cooled.StartSelection(ctx, SelectionConfig{Quotas: map[string]Quota{mediaCode: {Daily: 100}}, Cooldown: time.Hour}))
+	session, err := cooled.GetSession(&SessionParams{MediaCode: mediaCode, UserID: "first"})
+	require.NoError(t, err)
+	assert.Equal(t, "first", session.Account.UserID)
+	cooled.ReportRateLimit(session, "http 429")
+	cooled.ReportRateLimit(session, "http 429")
+	for i := 0; i < 2; i++ {
+		next, err := cooled.GetSession(&SessionParams{MediaCode: mediaCode, UserID: "first"})
+		require.NoError(t, err)
+		assert.Equal(t, "second", next.Account.UserID)
+	}
+	usage := cooled.AccountQuota(session.Account)
+	assert.Equal(t, 2, usage.RateLimits)
+	require.NotNil(t, usage.CooldownUntil)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *usage.CooldownUntil, time.Minute)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, events, 1, "repeated rate limits during cooldown notify once")
+	assert.Equal(t, "first", events[0].UserID)
+	assert.Equal(t, "http 429", events[0].Reason)
+}
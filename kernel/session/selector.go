@@ -0,0 +1,127 @@
+package session
+
+import (
+	"fmt"
+	"math/rand"
+	"noctua/internal/model"
+	"sync"
+	"time"
+)
+
+// 内置的账号选择策略
+const (
+	StrategyLRU        = "lru"         // 最久未使用优先
+	StrategyRoundRobin = "round_robin" // 按账号ID轮询
+	StrategyWeighted   = "weighted"    // 按健康分加权随机
+)
+
+// Candidate 候选账号，按ID升序传入
+type Candidate struct {
+	Account  *model.MediaAccount
+	LastUsed time.Time // 最后使用时间，含未保存的使用记录
+	Score    float64   // 健康分，(0, 1]
+}
+
+// Selector 账号选择策略，返回选中的候选下标
+type Selector interface {
+	Select(mediaCode string, candidates []Candidate) int
+}
+
+// SelectorFunc 函数形式的选择策略
+type SelectorFunc func(mediaCode string, candidates []Candidate) int
+
+func (f SelectorFunc) Select(mediaCode string, candidates []Candidate) int {
+	return f(mediaCode, candidates)
+}
+
+var (
+	selectorsMu sync.RWMutex
+	selectors   = map[string]func() Selector{
+		StrategyLRU:        func() Selector { return SelectorFunc(selectLRU) },
+		StrategyRoundRobin: func() Selector { return &roundRobin{cursor: map[string]uint{}} },
+		StrategyWeighted:   func() Selector { return newWeighted(rand.New(rand.NewSource(time.Now().UnixNano()))) },
+	}
+)
+
+// RegisterSelector 注册自定义选择策略，每个 Manager 调用 factory 创建独立的实例
+func RegisterSelector(name string, factory func() Selector) {
+	selectorsMu.Lock()
+	defer selectorsMu.Unlock()
+	selectors[name] = factory
+}
+
+// NewSelector 按名称创建选择策略，为空时使用 LRU
+func NewSelector(name string) (Selector, error) {
+	if name == "" {
+		name = StrategyLRU
+	}
+	selectorsMu.RLock()
+	factory, ok := selectors[name]
+	selectorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown account selection strategy: %s", name)
+	}
+	return factory(), nil
+}
+
+// selectLRU 选择最久未使用的账号，相同时选择ID较小的
+func selectLRU(_ string, candidates []Candidate) int {
+	selected := 0
+	for i := 1; i < len(candidates); i++ {
+		if candidates[i].LastUsed.Before(candidates[selected].LastUsed) {
+			selected = i
+		}
+	}
+	return selected
+}
+
+// roundRobin 按账号ID轮询，各平台独立计数
+type roundRobin struct {
+	mu     sync.Mutex
+	cursor map[string]uint // 各平台上次选中的账号ID
+}
+
+func (r *roundRobin) Select(mediaCode string, candidates []Candidate) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	selected := 0
+	last := r.cursor[mediaCode]
+	for i, candidate := range candidates {
+		if candidate.Account.ID > last {
+			selected = i
+			break
+		}
+	}
+	r.cursor[mediaCode] = candidates[selected].Account.ID
+	return selected
+}
+
+// weighted 按健康分加权随机
+type weighted struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func newWeighted(r *rand.Rand) *weighted {
+	return &weighted{rand: r}
+}
+
+func (w *weighted) Select(_ string, candidates []Candidate) int {
+	total := 0.0
+	for _, candidate := range candidates {
+		total += candidate.Score
+	}
+	if total <= 0 {
+		return 0
+	}
+	w.mu.Lock()
+	point := w.rand.Float64() * total
+	w.mu.Unlock()
+	for i, candidate := range candidates {
+		point -= candidate.Score
+		if point < 0 {
+			return i
+		}
+	}
+	return len(candidates) - 1
+}
@@ -0,0 +1,78 @@
+package session
+
+import (
+	"math/rand"
+	"noctua/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func candidates(ids ...uint) []Candidate {
+	result := make([]Candidate, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, Candidate{Account: &model.MediaAccount{ID: id}, Score: 1})
+	}
+	return result
+}
+
+func TestNewSelector(t *testing.T) {
+	for _, name := range []string{"", StrategyLRU, StrategyRoundRobin, StrategyWeighted} {
+		selector, err := NewSelector(name)
+		require.NoError(t, err, name)
+		assert.NotNil(t, selector)
+	}
+	_, err := NewSelector("unknown")
+	assert.Error(t, err)
+
+	RegisterSelector("first", func() Selector {
+		return SelectorFunc(func(string, []Candidate) int { return 0 })
+	})
+	selector, err := NewSelector("first")
+	require.NoError(t, err)
+	assert.Equal(t, 0, selector.Select("douyin", candidates(1, 2)))
+}
+
+func TestSelectLRU(t *testing.T) {
+	now := time.Now()
+	list := candidates(1, 2, 3)
+	list[0].LastUsed = now
+	list[1].LastUsed = now.Add(-time.Hour)
+	list[2].LastUsed = now.Add(-time.Hour)
+	assert.Equal(t, 1, selectLRU("douyin", list), "oldest wins, ties go to the smaller id")
+}
+
+func TestRoundRobin(t *testing.T) {
+	selector, err := NewSelector(StrategyRoundRobin)
+	require.NoError(t, err)
+	list := candidates(3, 5, 8)
+	var picked []uint
+	for i := 0; i < 4; i++ {
+		picked = append(picked, list[selector.Select("douyin", list)].Account.ID)
+	}
+	assert.Equal(t, []uint{3, 5, 8, 3}, picked)
+
+	// 上次选中的账号不可用时继续选择下一个ID
+	assert.Equal(t, 1, selector.Select("douyin", candidates(3, 8)))
+	// 各平台独立计数
+	assert.Equal(t, 0, selector.Select("kuaishou", list))
+}
+
+func TestWeighted(t *testing.T) {
+	selector := newWeighted(rand.New(rand.NewSource(1)))
+	list := candidates(1, 2, 3)
+	list[0].Score = 0
+	list[1].Score = 0.1
+	list[2].Score = 0.9
+	counts := make([]int, len(list))
+	for i := 0; i < 1000; i++ {
+		counts[selector.Select("douyin", list)]++
+	}
+	assert.Zero(t, counts[0])
+	assert.Greater(t, counts[2], counts[1]*4)
+
+	list[1].Score, list[2].Score = 0, 0
+	assert.Equal(t, 0, selector.Select("douyin", list))
+}
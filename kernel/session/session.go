@@ -8,6 +8,7 @@ import (
 	"noctua/pkg/logger"
 	"noctua/pkg/utils/str"
 	"noctua/types"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -17,15 +18,17 @@ import (
 type SessionManagerStatus struct {
 	ActiveSessions int                    `json:"activeSessions"`
 	TotalSessions  int                    `json:"totalSessions"`
+	Leases         int                    `json:"leases"` // 未释放的租约数
 	MediaDetails   map[string]MediaStatus `json:"mediaDetails"`
 	ProxyStatus    *proxy.ProxyPoolStatus `json:"proxyStatus"`
 	UserProxyPairs int                    `json:"userProxyPairs"`
+	Probe          ProbeStatus            `json:"probe"`
 }
 
 // MediaStatus 定义每个媒体的状态
 type MediaStatus struct {
 	SessionCount int `json:"sessionCount"`
-	ActiveCount  int `json:"activeCount"`
+	ActiveCount  int `json:"activeCount"` // 持有租约的会话数
 }
 
 type SessionParams struct {
@@ -33,10 +36,15 @@ type SessionParams struct {
 	SessionType      string
 	SessionRegion    string
 	UserID           string
-	KeepAlive        bool
+	KeepAlive        bool          // 持有租约，租约到期或释放前其他调用方无法获取该会话
+	LeaseTTL         time.Duration // 租期，为 0 时使用默认租期
+	Holder           string        // 租约持有方，用于查看租约
 	AllowNoneAccount bool
 	AccountType      int
+	ProjectID        uint // 所属项目，只使用该项目的账号
 	ExcludeUserIdMap map[string]int
+	JobID            string // 发起请求的采集任务ID，用于事件
+	AffinityKey      string // 会话亲和标识，已绑定账号时优先于 UserID，同一标识使用同一账号和代理直至不可用
 }
 
 // Manager 管理账号的 Cookie 和代理
@@ -44,8 +52,21 @@ type Manager struct {
 	mu           sync.Mutex
 	mediaAccount *model.MediaAccount
 	proxyPool    *proxy.ProxyPool
-	sessionMap   map[string]*sync.Map // 修改为 map[string]*sync.Map
+	sessionMap   map[string]map[string]*types.Session // 各平台的会话，key 为 userID，只在 mu 内读写
+	leases       map[string]*Lease                    // 会话租约，key 为租约ID，只在 mu 内读写
+	leaseTTL     time.Duration
+	affinities   map[string]*affinity // 会话亲和标识绑定的账号，只在 mu 内读写
+	affinityTTL  time.Duration
 	userProxyMap sync.Map
+	usage        sync.Map // 账号使用计数，key 为 mediaCode:userID
+	cookieCheck  CookieCheckConfig
+	prober       prober
+	selector     Selector
+	selection    SelectionConfig
+	quotas       sync.Map // 账号配额计数，key 为 mediaCode:userID
+	cookieJar    CookieJarConfig
+	jars         sync.Map // 合并响应 Set-Cookie 后的账号 Cookie，key 为 mediaCode:userID
+	emit         types.EventEmitter
 }
 
 // NewManager 创建 Manager
@@ -53,11 +74,31 @@ func NewManager(proxyPool *proxy.ProxyPool) *Manager {
 	return &Manager{
 		proxyPool:    proxyPool,
 		mediaAccount: &model.MediaAccount{},
-		sessionMap:   make(map[string]*sync.Map),
+		sessionMap:   make(map[string]map[string]*types.Session),
+		leases:       make(map[string]*Lease),
+		affinities:   make(map[string]*affinity),
 		userProxyMap: sync.Map{},
 	}
 }
 
+// SetEmitter 设置会话事件的发布函数
+func (sm *Manager) SetEmitter(emit types.EventEmitter) {
+	sm.emit = emit
+}
+
+// sessionInfo 事件中的会话信息
+func sessionInfo(session *types.Session, region string) types.SessionInfo {
+	info := types.SessionInfo{Region: region}
+	if session.Account != nil {
+		info.MediaCode = session.Account.MediaCode
+		info.UserID = session.Account.UserID
+	}
+	if session.ProxyInfo != nil {
+		info.ProxyKey = session.ProxyInfo.ProxyKey
+	}
+	return info
+}
+
 func (sm *Manager) LoadMediaProxyCache() {
 	cacheKeys := cache.CacheManager.Keys("media:proxy:*")
 	for _, cacheKey := range cacheKeys {
@@ -73,12 +114,9 @@ func (sm *Manager) LoadMediaProxyCache() {
 // SetMediaAccount 设置媒体账号
 func (sm *Manager) SetMediaAccount(account *model.MediaAccount, isInUse bool, proxyKey string, expireTime time.Time) error {
 	mediaCode := account.MediaCode
-	if account.Status == 100 {
-		if sessions, ok := sm.sessionMap[mediaCode]; ok {
-			if session, ok := sessions.Load(account.UserID); ok {
-				sm.proxyPool.ReleaseProxy(session.(*types.Session).ProxyInfo)
-				sessions.Delete(account.UserID)
-			}
+	if account.Status == model.MediaAccountStatusDisabled {
+		if session, ok := sm.removeSession(mediaCode, account.UserID); ok {
+			sm.proxyPool.ReleaseProxy(session.ProxyInfo)
 		}
 	} else {
 		// 缓存代理关系
@@ -98,6 +136,7 @@ func (sm *Manager) RenewSession(params *SessionParams) (*types.Session, error) {
 	var err error
 	if len(params.UserID) == 0 {
 		account = &model.MediaAccount{
+			ProjectID: params.ProjectID,
 			UserID:    str.GenerateRandString(64),
 			MediaCode: params.MediaCode,
 			Type:      params.AccountType,
@@ -105,12 +144,15 @@ func (sm *Manager) RenewSession(params *SessionParams) (*types.Session, error) {
 	} else {
 		// 查询数据库
 		account, err = sm.mediaAccount.FindMediaAccount(&model.QueryMediaAccountParams{
+			ProjectID: params.ProjectID,
 			MediaCode: params.MediaCode,
 			Type:      params.AccountType,
 			UserID:    params.UserID,
 		})
-		if err != nil {
+		// 账号不存在或不属于该项目时使用临时账号
+		if err != nil || account.ID == 0 {
 			account = &model.MediaAccount{
+				ProjectID: params.ProjectID,
 				UserID:    str.GenerateRandString(64),
 				MediaCode: params.MediaCode,
 				Type:      params.AccountType,
@@ -127,70 +169,176 @@ func (sm *Manager) RenewSession(params *SessionParams) (*types.Session, error) {
 	}, nil
 }
 
-// GetSession 获取会话
+// GetSession 获取会话，UserID 为优先使用的账号，不可用时按选择策略更换账号，KeepAlive 时持有租约
 func (sm *Manager) GetSession(params *SessionParams) (*types.Session, error) {
-	// 初始化 mediaCode 对应的 sync.Map
-	sm.mu.Lock()
-	if _, ok := sm.sessionMap[params.MediaCode]; !ok {
-		sm.sessionMap[params.MediaCode] = &sync.Map{}
+	session, _, err := sm.getSession(params)
+	return session, err
+}
+
+// Acquire 获取会话并持有租约，到期前需调用 Renew 续期，用完后调用 Release 释放
+func (sm *Manager) Acquire(params *SessionParams) (*types.Session, Lease, error) {
+	leased := *params
+	leased.KeepAlive = true
+	return sm.getSession(&leased)
+}
+
+// maxClaimAttempts 选中的会话被并发获取时重新选择的次数
+const maxClaimAttempts = 3
+
+func (sm *Manager) getSession(params *SessionParams) (*types.Session, Lease, error) {
+	if params.AffinityKey == "" {
+		return sm.claimSession(params)
 	}
-	sessions := sm.sessionMap[params.MediaCode]
-	sm.mu.Unlock()
+	// 亲和标识已绑定账号时优先使用该账号，不可用时按选择策略更换并重新绑定
+	pinned := sm.AffinityUser(params.AffinityKey)
+	if pinned != "" && pinned != params.UserID {
+		preferred := *params
+		preferred.UserID = pinned
+		params = &preferred
+	}
+	session, lease, err := sm.claimSession(params)
+	if err == nil {
+		sm.bindAffinity(params, pinned, session)
+	}
+	return session, lease, err
+}
+
+// claimSession 选择并获取会话，选中的会话被并发获取时重新选择
+func (sm *Manager) claimSession(params *SessionParams) (*types.Session, Lease, error) {
+	skip := make(map[string]bool)
+	for attempt := 0; ; attempt++ {
+		session, lease, conflict, err := sm.selectSession(params, skip)
+		if err != nil || conflict == "" {
+			return session, lease, err
+		}
+		if attempt+1 >= maxClaimAttempts {
+			return nil, Lease{}, fmt.Errorf("%w: account %s for media %s is held by another lease", ErrAllLeased, conflict, params.MediaCode)
+		}
+		skip[conflict] = true
+	}
+}
 
-	// 检查现有 session
-	excludeUserIds := make([]string, 0, len(params.ExcludeUserIdMap))
+// selectSession 选择账号并获取会话，选中的会话已被其他调用方租用时返回其账号ID
+func (sm *Manager) selectSession(params *SessionParams, skip map[string]bool) (*types.Session, Lease, string, error) {
+	now := time.Now()
+	excludeUserIds := make([]string, 0, len(params.ExcludeUserIdMap)+len(skip))
 	for id := range params.ExcludeUserIdMap {
 		excludeUserIds = append(excludeUserIds, id)
 	}
+	for id := range skip {
+		excludeUserIds = append(excludeUserIds, id)
+	}
 
-	var foundSession *types.Session
-	sessions.Range(func(key, value interface{}) bool {
-		session := value.(*types.Session)
-		if params.AccountType != session.Account.Type {
-			return true
+	// 检查现有 session，空闲会话的账号以副本参与选择
+	sm.mu.Lock()
+	selector, selection := sm.selector, sm.selection
+	expired := sm.reclaimLocked(now)
+	quota := selection.Quotas[params.MediaCode]
+	idleAccounts := make(map[string]*model.MediaAccount)
+	limited := false
+	leased := false
+	preferred := ""
+	sessions := sm.mediaSessionsLocked(params.MediaCode)
+	for userID, session := range sessions {
+		if params.AccountType != session.Account.Type || params.ProjectID != session.Account.ProjectID {
+			continue
 		}
-		if _, ok := params.ExcludeUserIdMap[session.Account.UserID]; ok {
-			return true
+		if _, ok := params.ExcludeUserIdMap[userID]; ok || skip[userID] {
+			continue
 		}
-		if session.InUsed {
-			excludeUserIds = append(excludeUserIds, session.Account.UserID)
-			return true
+		if now.After(session.ExpireTime) {
+			if session.LeaseID == "" {
+				delete(sessions, userID)
+			}
+			continue
 		}
-		if time.Now().After(session.ExpireTime) {
-			sessions.Delete(key)
-			return true
+		if session.LeaseID != "" {
+			leased = true
+			excludeUserIds = append(excludeUserIds, userID)
+			continue
 		}
-		if len(params.UserID) == 0 || params.UserID == session.Account.UserID {
-			if params.KeepAlive {
-				session.InUsed = true
-				sessions.Store(key, session) // 在 Range 内更新
-			}
-			foundSession = session
-			return false // 停止遍历
+		// 冷却中或超过配额的账号不参与选择
+		if !sm.accountAvailable(session.Account, quota, now) {
+			limited = true
+			excludeUserIds = append(excludeUserIds, userID)
+			continue
 		}
-		excludeUserIds = append(excludeUserIds, session.Account.UserID)
-		return true
-	})
-
-	if foundSession != nil {
-		return foundSession, nil
+		if params.UserID != "" && params.UserID == userID {
+			preferred = userID
+		}
+		account := *session.Account
+		idleAccounts[userID] = &account
+	}
+	sm.mu.Unlock()
+	sm.emitExpired(expired)
+	if selector == nil {
+		selector = SelectorFunc(selectLRU)
+	}
+	if preferred != "" {
+		return sm.claim(params, preferred, nil, now)
 	}
 
-	// 查询数据库
-	account, err := sm.mediaAccount.FindMediaAccount(&model.QueryMediaAccountParams{
-		MediaCode:      params.MediaCode,
-		Type:           params.AccountType,
-		UserID:         params.UserID,
-		ExcludeUserIDs: excludeUserIds,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("query available account failed: %v", err)
+	// 查询候选账号，优先账号不可用时从其他账号中选择
+	var accounts []*model.MediaAccount
+	var err error
+	if params.UserID != "" && !slices.Contains(excludeUserIds, params.UserID) {
+		accounts, err = sm.mediaAccount.QueryAvailableAccounts(&model.QueryMediaAccountParams{
+			ProjectID: params.ProjectID,
+			MediaCode: params.MediaCode,
+			Type:      params.AccountType,
+			UserID:    params.UserID,
+		})
+		if err != nil {
+			return nil, Lease{}, "", fmt.Errorf("query available account failed: %v", err)
+		}
+	}
+	if len(accounts) == 0 || !sm.accountAvailable(accounts[0], quota, now) {
+		accounts, err = sm.mediaAccount.QueryAvailableAccounts(&model.QueryMediaAccountParams{
+			ProjectID:      params.ProjectID,
+			MediaCode:      params.MediaCode,
+			Type:           params.AccountType,
+			ExcludeUserIDs: excludeUserIds,
+		})
+		if err != nil {
+			return nil, Lease{}, "", fmt.Errorf("query available account failed: %v", err)
+		}
+	}
+	candidates := make([]Candidate, 0, len(accounts))
+	for _, account := range accounts {
+		if idle, ok := idleAccounts[account.UserID]; ok {
+			account = idle
+		}
+		if !sm.accountAvailable(account, quota, now) {
+			continue
+		}
+		candidate := Candidate{Account: account, LastUsed: account.LastUsed, Score: 1}
+		if state := sm.quotaState(account, now); state != nil {
+			state.mu.Lock()
+			candidate.LastUsed = state.lastUsed
+			state.mu.Unlock()
+			candidate.Score = state.score(account, quota, now)
+		}
+		candidates = append(candidates, candidate)
 	}
-	if account.ID == 0 {
+
+	var account *model.MediaAccount
+	if len(candidates) > 0 {
+		account = candidates[selector.Select(params.MediaCode, candidates)].Account
+		if _, ok := idleAccounts[account.UserID]; ok {
+			return sm.claim(params, account.UserID, nil, now)
+		}
+	} else {
 		if !params.AllowNoneAccount {
-			return nil, fmt.Errorf("no available account for media: %s", params.MediaCode)
+			if leased && len(accounts) == 0 {
+				return nil, Lease{}, "", fmt.Errorf("%w: media %s", ErrAllLeased, params.MediaCode)
+			}
+			if limited || len(accounts) > 0 {
+				return nil, Lease{}, "", fmt.Errorf("all accounts for media %s are cooling down or over quota", params.MediaCode)
+			}
+			return nil, Lease{}, "", fmt.Errorf("no available account for media: %s", params.MediaCode)
 		}
 		account = &model.MediaAccount{
+			ProjectID: params.ProjectID,
 			UserID:    str.GenerateRandString(64),
 			MediaCode: params.MediaCode,
 			Type:      params.AccountType,
@@ -203,6 +351,7 @@ func (sm *Manager) GetSession(params *SessionParams) (*types.Session, error) {
 		Num:    1,
 		Type:   "dynamic",
 		Region: params.SessionRegion,
+		JobID:  params.JobID,
 	}
 	if params.AccountType > 1 {
 		proxyReq.Type = "static"
@@ -212,24 +361,24 @@ func (sm *Manager) GetSession(params *SessionParams) (*types.Session, error) {
 	}
 	proxyInfo, err := sm.proxyPool.GetAvailableProxy(proxyReq)
 	if err != nil {
-		return nil, fmt.Errorf("get proxy failed: %v", err)
+		return nil, Lease{}, "", fmt.Errorf("get proxy failed: %v", err)
 	}
 
 	expireTime := time.Now().Add(24 * 7 * time.Hour)
 	if proxyInfo.Useable {
 		expireTime = proxyInfo.GetExpireTime()
 	}
-	session := &types.Session{
+	session, lease, conflict, err := sm.claim(params, account.UserID, &types.Session{
 		Enabled:    true,
-		InUsed:     params.KeepAlive,
 		Account:    account,
 		ProxyInfo:  proxyInfo,
 		ExpireTime: expireTime,
+	}, now)
+	if session == nil || session.ProxyInfo != proxyInfo {
+		return session, lease, conflict, err
 	}
 
-	sessions.Store(account.UserID, session)
-
-	if session.InUsed && account.IsReal > 0 && proxyInfo.Useable {
+	if session.LeaseID != "" && account.IsReal > 0 && proxyInfo.Useable {
 		err := cache.CacheManager.Set(
 			"media:proxy:"+account.UserID,
 			proxyInfo.ProxyKey,
@@ -239,105 +388,228 @@ func (sm *Manager) GetSession(params *SessionParams) (*types.Session, error) {
 			logger.Log.Errorf("set proxy cache failed: %v", err)
 		}
 	}
-	return session, nil
+	return session, lease, "", nil
 }
 
-// ReplaceSession 更换指定会话的代理
-func (sm *Manager) ReplaceSession(mediaCode, userID, region string) (*types.Session, error) {
-	// 加锁确保线程安全
+// claim 获取账号的会话，created 为新建的会话，账号已有可用会话时沿用并归还 created 的代理，
+// 会话已被租用时返回其账号ID
+func (sm *Manager) claim(params *SessionParams, userID string, created *types.Session, now time.Time) (*types.Session, Lease, string, error) {
 	sm.mu.Lock()
-	// 获取对应媒体的会话集合
-	sessions, ok := sm.sessionMap[mediaCode]
+	sessions := sm.mediaSessionsLocked(params.MediaCode)
+	session, ok := sessions[userID]
+	if ok && now.After(session.ExpireTime) && session.LeaseID == "" {
+		delete(sessions, userID)
+		ok = false
+	}
+	var discard *types.ProxyInfo
+	switch {
+	case ok && created != nil:
+		discard = created.ProxyInfo
+	case !ok && created != nil:
+		session = created
+		sessions[userID] = session
+	case !ok:
+		// 选中的空闲会话已被移除
+		sm.mu.Unlock()
+		return nil, Lease{}, userID, nil
+	}
+	if session.LeaseID != "" {
+		sm.mu.Unlock()
+		if discard != nil {
+			sm.proxyPool.ReleaseProxy(discard)
+		}
+		return nil, Lease{}, userID, nil
+	}
+	var lease Lease
+	if params.KeepAlive {
+		lease = sm.newLeaseLocked(session, params, now)
+	}
+	session.JobID = params.JobID
+	sm.recordUsage(session.Account, now)
+	result := snapshot(session)
+	sm.applyJar(result.Account)
 	sm.mu.Unlock()
+
+	if discard != nil {
+		sm.proxyPool.ReleaseProxy(discard)
+	}
+	sm.recordAcquired(result)
+	sm.emit.Emit(types.SessionAcquiredEvent{
+		EventMeta:   types.NewEventMeta(params.JobID).WithProject(result.Account.ProjectID),
+		SessionInfo: sessionInfo(result, params.SessionRegion),
+	})
+	return result, lease, "", nil
+}
+
+// snapshot 复制会话及账号，调用方持有的会话与管理器内部状态互不影响，调用方需持有 sm.mu
+func snapshot(session *types.Session) *types.Session {
+	copied := *session
+	if session.Account != nil {
+		account := *session.Account
+		copied.Account = &account
+	}
+	return &copied
+}
+
+// mediaSessionsLocked 返回平台的会话集合，不存在时创建，调用方需持有 sm.mu
+func (sm *Manager) mediaSessionsLocked(mediaCode string) map[string]*types.Session {
+	sessions, ok := sm.sessionMap[mediaCode]
 	if !ok {
-		return nil, fmt.Errorf("no sessions found for media: %s", mediaCode)
+		sessions = make(map[string]*types.Session)
+		sm.sessionMap[mediaCode] = sessions
 	}
-	// 验证会话是否存在
-	current, ok := sessions.Load(userID)
+	return sessions
+}
+
+// ReplaceSession 更换指定会话的代理
+func (sm *Manager) ReplaceSession(mediaCode, userID, region string) (*types.Session, error) {
+	sm.mu.Lock()
+	current, ok := sm.sessionMap[mediaCode][userID]
 	if !ok {
+		sm.mu.Unlock()
 		return nil, fmt.Errorf("session for user %s in media %s not found or mismatched", userID, mediaCode)
 	}
-	currentSession := current.(*types.Session)
+	oldProxy, jobID := current.ProxyInfo, current.JobID
 	// 判断之前是否使用代理,未使用代理时直接返回保持不变
-	if currentSession.ProxyInfo == nil || !currentSession.ProxyInfo.Useable {
-		return currentSession, nil
+	if oldProxy == nil || !oldProxy.Useable {
+		result := snapshot(current)
+		sm.mu.Unlock()
+		return result, nil
 	}
-	// 移除旧的proxy
-	sm.proxyPool.RemoveProxy(currentSession.ProxyInfo)
-	// 构造代理请求
-	proxyReq := types.ProxyRequest{
+	sm.mu.Unlock()
+
+	// 获取新代理，等待期间不持有锁，旧代理在更换后释放，熔断中的代理由代理池暂存
+	newProxyInfo, err := sm.proxyPool.GetAvailableProxy(types.ProxyRequest{
 		Num:    1,
 		Type:   "dynamic", // 默认动态代理
 		Region: region,
-	}
-	// 获取新代理
-	newProxyInfo, err := sm.proxyPool.GetAvailableProxy(proxyReq)
+		JobID:  jobID,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get new proxy for user %s: %v", userID, err)
 	}
+
+	sm.mu.Lock()
+	current, ok = sm.sessionMap[mediaCode][userID]
+	// 等待期间会话已被移除
+	if !ok {
+		sm.mu.Unlock()
+		sm.proxyPool.ReleaseProxy(newProxyInfo)
+		return nil, fmt.Errorf("session for user %s in media %s not found or mismatched", userID, mediaCode)
+	}
+	// 等待期间已被其他调用方更换代理
+	if current.ProxyInfo != oldProxy {
+		result := snapshot(current)
+		sm.mu.Unlock()
+		sm.proxyPool.ReleaseProxy(newProxyInfo)
+		return result, nil
+	}
 	// 更新会话过期时间
 	if newProxyInfo.Useable {
-		currentSession.ExpireTime = newProxyInfo.GetExpireTime() // 使用代理的过期时间
+		current.ExpireTime = newProxyInfo.GetExpireTime() // 使用代理的过期时间
 	}
-	// 更新会话的代理信息
-	currentSession.ProxyInfo = newProxyInfo
-	sessions.Store(userID, currentSession) // 更新会话
+	current.ProxyInfo = newProxyInfo
+	result := snapshot(current)
+	sm.mu.Unlock()
+	sm.proxyPool.ReleaseProxy(oldProxy)
+	return result, nil
+}
 
-	return currentSession, nil
+// ReportProxyResult 上报会话代理的请求结果，用于代理评分和熔断
+func (sm *Manager) ReportProxyResult(session *types.Session, latency time.Duration, err error) {
+	if session == nil || session.ProxyInfo == nil || !session.ProxyInfo.Useable {
+		return
+	}
+	sm.proxyPool.ReportResult(session.ProxyInfo, latency, err)
 }
 
-// ReleaseSession 释放会话
+// ReleaseSession 释放会话，持有租约时释放租约
 func (sm *Manager) ReleaseSession(session *types.Session) {
-	if session == nil {
+	if session == nil || session.Account == nil {
 		return
 	}
-	if sessions, ok := sm.sessionMap[session.Account.MediaCode]; ok {
-		if s, ok := sessions.Load(session.Account.UserID); ok {
-			s.(*types.Session).InUsed = false
-			logger.Log.Infof("Released session for account %s", session.Account.UserID)
+	if session.LeaseID != "" {
+		if err := sm.Release(session.LeaseID); err != nil {
+			logger.Log.Warnf("Release session for account %s failed: %v", session.Account.UserID, err)
 		}
+		return
+	}
+	sm.mu.Lock()
+	_, ok := sm.sessionMap[session.Account.MediaCode][session.Account.UserID]
+	sm.mu.Unlock()
+	if !ok {
+		return
+	}
+	sm.counter(session.Account.MediaCode, session.Account.UserID).released.Add(1)
+	logger.Log.Infof("Released session for account %s", session.Account.UserID)
+	sm.emit.Emit(types.SessionReleasedEvent{
+		EventMeta:   types.NewEventMeta(session.JobID).WithProject(session.Account.ProjectID),
+		SessionInfo: sessionInfo(session, ""),
+	})
+}
+
+// removeSession 移除账号的会话及其租约，返回被移除的会话
+func (sm *Manager) removeSession(mediaCode, userID string) (*types.Session, bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	session, ok := sm.sessionMap[mediaCode][userID]
+	if !ok {
+		return nil, false
 	}
+	delete(sm.sessionMap[mediaCode], userID)
+	if lease, ok := sm.leases[session.LeaseID]; ok {
+		sm.removeLeaseLocked(lease)
+	}
+	return session, true
 }
 
-// InvalidateSession 标记账号失效
-func (sm *Manager) InvalidateSession(session *types.Session) error {
+// InvalidateSession 标记账号失效，reason 为失效原因
+func (sm *Manager) InvalidateSession(session *types.Session, reason string) error {
 	if session == nil || session.Account == nil {
 		return nil
 	}
 
-	session.Account.Status = 100
-	if _, err := session.Account.UpsertModel(); err != nil {
+	// 只更新状态，会话中的 Cookie 可能已被接口或导入替换
+	if err := session.Account.SetStatus(model.MediaAccountStatusDisabled, model.DisabledReasonBlocked); err != nil {
 		logger.Log.Errorf("Failed to update account status: %v", err)
 		return err
 	}
 
-	if sessions, ok := sm.sessionMap[session.Account.MediaCode]; ok {
-		if s, ok := sessions.Load(session.Account.UserID); ok {
-			sm.proxyPool.RemoveProxy(s.(*types.Session).ProxyInfo)
-			sessions.Delete(session.Account.UserID)
-		}
+	sm.counter(session.Account.MediaCode, session.Account.UserID).invalidated.Add(1)
+	if removed, ok := sm.removeSession(session.Account.MediaCode, session.Account.UserID); ok {
+		sm.proxyPool.RemoveProxy(removed.ProxyInfo, "session invalidated")
 	}
 	logger.Log.Infof("Invalidated account %s for media %s", session.Account.UserID, session.Account.MediaCode)
+	sm.emit.Emit(types.SessionInvalidatedEvent{
+		EventMeta:   types.NewEventMeta(session.JobID).WithProject(session.Account.ProjectID),
+		SessionInfo: sessionInfo(session, ""),
+		Reason:      reason,
+	})
 	return nil
 }
 
-// Status 返回会话管理状态
-func (sm *Manager) Status() *SessionManagerStatus {
+// Status 返回项目的会话状态，代理池为各项目共享的状态
+func (sm *Manager) Status(projectID uint) *SessionManagerStatus {
 	totalSessions := 0
 	activeSessions := 0
 	mediaDetails := make(map[string]MediaStatus)
 
+	sm.mu.Lock()
 	for mediaCode, sessions := range sm.sessionMap {
 		sessionCount := 0
 		activeCount := 0
-		sessions.Range(func(_, value interface{}) bool {
-			session := value.(*types.Session)
+		for _, session := range sessions {
+			if session.Account.ProjectID != projectID {
+				continue
+			}
 			sessionCount++
-			if session.InUsed {
+			if session.LeaseID != "" {
 				activeCount++
 			}
-			return true
-		})
+		}
+		if sessionCount == 0 {
+			continue
+		}
 		totalSessions += sessionCount
 		activeSessions += activeCount
 		mediaDetails[mediaCode] = MediaStatus{
@@ -345,6 +617,13 @@ func (sm *Manager) Status() *SessionManagerStatus {
 			ActiveCount:  activeCount,
 		}
 	}
+	leases := 0
+	for _, lease := range sm.leases {
+		if lease.ProjectID == projectID {
+			leases++
+		}
+	}
+	sm.mu.Unlock()
 
 	userProxyPairs := 0
 	sm.userProxyMap.Range(func(_, _ interface{}) bool {
@@ -354,9 +633,11 @@ func (sm *Manager) Status() *SessionManagerStatus {
 
 	return &SessionManagerStatus{
 		ActiveSessions: activeSessions,
+		Leases:         leases,
 		TotalSessions:  totalSessions,
 		MediaDetails:   mediaDetails,
 		UserProxyPairs: userProxyPairs,
 		ProxyStatus:    sm.proxyPool.Status(),
+		Probe:          sm.ProbeStatus().project(projectID),
 	}
 }
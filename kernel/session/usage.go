@@ -0,0 +1,97 @@
+package session
+
+import (
+	"noctua/types"
+	"sync/atomic"
+	"time"
+)
+
+// AccountUsage 账号在本进程内的使用情况
+type AccountUsage struct {
+	MediaCode      string    `json:"mediaCode"`
+	UserID         string    `json:"userId"`
+	Acquired       int64     `json:"acquired"`    // 获取会话次数
+	Released       int64     `json:"released"`    // 释放会话次数
+	Invalidated    int64     `json:"invalidated"` // 标记失效次数
+	LastAcquiredAt time.Time `json:"lastAcquiredAt,omitempty"`
+	HasSession     bool      `json:"hasSession"` // 当前是否持有会话
+	InUse          bool      `json:"inUse"`      // 是否被租用
+	LeaseID        string    `json:"leaseId,omitempty"`
+	ProxyKey       string    `json:"proxyKey,omitempty"`
+	ExpireTime     time.Time `json:"expireTime,omitempty"`
+	JobID          string    `json:"jobId,omitempty"`
+}
+
+// usageCounter 账号使用计数
+type usageCounter struct {
+	acquired       atomic.Int64
+	released       atomic.Int64
+	invalidated    atomic.Int64
+	lastAcquiredAt atomic.Int64
+}
+
+func usageKey(mediaCode, userID string) string {
+	return mediaCode + ":" + userID
+}
+
+func (sm *Manager) counter(mediaCode, userID string) *usageCounter {
+	value, _ := sm.usage.LoadOrStore(usageKey(mediaCode, userID), &usageCounter{})
+	return value.(*usageCounter)
+}
+
+func (sm *Manager) recordAcquired(session *types.Session) {
+	if session.Account == nil {
+		return
+	}
+	counter := sm.counter(session.Account.MediaCode, session.Account.UserID)
+	counter.acquired.Add(1)
+	counter.lastAcquiredAt.Store(time.Now().UnixNano())
+}
+
+// session 返回账号当前会话的副本，不存在时返回 false
+func (sm *Manager) session(mediaCode, userID string) (*types.Session, bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	session, ok := sm.sessionMap[mediaCode][userID]
+	if !ok {
+		return nil, false
+	}
+	return snapshot(session), true
+}
+
+// Usage 查询账号的使用计数及当前会话状态
+func (sm *Manager) Usage(mediaCode, userID string) AccountUsage {
+	usage := AccountUsage{MediaCode: mediaCode, UserID: userID}
+	if value, ok := sm.usage.Load(usageKey(mediaCode, userID)); ok {
+		counter := value.(*usageCounter)
+		usage.Acquired = counter.acquired.Load()
+		usage.Released = counter.released.Load()
+		usage.Invalidated = counter.invalidated.Load()
+		if nano := counter.lastAcquiredAt.Load(); nano > 0 {
+			usage.LastAcquiredAt = time.Unix(0, nano)
+		}
+	}
+	if session, ok := sm.session(mediaCode, userID); ok {
+		usage.HasSession = true
+		usage.InUse = session.LeaseID != ""
+		usage.LeaseID = session.LeaseID
+		usage.ExpireTime = session.ExpireTime
+		usage.JobID = session.JobID
+		if session.ProxyInfo != nil {
+			usage.ProxyKey = session.ProxyInfo.ProxyKey
+		}
+	}
+	return usage
+}
+
+// EvictAccount 移除账号的会话及租约并归还代理，账号被禁用或删除后调用，返回是否存在会话
+func (sm *Manager) EvictAccount(mediaCode, userID string) bool {
+	session, ok := sm.removeSession(mediaCode, userID)
+	if !ok {
+		return false
+	}
+	if session.ProxyInfo != nil {
+		sm.proxyPool.ReleaseProxy(session.ProxyInfo)
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package session
+
+import (
+	"context"
+	"noctua/internal/model"
+	"noctua/internal/proxy"
+	"noctua/pkg/utils/str"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageAndEvictAccount(t *testing.T) {
+	pool := proxy.NewProxyPool(context.Background(), proxy.ProxyPoolConfig{})
+	defer pool.Stop()
+	sm := NewManager(pool)
+
+	mediaCode := "usage" + str.GenerateRandString(8)
+	account := &model.MediaAccount{MediaCode: mediaCode, UserID: "u1", UID: "u1", Nickname: "u1", Type: 1}
+	require.NoError(t, account.Create())
+	session, _, err := sm.Acquire(&SessionParams{MediaCode: mediaCode, AccountType: 1, JobID: "job-1"})
+	require.NoError(t, err)
+	usage := sm.Usage(mediaCode, "u1")
+	assert.True(t, usage.InUse)
+	assert.Equal(t, session.LeaseID, usage.LeaseID)
+	sm.ReleaseSession(session)
+
+	usage = sm.Usage(mediaCode, "u1")
+	assert.Equal(t, int64(1), usage.Acquired)
+	assert.Equal(t, int64(1), usage.Released)
+	assert.True(t, usage.HasSession)
+	assert.False(t, usage.InUse)
+	assert.Equal(t, "job-1", usage.JobID)
+	assert.False(t, usage.LastAcquiredAt.IsZero())
+
+	assert.True(t, sm.EvictAccount(mediaCode, "u1"))
+	assert.False(t, sm.EvictAccount(mediaCode, "u1"))
+	assert.False(t, sm.EvictAccount("xhs", "u1"))
+	usage = sm.Usage(mediaCode, "u1")
+	assert.False(t, usage.HasSession)
+	assert.Equal(t, int64(1), usage.Acquired)
+
+	assert.Equal(t, AccountUsage{MediaCode: "xhs", UserID: "none"}, sm.Usage("xhs", "none"))
+}
@@ -0,0 +1,158 @@
+package sink
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CSVSink 每种数据类型单独写入一组 CSV 文件
+type CSVSink struct {
+	name     string
+	dir      string
+	maxBytes int64
+	interval time.Duration
+	mu       sync.Mutex
+	files    map[Kind]*rotateFile
+}
+
+func NewCSVSink(name, dir string, maxBytes int64, interval time.Duration) (*CSVSink, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("sink path can not be empty")
+	}
+	return &CSVSink{
+		name:     name,
+		dir:      dir,
+		maxBytes: maxBytes,
+		interval: interval,
+		files:    make(map[Kind]*rotateFile),
+	}, nil
+}
+
+func (s *CSVSink) Name() string {
+	return s.name
+}
+
+func (s *CSVSink) Write(_ context.Context, records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, record := range records {
+		header, row := csvRow(record.Data)
+		out, err := s.file(record.Kind, header)
+		if err != nil {
+			return err
+		}
+		var line strings.Builder
+		writer := csv.NewWriter(&line)
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+		writer.Flush()
+		if _, err := out.Write([]byte(line.String())); err != nil {
+			return err
+		}
+	}
+	for _, out := range s.files {
+		if err := out.Sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// file 获取数据类型对应的文件，新文件写入表头
+func (s *CSVSink) file(kind Kind, header []string) (*rotateFile, error) {
+	if out, ok := s.files[kind]; ok {
+		return out, nil
+	}
+	out, err := newRotateFile(s.dir, s.name+"-"+kind.String(), ".csv", s.maxBytes, s.interval)
+	if err != nil {
+		return nil, err
+	}
+	out.onOpen = func(f *os.File) error {
+		writer := csv.NewWriter(f)
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+	s.files[kind] = out
+	return out, nil
+}
+
+func (s *CSVSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var lastErr error
+	for _, out := range s.files {
+		if err := out.Close(); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// csvRow 通过 json tag 展开结构体字段
+func csvRow(data interface{}) ([]string, []string) {
+	v := reflect.ValueOf(data)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return []string{}, []string{}
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		raw, _ := json.Marshal(data)
+		return []string{"data"}, []string{string(raw)}
+	}
+	t := v.Type()
+	header := make([]string, 0, t.NumField())
+	row := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		header = append(header, name)
+		row = append(row, csvValue(v.Field(i).Interface()))
+	}
+	return header, row
+}
+
+func csvValue(value interface{}) string {
+	switch val := value.(type) {
+	case string:
+		return val
+	case time.Time:
+		if val.IsZero() {
+			return ""
+		}
+		return val.Format(time.RFC3339)
+	case fmt.Stringer:
+		return val.String()
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Ptr:
+		raw, _ := json.Marshal(value)
+		if string(raw) == "null" {
+			return ""
+		}
+		return string(raw)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,44 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"noctua/internal/model"
+)
+
+// DBSink 写入数据库采集表
+type DBSink struct {
+	name string
+}
+
+func NewDBSink(name string) *DBSink {
+	return &DBSink{name: name}
+}
+
+func (s *DBSink) Name() string {
+	return s.name
+}
+
+func (s *DBSink) Write(_ context.Context, records []Record) error {
+	for _, record := range records {
+		var err error
+		switch data := record.Data.(type) {
+		case *model.CrawlMedia:
+			err = data.UpsertModel()
+		case *model.CrawlComment:
+			err = data.UpsertModel()
+		case *model.CrawlUser:
+			err = data.UpsertModel()
+		default:
+			err = fmt.Errorf("db sink unsupported data type: %T", record.Data)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *DBSink) Close() error {
+	return nil
+}
@@ -0,0 +1,62 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"time"
+)
+
+const DefaultHTTPTimeout = 10 * time.Second
+
+// HTTPSink 以 JSON 批量推送到远端接口
+type HTTPSink struct {
+	name   string
+	url    string
+	client *resty.Client
+}
+
+// HTTPPayload 推送的请求体
+type HTTPPayload struct {
+	Sink    string   `json:"sink"`
+	Count   int      `json:"count"`
+	Records []Record `json:"records"`
+}
+
+func NewHTTPSink(name, url string, headers map[string]string, timeout time.Duration) (*HTTPSink, error) {
+	if url == "" {
+		return nil, fmt.Errorf("sink url can not be empty")
+	}
+	if timeout <= 0 {
+		timeout = DefaultHTTPTimeout
+	}
+	client := resty.New().
+		SetTimeout(timeout).
+		SetHeader("Content-Type", "application/json")
+	if len(headers) > 0 {
+		client.SetHeaders(headers)
+	}
+	return &HTTPSink{name: name, url: url, client: client}, nil
+}
+
+func (s *HTTPSink) Name() string {
+	return s.name
+}
+
+func (s *HTTPSink) Write(ctx context.Context, records []Record) error {
+	resp, err := s.client.R().
+		SetContext(ctx).
+		SetBody(HTTPPayload{Sink: s.name, Count: len(records), Records: records}).
+		Post(s.url)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() < 200 || resp.StatusCode() >= 300 {
+		return fmt.Errorf("http sink %s responded %d: %s", s.name, resp.StatusCode(), resp.String())
+	}
+	return nil
+}
+
+func (s *HTTPSink) Close() error {
+	return nil
+}
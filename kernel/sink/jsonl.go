@@ -0,0 +1,44 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// JSONLSink 按 JSON Lines 格式写入可切割文件
+type JSONLSink struct {
+	name string
+	out  *rotateFile
+}
+
+func NewJSONLSink(name, dir string, maxBytes int64, interval time.Duration) (*JSONLSink, error) {
+	out, err := newRotateFile(dir, name, ".jsonl", maxBytes, interval)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLSink{name: name, out: out}, nil
+}
+
+func (s *JSONLSink) Name() string {
+	return s.name
+}
+
+func (s *JSONLSink) Write(_ context.Context, records []Record) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	if _, err := s.out.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return s.out.Sync()
+}
+
+func (s *JSONLSink) Close() error {
+	return s.out.Close()
+}
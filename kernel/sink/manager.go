@@ -0,0 +1,340 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"noctua/pkg/logger"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultBatchSize     = 50
+	DefaultFlushInterval = 2 * time.Second
+	DefaultMaxRetries    = 3
+	DefaultRetryDelay    = time.Second
+	DefaultQueueSize     = 1000
+)
+
+// Config sink 管理配置
+type Config struct {
+	Default []string     `mapstructure:"default"` // 未指定路由时使用的 sink
+	Sinks   []SinkConfig `mapstructure:"sinks"`
+}
+
+// Validate 校验配置，sink 名称不能重复，类型需已注册，默认路由只能使用启用的 sink
+func (c Config) Validate() error {
+	var errs []error
+	enabled := make(map[string]bool)
+	for i, sinkCfg := range c.Sinks {
+		if sinkCfg.Name == "" {
+			errs = append(errs, fmt.Errorf("sink.sinks[%d]: name can not be empty", i))
+		} else if _, exists := enabled[sinkCfg.Name]; exists {
+			errs = append(errs, fmt.Errorf("sink.sinks[%d]: duplicate name %s", i, sinkCfg.Name))
+		}
+		factories.RLock()
+		_, registered := factories.items[sinkCfg.Type]
+		factories.RUnlock()
+		if !registered {
+			errs = append(errs, fmt.Errorf("sink.sinks[%d]: unsupported type %q", i, sinkCfg.Type))
+		}
+		enabled[sinkCfg.Name] = sinkCfg.Enabled
+	}
+	for _, name := range c.Default {
+		if on, exists := enabled[name]; !exists {
+			errs = append(errs, fmt.Errorf("sink.default: sink %s is not configured", name))
+		} else if !on {
+			errs = append(errs, fmt.Errorf("sink.default: sink %s is not enabled", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SinkConfig 单个 sink 的配置
+type SinkConfig struct {
+	Name           string            `mapstructure:"name"`
+	Type           string            `mapstructure:"type"` // db | jsonl | csv | http | stdout
+	Enabled        bool              `mapstructure:"enabled"`
+	Kinds          []string          `mapstructure:"kinds"` // 仅接收的数据类型，空为全部
+	BatchSize      int               `mapstructure:"batch_size"`
+	FlushInterval  time.Duration     `mapstructure:"flush_interval"`
+	MaxRetries     int               `mapstructure:"max_retries"`
+	RetryDelay     time.Duration     `mapstructure:"retry_delay"`
+	QueueSize      int               `mapstructure:"queue_size"`
+	Path           string            `mapstructure:"path"`            // jsonl / csv 输出目录
+	MaxBytes       int64             `mapstructure:"max_bytes"`       // jsonl / csv 单文件最大字节数
+	RotateInterval time.Duration     `mapstructure:"rotate_interval"` // jsonl / csv 文件切割间隔
+	URL            string            `mapstructure:"url"`             // http 推送地址
+	Headers        map[string]string `mapstructure:"headers"`         // http 请求头
+	Timeout        time.Duration     `mapstructure:"timeout"`         // http 超时时间
+}
+
+// WorkerStatus 单个 sink 的运行状态
+type WorkerStatus struct {
+	Type     string    `json:"type"`
+	Queued   int       `json:"queued"`
+	Capacity int       `json:"capacity"`
+	Written  int64     `json:"written"`
+	Failed   int64     `json:"failed"`
+	Retried  int64     `json:"retried"`
+	LastErr  string    `json:"lastErr"`
+	LastSent time.Time `json:"lastSent"`
+}
+
+// worker 每个 sink 独立的批量写入协程
+type worker struct {
+	sink    Sink
+	cfg     SinkConfig
+	kinds   map[Kind]struct{}
+	queue   chan Record
+	written atomic.Int64
+	failed  atomic.Int64
+	retried atomic.Int64
+	mu      sync.Mutex
+	lastErr string
+	last    time.Time
+}
+
+func (w *worker) accept(kind Kind) bool {
+	if len(w.kinds) == 0 {
+		return true
+	}
+	_, ok := w.kinds[kind]
+	return ok
+}
+
+// Manager 负责将数据并行分发到多个 sink
+type Manager struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	writers  sync.WaitGroup // 正在投递的 Write 调用，关闭队列前等待其返回
+	mu       sync.RWMutex
+	closed   bool
+	defaults []string
+	workers  map[string]*worker
+}
+
+// NewManager 根据配置创建 sink 管理器
+func NewManager(parentCtx context.Context, cfg Config) *Manager {
+	ctx, cancel := context.WithCancel(parentCtx)
+	m := &Manager{
+		ctx:      ctx,
+		cancel:   cancel,
+		defaults: cfg.Default,
+		workers:  make(map[string]*worker),
+	}
+	for _, sinkCfg := range cfg.Sinks {
+		if !sinkCfg.Enabled {
+			continue
+		}
+		s, err := Build(sinkCfg)
+		if err != nil {
+			logger.Log.Errorf("Build sink %s failed: %v", sinkCfg.Name, err)
+			continue
+		}
+		if err := m.Register(s, sinkCfg); err != nil {
+			logger.Log.Errorf("Register sink %s failed: %v", sinkCfg.Name, err)
+		}
+	}
+	return m
+}
+
+// Register 注册 sink 并启动写入协程
+func (m *Manager) Register(s Sink, cfg SinkConfig) error {
+	if cfg.Name == "" {
+		cfg.Name = s.Name()
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = DefaultRetryDelay
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	w := &worker{
+		sink:  s,
+		cfg:   cfg,
+		kinds: make(map[Kind]struct{}),
+		queue: make(chan Record, cfg.QueueSize),
+	}
+	for _, kind := range cfg.Kinds {
+		w.kinds[Kind(kind)] = struct{}{}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return fmt.Errorf("sink manager closed")
+	}
+	if _, exists := m.workers[cfg.Name]; exists {
+		return fmt.Errorf("sink %s already registered", cfg.Name)
+	}
+	m.workers[cfg.Name] = w
+	m.wg.Add(1)
+	go m.run(w)
+	logger.Log.Infof("Sink %s(%s) registered", cfg.Name, cfg.Type)
+	return nil
+}
+
+// Write 按路由投递数据，route 为空时使用默认 sink，路由包含未注册的 sink 时不投递并返回错误
+func (m *Manager) Write(route []string, records ...Record) error {
+	m.mu.RLock()
+	if m.closed {
+		m.mu.RUnlock()
+		return fmt.Errorf("sink manager closed")
+	}
+	if len(route) == 0 {
+		route = m.defaults
+	}
+	targets := make([]*worker, 0, len(route))
+	for _, name := range route {
+		w, ok := m.workers[name]
+		if !ok {
+			m.mu.RUnlock()
+			return fmt.Errorf("sink %s not registered", name)
+		}
+		targets = append(targets, w)
+	}
+	// 队列已满时会阻塞，投递期间不持有锁，Close 取消上下文后返回
+	m.writers.Add(1)
+	m.mu.RUnlock()
+	defer m.writers.Done()
+
+	for _, w := range targets {
+		for _, record := range records {
+			if !w.accept(record.Kind) {
+				continue
+			}
+			if record.CreatedAt.IsZero() {
+				record.CreatedAt = time.Now()
+			}
+			select {
+			case w.queue <- record:
+			case <-m.ctx.Done():
+				return m.ctx.Err()
+			}
+		}
+	}
+	return nil
+}
+
+// run 批量收集并写入
+func (m *Manager) run(w *worker) {
+	defer m.wg.Done()
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Record, 0, w.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		m.flush(w, batch)
+		batch = make([]Record, 0, w.cfg.BatchSize)
+	}
+	for {
+		select {
+		case record, ok := <-w.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, record)
+			if len(batch) >= w.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// flush 写入一个批次，失败时指数退避重试
+func (m *Manager) flush(w *worker, batch []Record) {
+	var err error
+	for attempt := 0; attempt <= w.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			w.retried.Add(1)
+			delay := w.cfg.RetryDelay * time.Duration(1<<(attempt-1))
+			select {
+			case <-time.After(delay):
+			case <-m.ctx.Done():
+				// 关闭期间不再等待，直接做最后一次尝试
+			}
+		}
+		// 关闭时 m.ctx 已取消，写入使用独立上下文保证剩余数据落地
+		err = w.sink.Write(context.Background(), batch)
+		if err == nil {
+			w.written.Add(int64(len(batch)))
+			w.mu.Lock()
+			w.last = time.Now()
+			w.mu.Unlock()
+			return
+		}
+		logger.Log.Warnf("Sink %s write %d records failed (attempt %d): %v", w.cfg.Name, len(batch), attempt+1, err)
+	}
+	w.failed.Add(int64(len(batch)))
+	w.mu.Lock()
+	w.lastErr = err.Error()
+	w.mu.Unlock()
+	logger.Log.Errorf("Sink %s dropped %d records after %d retries: %v", w.cfg.Name, len(batch), w.cfg.MaxRetries, err)
+}
+
+// Status 返回各 sink 状态
+func (m *Manager) Status() map[string]*WorkerStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	status := make(map[string]*WorkerStatus, len(m.workers))
+	for name, w := range m.workers {
+		w.mu.Lock()
+		status[name] = &WorkerStatus{
+			Type:     w.cfg.Type,
+			Queued:   len(w.queue),
+			Capacity: cap(w.queue),
+			Written:  w.written.Load(),
+			Failed:   w.failed.Load(),
+			Retried:  w.retried.Load(),
+			LastErr:  w.lastErr,
+			LastSent: w.last,
+		}
+		w.mu.Unlock()
+	}
+	return status
+}
+
+// Close 刷新剩余数据并关闭所有 sink
+func (m *Manager) Close() {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return
+	}
+	m.closed = true
+	m.mu.Unlock()
+
+	// 取消上下文使阻塞的 Write 返回，全部返回后才能关闭队列
+	m.cancel()
+	m.writers.Wait()
+	for _, w := range m.workers {
+		close(w.queue)
+	}
+	m.wg.Wait()
+	for name, w := range m.workers {
+		if err := w.sink.Close(); err != nil {
+			logger.Log.Errorf("Close sink %s failed: %v", name, err)
+		}
+	}
+}
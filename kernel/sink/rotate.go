@@ -0,0 +1,118 @@
+package sink
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const DefaultMaxBytes = 64 << 20 // 64MB
+
+// rotateFile 按大小和时间切割的文件
+type rotateFile struct {
+	mu       sync.Mutex
+	dir      string
+	prefix   string
+	ext      string
+	maxBytes int64
+	interval time.Duration
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	seq      int
+	onOpen   func(f *os.File) error // 新文件创建后的回调，用于写入表头
+}
+
+func newRotateFile(dir, prefix, ext string, maxBytes int64, interval time.Duration) (*rotateFile, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("sink path can not be empty")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create sink directory %s failed: %v", dir, err)
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	return &rotateFile{
+		dir:      dir,
+		prefix:   prefix,
+		ext:      ext,
+		maxBytes: maxBytes,
+		interval: interval,
+	}, nil
+}
+
+// Write 写入数据，必要时切割文件
+func (r *rotateFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.needRotate(int64(len(p))) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotateFile) needRotate(incoming int64) bool {
+	if r.file == nil {
+		return true
+	}
+	if r.size > 0 && r.size+incoming > r.maxBytes {
+		return true
+	}
+	return r.interval > 0 && time.Since(r.openedAt) >= r.interval
+}
+
+func (r *rotateFile) rotate() error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			return err
+		}
+		r.file = nil
+	}
+	r.seq++
+	name := fmt.Sprintf("%s-%s-%d%s", r.prefix, time.Now().Format("20060102-150405"), r.seq, r.ext)
+	f, err := os.OpenFile(filepath.Join(r.dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("open sink file %s failed: %v", name, err)
+	}
+	r.file = f
+	r.size = 0
+	r.openedAt = time.Now()
+	if r.onOpen != nil {
+		if err := r.onOpen(f); err != nil {
+			return err
+		}
+		if info, err := f.Stat(); err == nil {
+			r.size = info.Size()
+		}
+	}
+	return nil
+}
+
+// Sync 刷新到磁盘
+func (r *rotateFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Sync()
+}
+
+// Close 关闭当前文件
+func (r *rotateFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
@@ -0,0 +1,83 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Kind 数据类型
+type Kind string
+
+const (
+	KindMedia   Kind = "media"
+	KindComment Kind = "comment"
+	KindUser    Kind = "user"
+)
+
+func (k Kind) String() string {
+	return string(k)
+}
+
+// Record 投递到 sink 的单条数据
+type Record struct {
+	Kind         Kind        `json:"kind"`
+	ProjectID    uint        `json:"projectId"` // 所属项目
+	MediaCode    string      `json:"mediaCode"`
+	TaskId       string      `json:"taskId"`
+	SourceTaskId string      `json:"sourceTaskId"`
+	Data         interface{} `json:"data"` // *model.CrawlMedia / *model.CrawlComment / *model.CrawlUser
+	CreatedAt    time.Time   `json:"createdAt"`
+}
+
+// Sink 数据输出接口，所有输出端都必须实现
+type Sink interface {
+	Name() string
+	Write(ctx context.Context, records []Record) error
+	Close() error
+}
+
+// Factory 根据配置创建 sink
+type Factory func(cfg SinkConfig) (Sink, error)
+
+var factories = struct {
+	sync.RWMutex
+	items map[string]Factory
+}{items: make(map[string]Factory)}
+
+// RegisterFactory 注册 sink 类型
+func RegisterFactory(typ string, factory Factory) {
+	factories.Lock()
+	defer factories.Unlock()
+	factories.items[typ] = factory
+}
+
+// Build 通过类型创建 sink 实例
+func Build(cfg SinkConfig) (Sink, error) {
+	factories.RLock()
+	factory, ok := factories.items[cfg.Type]
+	factories.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported sink type: %s", cfg.Type)
+	}
+	return factory(cfg)
+}
+
+func init() {
+	RegisterFactory("db", func(cfg SinkConfig) (Sink, error) {
+		return NewDBSink(cfg.Name), nil
+	})
+	RegisterFactory("jsonl", func(cfg SinkConfig) (Sink, error) {
+		return NewJSONLSink(cfg.Name, cfg.Path, cfg.MaxBytes, cfg.RotateInterval)
+	})
+	RegisterFactory("csv", func(cfg SinkConfig) (Sink, error) {
+		return NewCSVSink(cfg.Name, cfg.Path, cfg.MaxBytes, cfg.RotateInterval)
+	})
+	RegisterFactory("http", func(cfg SinkConfig) (Sink, error) {
+		return NewHTTPSink(cfg.Name, cfg.URL, cfg.Headers, cfg.Timeout)
+	})
+	RegisterFactory("stdout", func(cfg SinkConfig) (Sink, error) {
+		return NewStdoutSink(cfg.Name), nil
+	})
+}
@@ -0,0 +1,197 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"noctua/internal/model"
+	"noctua/pkg/logger"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	logger.Init(&logger.LoggerConfig{Level: "error"})
+}
+
+// memorySink 记录写入批次，可模拟失败
+type memorySink struct {
+	mu      sync.Mutex
+	name    string
+	batches [][]Record
+	fails   int
+	block   chan struct{} // 不为空时写入阻塞至关闭
+}
+
+func (m *memorySink) Name() string { return m.name }
+
+func (m *memorySink) Write(_ context.Context, records []Record) error {
+	if m.block != nil {
+		<-m.block
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fails > 0 {
+		m.fails--
+		return errors.New("mock failure")
+	}
+	m.batches = append(m.batches, records)
+	return nil
+}
+
+func (m *memorySink) Close() error { return nil }
+
+func (m *memorySink) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	total := 0
+	for _, batch := range m.batches {
+		total += len(batch)
+	}
+	return total
+}
+
+func TestManagerRoutingAndBatching(t *testing.T) {
+	m := NewManager(context.Background(), Config{Default: []string{"a"}})
+	a := &memorySink{name: "a"}
+	b := &memorySink{name: "b", fails: 1}
+	assert.NoError(t, m.Register(a, SinkConfig{Type: "memory", BatchSize: 2, FlushInterval: time.Hour}))
+	assert.NoError(t, m.Register(b, SinkConfig{Type: "memory", BatchSize: 10, FlushInterval: 20 * time.Millisecond, RetryDelay: time.Millisecond, Kinds: []string{"comment"}}))
+
+	// 默认路由只写入 a
+	assert.NoError(t, m.Write(nil, Record{Kind: KindMedia}, Record{Kind: KindMedia}))
+	// 指定路由并行写入 a、b，b 只接收评论
+	assert.NoError(t, m.Write([]string{"a", "b"}, Record{Kind: KindComment}, Record{Kind: KindMedia}))
+
+	assert.Eventually(t, func() bool { return a.count() == 4 && b.count() == 1 }, time.Second, 10*time.Millisecond)
+	assert.Len(t, a.batches, 2, "batch size 2 should flush twice")
+
+	status := m.Status()
+	assert.Equal(t, int64(1), status["b"].Retried)
+	assert.Equal(t, int64(0), status["b"].Failed)
+	m.Close()
+	assert.Error(t, m.Write(nil, Record{Kind: KindMedia}))
+}
+
+func TestManagerFlushOnClose(t *testing.T) {
+	m := NewManager(context.Background(), Config{})
+	a := &memorySink{name: "a"}
+	assert.NoError(t, m.Register(a, SinkConfig{BatchSize: 100, FlushInterval: time.Hour}))
+	assert.NoError(t, m.Write([]string{"a"}, Record{Kind: KindUser}))
+	m.Close()
+	assert.Equal(t, 1, a.count())
+}
+
+func TestManagerUnknownRoute(t *testing.T) {
+	m := NewManager(context.Background(), Config{})
+	defer m.Close()
+	a := &memorySink{name: "a"}
+	assert.NoError(t, m.Register(a, SinkConfig{BatchSize: 1, FlushInterval: time.Hour}))
+	assert.EqualError(t, m.Write([]string{"a", "missing"}, Record{Kind: KindUser}), "sink missing not registered")
+	// 路由包含未注册的 sink 时不投递
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 0, a.count())
+}
+
+func TestManagerCloseWhileWriteBlocked(t *testing.T) {
+	m := NewManager(context.Background(), Config{})
+	a := &memorySink{name: "a", block: make(chan struct{})}
+	assert.NoError(t, m.Register(a, SinkConfig{BatchSize: 1, QueueSize: 1, FlushInterval: time.Hour}))
+	// 第一条由写入协程取出后阻塞在 sink，第二条占满队列
+	assert.NoError(t, m.Write([]string{"a"}, Record{Kind: KindUser}))
+	assert.Eventually(t, func() bool { return m.Status()["a"].Queued == 0 }, time.Second, 5*time.Millisecond)
+	assert.NoError(t, m.Write([]string{"a"}, Record{Kind: KindUser}))
+
+	blocked := make(chan error, 1)
+	go func() { blocked <- m.Write([]string{"a"}, Record{Kind: KindUser}) }()
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, blocked)
+	closed := make(chan struct{})
+	go func() {
+		m.Close()
+		close(closed)
+	}()
+	// 阻塞的投递不持有锁，Close 取消后返回
+	select {
+	case err := <-blocked:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("blocked Write did not return after Close")
+	}
+	close(a.block)
+	<-closed
+	assert.Equal(t, 2, a.count())
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, Config{
+		Default: []string{"db"},
+		Sinks:   []SinkConfig{{Name: "db", Type: "db", Enabled: true}, {Name: "jsonl", Type: "jsonl"}},
+	}.Validate())
+
+	err := Config{
+		Default: []string{"jsonl", "missing"},
+		Sinks:   []SinkConfig{{Name: "jsonl", Type: "jsonl"}, {Name: "jsonl", Type: "kafka"}, {Type: "db"}},
+	}.Validate()
+	for _, message := range []string{
+		"sink.sinks[1]: duplicate name jsonl",
+		`sink.sinks[1]: unsupported type "kafka"`,
+		"sink.sinks[2]: name can not be empty",
+		"sink.default: sink jsonl is not enabled",
+		"sink.default: sink missing is not configured",
+	} {
+		assert.ErrorContains(t, err, message)
+	}
+}
+
+func TestJSONLSinkRotate(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewJSONLSink("export", dir, 200, 0)
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		err = s.Write(context.Background(), []Record{{Kind: KindMedia, Data: &model.CrawlMedia{MediaID: "m1", Title: "hello"}}})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, s.Close())
+
+	files, _ := filepath.Glob(filepath.Join(dir, "export-*.jsonl"))
+	assert.Greater(t, len(files), 1, "should rotate by size")
+	lines := 0
+	for _, name := range files {
+		f, _ := os.Open(name)
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			lines++
+		}
+		_ = f.Close()
+	}
+	assert.Equal(t, 5, lines)
+}
+
+func TestCSVSinkHeader(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewCSVSink("export", dir, 0, 0)
+	assert.NoError(t, err)
+	err = s.Write(context.Background(), []Record{
+		{Kind: KindComment, Data: &model.CrawlComment{CommentID: "c1", Content: "a,b"}},
+		{Kind: KindComment, Data: &model.CrawlComment{CommentID: "c2", Content: "quote\""}},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, s.Close())
+
+	files, _ := filepath.Glob(filepath.Join(dir, "export-comment-*.csv"))
+	assert.Len(t, files, 1)
+	f, _ := os.Open(files[0])
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Contains(t, rows[0], "comment_id")
+	assert.Contains(t, rows[1], "a,b")
+}
@@ -0,0 +1,40 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// StdoutSink 输出到标准输出，便于调试和管道处理
+type StdoutSink struct {
+	name string
+	mu   sync.Mutex
+	out  io.Writer
+}
+
+func NewStdoutSink(name string) *StdoutSink {
+	return &StdoutSink{name: name, out: os.Stdout}
+}
+
+func (s *StdoutSink) Name() string {
+	return s.name
+}
+
+func (s *StdoutSink) Write(_ context.Context, records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	encoder := json.NewEncoder(s.out)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *StdoutSink) Close() error {
+	return nil
+}
@@ -0,0 +1,281 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"noctua/kernel/bus"
+	"noctua/types"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultClientBuffer      = 256
+	DefaultHeartbeatInterval = 15 * time.Second
+	DefaultWriteTimeout      = 10 * time.Second
+	DefaultMaxClients        = 100
+
+	ReasonSlowConsumer = "slow consumer"
+	ReasonClosed       = "stream closed"
+)
+
+var (
+	ErrClosed         = errors.New("stream hub closed")
+	ErrTooManyClients = errors.New("too many stream clients")
+)
+
+// Config 推送配置
+type Config struct {
+	ClientBuffer      int           `mapstructure:"client_buffer"`      // 每个客户端的待发送队列长度，写满即断开
+	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"` // 心跳间隔
+	WriteTimeout      time.Duration `mapstructure:"write_timeout"`      // 单条消息写超时，超时即断开
+	MaxClients        int           `mapstructure:"max_clients"`        // 最大连接数
+	AllowedOrigins    []string      `mapstructure:"allowed_origins"`    // 允许建立 WebSocket 连接的来源，* 表示不限制
+}
+
+// AllowOrigin 判断 WebSocket 握手的来源是否允许，未携带 Origin 的非浏览器客户端和同源请求始终允许
+func (c Config) AllowOrigin(origin *url.URL, host string) bool {
+	if origin == nil || strings.EqualFold(origin.Host, host) {
+		return true
+	}
+	value := origin.Scheme + "://" + origin.Host
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Status 推送状态
+type Status struct {
+	Clients   []ClientStatus `json:"clients"`
+	Broadcast uint64         `json:"broadcast"` // 已广播的消息数
+	Evicted   uint64         `json:"evicted"`   // 因消费过慢被断开的客户端数
+	Dropped   int64          `json:"dropped"`   // 总线订阅丢弃的事件数
+}
+
+// ClientStatus 客户端状态
+type ClientStatus struct {
+	ID          uint64    `json:"id"`
+	Remote      string    `json:"remote"`
+	Filter      Filter    `json:"filter"`
+	Pending     int       `json:"pending"`
+	Delivered   uint64    `json:"delivered"`
+	ConnectedAt time.Time `json:"connectedAt"`
+}
+
+// Client 已订阅的客户端
+type Client struct {
+	ID          uint64
+	Remote      string
+	filter      Filter
+	send        chan *Message
+	done        chan struct{}
+	once        sync.Once
+	reason      string
+	delivered   atomic.Uint64
+	connectedAt time.Time
+}
+
+// C 待发送的消息
+func (c *Client) C() <-chan *Message {
+	return c.send
+}
+
+// Done 客户端被断开时关闭
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Reason 断开原因，Done 关闭后有效
+func (c *Client) Reason() string {
+	<-c.done
+	return c.reason
+}
+
+func (c *Client) close(reason string) {
+	c.once.Do(func() {
+		c.reason = reason
+		close(c.done)
+	})
+}
+
+// Hub 向客户端广播总线事件和运行时数据
+type Hub struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	config    Config
+	sub       *bus.Subscription[any]
+	mu        sync.RWMutex
+	clients   map[uint64]*Client
+	nextID    uint64
+	closed    bool
+	broadcast atomic.Uint64
+	evicted   atomic.Uint64
+	wg        sync.WaitGroup
+	projectOf types.ProjectResolver // 确定消息所属项目
+}
+
+// NewHub 创建推送中心并订阅总线全部事件，projectOf 用于确定消息所属项目
+func NewHub(ctx context.Context, eventBus *bus.EventBus, config Config, projectOf types.ProjectResolver) *Hub {
+	if config.ClientBuffer <= 0 {
+		config.ClientBuffer = DefaultClientBuffer
+	}
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = DefaultHeartbeatInterval
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = DefaultWriteTimeout
+	}
+	if config.MaxClients <= 0 {
+		config.MaxClients = DefaultMaxClients
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	h := &Hub{
+		ctx:       ctx,
+		cancel:    cancel,
+		config:    config,
+		clients:   make(map[uint64]*Client),
+		projectOf: projectOf,
+		// 推送是尽力而为的，总线积压时丢弃最旧的事件，不阻塞发布方
+		sub: bus.Subscribe[any](eventBus, bus.Options{Topic: bus.WildcardAll, Mode: bus.ModeDropOldest, Buffer: config.ClientBuffer * 4}),
+	}
+	h.wg.Add(1)
+	go h.loop()
+	return h
+}
+
+// Config 生效的配置
+func (h *Hub) Config() Config {
+	return h.config
+}
+
+// PublishRuntime 广播运行时数据，可注册为 Kernel 的 RuntimeHandler
+func (h *Hub) PublishRuntime(data types.RuntimeData) {
+	h.Broadcast(runtimeMessage(data, h.projectOf.Runtime(data)))
+}
+
+// Broadcast 将消息分发给匹配的客户端，客户端队列已满时将其断开
+func (h *Hub) Broadcast(msg *Message) {
+	if msg.encoded == nil {
+		msg.encode()
+	}
+	var slow []*Client
+	h.mu.RLock()
+	for _, client := range h.clients {
+		if !client.filter.Match(msg) {
+			continue
+		}
+		select {
+		case client.send <- msg:
+			client.delivered.Add(1)
+		default:
+			slow = append(slow, client)
+		}
+	}
+	h.mu.RUnlock()
+	h.broadcast.Add(1)
+	for _, client := range slow {
+		if h.remove(client, ReasonSlowConsumer) {
+			h.evicted.Add(1)
+		}
+	}
+}
+
+// Register 注册客户端
+func (h *Hub) Register(remote string, filter Filter) (*Client, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, ErrClosed
+	}
+	if len(h.clients) >= h.config.MaxClients {
+		return nil, ErrTooManyClients
+	}
+	h.nextID++
+	client := &Client{
+		ID:          h.nextID,
+		Remote:      remote,
+		filter:      filter,
+		send:        make(chan *Message, h.config.ClientBuffer),
+		done:        make(chan struct{}),
+		connectedAt: time.Now(),
+	}
+	h.clients[client.ID] = client
+	return client, nil
+}
+
+// Unregister 注销客户端
+func (h *Hub) Unregister(client *Client) {
+	h.remove(client, ReasonClosed)
+}
+
+// remove 移除并断开客户端，返回客户端是否仍在注册中
+func (h *Hub) remove(client *Client, reason string) bool {
+	h.mu.Lock()
+	_, ok := h.clients[client.ID]
+	delete(h.clients, client.ID)
+	h.mu.Unlock()
+	client.close(reason)
+	return ok
+}
+
+// Status 返回推送状态，只列出所属项目的客户端
+func (h *Hub) Status(projectID uint) Status {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	status := Status{
+		Clients:   make([]ClientStatus, 0, len(h.clients)),
+		Broadcast: h.broadcast.Load(),
+		Evicted:   h.evicted.Load(),
+		Dropped:   h.sub.Dropped(),
+	}
+	for _, client := range h.clients {
+		if client.filter.ProjectID != projectID {
+			continue
+		}
+		status.Clients = append(status.Clients, ClientStatus{
+			ID:          client.ID,
+			Remote:      client.Remote,
+			Filter:      client.filter,
+			Pending:     len(client.send),
+			Delivered:   client.delivered.Load(),
+			ConnectedAt: client.connectedAt,
+		})
+	}
+	return status
+}
+
+// Close 停止订阅并断开所有客户端
+func (h *Hub) Close() {
+	h.sub.Cancel()
+	h.cancel()
+	h.wg.Wait()
+	h.mu.Lock()
+	h.closed = true
+	clients := h.clients
+	h.clients = make(map[uint64]*Client)
+	h.mu.Unlock()
+	for _, client := range clients {
+		client.close(ReasonClosed)
+	}
+}
+
+func (h *Hub) loop() {
+	defer h.wg.Done()
+	for {
+		select {
+		case event, ok := <-h.sub.C():
+			if !ok {
+				return
+			}
+			h.Broadcast(eventMessage(event, h.projectOf.Event(event)))
+		case <-h.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"noctua/kernel/bus"
+	"noctua/types"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordWriter 记录写出的消息
+type recordWriter struct {
+	mu       sync.Mutex
+	messages []*Message
+	err      error
+}
+
+func (w *recordWriter) WriteMessage(msg *Message, _ time.Time) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.messages = append(w.messages, msg)
+	return nil
+}
+
+func (w *recordWriter) types() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var list []string
+	for _, msg := range w.messages {
+		list = append(list, msg.Type)
+	}
+	return list
+}
+
+func newHub(t *testing.T, config Config) (*bus.EventBus, *Hub) {
+	eventBus := bus.NewEventBus(0)
+	hub := NewHub(context.Background(), eventBus, config, nil)
+	t.Cleanup(func() {
+		hub.Close()
+		eventBus.Close()
+	})
+	return eventBus, hub
+}
+
+func receive(t *testing.T, client *Client) *Message {
+	select {
+	case msg := <-client.C():
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("receive timeout")
+		return nil
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	started := eventMessage(types.TaskStartedEvent{EventMeta: types.NewEventMeta("job-1")}, 0)
+	proxy := eventMessage(types.ProxyRemovedEvent{EventMeta: types.NewEventMeta("")}, 0)
+	comment := runtimeMessage(types.NewRuntimeData(types.RuntimeEventCodeCrawl, types.EventData{}).WithJob("job-1"), 0)
+	notice := runtimeMessage(types.NewRuntimeData(types.RuntimeEventCodeNotification, types.EventData{}), 0)
+	other := runtimeMessage(types.NewRuntimeData(types.RuntimeEventCodeNotification, types.EventData{}), 2)
+
+	all := Filter{}
+	for _, msg := range []*Message{started, proxy, comment, notice} {
+		assert.True(t, all.Match(msg))
+	}
+	// 其他项目的消息始终被过滤
+	assert.False(t, all.Match(other))
+	assert.True(t, Filter{ProjectID: 2}.Match(other))
+	assert.False(t, Filter{ProjectID: 2}.Match(notice))
+
+	job := ParseFilter("", "", "", "job-1, job-2")
+	assert.True(t, job.Match(started))
+	assert.True(t, job.Match(comment))
+	assert.False(t, job.Match(proxy))
+	assert.False(t, job.Match(notice))
+
+	topic := ParseFilter("", "task.*", "", "")
+	assert.True(t, topic.Match(started))
+	assert.False(t, topic.Match(proxy))
+	assert.True(t, topic.Match(comment))
+
+	code := ParseFilter("runtime", "", types.RuntimeEventCodeNotification, "")
+	assert.False(t, code.Match(started))
+	assert.False(t, code.Match(comment))
+	assert.True(t, code.Match(notice))
+	assert.True(t, code.Match(HeartbeatMessage()))
+}
+
+func TestHubBroadcast(t *testing.T) {
+	eventBus, hub := newHub(t, Config{})
+	jobClient, err := hub.Register("a", ParseFilter("", "", "", "job-1"))
+	require.NoError(t, err)
+	noticeClient, err := hub.Register("b", ParseFilter("runtime", "", types.RuntimeEventCodeNotification, ""))
+	require.NoError(t, err)
+
+	eventBus.Publish(types.TaskStartedEvent{EventMeta: types.NewEventMeta("job-1"), TaskInfo: types.TaskInfo{TaskID: "t1"}})
+	msg := receive(t, jobClient)
+	assert.Equal(t, TypeEvent, msg.Type)
+	assert.Equal(t, types.TopicTaskStarted, msg.Topic)
+	assert.Contains(t, string(msg.JSON()), `"taskId":"t1"`)
+
+	hub.PublishRuntime(types.NewRuntimeData(types.RuntimeEventCodeNotification, types.EventData{Title: "hello"}))
+	msg = receive(t, noticeClient)
+	assert.Equal(t, TypeRuntime, msg.Type)
+	assert.Equal(t, types.RuntimeEventCodeNotification, msg.Code)
+	assert.Contains(t, string(msg.JSON()), `"title":"hello"`)
+
+	assert.Empty(t, jobClient.C())
+	assert.Empty(t, noticeClient.C())
+	assert.Len(t, hub.Status(0).Clients, 2)
+}
+
+func TestHubEvictSlowConsumer(t *testing.T) {
+	_, hub := newHub(t, Config{ClientBuffer: 2})
+	slow, err := hub.Register("slow", Filter{})
+	require.NoError(t, err)
+	fast, err := hub.Register("fast", Filter{})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		hub.PublishRuntime(types.NewRuntimeData(types.RuntimeEventCodeCrawl, types.EventData{}))
+		receive(t, fast)
+	}
+	select {
+	case <-slow.Done():
+	case <-time.After(time.Second):
+		t.Fatal("slow consumer not evicted")
+	}
+	assert.Equal(t, ReasonSlowConsumer, slow.Reason())
+	status := hub.Status(0)
+	assert.Equal(t, uint64(1), status.Evicted)
+	require.Len(t, status.Clients, 1)
+	assert.Equal(t, fast.ID, status.Clients[0].ID)
+}
+
+func TestHubMaxClients(t *testing.T) {
+	_, hub := newHub(t, Config{MaxClients: 1})
+	client, err := hub.Register("a", Filter{})
+	require.NoError(t, err)
+	_, err = hub.Register("b", Filter{})
+	assert.ErrorIs(t, err, ErrTooManyClients)
+
+	hub.Unregister(client)
+	_, err = hub.Register("b", Filter{})
+	assert.NoError(t, err)
+}
+
+func TestHubServe(t *testing.T) {
+	_, hub := newHub(t, Config{HeartbeatInterval: 20 * time.Millisecond})
+	client, err := hub.Register("a", Filter{})
+	require.NoError(t, err)
+	writer := &recordWriter{}
+	done := make(chan error, 1)
+	go func() {
+		done <- hub.Serve(context.Background(), client, writer)
+	}()
+
+	hub.PublishRuntime(types.NewRuntimeData(types.RuntimeEventCodeCrawl, types.EventData{}))
+	assert.Eventually(t, func() bool {
+		list := writer.types()
+		return len(list) >= 2 && list[0] == TypeRuntime && list[len(list)-1] == TypeHeartbeat
+	}, time.Second, 5*time.Millisecond)
+
+	// 写入失败时结束并注销
+	writer.mu.Lock()
+	writer.err = errors.New("broken pipe")
+	writer.mu.Unlock()
+	select {
+	case err := <-done:
+		assert.EqualError(t, err, "broken pipe")
+	case <-time.After(time.Second):
+		t.Fatal("serve not stopped")
+	}
+	assert.Empty(t, hub.Status(0).Clients)
+
+	// 关闭推送中心时断开客户端
+	client, err = hub.Register("b", Filter{})
+	require.NoError(t, err)
+	go func() {
+		done <- hub.Serve(context.Background(), client, &recordWriter{})
+	}()
+	hub.Close()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("serve not stopped")
+	}
+	assert.Equal(t, ReasonClosed, client.Reason())
+}
+
+func TestConfigAllowOrigin(t *testing.T) {
+	parse := func(raw string) *url.URL {
+		u, err := url.Parse(raw)
+		require.NoError(t, err)
+		return u
+	}
+	config := Config{}
+	// 未携带 Origin 和同源请求始终允许
+	assert.True(t, config.AllowOrigin(nil, "localhost:8080"))
+	assert.True(t, config.AllowOrigin(parse("http://localhost:8080"), "localhost:8080"))
+	assert.False(t, config.AllowOrigin(parse("https://evil.example"), "localhost:8080"))
+
+	config.AllowedOrigins = []string{"https://app.example/"}
+	assert.True(t, config.AllowOrigin(parse("https://APP.example"), "localhost:8080"))
+	assert.False(t, config.AllowOrigin(parse("http://app.example"), "localhost:8080"))
+	assert.False(t, config.AllowOrigin(parse("https://evil.example"), "localhost:8080"))
+
+	config.AllowedOrigins = []string{"*"}
+	assert.True(t, config.AllowOrigin(parse("https://evil.example"), "localhost:8080"))
+}
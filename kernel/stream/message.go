@@ -0,0 +1,151 @@
+// Package stream 将运行时数据和总线事件实时推送给订阅的客户端
+package stream
+
+import (
+	"encoding/json"
+	"noctua/kernel/bus"
+	"noctua/types"
+	"strings"
+	"time"
+)
+
+const (
+	TypeEvent     = "event"     // 总线事件
+	TypeRuntime   = "runtime"   // 运行时数据
+	TypeHeartbeat = "heartbeat" // 心跳
+)
+
+// Message 推送给客户端的消息
+type Message struct {
+	Type      string      `json:"type"`
+	Topic     string      `json:"topic,omitempty"`
+	JobID     string      `json:"jobId,omitempty"`
+	ProjectID uint        `json:"projectId,omitempty"` // 所属项目，客户端只收到所属项目的消息
+	Code      string      `json:"code,omitempty"`      // 运行时数据的 EventCode
+	Data      interface{} `json:"data,omitempty"`
+	Time      time.Time   `json:"time"`
+
+	encoded []byte
+}
+
+// JSON 返回编码后的消息，广播的消息在分发前已编码，各客户端共用
+func (m *Message) JSON() []byte {
+	if m.encoded != nil {
+		return m.encoded
+	}
+	encoded, _ := json.Marshal(m)
+	return encoded
+}
+
+func (m *Message) encode() *Message {
+	encoded, err := json.Marshal(m)
+	if err != nil {
+		// 负载无法编码时只保留消息头
+		m.Data = nil
+		encoded, _ = json.Marshal(m)
+	}
+	m.encoded = encoded
+	return m
+}
+
+// HeartbeatMessage 心跳消息
+func HeartbeatMessage() *Message {
+	return (&Message{Type: TypeHeartbeat, Time: time.Now()}).encode()
+}
+
+func eventMessage(event interface{}, projectID uint) *Message {
+	msg := &Message{Type: TypeEvent, Topic: bus.TopicOf(event), ProjectID: projectID, Data: event, Time: time.Now()}
+	if lifecycle, ok := event.(types.LifecycleEvent); ok {
+		meta := lifecycle.Meta()
+		msg.JobID = meta.JobID
+		if !meta.OccurredAt.IsZero() {
+			msg.Time = meta.OccurredAt
+		}
+	}
+	return msg
+}
+
+func runtimeMessage(data types.RuntimeData, projectID uint) *Message {
+	msg := &Message{
+		Type:      TypeRuntime,
+		Topic:     "runtime." + string(data.EventCode),
+		JobID:     data.JobID,
+		ProjectID: projectID,
+		Code:      string(data.EventCode),
+		Data:      data.EventData,
+		Time:      data.EventTime,
+	}
+	if msg.Time.IsZero() {
+		msg.Time = time.Now()
+	}
+	return msg
+}
+
+// Filter 客户端订阅条件，各项为空时不过滤，非空时需同时满足
+type Filter struct {
+	Types     []string `json:"types,omitempty"`  // event / runtime
+	Topics    []string `json:"topics,omitempty"` // 事件主题模式，支持 * 和 # 通配，仅作用于总线事件
+	Codes     []string `json:"codes,omitempty"`  // 运行时数据 EventCode，仅作用于运行时数据
+	JobIDs    []string `json:"jobIds,omitempty"` // 采集任务ID，设置后不属于任何任务的消息也会被过滤
+	ProjectID uint     `json:"projectId"`        // 所属项目，始终过滤，由服务端按请求头设置
+}
+
+// ParseFilter 从逗号分隔的查询参数构造过滤条件
+func ParseFilter(typ, topic, code, jobID string) Filter {
+	return Filter{
+		Types:  splitList(typ),
+		Topics: splitList(topic),
+		Codes:  splitList(code),
+		JobIDs: splitList(jobID),
+	}
+}
+
+// Match 判断消息是否满足订阅条件，心跳始终满足
+func (f Filter) Match(msg *Message) bool {
+	if msg.Type == TypeHeartbeat {
+		return true
+	}
+	if msg.ProjectID != f.ProjectID {
+		return false
+	}
+	if len(f.Types) > 0 && !contains(f.Types, msg.Type) {
+		return false
+	}
+	if len(f.JobIDs) > 0 && !contains(f.JobIDs, msg.JobID) {
+		return false
+	}
+	switch msg.Type {
+	case TypeEvent:
+		if len(f.Topics) == 0 {
+			return true
+		}
+		for _, pattern := range f.Topics {
+			if bus.MatchTopic(pattern, msg.Topic) {
+				return true
+			}
+		}
+		return false
+	case TypeRuntime:
+		return len(f.Codes) == 0 || contains(f.Codes, msg.Code)
+	}
+	return true
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
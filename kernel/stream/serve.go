@@ -0,0 +1,34 @@
+package stream
+
+import (
+	"context"
+	"time"
+)
+
+// Writer 传输层，SSE 和 WebSocket 各自实现
+type Writer interface {
+	// WriteMessage 写出一条消息，需在 deadline 前完成
+	WriteMessage(msg *Message, deadline time.Time) error
+}
+
+// Serve 将客户端的消息写入传输层并定时发送心跳，直到连接断开、客户端被驱逐或写入失败
+func (h *Hub) Serve(ctx context.Context, client *Client, w Writer) error {
+	defer h.Unregister(client)
+	heartbeat := time.NewTicker(h.config.HeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		var msg *Message
+		select {
+		case msg = <-client.C():
+		case <-heartbeat.C:
+			msg = HeartbeatMessage()
+		case <-client.Done():
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+		if err := w.WriteMessage(msg, time.Now().Add(h.config.WriteTimeout)); err != nil {
+			return err
+		}
+	}
+}
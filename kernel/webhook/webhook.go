@@ -0,0 +1,376 @@
+// Package webhook 将总线事件签名后推送到订阅的外部地址，失败按退避重试并记录投递日志
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"noctua/internal/model"
+	"noctua/kernel/bus"
+	"noctua/pkg/logger"
+	"noctua/pkg/utils/encrypt"
+	"noctua/types"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultWorkers      = 4
+	DefaultTimeout      = 10 * time.Second
+	DefaultMaxAttempts  = 6
+	DefaultBaseDelay    = 5 * time.Second
+	DefaultMaxDelay     = 10 * time.Minute
+	DefaultPollInterval = time.Second
+
+	HeaderEvent     = "X-Noctua-Event"
+	HeaderDelivery  = "X-Noctua-Delivery"
+	HeaderTimestamp = "X-Noctua-Timestamp"
+	HeaderSignature = "X-Noctua-Signature"
+
+	maxResponseBody = 1024
+)
+
+var (
+	ErrSubscriptionRemoved = errors.New("webhook subscription removed")
+	ErrDeliveryPending     = errors.New("webhook delivery is still pending")
+)
+
+// Config Webhook 投递配置
+type Config struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	Workers      int           `mapstructure:"workers"`       // 并发投递数
+	Timeout      time.Duration `mapstructure:"timeout"`       // 单次请求超时
+	MaxAttempts  int           `mapstructure:"max_attempts"`  // 最多投递次数，含首次
+	BaseDelay    time.Duration `mapstructure:"base_delay"`    // 首次重试间隔，之后逐次翻倍
+	MaxDelay     time.Duration `mapstructure:"max_delay"`     // 重试间隔上限
+	PollInterval time.Duration `mapstructure:"poll_interval"` // 扫描到期重试的间隔
+}
+
+// Payload 推送的请求体，重新投递时 EventID 不变，接收方可据此去重
+type Payload struct {
+	EventID    string      `json:"eventId"`
+	Topic      string      `json:"topic"`
+	JobID      string      `json:"jobId,omitempty"`
+	ProjectID  uint        `json:"projectId,omitempty"`
+	OccurredAt time.Time   `json:"occurredAt"`
+	Data       interface{} `json:"data"`
+}
+
+// Sign 计算签名，签名内容为 "时间戳.请求体"，接收方以相同方式校验 X-Noctua-Signature
+func Sign(secret, timestamp, body string) string {
+	return encrypt.HmacSha256(timestamp+"."+body, secret)
+}
+
+// Dispatcher 订阅总线事件并投递到匹配的 Webhook
+type Dispatcher struct {
+	ctx           context.Context
+	cancel        context.CancelFunc
+	config        Config
+	sub           *bus.Subscription[any]
+	client        *resty.Client
+	mu            sync.RWMutex
+	subscriptions []model.WebhookSubscription
+	queue         chan uint
+	inflight      sync.Map // 已入队或投递中的记录ID，避免扫描时重复投递
+	wg            sync.WaitGroup
+	store         *model.WebhookDelivery
+	projectOf     types.ProjectResolver // 确定事件所属项目
+}
+
+// New 创建投递器，载入订阅并开始消费总线事件，事件只投递给所属项目的订阅
+func New(ctx context.Context, eventBus *bus.EventBus, config Config, projectOf types.ProjectResolver) *Dispatcher {
+	if config.Workers <= 0 {
+		config.Workers = DefaultWorkers
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+	if config.BaseDelay <= 0 {
+		config.BaseDelay = DefaultBaseDelay
+	}
+	if config.MaxDelay <= 0 {
+		config.MaxDelay = DefaultMaxDelay
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	d := &Dispatcher{
+		ctx:    ctx,
+		cancel: cancel,
+		config: config,
+		// 事件只在匹配订阅后落库，不能因积压丢弃
+		sub: bus.Subscribe[any](eventBus, bus.Options{Topic: bus.WildcardAll, Mode: bus.ModeUnbounded}),
+		client: resty.New().
+			SetTimeout(config.Timeout).
+			SetHeader("Content-Type", "application/json"),
+		queue:     make(chan uint, config.Workers*10),
+		store:     &model.WebhookDelivery{},
+		projectOf: projectOf,
+	}
+	if err := d.Reload(); err != nil {
+		logger.Log.Errorf("Load webhook subscriptions failed: %v", err)
+	}
+	d.wg.Add(2 + config.Workers)
+	go d.dispatchLoop()
+	go d.pollLoop()
+	for i := 0; i < config.Workers; i++ {
+		go d.worker()
+	}
+	return d
+}
+
+// Reload 重新载入已启用的订阅，订阅变更后调用
+func (d *Dispatcher) Reload() error {
+	subscriptions, err := (&model.WebhookSubscription{}).All(true)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.subscriptions = subscriptions
+	d.mu.Unlock()
+	return nil
+}
+
+// Redeliver 以原请求体重新投递，生成新的投递记录
+func (d *Dispatcher) Redeliver(id uint) (*model.WebhookDelivery, error) {
+	origin, err := d.store.Find(id)
+	if err != nil {
+		return nil, err
+	}
+	if origin.Status == model.WebhookDeliveryPending || origin.Status == model.WebhookDeliveryRetrying {
+		return nil, ErrDeliveryPending
+	}
+	delivery := &model.WebhookDelivery{
+		DeliveryID:     uuid.NewString(),
+		SubscriptionID: origin.SubscriptionID,
+		ProjectID:      origin.ProjectID,
+		RedeliveryOf:   origin.DeliveryID,
+		Topic:          origin.Topic,
+		JobID:          origin.JobID,
+		Payload:        origin.Payload,
+		Status:         model.WebhookDeliveryPending,
+		NextAttemptAt:  time.Now(),
+	}
+	if err := delivery.Create(); err != nil {
+		return nil, err
+	}
+	d.enqueue(delivery.ID)
+	return delivery, nil
+}
+
+// Close 停止消费事件并等待投递中的请求结束，未完成的记录在下次启动后继续投递
+func (d *Dispatcher) Close() {
+	d.sub.Cancel()
+	d.cancel()
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) dispatchLoop() {
+	defer d.wg.Done()
+	for {
+		select {
+		case event, ok := <-d.sub.C():
+			if !ok {
+				return
+			}
+			d.dispatch(event)
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatch 为所属项目中匹配的订阅生成投递记录
+func (d *Dispatcher) dispatch(event interface{}) {
+	topic := bus.TopicOf(event)
+	projectID := d.projectOf.Event(event)
+	d.mu.RLock()
+	var matched []uint
+	for i := range d.subscriptions {
+		if d.subscriptions[i].ProjectID != projectID {
+			continue
+		}
+		for _, pattern := range d.subscriptions[i].Topics() {
+			if bus.MatchTopic(pattern, topic) {
+				matched = append(matched, d.subscriptions[i].ID)
+				break
+			}
+		}
+	}
+	d.mu.RUnlock()
+	if len(matched) == 0 {
+		return
+	}
+	payload := Payload{EventID: uuid.NewString(), Topic: topic, ProjectID: projectID, OccurredAt: time.Now(), Data: event}
+	if lifecycle, ok := event.(types.LifecycleEvent); ok {
+		meta := lifecycle.Meta()
+		payload.JobID = meta.JobID
+		if !meta.OccurredAt.IsZero() {
+			payload.OccurredAt = meta.OccurredAt
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		logger.Log.Errorf("Marshal webhook payload %s failed: %v", topic, err)
+		return
+	}
+	for _, subscriptionID := range matched {
+		delivery := &model.WebhookDelivery{
+			DeliveryID:     uuid.NewString(),
+			SubscriptionID: subscriptionID,
+			ProjectID:      projectID,
+			Topic:          topic,
+			JobID:          payload.JobID,
+			Payload:        string(body),
+			Status:         model.WebhookDeliveryPending,
+			NextAttemptAt:  time.Now(),
+		}
+		if err := delivery.Create(); err != nil {
+			logger.Log.Errorf("Create webhook delivery %s for subscription %d failed: %v", topic, subscriptionID, err)
+			continue
+		}
+		d.enqueue(delivery.ID)
+	}
+}
+
+// enqueue 投递队列已满时跳过，由 pollLoop 稍后补投
+func (d *Dispatcher) enqueue(id uint) {
+	if _, loaded := d.inflight.LoadOrStore(id, struct{}{}); loaded {
+		return
+	}
+	select {
+	case d.queue <- id:
+	default:
+		d.inflight.Delete(id)
+	}
+}
+
+// pollLoop 定时扫描到期的重试和启动前未完成的投递
+func (d *Dispatcher) pollLoop() {
+	defer d.wg.Done()
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			deliveries, err := d.store.Due(time.Now(), cap(d.queue))
+			if err != nil {
+				logger.Log.Errorf("Query due webhook deliveries failed: %v", err)
+				continue
+			}
+			for i := range deliveries {
+				d.enqueue(deliveries[i].ID)
+			}
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) worker() {
+	defer d.wg.Done()
+	for {
+		select {
+		case id := <-d.queue:
+			d.deliver(id)
+			d.inflight.Delete(id)
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver 执行一次投递并记录结果
+func (d *Dispatcher) deliver(id uint) {
+	delivery, err := d.store.Find(id)
+	if err != nil {
+		logger.Log.Errorf("Load webhook delivery %d failed: %v", id, err)
+		return
+	}
+	if delivery.Status != model.WebhookDeliveryPending && delivery.Status != model.WebhookDeliveryRetrying {
+		return
+	}
+	subscription, err := (&model.WebhookSubscription{}).Find(delivery.SubscriptionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		delivery.Status = model.WebhookDeliveryFailed
+		delivery.Error = ErrSubscriptionRemoved.Error()
+		d.save(delivery)
+		return
+	}
+	if err != nil {
+		logger.Log.Errorf("Load webhook subscription %d failed: %v", delivery.SubscriptionID, err)
+		return
+	}
+	delivery.Attempts++
+	code, body, err := d.post(subscription, delivery)
+	delivery.ResponseCode = code
+	delivery.ResponseBody = body
+	if err == nil {
+		delivery.Status = model.WebhookDeliverySuccess
+		delivery.Error = ""
+		delivery.DeliveredAt = time.Now()
+		d.save(delivery)
+		return
+	}
+	delivery.Error = err.Error()
+	if delivery.Attempts >= d.config.MaxAttempts {
+		delivery.Status = model.WebhookDeliveryFailed
+		logger.Log.Warnf("Webhook delivery %s to %s failed after %d attempts: %v", delivery.DeliveryID, subscription.URL, delivery.Attempts, err)
+	} else {
+		delivery.Status = model.WebhookDeliveryRetrying
+		delivery.NextAttemptAt = time.Now().Add(d.backoff(delivery.Attempts))
+	}
+	d.save(delivery)
+}
+
+func (d *Dispatcher) post(subscription *model.WebhookSubscription, delivery *model.WebhookDelivery) (int, string, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request := d.client.R().
+		SetContext(d.ctx).
+		SetHeader(HeaderEvent, delivery.Topic).
+		SetHeader(HeaderDelivery, delivery.DeliveryID).
+		SetHeader(HeaderTimestamp, timestamp).
+		SetBody(delivery.Payload)
+	if subscription.Secret != "" {
+		request.SetHeader(HeaderSignature, Sign(subscription.Secret, timestamp, delivery.Payload))
+	}
+	resp, err := request.Post(subscription.URL)
+	if err != nil {
+		return 0, "", err
+	}
+	body := resp.String()
+	if len(body) > maxResponseBody {
+		body = body[:maxResponseBody]
+	}
+	if resp.StatusCode() < 200 || resp.StatusCode() >= 300 {
+		return resp.StatusCode(), body, fmt.Errorf("webhook responded %d", resp.StatusCode())
+	}
+	return resp.StatusCode(), body, nil
+}
+
+// backoff 第 n 次失败后的重试间隔
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.config.BaseDelay
+	for i := 1; i < attempts && delay < d.config.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > d.config.MaxDelay {
+		delay = d.config.MaxDelay
+	}
+	return delay
+}
+
+func (d *Dispatcher) save(delivery *model.WebhookDelivery) {
+	if err := delivery.Save(); err != nil {
+		logger.Log.Errorf("Save webhook delivery %s failed: %v", delivery.DeliveryID, err)
+	}
+}
@@ -0,0 +1,188 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"noctua/internal/model"
+	"noctua/kernel/bus"
+	"noctua/pkg/database/dbtest"
+	"noctua/types"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain 数据库只能初始化一次，所有测试共享同一个库
+func TestMain(m *testing.M) {
+	dbtest.Main(m, &model.WebhookSubscription{}, &model.WebhookDelivery{})
+}
+
+// receiver 记录收到的请求，前 failures 次返回 500
+type receiver struct {
+	mu       sync.Mutex
+	failures atomic.Int32
+	requests []*http.Request
+	bodies   []string
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, string(body))
+	r.mu.Unlock()
+	if r.failures.Add(-1) >= 0 {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, _ = w.Write([]byte("ok"))
+}
+
+func (r *receiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+func setup(t *testing.T, events, secret string, failures int32, config Config) (*bus.EventBus, *Dispatcher, *receiver, *model.WebhookSubscription) {
+	recv := &receiver{}
+	recv.failures.Store(failures)
+	server := httptest.NewServer(recv)
+	subscription := &model.WebhookSubscription{Name: t.Name(), URL: server.URL, Events: events, Secret: secret, Enabled: true}
+	require.NoError(t, subscription.Create())
+	eventBus := bus.NewEventBus(0)
+	config.PollInterval = 10 * time.Millisecond
+	config.BaseDelay = 10 * time.Millisecond
+	dispatcher := New(context.Background(), eventBus, config, nil)
+	t.Cleanup(func() {
+		dispatcher.Close()
+		eventBus.Close()
+		server.Close()
+		// 避免影响其他测试的订阅
+		_ = subscription.Delete(subscription.ID)
+	})
+	return eventBus, dispatcher, recv, subscription
+}
+
+func waitDelivery(t *testing.T, subscription *model.WebhookSubscription, status string) model.WebhookDelivery {
+	var delivery model.WebhookDelivery
+	params := &model.WebhookDeliveryQueryParams{ProjectID: subscription.ProjectID, SubscriptionID: subscription.ID}
+	require.Eventually(t, func() bool {
+		result, err := (&model.WebhookDelivery{}).List(params, 1, 10)
+		if err != nil || len(result.Items) == 0 {
+			return false
+		}
+		delivery = result.Items[0]
+		return delivery.Status == status
+	}, 3*time.Second, 10*time.Millisecond)
+	return delivery
+}
+
+func TestDispatcherSignedDeliveryWithRetry(t *testing.T) {
+	eventBus, _, recv, subscription := setup(t, "job.finished, session.*", "secret", 2, Config{})
+	eventBus.Publish(types.TaskStartedEvent{EventMeta: types.NewEventMeta("job-1")})
+	eventBus.Publish(types.JobFinishedEvent{EventMeta: types.NewEventMeta("job-1"), Code: types.CrawlEndCodeReachClean})
+
+	delivery := waitDelivery(t, subscription, model.WebhookDeliverySuccess)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, http.StatusOK, delivery.ResponseCode)
+	assert.Equal(t, types.TopicJobFinished, delivery.Topic)
+	assert.Equal(t, "job-1", delivery.JobID)
+	require.Equal(t, 3, recv.count())
+
+	recv.mu.Lock()
+	req, body := recv.requests[2], recv.bodies[2]
+	recv.mu.Unlock()
+	assert.Equal(t, types.TopicJobFinished, req.Header.Get(HeaderEvent))
+	assert.Equal(t, delivery.DeliveryID, req.Header.Get(HeaderDelivery))
+	assert.Equal(t, Sign("secret", req.Header.Get(HeaderTimestamp), body), req.Header.Get(HeaderSignature))
+
+	var payload struct {
+		Payload
+		Data types.JobFinishedEvent `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &payload))
+	assert.Equal(t, types.TopicJobFinished, payload.Topic)
+	assert.Equal(t, "job-1", payload.JobID)
+	assert.NotEmpty(t, payload.EventID)
+	assert.Equal(t, types.CrawlEndCodeReachClean, payload.Data.Code)
+
+	// 未匹配的事件不生成投递记录
+	result, err := (&model.WebhookDelivery{}).List(&model.WebhookDeliveryQueryParams{SubscriptionID: subscription.ID}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.Total)
+}
+
+func TestDispatcherFailAndRedeliver(t *testing.T) {
+	eventBus, dispatcher, recv, subscription := setup(t, "#", "", 2, Config{MaxAttempts: 2})
+	eventBus.Publish(types.SessionInvalidatedEvent{EventMeta: types.NewEventMeta("job-2"), Reason: "blocked"})
+
+	failed := waitDelivery(t, subscription, model.WebhookDeliveryFailed)
+	assert.Equal(t, 2, failed.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, failed.ResponseCode)
+	assert.Equal(t, "webhook responded 500", failed.Error)
+
+	redelivery, err := dispatcher.Redeliver(failed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, failed.DeliveryID, redelivery.RedeliveryOf)
+	delivered := waitDelivery(t, subscription, model.WebhookDeliverySuccess)
+	assert.Equal(t, redelivery.ID, delivered.ID)
+	assert.Equal(t, failed.Payload, delivered.Payload)
+	assert.Equal(t, 3, recv.count())
+
+	recv.mu.Lock()
+	assert.Empty(t, recv.requests[2].Header.Get(HeaderSignature))
+	recv.mu.Unlock()
+}
+
+func TestDispatcherBackoff(t *testing.T) {
+	d := &Dispatcher{config: Config{BaseDelay: time.Second, MaxDelay: 5 * time.Second}}
+	assert.Equal(t, time.Second, d.backoff(1))
+	assert.Equal(t, 2*time.Second, d.backoff(2))
+	assert.Equal(t, 4*time.Second, d.backoff(3))
+	assert.Equal(t, 5*time.Second, d.backoff(4))
+	assert.Equal(t, 5*time.Second, d.backoff(10))
+}
+
+func TestDispatcherProjectScope(t *testing.T) {
+	recv := &receiver{}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+	subscription := &model.WebhookSubscription{ProjectID: 2, Name: t.Name(), URL: server.URL, Events: "#", Enabled: true}
+	require.NoError(t, subscription.Create())
+	defer func() { _ = subscription.Delete(subscription.ID) }()
+	eventBus := bus.NewEventBus(0)
+	defer eventBus.Close()
+	projects := map[string]uint{"job-p1": 1, "job-p2": 2}
+	dispatcher := New(context.Background(), eventBus, Config{PollInterval: 10 * time.Millisecond}, func(jobID string) uint {
+		return projects[jobID]
+	})
+	defer dispatcher.Close()
+
+	// 只投递所属项目的事件，显式标记的项目优先
+	eventBus.Publish(types.JobFinishedEvent{EventMeta: types.NewEventMeta("job-p1")})
+	eventBus.Publish(types.SessionInvalidatedEvent{EventMeta: types.NewEventMeta("").WithProject(1)})
+	eventBus.Publish(types.JobFinishedEvent{EventMeta: types.NewEventMeta("job-p2")})
+	delivery := waitDelivery(t, subscription, model.WebhookDeliverySuccess)
+	assert.Equal(t, uint(2), delivery.ProjectID)
+	assert.Equal(t, "job-p2", delivery.JobID)
+	assert.Equal(t, 1, recv.count())
+
+	result, err := (&model.WebhookDelivery{}).List(&model.WebhookDeliveryQueryParams{ProjectID: 2, SubscriptionID: subscription.ID}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.Total)
+	result, err = (&model.WebhookDelivery{}).List(&model.WebhookDeliveryQueryParams{ProjectID: 1, SubscriptionID: subscription.ID}, 1, 10)
+	require.NoError(t, err)
+	assert.Zero(t, result.Total)
+
+	redelivery, err := dispatcher.Redeliver(delivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), redelivery.ProjectID)
+}
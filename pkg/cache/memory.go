@@ -1,9 +1,11 @@
 package cache
 
 import (
+	"bytes"
 	"encoding/gob"
 	"github.com/patrickmn/go-cache"
 	"noctua/pkg/logger"
+	"noctua/pkg/secret"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -88,29 +90,26 @@ func (m *MemoryCache) SaveToFile() {
 		return
 	}
 
-	file, err := os.Create(m.persistFile)
-	if err != nil {
-		logger.Log.Errorf("Failed to persist cache to %s: %v", m.persistFile, err)
-		return
-	}
-	defer func(file *os.File) {
-		err := file.Close()
-		if err != nil {
-			logger.Log.Errorf("Failed to close file %s: %v", file.Name(), err)
-		}
-	}(file)
-
-	encoder := gob.NewEncoder(file)
+	var buf bytes.Buffer
 	items := m.store.Items()
-	if err := encoder.Encode(items); err != nil {
+	if err := gob.NewEncoder(&buf).Encode(items); err != nil {
 		logger.Log.Errorf("Failed to encode cache to %s: %v", m.persistFile, err)
 		return
 	}
+	// 缓存中包含账号与代理的对应关系，配置密钥时加密保存
+	data, err := secret.Encrypt(buf.Bytes())
+	if err != nil {
+		logger.Log.Errorf("Failed to encrypt cache to %s: %v", m.persistFile, err)
+		return
+	}
+	if err := os.WriteFile(m.persistFile, data, 0600); err != nil {
+		logger.Log.Errorf("Failed to persist cache to %s: %v", m.persistFile, err)
+	}
 }
 
 // LoadFromFile **Gob 读取**
 func (m *MemoryCache) LoadFromFile() {
-	file, err := os.Open(m.persistFile)
+	data, err := os.ReadFile(m.persistFile)
 	if os.IsNotExist(err) {
 		logger.Log.Warnf("Cache file %s does not exist, possibly first run", m.persistFile)
 		return
@@ -118,16 +117,15 @@ func (m *MemoryCache) LoadFromFile() {
 		logger.Log.Errorf("Failed to read cache file %s: %v", m.persistFile, err)
 		return
 	}
-	defer func(file *os.File) {
-		err := file.Close()
-		if err != nil {
-			logger.Log.Errorf("Failed to close file %s: %v", file.Name(), err)
-		}
-	}(file)
+	// 未加密的旧文件原样读取
+	data, err = secret.Decrypt(data)
+	if err != nil {
+		logger.Log.Errorf("Failed to decrypt cache file %s: %v", m.persistFile, err)
+		return
+	}
 
 	var items map[string]cache.Item
-	decoder := gob.NewDecoder(file)
-	if err := decoder.Decode(&items); err != nil {
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&items); err != nil {
 		logger.Log.Errorf("Failed to decode cache from %s: %v", m.persistFile, err)
 		return
 	}
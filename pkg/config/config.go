@@ -1,15 +1,21 @@
 package config
 
 import (
+	"context"
 	"github.com/fsnotify/fsnotify"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"log"
+	"noctua/pkg/logger"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
+// watchDebounce 保存文件时可能连续触发多次事件，合并为一次回调
+const watchDebounce = 300 * time.Millisecond
+
 type Config struct {
 	Sort int // 排序，用于多个配置文件的加载
 	Name string
@@ -127,6 +133,48 @@ func watchConfig() {
 	})
 }
 
+// WatchFile 监听文件修改并回调，监听所在目录以兼容编辑器替换文件的保存方式，ctx 结束后停止
+func WatchFile(ctx context.Context, path string, onChange func()) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(filepath.Dir(absPath)); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	go func() {
+		defer watcher.Close()
+		var pending <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == absPath && event.Has(fsnotify.Write|fsnotify.Create) {
+					pending = time.After(watchDebounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Log.Warnf("Watch config file %s failed: %v", absPath, err)
+			case <-pending:
+				pending = nil
+				onChange()
+			}
+		}
+	}()
+	return nil
+}
+
 func pathExists(path string) (bool, error) {
 	_, err := os.Stat(path)
 	if err == nil {
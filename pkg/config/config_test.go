@@ -0,0 +1,42 @@
+package config
+
+import (
+	"context"
+	"noctua/pkg/logger"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchFile(t *testing.T) {
+	logger.Init(&logger.LoggerConfig{Level: "error"})
+	dir := t.TempDir()
+	path := filepath.Join(dir, "proxy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("proxy: {}\n"), 0600))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var changes atomic.Int32
+	require.NoError(t, WatchFile(ctx, path, func() { changes.Add(1) }))
+
+	// 同目录的其他文件不触发
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.yaml"), []byte("a: 1\n"), 0600))
+	time.Sleep(2 * watchDebounce)
+	assert.Equal(t, int32(0), changes.Load())
+
+	// 连续多次修改合并为一次回调
+	for i := 0; i < 3; i++ {
+		require.NoError(t, os.WriteFile(path, []byte("proxy:\n  batch_size: 5\n"), 0600))
+	}
+	assert.Eventually(t, func() bool { return changes.Load() == 1 }, 2*time.Second, 20*time.Millisecond)
+
+	// 以替换文件的方式保存
+	tmp := filepath.Join(dir, "proxy.yaml.tmp")
+	require.NoError(t, os.WriteFile(tmp, []byte("proxy:\n  batch_size: 6\n"), 0600))
+	require.NoError(t, os.Rename(tmp, path))
+	assert.Eventually(t, func() bool { return changes.Load() == 2 }, 2*time.Second, 20*time.Millisecond)
+}
@@ -0,0 +1,471 @@
+// Package cookie 解析和导出账号 Cookie，支持 Netscape cookies.txt、浏览器插件 JSON、Cookie 请求头和 HAR
+package cookie
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format Cookie 格式
+type Format string
+
+const (
+	FormatAuto     Format = ""         // 自动识别
+	FormatNetscape Format = "netscape" // Netscape cookies.txt
+	FormatJSON     Format = "json"     // 浏览器插件导出的 JSON 数组
+	FormatHeader   Format = "header"   // Cookie 请求头，如 a=1; b=2
+	FormatHAR      Format = "har"      // HAR 文件或 HAR 中的 cookies 数组
+)
+
+var ErrEmpty = errors.New("cookie: no cookies found")
+
+// Cookie 单个 Cookie，Expires 为零值时表示会话 Cookie
+type Cookie struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Expires  time.Time `json:"expires"`
+	HttpOnly bool      `json:"httpOnly,omitempty"`
+	Secure   bool      `json:"secure,omitempty"`
+	SameSite string    `json:"sameSite,omitempty"`
+}
+
+// Session 是否为会话 Cookie
+func (c Cookie) Session() bool {
+	return c.Expires.IsZero()
+}
+
+// Expired 在 t 时刻是否已过期
+func (c Cookie) Expired(t time.Time) bool {
+	return !c.Session() && !c.Expires.After(t)
+}
+
+// Cookies Cookie 列表
+type Cookies []Cookie
+
+// Parse 自动识别格式并解析
+func Parse(data string) (Cookies, Format, error) {
+	format := Detect(data)
+	cookies, err := ParseFormat(data, format)
+	return cookies, format, err
+}
+
+// ParseFormat 按指定格式解析，format 为空时自动识别
+func ParseFormat(data string, format Format) (Cookies, error) {
+	if format == FormatAuto {
+		format = Detect(data)
+	}
+	var cookies Cookies
+	var err error
+	switch format {
+	case FormatNetscape:
+		cookies, err = parseNetscape(data)
+	case FormatJSON, FormatHAR:
+		cookies, err = parseJSON(data)
+	case FormatHeader:
+		cookies = parseHeader(data)
+	default:
+		return nil, fmt.Errorf("cookie: unsupported format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(cookies) == 0 {
+		return nil, ErrEmpty
+	}
+	return cookies, nil
+}
+
+// Detect 识别数据格式
+func Detect(data string) Format {
+	data = strings.TrimSpace(data)
+	switch {
+	case strings.HasPrefix(data, "{"):
+		// HAR 文件或 {"cookies": [...]} 形式的导出
+		if strings.Contains(data, `"log"`) {
+			return FormatHAR
+		}
+		return FormatJSON
+	case strings.HasPrefix(data, "["):
+		// HAR 中的 expires 为 ISO 8601 字符串
+		var items []map[string]json.RawMessage
+		if json.Unmarshal([]byte(data), &items) == nil {
+			for _, item := range items {
+				if expires, ok := item["expires"]; ok && strings.HasPrefix(string(expires), `"`) {
+					return FormatHAR
+				}
+			}
+		}
+		return FormatJSON
+	case strings.HasPrefix(data, "#") || strings.Contains(data, "\t"):
+		return FormatNetscape
+	default:
+		return FormatHeader
+	}
+}
+
+// Encode 导出为指定格式
+func (c Cookies) Encode(format Format) (string, error) {
+	switch format {
+	case FormatNetscape:
+		return c.Netscape(), nil
+	case FormatJSON, FormatAuto:
+		return c.JSON()
+	case FormatHeader:
+		return c.Header(), nil
+	case FormatHAR:
+		return c.HAR()
+	default:
+		return "", fmt.Errorf("cookie: unsupported format %q", format)
+	}
+}
+
+// Header 导出为 Cookie 请求头
+func (c Cookies) Header() string {
+	parts := make([]string, 0, len(c))
+	for _, cookie := range c {
+		if cookie.Name != "" {
+			parts = append(parts, cookie.Name+"="+cookie.Value)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Get 按名称查询 Cookie，同名时返回最后一个
+func (c Cookies) Get(name string) (Cookie, bool) {
+	for i := len(c) - 1; i >= 0; i-- {
+		if c[i].Name == name {
+			return c[i], true
+		}
+	}
+	return Cookie{}, false
+}
+
+// Valid 过滤在 t 时刻已过期的 Cookie
+func (c Cookies) Valid(t time.Time) Cookies {
+	valid := make(Cookies, 0, len(c))
+	for _, cookie := range c {
+		if !cookie.Expired(t) {
+			valid = append(valid, cookie)
+		}
+	}
+	return valid
+}
+
+// ExpiryCheck 关键 Cookie 的检查结果
+type ExpiryCheck struct {
+	Missing  []string  `json:"missing,omitempty"`  // 缺失的关键 Cookie
+	Expiring []string  `json:"expiring,omitempty"` // 截止时间前过期的关键 Cookie
+	ExpireAt time.Time `json:"expireAt"`           // 关键 Cookie 中最早的过期时间，均为会话 Cookie 时为零值
+}
+
+// OK 关键 Cookie 均存在且在截止时间前不会过期
+func (r ExpiryCheck) OK() bool {
+	return len(r.Missing) == 0 && len(r.Expiring) == 0
+}
+
+// CheckExpiry 检查关键 Cookie 在 deadline 前是否会过期，essential 为空时检查全部 Cookie
+func (c Cookies) CheckExpiry(essential []string, deadline time.Time) ExpiryCheck {
+	var result ExpiryCheck
+	check := func(cookie Cookie) {
+		if cookie.Session() {
+			return
+		}
+		if result.ExpireAt.IsZero() || cookie.Expires.Before(result.ExpireAt) {
+			result.ExpireAt = cookie.Expires
+		}
+		if cookie.Expired(deadline) {
+			result.Expiring = append(result.Expiring, cookie.Name)
+		}
+	}
+	if len(essential) == 0 {
+		for _, cookie := range c {
+			check(cookie)
+		}
+		return result
+	}
+	for _, name := range essential {
+		cookie, ok := c.Get(name)
+		if !ok {
+			result.Missing = append(result.Missing, name)
+			continue
+		}
+		check(cookie)
+	}
+	return result
+}
+
+// extensionCookie 浏览器插件、Playwright 及 HAR 的 JSON 字段
+type extensionCookie struct {
+	Name           string          `json:"name"`
+	Value          string          `json:"value"`
+	Domain         string          `json:"domain,omitempty"`
+	Path           string          `json:"path,omitempty"`
+	ExpirationDate *float64        `json:"expirationDate,omitempty"` // 插件导出，秒级时间戳
+	Expires        json.RawMessage `json:"expires,omitempty"`        // HAR 为 ISO 8601，Playwright 为秒级时间戳，-1 为会话 Cookie
+	HttpOnly       bool            `json:"httpOnly,omitempty"`
+	Secure         bool            `json:"secure,omitempty"`
+	SameSite       string          `json:"sameSite,omitempty"`
+	Session        bool            `json:"session,omitempty"`
+	HostOnly       bool            `json:"hostOnly,omitempty"`
+}
+
+func (e extensionCookie) cookie() (Cookie, error) {
+	cookie := Cookie{
+		Name:     e.Name,
+		Value:    e.Value,
+		Domain:   e.Domain,
+		Path:     e.Path,
+		HttpOnly: e.HttpOnly,
+		Secure:   e.Secure,
+		SameSite: e.SameSite,
+	}
+	if e.Session {
+		return cookie, nil
+	}
+	if e.ExpirationDate != nil {
+		cookie.Expires = fromUnix(*e.ExpirationDate)
+		return cookie, nil
+	}
+	if len(e.Expires) == 0 || string(e.Expires) == "null" {
+		return cookie, nil
+	}
+	var expires interface{}
+	if err := json.Unmarshal(e.Expires, &expires); err != nil {
+		return cookie, err
+	}
+	switch v := expires.(type) {
+	case float64:
+		cookie.Expires = fromUnix(v)
+	case string:
+		if v == "" {
+			break
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return cookie, fmt.Errorf("cookie: invalid expires %q of %s", v, e.Name)
+		}
+		cookie.Expires = t
+	}
+	return cookie, nil
+}
+
+func fromUnix(seconds float64) time.Time {
+	if seconds <= 0 {
+		return time.Time{}
+	}
+	sec, frac := math.Modf(seconds)
+	return time.Unix(int64(sec), int64(frac*1e9))
+}
+
+func parseJSON(data string) (Cookies, error) {
+	data = strings.TrimSpace(data)
+	var items []extensionCookie
+	if strings.HasPrefix(data, "{") {
+		var document struct {
+			Cookies []extensionCookie `json:"cookies"`
+			Log     struct {
+				Entries []struct {
+					Request struct {
+						Cookies []extensionCookie `json:"cookies"`
+					} `json:"request"`
+					Response struct {
+						Cookies []extensionCookie `json:"cookies"`
+					} `json:"response"`
+				} `json:"entries"`
+			} `json:"log"`
+		}
+		if err := json.Unmarshal([]byte(data), &document); err != nil {
+			return nil, fmt.Errorf("cookie: invalid json: %w", err)
+		}
+		items = document.Cookies
+		// HAR 中后出现的响应 Cookie 覆盖先前的值
+		for _, entry := range document.Log.Entries {
+			items = append(items, entry.Request.Cookies...)
+			items = append(items, entry.Response.Cookies...)
+		}
+	} else if err := json.Unmarshal([]byte(data), &items); err != nil {
+		return nil, fmt.Errorf("cookie: invalid json: %w", err)
+	}
+	cookies := make(Cookies, 0, len(items))
+	for _, item := range items {
+		if item.Name == "" {
+			continue
+		}
+		cookie, err := item.cookie()
+		if err != nil {
+			return nil, err
+		}
+		cookies = append(cookies, cookie)
+	}
+	return dedupe(cookies), nil
+}
+
+// JSON 导出为浏览器插件格式的 JSON 数组，账号中以该格式保存
+func (c Cookies) JSON() (string, error) {
+	items := make([]extensionCookie, 0, len(c))
+	for _, cookie := range c {
+		item := extensionCookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   cookie.Domain,
+			Path:     cookie.Path,
+			HttpOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+			SameSite: cookie.SameSite,
+			Session:  cookie.Session(),
+			HostOnly: cookie.Domain != "" && !strings.HasPrefix(cookie.Domain, "."),
+		}
+		if !cookie.Session() {
+			expirationDate := float64(cookie.Expires.Unix())
+			item.ExpirationDate = &expirationDate
+		}
+		items = append(items, item)
+	}
+	data, err := json.Marshal(items)
+	return string(data), err
+}
+
+// HAR 导出为 HAR 的 cookies 数组
+func (c Cookies) HAR() (string, error) {
+	items := make([]extensionCookie, 0, len(c))
+	for _, cookie := range c {
+		item := extensionCookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   cookie.Domain,
+			Path:     cookie.Path,
+			HttpOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		}
+		if !cookie.Session() {
+			item.Expires, _ = json.Marshal(cookie.Expires.UTC().Format(time.RFC3339))
+		}
+		items = append(items, item)
+	}
+	data, err := json.Marshal(items)
+	return string(data), err
+}
+
+const netscapeHttpOnlyPrefix = "#HttpOnly_"
+
+func parseNetscape(data string) (Cookies, error) {
+	var cookies Cookies
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+		httpOnly := false
+		if strings.HasPrefix(line, netscapeHttpOnlyPrefix) {
+			httpOnly = true
+			line = strings.TrimPrefix(line, netscapeHttpOnlyPrefix)
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 7 {
+			return nil, fmt.Errorf("cookie: invalid netscape line %d", i+1)
+		}
+		cookie := Cookie{
+			Domain:   fields[0],
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    strings.Join(fields[6:], "\t"),
+			HttpOnly: httpOnly,
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cookie: invalid netscape expires on line %d", i+1)
+		}
+		if expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+		}
+		cookies = append(cookies, cookie)
+	}
+	return dedupe(cookies), nil
+}
+
+// Netscape 导出为 Netscape cookies.txt
+func (c Cookies) Netscape() string {
+	var builder strings.Builder
+	builder.WriteString("# Netscape HTTP Cookie File\n")
+	for _, cookie := range c {
+		domain := cookie.Domain
+		if cookie.HttpOnly {
+			domain = netscapeHttpOnlyPrefix + domain
+		}
+		path := cookie.Path
+		if path == "" {
+			path = "/"
+		}
+		var expires int64
+		if !cookie.Session() {
+			expires = cookie.Expires.Unix()
+		}
+		fmt.Fprintf(&builder, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain,
+			boolString(strings.HasPrefix(cookie.Domain, ".")),
+			path,
+			boolString(cookie.Secure),
+			expires,
+			cookie.Name,
+			cookie.Value,
+		)
+	}
+	return builder.String()
+}
+
+func boolString(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func parseHeader(data string) Cookies {
+	data = strings.TrimSpace(data)
+	if len(data) > 7 && strings.EqualFold(data[:7], "cookie:") {
+		data = data[7:]
+	}
+	var cookies Cookies
+	for _, part := range strings.Split(data, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name = strings.TrimSpace(name); name != "" {
+			cookies = append(cookies, Cookie{Name: name, Value: strings.TrimSpace(value)})
+		}
+	}
+	return dedupe(cookies)
+}
+
+// dedupe 同名同域的 Cookie 只保留最后一个，保持首次出现的顺序
+func dedupe(cookies Cookies) Cookies {
+	index := make(map[string]int, len(cookies))
+	result := make(Cookies, 0, len(cookies))
+	for _, cookie := range cookies {
+		key := cookie.Name + "\x00" + cookie.Domain + "\x00" + cookie.Path
+		if i, ok := index[key]; ok {
+			result[i] = cookie
+			continue
+		}
+		index[key] = len(result)
+		result = append(result, cookie)
+	}
+	return result
+}
+
+// Names 返回 Cookie 名称，按字母排序
+func (c Cookies) Names() []string {
+	names := make([]string, 0, len(c))
+	for _, cookie := range c {
+		names = append(names, cookie.Name)
+	}
+	sort.Strings(names)
+	return names
+}
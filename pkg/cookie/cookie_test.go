@@ -0,0 +1,96 @@
+package cookie
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const netscapeFile = "# Netscape HTTP Cookie File\n" +
+	".douyin.com\tTRUE\t/\tFALSE\t1900000000\tsessionid\tabc\n" +
+	"#HttpOnly_.douyin.com\tTRUE\t/\tTRUE\t0\tttwid\tx\ty\n"
+
+func TestParseFormats(t *testing.T) {
+	cases := []struct {
+		name   string
+		data   string
+		format Format
+	}{
+		{"netscape", netscapeFile, FormatNetscape},
+		{"extension", `[{"name":"sessionid","value":"abc","domain":".douyin.com","expirationDate":1900000000.5},{"name":"ttwid","value":"x\ty","session":true}]`, FormatJSON},
+		{"playwright", `{"cookies":[{"name":"sessionid","value":"abc","expires":1900000000},{"name":"ttwid","value":"x\ty","expires":-1}]}`, FormatJSON},
+		{"har", `[{"name":"sessionid","value":"abc","expires":"2030-03-17T17:46:40Z"},{"name":"ttwid","value":"x\ty"}]`, FormatHAR},
+		{"har document", `{"log":{"entries":[{"request":{"cookies":[{"name":"sessionid","value":"old"},{"name":"ttwid","value":"x\ty"}]},"response":{"cookies":[{"name":"sessionid","value":"abc","expires":"2030-03-17T17:46:40Z"}]}}]}}`, FormatHAR},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cookies, format, err := Parse(tc.data)
+			require.NoError(t, err)
+			assert.Equal(t, tc.format, format)
+			session, ok := cookies.Get("sessionid")
+			require.True(t, ok)
+			assert.Equal(t, "abc", session.Value)
+			assert.Equal(t, int64(1900000000), session.Expires.Unix())
+			ttwid, ok := cookies.Get("ttwid")
+			require.True(t, ok)
+			assert.Equal(t, "x\ty", ttwid.Value)
+			assert.True(t, ttwid.Session())
+		})
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	cookies, format, err := Parse("Cookie: a=1; b=x=y;; a=2")
+	require.NoError(t, err)
+	assert.Equal(t, FormatHeader, format)
+	assert.Equal(t, "a=2; b=x=y", cookies.Header())
+
+	_, _, err = Parse("  ")
+	assert.ErrorIs(t, err, ErrEmpty)
+	_, err = ParseFormat("[{", FormatJSON)
+	assert.Error(t, err)
+	_, err = ParseFormat("a\tb", FormatNetscape)
+	assert.Error(t, err)
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	expires := time.Unix(1900000000, 0)
+	cookies := Cookies{
+		{Name: "sessionid", Value: "abc", Domain: ".douyin.com", Path: "/", Expires: expires, HttpOnly: true, Secure: true},
+		{Name: "ttwid", Value: "x", Domain: ".douyin.com", Path: "/"},
+	}
+	for _, format := range []Format{FormatNetscape, FormatJSON, FormatHAR} {
+		data, err := cookies.Encode(format)
+		require.NoError(t, err)
+		parsed, detected, err := Parse(data)
+		require.NoError(t, err, format)
+		assert.Equal(t, format, detected)
+		require.Len(t, parsed, 2)
+		assert.True(t, parsed[0].Expires.Equal(expires), format)
+		assert.Equal(t, "abc", parsed[0].Value)
+		assert.True(t, parsed[1].Session(), format)
+	}
+}
+
+func TestCheckExpiry(t *testing.T) {
+	now := time.Now()
+	cookies := Cookies{
+		{Name: "sessionid", Value: "a", Expires: now.Add(48 * time.Hour)},
+		{Name: "sid_guard", Value: "b", Expires: now.Add(time.Hour)},
+		{Name: "ttwid", Value: "c"},
+	}
+	check := cookies.CheckExpiry([]string{"sessionid", "ttwid", "uid_tt"}, now.Add(24*time.Hour))
+	assert.Equal(t, []string{"uid_tt"}, check.Missing)
+	assert.Empty(t, check.Expiring)
+	assert.True(t, check.ExpireAt.Equal(now.Add(48*time.Hour)))
+	assert.False(t, check.OK())
+
+	check = cookies.CheckExpiry(nil, now.Add(24*time.Hour))
+	assert.Equal(t, []string{"sid_guard"}, check.Expiring)
+	assert.True(t, check.ExpireAt.Equal(now.Add(time.Hour)))
+
+	assert.True(t, cookies.CheckExpiry([]string{"sessionid"}, now).OK())
+	assert.Len(t, cookies.Valid(now.Add(2*time.Hour)), 2)
+}
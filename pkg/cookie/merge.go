@@ -0,0 +1,148 @@
+package cookie
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Diff Cookie 的变化，只记录名称
+type Diff struct {
+	Added   []string `json:"added,omitempty"`
+	Updated []string `json:"updated,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// Empty 是否没有变化
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Updated) == 0 && len(d.Removed) == 0
+}
+
+// String 以 +新增 ~更新 -删除 的形式展示
+func (d Diff) String() string {
+	parts := make([]string, 0, len(d.Added)+len(d.Updated)+len(d.Removed))
+	for _, name := range d.Added {
+		parts = append(parts, "+"+name)
+	}
+	for _, name := range d.Updated {
+		parts = append(parts, "~"+name)
+	}
+	for _, name := range d.Removed {
+		parts = append(parts, "-"+name)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Append 合并另一次变化，同名 Cookie 以最后一次变化为准，新增后又更新的仍记为新增
+func (d Diff) Append(other Diff) Diff {
+	result := Diff{}
+	state := make(map[string]string)
+	var order []string
+	record := func(names []string, kind string) {
+		for _, name := range names {
+			previous, ok := state[name]
+			if !ok {
+				order = append(order, name)
+			}
+			if previous == "added" && kind == "updated" {
+				continue
+			}
+			state[name] = kind
+		}
+	}
+	record(d.Added, "added")
+	record(d.Updated, "updated")
+	record(d.Removed, "removed")
+	record(other.Added, "added")
+	record(other.Updated, "updated")
+	record(other.Removed, "removed")
+	for _, name := range order {
+		switch state[name] {
+		case "added":
+			result.Added = append(result.Added, name)
+		case "updated":
+			result.Updated = append(result.Updated, name)
+		case "removed":
+			result.Removed = append(result.Removed, name)
+		}
+	}
+	return result
+}
+
+// FromHTTP 转换响应中的 Set-Cookie，Max-Age 小于 0 的 Cookie 视为已过期
+func FromHTTP(cookies []*http.Cookie, now time.Time) Cookies {
+	result := make(Cookies, 0, len(cookies))
+	for _, c := range cookies {
+		if c == nil || c.Name == "" {
+			continue
+		}
+		item := Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Expires:  c.Expires,
+			HttpOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		switch {
+		case c.MaxAge < 0:
+			item.Expires = time.Unix(1, 0)
+		case c.MaxAge > 0:
+			item.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		}
+		switch c.SameSite {
+		case http.SameSiteLaxMode:
+			item.SameSite = "lax"
+		case http.SameSiteStrictMode:
+			item.SameSite = "strict"
+		case http.SameSiteNoneMode:
+			item.SameSite = "no_restriction"
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// sameDomain 域名相同或任一方未设置域名，忽略开头的点
+func sameDomain(a, b string) bool {
+	return a == "" || b == "" || strings.TrimPrefix(a, ".") == strings.TrimPrefix(b, ".")
+}
+
+// Merge 将 updates 合并到 c 并返回新的列表，同名且域名相同的 Cookie 被替换，在 now 时刻已过期的 Cookie 被删除
+func (c Cookies) Merge(updates Cookies, now time.Time) (Cookies, Diff) {
+	result := slices.Clone(c)
+	diff := Diff{}
+	for _, update := range updates {
+		index := slices.IndexFunc(result, func(existing Cookie) bool {
+			return existing.Name == update.Name && sameDomain(existing.Domain, update.Domain)
+		})
+		if update.Expired(now) {
+			if index >= 0 {
+				result = slices.Delete(result, index, index+1)
+				diff.Removed = append(diff.Removed, update.Name)
+			}
+			continue
+		}
+		if index < 0 {
+			result = append(result, update)
+			diff.Added = append(diff.Added, update.Name)
+			continue
+		}
+		existing := result[index]
+		if update.Domain == "" {
+			update.Domain = existing.Domain
+		}
+		if update.Path == "" {
+			update.Path = existing.Path
+		}
+		if existing != update {
+			result[index] = update
+			if existing.Value != update.Value || !existing.Expires.Equal(update.Expires) {
+				diff.Updated = append(diff.Updated, update.Name)
+			}
+		}
+	}
+	return result, diff
+}
@@ -0,0 +1,51 @@
+package cookie
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	now := time.Now()
+	cookies := Cookies{
+		{Name: "sessionid", Value: "a", Domain: ".douyin.com", Path: "/"},
+		{Name: "ttwid", Value: "b", Domain: ".douyin.com", Path: "/"},
+		{Name: "msToken", Value: "c"},
+		{Name: "sessionid", Value: "other", Domain: ".toutiao.com"},
+	}
+	updates := FromHTTP([]*http.Cookie{
+		{Name: "ttwid", Value: "b2", Domain: "douyin.com", MaxAge: 3600},
+		{Name: "msToken", MaxAge: -1},
+		{Name: "odin_tt", Value: "d", Domain: ".douyin.com", Path: "/", HttpOnly: true, SameSite: http.SameSiteNoneMode},
+		{Name: "sessionid", Value: "a", Domain: ".douyin.com", Path: "/"},
+	}, now)
+	require.Len(t, updates, 4)
+	assert.True(t, updates[1].Expired(now))
+	assert.Equal(t, "no_restriction", updates[2].SameSite)
+
+	merged, diff := cookies.Merge(updates, now)
+	assert.Equal(t, Diff{Added: []string{"odin_tt"}, Updated: []string{"ttwid"}, Removed: []string{"msToken"}}, diff)
+	assert.Equal(t, "+odin_tt ~ttwid -msToken", diff.String())
+	assert.Len(t, merged, 4)
+	ttwid, ok := merged.Get("ttwid")
+	require.True(t, ok)
+	assert.Equal(t, "b2", ttwid.Value)
+	assert.Equal(t, "/", ttwid.Path, "missing path keeps the existing one")
+	assert.True(t, ttwid.Expires.Equal(now.Add(time.Hour)))
+	assert.Equal(t, "a", cookies[0].Value, "merge does not modify the receiver")
+	assert.Len(t, cookies, 4)
+
+	_, diff = merged.Merge(updates, now)
+	assert.True(t, diff.Empty())
+}
+
+func TestDiffAppend(t *testing.T) {
+	diff := Diff{Added: []string{"a"}, Updated: []string{"b"}}.
+		Append(Diff{Updated: []string{"a", "c"}, Removed: []string{"b"}})
+	assert.Equal(t, Diff{Added: []string{"a"}, Updated: []string{"c"}, Removed: []string{"b"}}, diff)
+	assert.True(t, Diff{}.Append(Diff{}).Empty())
+}
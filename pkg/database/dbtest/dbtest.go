@@ -0,0 +1,30 @@
+// Package dbtest 为测试初始化临时 sqlite 数据库
+package dbtest
+
+import (
+	"noctua/pkg/database"
+	"noctua/pkg/logger"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Main 在 TestMain 中调用，创建临时 sqlite 数据库并迁移 models 后运行测试，结束时删除数据库并退出
+// 数据库只能初始化一次，同一个包的测试共享同一个库，-count 多次运行时数据会保留
+func Main(m *testing.M, models ...interface{}) {
+	dir, err := os.MkdirTemp("", "dbtest")
+	if err != nil {
+		panic(err)
+	}
+	logger.Init(&logger.LoggerConfig{Level: "error"})
+	database.InitDB(&database.Config{Driver: "sqlite", DSN: filepath.Join(dir, "test.db")})
+	if database.DB == nil {
+		panic("init sqlite database failed")
+	}
+	if err := database.Migrate(database.DB, models); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -32,7 +32,7 @@ func Paginate[T any](query *gorm.DB, opt ListOptions) (PageResult[T], error) {
 		opt.Sort = "id"
 	}
 	if len(opt.Order) == 0 {
-		opt.Sort = "desc"
+		opt.Order = "desc"
 	}
 
 	// 计算偏移量
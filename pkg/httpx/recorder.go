@@ -0,0 +1,341 @@
+package httpx
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RecordMode 录制回放模式
+type RecordMode string
+
+const (
+	RecordModeOff    RecordMode = ""       // 直连
+	RecordModeRecord RecordMode = "record" // 请求真实接口并保存响应
+	RecordModeReplay RecordMode = "replay" // 仅从录制文件返回响应
+)
+
+// Redacted 脱敏后的占位值
+const Redacted = "[REDACTED]"
+
+var ErrFixtureNotFound = errors.New("fixture not found")
+
+var (
+	// 默认脱敏的请求/响应头
+	defaultRedactHeaders = []string{"Cookie", "Set-Cookie", "Authorization", "X-Secsdk-Csrf-Token", "X-Tt-Token"}
+	// 默认脱敏的查询参数
+	defaultRedactParams = []string{"msToken", "a_bogus", "X-Bogus", "webid", "verifyFp", "fp", "uifid"}
+	// 每次请求都会变化、不参与匹配的查询参数
+	defaultIgnoreParams = []string{"msToken", "a_bogus", "X-Bogus", "webid", "verifyFp", "fp", "uifid", "search_id"}
+)
+
+// RecordConfig 录制回放配置
+type RecordConfig struct {
+	Mode          RecordMode `mapstructure:"mode" json:"mode"`
+	Dir           string     `mapstructure:"dir" json:"dir"`                      // 录制文件目录
+	RedactHeaders []string   `mapstructure:"redact_headers" json:"redactHeaders"` // 追加的脱敏请求头
+	RedactParams  []string   `mapstructure:"redact_params" json:"redactParams"`   // 追加的脱敏参数
+	IgnoreParams  []string   `mapstructure:"ignore_params" json:"ignoreParams"`   // 追加的匹配时忽略参数
+}
+
+// Fixture 单次请求的录制内容
+type Fixture struct {
+	Request  FixtureRequest  `json:"request"`
+	Response FixtureResponse `json:"response"`
+}
+
+type FixtureRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type FixtureResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+// Recorder 基于 http.RoundTripper 的录制回放器
+type Recorder struct {
+	config        RecordConfig
+	redactHeaders map[string]bool
+	redactParams  map[string]bool
+	ignoreParams  map[string]bool
+	mu            sync.Mutex
+	counter       map[string]int // 相同请求的出现次数，用于按顺序录制和回放
+}
+
+// NewRecorder 创建录制回放器，Mode 为空时返回 nil
+func NewRecorder(config RecordConfig) (*Recorder, error) {
+	switch config.Mode {
+	case RecordModeOff:
+		return nil, nil
+	case RecordModeRecord, RecordModeReplay:
+	default:
+		return nil, fmt.Errorf("unsupported record mode: %s", config.Mode)
+	}
+	if config.Dir == "" {
+		return nil, fmt.Errorf("record dir is required")
+	}
+	if config.Mode == RecordModeRecord {
+		if err := os.MkdirAll(config.Dir, 0755); err != nil {
+			return nil, fmt.Errorf("create record dir %s failed: %v", config.Dir, err)
+		}
+	}
+	return &Recorder{
+		config:        config,
+		redactHeaders: toSet(defaultRedactHeaders, config.RedactHeaders, true),
+		redactParams:  toSet(defaultRedactParams, config.RedactParams, false),
+		ignoreParams:  toSet(defaultIgnoreParams, config.IgnoreParams, false),
+		counter:       make(map[string]int),
+	}, nil
+}
+
+func toSet(defaults, extra []string, canonical bool) map[string]bool {
+	set := make(map[string]bool)
+	for _, list := range [][]string{defaults, extra} {
+		for _, key := range list {
+			if canonical {
+				key = http.CanonicalHeaderKey(key)
+			}
+			set[key] = true
+		}
+	}
+	return set
+}
+
+// Mode 当前模式
+func (r *Recorder) Mode() RecordMode {
+	return r.config.Mode
+}
+
+// Dir 录制文件目录
+func (r *Recorder) Dir() string {
+	return r.config.Dir
+}
+
+// Wrap 包装底层 Transport，base 为空时使用 http.DefaultTransport
+func (r *Recorder) Wrap(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &recordTransport{recorder: r, base: base}
+}
+
+// ProxyTransport 基于默认 Transport 创建带代理的 Transport
+func ProxyTransport(proxy string) http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy == "" {
+		return transport
+	}
+	proxyURL, err := url.Parse(proxy)
+	if err == nil {
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return transport
+}
+
+type recordTransport struct {
+	recorder *Recorder
+	base     http.RoundTripper
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := t.recorder.key(req)
+	index := t.recorder.next(key)
+	if t.recorder.config.Mode == RecordModeReplay {
+		return t.recorder.replay(req, key, index)
+	}
+	return t.recorder.record(t.base, req, key, index)
+}
+
+// key 由请求方法、地址和稳定的查询参数生成
+func (r *Recorder) key(req *http.Request) string {
+	query := req.URL.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		if !r.ignoreParams[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var builder strings.Builder
+	builder.WriteString(req.Method + " " + req.URL.Host + req.URL.Path)
+	for _, name := range names {
+		values := query[name]
+		sort.Strings(values)
+		builder.WriteString("&" + name + "=" + strings.Join(values, ","))
+	}
+	sum := sha1.Sum([]byte(builder.String()))
+	return fixtureName(req) + "-" + hex.EncodeToString(sum[:])[:12]
+}
+
+func fixtureName(req *http.Request) string {
+	path := strings.Trim(req.URL.Path, "/")
+	if path == "" {
+		path = "index"
+	}
+	replacer := strings.NewReplacer("/", "_", ".", "_", ":", "_")
+	return strings.ToLower(req.Method) + "_" + replacer.Replace(req.URL.Host+"_"+path)
+}
+
+func (r *Recorder) next(key string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	index := r.counter[key]
+	r.counter[key] = index + 1
+	return index
+}
+
+func (r *Recorder) path(key string, index int) string {
+	return filepath.Join(r.config.Dir, fmt.Sprintf("%s-%03d.json", key, index))
+}
+
+func (r *Recorder) record(base http.RoundTripper, req *http.Request, key string, index int) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err == nil {
+			reqBody, _ = io.ReadAll(body)
+			_ = body.Close()
+		}
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	fixture := Fixture{
+		Request: FixtureRequest{
+			Method: req.Method,
+			URL:    r.redactURL(req.URL),
+			Header: r.redactHeader(req.Header),
+			Body:   r.redactBody(string(reqBody)),
+		},
+		Response: FixtureResponse{
+			StatusCode: resp.StatusCode,
+			Header:     r.redactHeader(resp.Header),
+			Body:       string(respBody),
+		},
+	}
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(r.path(key, index), data, 0644); err != nil {
+		return nil, fmt.Errorf("write fixture failed: %v", err)
+	}
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, key string, index int) (*http.Response, error) {
+	// 请求次数多于录制次数时，重复返回最后一次的响应
+	var data []byte
+	var err error
+	for i := index; i >= 0; i-- {
+		data, err = os.ReadFile(r.path(key, i))
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrFixtureNotFound, req.Method, r.redactURL(req.URL))
+	}
+	fixture := &Fixture{}
+	if err = json.Unmarshal(data, fixture); err != nil {
+		return nil, fmt.Errorf("decode fixture %s failed: %v", key, err)
+	}
+	header := fixture.Response.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.Response.StatusCode, http.StatusText(fixture.Response.StatusCode)),
+		StatusCode:    fixture.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(fixture.Response.Body)),
+		ContentLength: int64(len(fixture.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (r *Recorder) redactURL(u *url.URL) string {
+	clone := *u
+	query := clone.Query()
+	for name := range query {
+		if r.redactParams[name] {
+			query.Set(name, Redacted)
+		}
+	}
+	clone.RawQuery = query.Encode()
+	clone.User = nil
+	return clone.String()
+}
+
+// redactBody 脱敏请求体中以 key=value 形式出现的参数，如签名请求中的查询串
+func (r *Recorder) redactBody(body string) string {
+	for name := range r.redactParams {
+		pattern := regexp.MustCompile(`((?:^|[?&"])` + regexp.QuoteMeta(name) + `=)[^&"\s]*`)
+		body = pattern.ReplaceAllString(body, "${1}"+Redacted)
+	}
+	return body
+}
+
+func (r *Recorder) redactHeader(header http.Header) http.Header {
+	result := header.Clone()
+	for name, values := range result {
+		if !r.redactHeaders[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		redacted := make([]string, len(values))
+		for i, value := range values {
+			redacted[i] = redactCookie(name, value)
+		}
+		result[name] = redacted
+	}
+	return result
+}
+
+// redactCookie 保留 cookie 名称，仅替换取值
+func redactCookie(name, value string) string {
+	switch http.CanonicalHeaderKey(name) {
+	case "Cookie":
+		parts := strings.Split(value, ";")
+		for i, part := range parts {
+			if kv := strings.SplitN(strings.TrimSpace(part), "=", 2); len(kv) == 2 {
+				parts[i] = kv[0] + "=" + Redacted
+			}
+		}
+		return strings.Join(parts, ";")
+	case "Set-Cookie":
+		parts := strings.SplitN(value, ";", 2)
+		if kv := strings.SplitN(parts[0], "=", 2); len(kv) == 2 {
+			parts[0] = kv[0] + "=" + Redacted
+		}
+		return strings.Join(parts, ";")
+	}
+	return Redacted
+}
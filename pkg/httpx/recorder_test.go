@@ -0,0 +1,74 @@
+package httpx
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorderRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.SetCookie(w, &http.Cookie{Name: "msToken", Value: "secret-token"})
+		_, _ = w.Write([]byte(`{"page":"` + r.URL.Query().Get("cursor") + `"}`))
+	}))
+
+	recorder, err := NewRecorder(RecordConfig{Mode: RecordModeRecord, Dir: dir})
+	assert.NoError(t, err)
+	client := &http.Client{Transport: recorder.Wrap(nil)}
+	for _, cursor := range []string{"0", "1"} {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/api/list/?cursor="+cursor+"&msToken=abc",
+			strings.NewReader("query_params=aid=1&msToken=abc"))
+		req.Header.Set("Cookie", "sessionid=xyz; ttwid=123")
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		assert.Equal(t, `{"page":"`+cursor+`"}`, string(body))
+	}
+	server.Close()
+	assert.Equal(t, 2, calls)
+
+	// 录制文件中不应出现敏感信息
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	assert.Len(t, files, 2)
+	for _, name := range files {
+		data, _ := os.ReadFile(name)
+		content := string(data)
+		assert.NotContains(t, content, "secret-token")
+		assert.NotContains(t, content, "xyz")
+		assert.NotContains(t, content, "msToken=abc")
+		assert.Contains(t, content, "sessionid="+Redacted)
+	}
+
+	// 回放时忽略变化的 msToken，按 cursor 返回对应响应
+	replayer, err := NewRecorder(RecordConfig{Mode: RecordModeReplay, Dir: dir})
+	assert.NoError(t, err)
+	client = &http.Client{Transport: replayer.Wrap(nil)}
+	resp, err := client.Post(server.URL+"/api/list/?cursor=1&msToken=other", "text/plain", nil)
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, `{"page":"1"}`, string(body))
+	assert.Equal(t, 2, calls)
+
+	_, err = client.Post(server.URL+"/api/list/?cursor=9", "text/plain", nil)
+	assert.True(t, errors.Is(err, ErrFixtureNotFound))
+}
+
+func TestRecorderOff(t *testing.T) {
+	recorder, err := NewRecorder(RecordConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, recorder)
+
+	_, err = NewRecorder(RecordConfig{Mode: "unknown", Dir: t.TempDir()})
+	assert.Error(t, err)
+}
@@ -7,6 +7,7 @@ import (
 	"noctua/pkg/config"
 	"noctua/pkg/database"
 	"noctua/pkg/logger"
+	"noctua/pkg/secret"
 	"noctua/pkg/utils/file"
 	"path/filepath"
 	"time"
@@ -30,6 +31,20 @@ func init() {
 		Level:  viper.GetString("log.level"),
 		Path:   filepath.Join(runtimePath, "logs"),
 	})
+	// 加载账号凭据及缓存文件的加密密钥，密钥错误时无法读取已加密的数据，直接退出
+	keyFile := viper.GetString("secret.key_file")
+	if len(keyFile) > 0 && !filepath.IsAbs(keyFile) {
+		keyFile = file.GetResourcePath(keyFile)
+	}
+	if err := secret.Init(&secret.Config{
+		Keys:    viper.GetString("secret.keys"),
+		KeyFile: keyFile,
+	}); err != nil {
+		logger.Log.Fatalf("Load secret keys failed: %v", err)
+	}
+	if !secret.Enabled() {
+		logger.Log.Warn("No secret key configured, account credentials are stored in plaintext")
+	}
 	// 初始化缓存
 	cache.NewCache(&cache.CacheConfig{
 		CacheType:   viper.GetString("cache.type"),
@@ -0,0 +1,217 @@
+package secret
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// prefix 密文前缀，格式为 enc:v1:<密钥ID>:<base64(nonce+密文)>
+const prefix = "enc:v1:"
+
+var (
+	ErrNoKey      = errors.New("secret: no encryption key configured")
+	ErrUnknownKey = errors.New("secret: unknown encryption key")
+	ErrMalformed  = errors.New("secret: malformed ciphertext")
+)
+
+// Config 字段加密配置，未配置密钥时不加密
+type Config struct {
+	Keys    string // 逗号分隔的 id:base64 密钥，第一个用于加密，其余只用于解密旧数据
+	KeyFile string // 密钥文件，每行一个 id:base64 密钥，格式同 Keys，Keys 为空时使用
+}
+
+// Keyring 加解密使用的密钥，支持轮换
+type Keyring struct {
+	primary string
+	aeads   map[string]cipher.AEAD
+}
+
+var current atomic.Pointer[Keyring]
+
+// Init 按配置加载密钥，未配置密钥时返回 nil 且不加密
+func Init(config *Config) error {
+	specs := config.Keys
+	if specs == "" && config.KeyFile != "" {
+		data, err := os.ReadFile(config.KeyFile)
+		if err != nil {
+			return fmt.Errorf("secret: read key file failed: %w", err)
+		}
+		specs = string(data)
+	}
+	fields := strings.FieldsFunc(specs, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r'
+	})
+	var keys []string
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field != "" && !strings.HasPrefix(field, "#") {
+			keys = append(keys, field)
+		}
+	}
+	if len(keys) == 0 {
+		SetKeyring(nil)
+		return nil
+	}
+	keyring, err := NewKeyring(keys...)
+	if err != nil {
+		return err
+	}
+	SetKeyring(keyring)
+	return nil
+}
+
+// NewKeyring 解析 id:base64 格式的密钥，第一个用于加密，密钥长度为 16、24 或 32 字节
+func NewKeyring(keys ...string) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKey
+	}
+	keyring := &Keyring{aeads: make(map[string]cipher.AEAD, len(keys))}
+	for i, spec := range keys {
+		id, encoded, ok := strings.Cut(spec, ":")
+		if !ok || id == "" || strings.ContainsAny(id, ": ") {
+			return nil, fmt.Errorf("secret: key %d should be id:base64", i+1)
+		}
+		if _, exists := keyring.aeads[id]; exists {
+			return nil, fmt.Errorf("secret: duplicate key id %s", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("secret: decode key %s failed: %w", id, err)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("secret: key %s: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("secret: key %s: %w", id, err)
+		}
+		keyring.aeads[id] = aead
+		if i == 0 {
+			keyring.primary = id
+		}
+	}
+	return keyring, nil
+}
+
+// SetKeyring 替换当前密钥，为 nil 时不加密
+func SetKeyring(keyring *Keyring) {
+	current.Store(keyring)
+}
+
+// Enabled 是否已配置密钥
+func Enabled() bool {
+	return current.Load() != nil
+}
+
+// Primary 用于加密的密钥ID
+func (k *Keyring) Primary() string {
+	return k.primary
+}
+
+// Encrypt 使用当前密钥加密
+func (k *Keyring) Encrypt(plaintext []byte) ([]byte, error) {
+	aead := k.aeads[k.primary]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+	result := make([]byte, 0, len(prefix)+len(k.primary)+1+base64.StdEncoding.EncodedLen(len(sealed)))
+	result = append(result, prefix...)
+	result = append(result, k.primary...)
+	result = append(result, ':')
+	return base64.StdEncoding.AppendEncode(result, sealed), nil
+}
+
+// Decrypt 使用密文对应的密钥解密
+func (k *Keyring) Decrypt(ciphertext []byte) ([]byte, error) {
+	id, payload, err := split(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	aead, ok := k.aeads[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(string(payload))
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("secret: decrypt with key %s failed: %w", id, err)
+	}
+	return plaintext, nil
+}
+
+// split 拆分密文的密钥ID和内容
+func split(ciphertext []byte) (string, []byte, error) {
+	if !IsEncrypted(ciphertext) {
+		return "", nil, ErrMalformed
+	}
+	id, payload, ok := bytes.Cut(ciphertext[len(prefix):], []byte(":"))
+	if !ok || len(id) == 0 {
+		return "", nil, ErrMalformed
+	}
+	return string(id), payload, nil
+}
+
+// IsEncrypted 是否为本包生成的密文
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(prefix))
+}
+
+// Encrypt 使用当前密钥加密，未配置密钥时原样返回
+func Encrypt(plaintext []byte) ([]byte, error) {
+	keyring := current.Load()
+	if keyring == nil {
+		return plaintext, nil
+	}
+	return keyring.Encrypt(plaintext)
+}
+
+// Decrypt 解密，非密文的旧数据原样返回
+func Decrypt(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	keyring := current.Load()
+	if keyring == nil {
+		return nil, ErrNoKey
+	}
+	return keyring.Decrypt(data)
+}
+
+// EncryptString 加密字符串，空字符串不加密
+func EncryptString(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	result, err := Encrypt([]byte(value))
+	return string(result), err
+}
+
+// DecryptString 解密字符串，非密文的旧数据原样返回
+func DecryptString(value string) (string, error) {
+	result, err := Decrypt([]byte(value))
+	return string(result), err
+}
+
+// NeedsRotation 值未加密或不是使用当前密钥加密，未配置密钥时返回 false
+func NeedsRotation(value string) bool {
+	keyring := current.Load()
+	if keyring == nil || value == "" {
+		return false
+	}
+	id, _, err := split([]byte(value))
+	return err != nil || id != keyring.primary
+}
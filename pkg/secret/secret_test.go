@@ -0,0 +1,120 @@
+package secret
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKey(t *testing.T, id string) string {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return id + ":" + base64.StdEncoding.EncodeToString(key)
+}
+
+func useKeys(t *testing.T, keys ...string) {
+	keyring, err := NewKeyring(keys...)
+	require.NoError(t, err)
+	SetKeyring(keyring)
+	t.Cleanup(func() { SetKeyring(nil) })
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	useKeys(t, newKey(t, "k1"))
+	encrypted, err := EncryptString("sessionid=abc")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, "enc:v1:k1:"))
+	assert.NotContains(t, encrypted, "abc")
+
+	other, err := EncryptString("sessionid=abc")
+	require.NoError(t, err)
+	assert.NotEqual(t, encrypted, other, "nonce should be random")
+
+	plaintext, err := DecryptString(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "sessionid=abc", plaintext)
+
+	empty, err := EncryptString("")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	// 未加密的旧数据原样返回
+	plaintext, err = DecryptString("legacy")
+	require.NoError(t, err)
+	assert.Equal(t, "legacy", plaintext)
+
+	// 密文被篡改
+	tampered := []byte(encrypted)
+	tampered[len(tampered)-3] ^= 1
+	_, err = Decrypt(tampered)
+	assert.Error(t, err)
+	_, err = DecryptString("enc:v1:k1:%%%")
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey, newKeySpec := newKey(t, "old"), newKey(t, "new")
+	useKeys(t, oldKey)
+	encrypted, err := EncryptString("value")
+	require.NoError(t, err)
+	assert.False(t, NeedsRotation(encrypted))
+	assert.True(t, NeedsRotation("plaintext"))
+	assert.False(t, NeedsRotation(""))
+
+	useKeys(t, newKeySpec, oldKey)
+	assert.True(t, NeedsRotation(encrypted))
+	plaintext, err := DecryptString(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "value", plaintext)
+	rotated, err := EncryptString(plaintext)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(rotated, "enc:v1:new:"))
+	assert.False(t, NeedsRotation(rotated))
+
+	// 移除旧密钥后无法解密
+	useKeys(t, newKeySpec)
+	_, err = DecryptString(encrypted)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	SetKeyring(nil)
+	_, err = DecryptString(rotated)
+	assert.ErrorIs(t, err, ErrNoKey)
+	assert.False(t, NeedsRotation("plaintext"))
+}
+
+func TestNewKeyringInvalid(t *testing.T) {
+	for _, spec := range []string{"nokey", ":abc", "k1:***", "k1:" + base64.StdEncoding.EncodeToString([]byte("short"))} {
+		_, err := NewKeyring(spec)
+		assert.Error(t, err, spec)
+	}
+	key := newKey(t, "k1")
+	_, err := NewKeyring(key, key)
+	assert.Error(t, err)
+	_, err = NewKeyring()
+	assert.ErrorIs(t, err, ErrNoKey)
+}
+
+func TestInit(t *testing.T) {
+	t.Cleanup(func() { SetKeyring(nil) })
+	require.NoError(t, Init(&Config{}))
+	assert.False(t, Enabled())
+
+	first, second := newKey(t, "a"), newKey(t, "b")
+	path := filepath.Join(t.TempDir(), "keys")
+	require.NoError(t, os.WriteFile(path, []byte("# 当前密钥\n"+first+"\n"+second+"\n"), 0600))
+	require.NoError(t, Init(&Config{KeyFile: path}))
+	assert.Equal(t, "a", current.Load().Primary())
+
+	// Keys 优先于密钥文件
+	require.NoError(t, Init(&Config{Keys: second + "," + first, KeyFile: path}))
+	assert.Equal(t, "b", current.Load().Primary())
+
+	assert.Error(t, Init(&Config{KeyFile: filepath.Join(t.TempDir(), "missing")}))
+}
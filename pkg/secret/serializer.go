@@ -0,0 +1,46 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm/schema"
+	"reflect"
+)
+
+func init() {
+	schema.RegisterSerializer("encrypted", Serializer{})
+}
+
+// Serializer 字符串字段的 GORM 加密序列化，字段标签为 serializer:encrypted
+// 写入时使用当前密钥加密，读取时解密，未加密的旧数据原样读取
+// 注意：Updates(map) 不经过序列化，需要先调用 EncryptString
+type Serializer struct{}
+
+// Scan 读取并解密
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	default:
+		return fmt.Errorf("secret: unsupported value type %T for field %s", dbValue, field.Name)
+	}
+	plaintext, err := DecryptString(value)
+	if err != nil {
+		return fmt.Errorf("field %s: %w", field.Name, err)
+	}
+	field.ReflectValueOf(ctx, dst).SetString(plaintext)
+	return nil
+}
+
+// Value 加密后写入
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("secret: unsupported value type %T for field %s", fieldValue, field.Name)
+	}
+	return EncryptString(value)
+}
@@ -0,0 +1,117 @@
+package secret_test
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"noctua/internal/model"
+	"noctua/pkg/database"
+	"noctua/pkg/database/dbtest"
+	"noctua/pkg/secret"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain 数据库只能初始化一次，所有测试共享同一个库
+func TestMain(m *testing.M) {
+	dbtest.Main(m, &model.MediaAccount{})
+}
+
+func newKeyring(t *testing.T, ids ...string) []string {
+	var specs []string
+	for _, id := range ids {
+		key := make([]byte, 32)
+		_, err := rand.Read(key)
+		require.NoError(t, err)
+		specs = append(specs, id+":"+base64.StdEncoding.EncodeToString(key))
+	}
+	return specs
+}
+
+func setKeys(t *testing.T, specs ...string) {
+	keyring, err := secret.NewKeyring(specs...)
+	require.NoError(t, err)
+	secret.SetKeyring(keyring)
+}
+
+// rawColumn 读取数据库中的原始值
+func rawColumn(t *testing.T, id uint, column string) string {
+	var value string
+	require.NoError(t, database.DB.Table("media_account").Select(column).Where("id = ?", id).Scan(&value).Error)
+	return value
+}
+
+func TestEncryptedAccountCredentials(t *testing.T) {
+	// 统计依赖表中的全部账号，清空上次运行的数据
+	require.NoError(t, database.DB.Exec("DELETE FROM media_account").Error)
+	t.Cleanup(func() { secret.SetKeyring(nil) })
+
+	// 启用加密前写入的明文数据
+	legacy := &model.MediaAccount{MediaCode: "dy", UserID: "legacy", UID: "legacy", Nickname: "legacy", Cookie: "a=1", UserAgent: "ua"}
+	require.NoError(t, legacy.Create())
+	assert.Equal(t, "a=1", rawColumn(t, legacy.ID, "cookie"))
+
+	keys := newKeyring(t, "k1", "k2")
+	setKeys(t, keys[0])
+	account := &model.MediaAccount{MediaCode: "dy", UserID: "u1", UID: "u1", Nickname: "u1", Cookie: "sessionid=s1", DeviceInfo: "{}"}
+	require.NoError(t, account.Create())
+	assert.True(t, strings.HasPrefix(rawColumn(t, account.ID, "cookie"), "enc:v1:k1:"))
+	assert.True(t, strings.HasPrefix(rawColumn(t, account.ID, "device_info"), "enc:v1:k1:"))
+	assert.Empty(t, rawColumn(t, account.ID, "user_agent"))
+
+	found, err := account.Find(account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "sessionid=s1", found.Cookie)
+	assert.Equal(t, "{}", found.DeviceInfo)
+	found, err = legacy.Find(legacy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "a=1", found.Cookie)
+
+	// 按字段更新及 map 更新均写入密文
+	account.UserAgent = "Mozilla"
+	require.NoError(t, account.UpdateFields("user_agent"))
+	assert.True(t, strings.HasPrefix(rawColumn(t, account.ID, "user_agent"), "enc:v1:k1:"))
+	require.NoError(t, account.ReplaceCookie("sessionid=s2"))
+	assert.True(t, strings.HasPrefix(rawColumn(t, account.ID, "cookie"), "enc:v1:k1:"))
+	account.Cookie = "sessionid=s3"
+	saved, err := account.SaveMergedCookie(account.CookieVersion)
+	require.NoError(t, err)
+	assert.True(t, saved)
+	found, err = account.Find(account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "sessionid=s3", found.Cookie)
+	assert.Equal(t, "Mozilla", found.UserAgent)
+
+	// 轮换密钥后重新加密，包括明文旧数据
+	setKeys(t, keys[1], keys[0])
+	result, err := (&model.MediaAccount{}).ReencryptCredentials(1)
+	require.NoError(t, err)
+	assert.Equal(t, model.ReencryptResult{Scanned: 2, Updated: 2}, result)
+	for _, id := range []uint{legacy.ID, account.ID} {
+		assert.True(t, strings.HasPrefix(rawColumn(t, id, "cookie"), "enc:v1:k2:"))
+	}
+	assert.True(t, strings.HasPrefix(rawColumn(t, legacy.ID, "user_agent"), "enc:v1:k2:"))
+	result, err = (&model.MediaAccount{}).ReencryptCredentials(10)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Updated)
+
+	// 移除旧密钥后仍可读取
+	setKeys(t, keys[1])
+	found, err = account.Find(account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "sessionid=s3", found.Cookie)
+
+	// 无法解密的账号计入失败
+	setKeys(t, newKeyring(t, "k3")...)
+	result, err = (&model.MediaAccount{}).ReencryptCredentials(10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{legacy.ID, account.ID}, result.FailedIDs)
+	_, err = account.Find(account.ID)
+	assert.ErrorIs(t, err, secret.ErrUnknownKey)
+
+	secret.SetKeyring(nil)
+	_, err = (&model.MediaAccount{}).ReencryptCredentials(10)
+	assert.ErrorIs(t, err, secret.ErrNoKey)
+}
@@ -453,3 +453,18 @@ func atoi(s string) int {
 	}
 	return n
 }
+
+// MaskPlaceholder 掩码占位符
+const MaskPlaceholder = "****"
+
+// Mask 保留首尾各 keep 个字符，中间替换为 ****，长度不足时整体替换
+func Mask(s string, keep int) string {
+	if s == "" {
+		return ""
+	}
+	runes := []rune(s)
+	if keep <= 0 || len(runes) <= keep*2 {
+		return MaskPlaceholder
+	}
+	return string(runes[:keep]) + MaskPlaceholder + string(runes[len(runes)-keep:])
+}
@@ -9,3 +9,17 @@ func TestCompareVersions(t *testing.T) {
 	data := CompareVersions("v1.1.03", "v1.1.03")
 	fmt.Println(data)
 }
+
+func TestMask(t *testing.T) {
+	cases := map[string]string{
+		"":                 "",
+		"short":            "****",
+		"sessionid=abcdef": "sess****cdef",
+		"账号cookie值很长很长":    "账号co****很长很长",
+	}
+	for input, want := range cases {
+		if got := Mask(input, 4); got != want {
+			t.Errorf("Mask(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
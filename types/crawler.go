@@ -24,17 +24,26 @@ func (s *Keywords) Set(value string) error {
 }
 
 type CrawlParams struct {
-	MediaCode        string   `json:"mediaCode"`
-	CrawlType        string   `json:"crawlType"`
-	Region           string   `json:"region"`
-	MaxCount         int      `json:"maxCount"`
-	Keywords         []string `json:"keywords"`
-	WithUser         bool     `json:"withUser"`
-	WithComment      bool     `json:"withComment"`
-	WithCommentUser  bool     `json:"withCommentUser"`
-	WithAllCreations bool     `json:"withAllCreations"` // 是否获取全部作品
-	AutoPagination   bool     `json:"autoPagination"`   // 是否自动翻页
-	TargetPurgeCount int64    `json:"targetPurgeCount"` // 目标清洗数量
+	MediaCode        string        `json:"mediaCode" validate:"required"`
+	CrawlType        string        `json:"crawlType" validate:"required"`
+	Region           string        `json:"region" validate:"required"`
+	MaxCount         int           `json:"maxCount" validate:"gte=0"`
+	Keywords         []string      `json:"keywords" validate:"required,min=1,dive,required"`
+	WithUser         bool          `json:"withUser"`
+	WithComment      bool          `json:"withComment"`
+	WithCommentUser  bool          `json:"withCommentUser"`
+	WithAllCreations bool          `json:"withAllCreations"`                         // 是否获取全部作品
+	AutoPagination   bool          `json:"autoPagination"`                           // 是否自动翻页
+	TargetPurgeCount int64         `json:"targetPurgeCount" validate:"gte=0"`        // 目标清洗数量
+	Sinks            []string      `json:"sinks" validate:"omitempty,dive,required"` // 数据输出路由，为空时使用默认 sink
+	Record           *RecordParams `json:"record" validate:"omitempty"`              // HTTP 录制回放，为空时使用全局配置
+	ProjectID        uint          `json:"projectId"`                                // 所属项目，接口按请求头 X-Project 设置，0 为默认项目
+}
+
+// RecordParams 任务级 HTTP 录制回放参数
+type RecordParams struct {
+	Mode    string `json:"mode" validate:"omitempty,oneof=record replay"` // record / replay，为空沿用全局配置
+	Fixture string `json:"fixture" validate:"omitempty,excludesall=/\\"`  // 录制文件子目录名
 }
 
 type SearchParams struct {
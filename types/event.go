@@ -1,9 +1,6 @@
 package types
 
-import (
-	"time"
-)
-
+// CrawlEndCode 采集结束原因
 type CrawlEndCode int
 
 const (
@@ -14,19 +11,3 @@ const (
 	CrawlEndCodeForcedStop CrawlEndCode = 50
 	CrawlEndCodeRoundMaxed CrawlEndCode = 60
 )
-
-// 单个清洗完成事件
-type CrawlStartEvent struct {
-	*CrawlParams
-}
-
-// 单个清洗完成事件
-type CrawlStopEvent struct {
-	ReachedAt time.Time
-}
-
-// 采集结束事件
-type CrawlEndEvent struct {
-	Code      CrawlEndCode
-	ReceiveAt time.Time
-}
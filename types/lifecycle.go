@@ -0,0 +1,275 @@
+package types
+
+import "time"
+
+// EventEmitter 事件发布函数，由 kernel 注入 EventBus.Publish，为空时忽略
+type EventEmitter func(event interface{})
+
+// Emit 发布事件
+func (e EventEmitter) Emit(event interface{}) {
+	if e != nil {
+		e(event)
+	}
+}
+
+// EventMeta 生命周期事件公共字段
+type EventMeta struct {
+	JobID      string    `json:"jobId"`               // 采集任务ID，与任务无关的事件为空
+	ProjectID  uint      `json:"projectId,omitempty"` // 所属项目，为 0 时按所属任务确定，均未设置时为默认项目
+	OccurredAt time.Time `json:"occurredAt"`          // 事件发生时间
+}
+
+// Meta 返回公共字段，嵌入 EventMeta 的事件均实现 LifecycleEvent
+func (m EventMeta) Meta() EventMeta {
+	return m
+}
+
+// LifecycleEvent 带主题和公共字段的生命周期事件
+type LifecycleEvent interface {
+	Topic() string
+	Meta() EventMeta
+}
+
+// NewEventMeta 创建事件公共字段
+func NewEventMeta(jobID string) EventMeta {
+	return EventMeta{JobID: jobID, OccurredAt: time.Now()}
+}
+
+// WithProject 标记所属项目，用于与任务无关的账号及会话事件
+func (m EventMeta) WithProject(projectID uint) EventMeta {
+	m.ProjectID = projectID
+	return m
+}
+
+// JobStartedEvent 采集任务开始
+type JobStartedEvent struct {
+	EventMeta
+	Params *CrawlParams `json:"params"`
+}
+
+// JobFinishedEvent 采集任务结束
+type JobFinishedEvent struct {
+	EventMeta
+	Code      CrawlEndCode `json:"code"`
+	StartedAt time.Time    `json:"startedAt"`
+	Error     string       `json:"error,omitempty"`
+}
+
+// JobStoppingEvent 请求结束采集任务，由爬虫或管理器发布，Code 为结束原因
+type JobStoppingEvent struct {
+	EventMeta
+	Code CrawlEndCode `json:"code"`
+}
+
+// TaskInfo 调度任务信息
+type TaskInfo struct {
+	TaskID       string `json:"taskId"`
+	ParentTaskID string `json:"parentTaskId"`
+	SourceTaskID string `json:"sourceTaskId"`
+	QueueKey     string `json:"queueKey"`
+	Attempt      int    `json:"attempt"` // 当前重试次数，首次执行为 0
+}
+
+// TaskSubmittedEvent 任务入队
+type TaskSubmittedEvent struct {
+	EventMeta
+	TaskInfo
+}
+
+// TaskStartedEvent 任务开始执行
+type TaskStartedEvent struct {
+	EventMeta
+	TaskInfo
+}
+
+// TaskSucceededEvent 任务执行成功
+type TaskSucceededEvent struct {
+	EventMeta
+	TaskInfo
+	Duration time.Duration `json:"duration"`
+}
+
+// TaskFailedEvent 任务重试耗尽后失败
+type TaskFailedEvent struct {
+	EventMeta
+	TaskInfo
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error"`
+}
+
+// TaskRetriedEvent 任务失败后等待重试
+type TaskRetriedEvent struct {
+	EventMeta
+	TaskInfo
+	Delay time.Duration `json:"delay"`
+	Error string        `json:"error"`
+}
+
+// SessionInfo 会话信息
+type SessionInfo struct {
+	MediaCode string `json:"mediaCode"`
+	UserID    string `json:"userId"`
+	Region    string `json:"region"`
+	ProxyKey  string `json:"proxyKey"`
+}
+
+// SessionAcquiredEvent 获取会话
+type SessionAcquiredEvent struct {
+	EventMeta
+	SessionInfo
+}
+
+// SessionReleasedEvent 释放会话
+type SessionReleasedEvent struct {
+	EventMeta
+	SessionInfo
+}
+
+// SessionLeaseExpiredEvent 会话租约到期未续期，已被回收
+type SessionLeaseExpiredEvent struct {
+	EventMeta
+	SessionInfo
+	LeaseID   string    `json:"leaseId"`
+	Holder    string    `json:"holder,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// SessionFailoverEvent 亲和标识绑定的账号不可用，改用其他账号
+type SessionFailoverEvent struct {
+	EventMeta
+	SessionInfo
+	AffinityKey    string `json:"affinityKey"`
+	PreviousUserID string `json:"previousUserId"`
+}
+
+// SessionInvalidatedEvent 会话失效，账号被标记为不可用
+type SessionInvalidatedEvent struct {
+	EventMeta
+	SessionInfo
+	Reason string `json:"reason"`
+}
+
+// ProxyEventInfo 代理信息
+type ProxyEventInfo struct {
+	ProxyKey  string `json:"proxyKey"`
+	ProxyType string `json:"proxyType"`
+	Region    string `json:"region"`
+}
+
+// ProxyAcquiredEvent 获取代理
+type ProxyAcquiredEvent struct {
+	EventMeta
+	ProxyEventInfo
+}
+
+// ProxyRemovedEvent 代理移出代理池
+type ProxyRemovedEvent struct {
+	EventMeta
+	ProxyEventInfo
+	Reason string `json:"reason"`
+}
+
+// ProxyCircuitOpenEvent 代理连续失败后熔断
+type ProxyCircuitOpenEvent struct {
+	EventMeta
+	ProxyEventInfo
+	Failures int       `json:"failures"` // 连续失败次数
+	Until    time.Time `json:"until"`    // 熔断截止时间
+	Error    string    `json:"error"`    // 最近一次失败原因
+}
+
+// AccountBlockedEvent 账号被平台限制
+type AccountBlockedEvent struct {
+	EventMeta
+	MediaCode string `json:"mediaCode"`
+	UserID    string `json:"userId"`
+	Reason    string `json:"reason"`
+}
+
+// AccountCookieExpiringEvent 账号关键 Cookie 缺失或即将过期
+type AccountCookieExpiringEvent struct {
+	EventMeta
+	MediaCode string    `json:"mediaCode"`
+	UserID    string    `json:"userId"`
+	Missing   []string  `json:"missing,omitempty"`
+	Expiring  []string  `json:"expiring,omitempty"`
+	ExpireAt  time.Time `json:"expireAt"`
+	Error     string    `json:"error,omitempty"`
+	Disabled  bool      `json:"disabled"` // 账号是否已被禁用
+}
+
+// AccountProbedEvent 账号探测后被禁用或恢复
+type AccountProbedEvent struct {
+	EventMeta
+	MediaCode string `json:"mediaCode"`
+	UserID    string `json:"userId"`
+	Action    string `json:"action"`   // disabled 或 enabled
+	Failures  int    `json:"failures"` // 连续失败次数
+	Error     string `json:"error,omitempty"`
+}
+
+// AccountCooldownEvent 账号触发限流，进入冷却
+type AccountCooldownEvent struct {
+	EventMeta
+	MediaCode string    `json:"mediaCode"`
+	UserID    string    `json:"userId"`
+	Reason    string    `json:"reason"`
+	Until     time.Time `json:"until"` // 冷却截止时间
+}
+
+// ChannelSaturatedEvent 数据通道占用率超过阈值
+type ChannelSaturatedEvent struct {
+	EventMeta
+	Channel    string  `json:"channel"`
+	Length     int     `json:"length"`
+	Capacity   int     `json:"capacity"`
+	Saturation float64 `json:"saturation"`
+	SpillDepth int     `json:"spillDepth"`
+}
+
+// 事件主题，按 "." 分段，可使用 task.* 等通配订阅
+const (
+	TopicJobStarted         = "job.started"
+	TopicJobStopping        = "job.stopping"
+	TopicJobFinished        = "job.finished"
+	TopicTaskSubmitted      = "task.submitted"
+	TopicTaskStarted        = "task.started"
+	TopicTaskSucceeded      = "task.succeeded"
+	TopicTaskFailed         = "task.failed"
+	TopicTaskRetried        = "task.retried"
+	TopicSessionAcquired    = "session.acquired"
+	TopicSessionReleased    = "session.released"
+	TopicSessionExpired     = "session.lease_expired"
+	TopicSessionInvalidated = "session.invalidated"
+	TopicSessionFailover    = "session.failover"
+	TopicProxyAcquired      = "proxy.acquired"
+	TopicProxyRemoved       = "proxy.removed"
+	TopicProxyCircuitOpen   = "proxy.circuit_open"
+	TopicAccountBlocked     = "account.blocked"
+	TopicAccountCookie      = "account.cookie_expiring"
+	TopicAccountProbed      = "account.probed"
+	TopicAccountCooldown    = "account.cooldown"
+	TopicChannelSaturated   = "channel.saturated"
+)
+
+func (JobStartedEvent) Topic() string            { return TopicJobStarted }
+func (JobStoppingEvent) Topic() string           { return TopicJobStopping }
+func (JobFinishedEvent) Topic() string           { return TopicJobFinished }
+func (TaskSubmittedEvent) Topic() string         { return TopicTaskSubmitted }
+func (TaskStartedEvent) Topic() string           { return TopicTaskStarted }
+func (TaskSucceededEvent) Topic() string         { return TopicTaskSucceeded }
+func (TaskFailedEvent) Topic() string            { return TopicTaskFailed }
+func (TaskRetriedEvent) Topic() string           { return TopicTaskRetried }
+func (SessionAcquiredEvent) Topic() string       { return TopicSessionAcquired }
+func (SessionReleasedEvent) Topic() string       { return TopicSessionReleased }
+func (SessionLeaseExpiredEvent) Topic() string   { return TopicSessionExpired }
+func (SessionInvalidatedEvent) Topic() string    { return TopicSessionInvalidated }
+func (SessionFailoverEvent) Topic() string       { return TopicSessionFailover }
+func (ProxyAcquiredEvent) Topic() string         { return TopicProxyAcquired }
+func (ProxyRemovedEvent) Topic() string          { return TopicProxyRemoved }
+func (ProxyCircuitOpenEvent) Topic() string      { return TopicProxyCircuitOpen }
+func (AccountBlockedEvent) Topic() string        { return TopicAccountBlocked }
+func (AccountCookieExpiringEvent) Topic() string { return TopicAccountCookie }
+func (AccountProbedEvent) Topic() string         { return TopicAccountProbed }
+func (AccountCooldownEvent) Topic() string       { return TopicAccountCooldown }
+func (ChannelSaturatedEvent) Topic() string      { return TopicChannelSaturated }
@@ -0,0 +1,33 @@
+package types
+
+// ProjectResolver 返回采集任务所属的项目，由 kernel 注入，用于按项目隔离事件和运行时数据
+type ProjectResolver func(jobID string) uint
+
+// Job 任务所属的项目，任务为空或未知时为默认项目 0
+func (r ProjectResolver) Job(jobID string) uint {
+	if r == nil || jobID == "" {
+		return 0
+	}
+	return r(jobID)
+}
+
+// Event 事件所属的项目，已标记项目时直接使用，否则按所属任务确定
+func (r ProjectResolver) Event(event interface{}) uint {
+	lifecycle, ok := event.(LifecycleEvent)
+	if !ok {
+		return 0
+	}
+	meta := lifecycle.Meta()
+	if meta.ProjectID != 0 {
+		return meta.ProjectID
+	}
+	return r.Job(meta.JobID)
+}
+
+// Runtime 运行时数据所属的项目，已标记项目时直接使用，否则按所属任务确定
+func (r ProjectResolver) Runtime(data RuntimeData) uint {
+	if data.ProjectID != 0 {
+		return data.ProjectID
+	}
+	return r.Job(data.JobID)
+}
@@ -28,10 +28,22 @@ type EventData struct {
 	ReceivedAt time.Time       `json:"receivedAt"` // 接收时间
 }
 
+// RuntimeEmitter 运行时数据发布函数，由 kernel 注入，为空时忽略
+type RuntimeEmitter func(data RuntimeData)
+
+// Emit 发布运行时数据
+func (e RuntimeEmitter) Emit(data RuntimeData) {
+	if e != nil {
+		e(data)
+	}
+}
+
 type RuntimeData struct {
 	EventCode RuntimeEventCode
 	EventData EventData
 	EventTime time.Time
+	JobID     string // 所属采集任务，全局通知为空
+	ProjectID uint   // 所属项目，为 0 时按所属任务确定
 }
 
 func NewRuntimeData(code RuntimeEventCode, data EventData) RuntimeData {
@@ -44,3 +56,15 @@ func NewRuntimeData(code RuntimeEventCode, data EventData) RuntimeData {
 		EventTime: time.Now(),
 	}
 }
+
+// WithJob 标记所属采集任务
+func (r RuntimeData) WithJob(jobID string) RuntimeData {
+	r.JobID = jobID
+	return r
+}
+
+// WithProject 标记所属项目
+func (r RuntimeData) WithProject(projectID uint) RuntimeData {
+	r.ProjectID = projectID
+	return r
+}
@@ -6,6 +6,7 @@ type ProxyRequest struct {
 	Type     string `json:"type"`
 	Region   string `json:"region"`
 	ProxyKey string `json:"proxyKey"`
+	JobID    string `json:"jobId"` // 发起请求的采集任务ID，用于事件
 }
 
 // ProxyResponse API 返回结构
@@ -11,5 +11,6 @@ type Session struct {
 	Account    *model.MediaAccount // 账号信息
 	ProxyInfo  *ProxyInfo          // 绑定的代理 IP
 	ExpireTime time.Time           // 代理 IP 过期时间
-	InUsed     bool
+	LeaseID    string              // 持有的租约ID，为空时为共享使用
+	JobID      string              // 最近一次获取会话的采集任务ID
 }